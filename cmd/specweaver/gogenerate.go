@@ -0,0 +1,126 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/christopherklint97/specweaver/pkg/generator"
+)
+
+// directiveFlags are the flags goGenerateDirective sets itself or that only
+// make sense on the command line
+var directiveFlags = []string{"spec", "output", "package", "types-output", "go-generate", "check", "version", "format", "v", "q"}
+
+// writeGoGenerate writes generate.go to the output directory of target with a
+// //go:generate directive that repeats this run, unless the file exists, and
+// unless quiet prints the directive and the import path of the generated package
+func writeGoGenerate(target generateTarget, fs *flag.FlagSet, quiet bool) error {
+	directive, err := goGenerateDirective(target, directiveArgs(fs))
+	if err != nil {
+		return err
+	}
+
+	written, err := writeIfMissing(filepath.Join(target.outputDir, "generate.go"),
+		fmt.Sprintf("package %s\n\n%s\n", target.packageName, directive))
+	if err != nil {
+		return fmt.Errorf("writing generate.go: %w", err)
+	}
+	if quiet {
+		return nil
+	}
+	if written {
+		fmt.Printf("  - generate.go: %s\n", directive)
+	} else {
+		fmt.Printf("  ! generate.go exists and was kept; the directive for this run is:\n    %s\n", directive)
+	}
+
+	if importPath, ok := generator.ImportPath(target.outputDir); ok {
+		fmt.Printf("  Import the generated package as %q\n", importPath)
+	}
+	return nil
+}
+
+// goGenerateDirective returns a //go:generate directive running specweaver
+// from the PATH to generate target from within its output directory, passing
+// args on. Both init and -go-generate write it, so generated packages are
+// regenerated the same way however they were set up.
+func goGenerateDirective(target generateTarget, args []string) (string, error) {
+	command := []string{"specweaver"}
+	specPath, err := relativeTo(target.outputDir, target.specPath)
+	if err != nil {
+		return "", err
+	}
+	command = append(command, "-spec", specPath, "-output", ".", "-package", target.packageName)
+	if target.typesDir != "" {
+		typesDir, err := relativeTo(target.outputDir, target.typesDir)
+		if err != nil {
+			return "", err
+		}
+		command = append(command, "-types-output", typesDir)
+	}
+	command = append(command, args...)
+
+	for i, arg := range command {
+		if strings.ContainsAny(arg, " \t\"'`") {
+			command[i] = strconv.Quote(arg)
+		}
+	}
+	return "//go:generate " + strings.Join(command, " "), nil
+}
+
+// directiveArgs returns the flags set on fs that a go:generate directive
+// should pass on, in name order
+func directiveArgs(fs *flag.FlagSet) []string {
+	var args []string
+	fs.Visit(func(f *flag.Flag) {
+		for _, name := range directiveFlags {
+			if f.Name == name {
+				return
+			}
+		}
+		if b, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && b.IsBoolFlag() && f.Value.String() == "true" {
+			args = append(args, "-"+f.Name)
+			return
+		}
+		args = append(args, "-"+f.Name+"="+f.Value.String())
+	})
+	return args
+}
+
+// relativeTo returns path relative to dir, with forward slashes
+func relativeTo(dir, path string) (string, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(absDir, absPath)
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// findGoMod returns the path of the go.mod of the module enclosing dir
+func findGoMod(dir string) (string, bool) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", false
+	}
+	for current := absDir; ; current = filepath.Dir(current) {
+		goMod := filepath.Join(current, "go.mod")
+		if _, err := os.Stat(goMod); err == nil {
+			return goMod, true
+		}
+		if filepath.Dir(current) == current {
+			return "", false
+		}
+	}
+}
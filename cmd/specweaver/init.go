@@ -0,0 +1,252 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/christopherklint97/specweaver/pkg/generator"
+	"github.com/christopherklint97/specweaver/pkg/openapi"
+	"github.com/christopherklint97/specweaver/pkg/parser"
+)
+
+// initGoVersion is the go directive of go.mod files written by init, the
+// oldest Go version the generated code and the router support
+const initGoVersion = "1.24"
+
+// initPackage is the directory and package name of the generated code
+const initPackage = "api"
+
+// starterSpec is the spec written by init when the project has none yet
+const starterSpec = `openapi: 3.1.0
+info:
+  title: Todo API
+  version: 0.1.0
+  description: A starter API generated by specweaver init
+
+paths:
+  /todos:
+    get:
+      operationId: listTodos
+      summary: List todos
+      responses:
+        '200':
+          description: The todos
+          content:
+            application/json:
+              schema:
+                type: array
+                items:
+                  $ref: '#/components/schemas/Todo'
+    post:
+      operationId: createTodo
+      summary: Create a todo
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/NewTodo'
+      responses:
+        '201':
+          description: The created todo
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Todo'
+        '400':
+          description: Invalid request
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Error'
+
+  /todos/{todoId}:
+    get:
+      operationId: getTodo
+      summary: Get a todo
+      parameters:
+        - name: todoId
+          in: path
+          required: true
+          schema:
+            type: integer
+            format: int64
+      responses:
+        '200':
+          description: The todo
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Todo'
+        '404':
+          description: Todo not found
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Error'
+
+components:
+  schemas:
+    NewTodo:
+      type: object
+      required:
+        - title
+      properties:
+        title:
+          type: string
+        done:
+          type: boolean
+
+    Todo:
+      type: object
+      required:
+        - id
+        - title
+        - done
+      properties:
+        id:
+          type: integer
+          format: int64
+        title:
+          type: string
+        done:
+          type: boolean
+
+    Error:
+      type: object
+      required:
+        - message
+      properties:
+        message:
+          type: string
+`
+
+// starterLintConfig returns the lint configuration written by init, listing
+// every lint rule with its default severity for users to adjust
+func starterLintConfig() string {
+	var sb strings.Builder
+	sb.WriteString("# Configuration of specweaver lint. Set a rule to error, warning, info or off;\n")
+	sb.WriteString("# rules left out keep their default severity.\n")
+	sb.WriteString("rules:\n")
+	for _, rule := range openapi.LintRules {
+		sb.WriteString(fmt.Sprintf("  %s: %s # %s\n", rule.Name, rule.Severity, rule.Description))
+	}
+	return sb.String()
+}
+
+// runInit scaffolds a project: a starter spec, a lint configuration, a go.mod,
+// the generated package with handler stubs, and a main package serving them
+func runInit(args []string) int {
+	fs := flag.NewFlagSet("specweaver init", flag.ExitOnError)
+	dir := fs.String("dir", ".", "Project directory, created if needed")
+	modulePath := fs.String("module", "", "Module path for a new go.mod (default: the project directory name)")
+	routerTarget := fs.String("router", generator.RouterBuiltin, "Router to register generated routes on (builtin, chi, stdlib)")
+	fs.Parse(args)
+
+	if err := initProject(*dir, *modulePath, *routerTarget); err != nil {
+		fmt.Fprintf(os.Stderr, "Error %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// initProject writes the files of a new project to dir. Existing files are
+// kept, so init can also scaffold a server for a spec written beforehand.
+func initProject(dir, modulePath, routerTarget string) error {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return fmt.Errorf("resolving project directory: %w", err)
+	}
+	if err := os.MkdirAll(absDir, 0755); err != nil {
+		return fmt.Errorf("creating project directory: %w", err)
+	}
+
+	specPath := filepath.Join(absDir, initPackage+".yaml")
+	written, err := writeIfMissing(specPath, starterSpec)
+	if err != nil {
+		return fmt.Errorf("writing starter spec: %w", err)
+	}
+	if written {
+		fmt.Printf("✓ Wrote starter spec %s\n", specPath)
+	} else {
+		fmt.Printf("✓ Using existing spec %s\n", specPath)
+	}
+
+	lintConfigPath := filepath.Join(absDir, defaultLintConfig)
+	written, err = writeIfMissing(lintConfigPath, starterLintConfig())
+	if err != nil {
+		return fmt.Errorf("writing lint config: %w", err)
+	}
+	if written {
+		fmt.Printf("✓ Wrote lint config %s\n", lintConfigPath)
+	}
+
+	if _, ok := findGoMod(absDir); !ok {
+		if modulePath == "" {
+			modulePath = filepath.Base(absDir)
+		}
+		goMod := fmt.Sprintf("module %s\n\ngo %s\n", modulePath, initGoVersion)
+		if err := os.WriteFile(filepath.Join(absDir, "go.mod"), []byte(goMod), 0644); err != nil {
+			return fmt.Errorf("writing go.mod: %w", err)
+		}
+		fmt.Printf("✓ Wrote go.mod for module %s\n", modulePath)
+	}
+
+	// Record the generation flags where go generate finds them
+	outputDir := filepath.Join(absDir, initPackage)
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("creating output directory: %w", err)
+	}
+	directive, err := goGenerateDirective(generateTarget{specPath: specPath, outputDir: outputDir, packageName: initPackage},
+		[]string{"-router=" + routerTarget, "-stubs"})
+	if err != nil {
+		return err
+	}
+	if _, err := writeIfMissing(filepath.Join(outputDir, "generate.go"), fmt.Sprintf("package %s\n\n%s\n", initPackage, directive)); err != nil {
+		return fmt.Errorf("writing generate.go: %w", err)
+	}
+
+	p := parser.New()
+	if err := p.ParseFile(specPath); err != nil {
+		return fmt.Errorf("parsing OpenAPI spec: %w", err)
+	}
+	gen := generator.NewGenerator(p.GetSpec(), generator.Config{
+		OutputDir:   outputDir,
+		PackageName: initPackage,
+		Router:      routerTarget,
+		Stubs:       true,
+	})
+	if err := gen.Generate(); err != nil {
+		return fmt.Errorf("generating code: %w", err)
+	}
+
+	fmt.Printf("\nNext steps:\n")
+	fmt.Printf("  cd %s\n", dir)
+	fmt.Printf("  go mod tidy\n")
+	fmt.Printf("  go run ./%s/cmd/server\n", initPackage)
+	fmt.Printf("  specweaver lint -spec %s.yaml\n", initPackage)
+	fmt.Printf("\nImplement operations in %s, and run go generate ./... after editing the spec.\n",
+		filepath.Join(initPackage, "cmd", "server", "main.go"))
+	return nil
+}
+
+// writeIfMissing writes content to path unless the file exists, and reports
+// whether it wrote it
+func writeIfMissing(path, content string) (bool, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if errors.Is(err, os.ErrExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+		return false, err
+	}
+	return true, f.Close()
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/christopherklint97/specweaver/pkg/openapi"
+	"gopkg.in/yaml.v3"
+)
+
+// Default paths of the lint configuration and ignore files, read from the
+// current directory when they exist
+const (
+	defaultLintConfig = ".specweaver-lint.yaml"
+	defaultLintIgnore = ".specweaver-lint-ignore"
+)
+
+// lintReport is the JSON output of the lint command
+type lintReport struct {
+	Spec     string            `json:"spec"`
+	Findings []openapi.Finding `json:"findings"`
+	Ignored  int               `json:"ignored"`
+}
+
+// runLint checks a spec against the lint rules
+func runLint(args []string) int {
+	fs := flag.NewFlagSet("specweaver lint", flag.ExitOnError)
+	specPath := fs.String("spec", "", "Path to OpenAPI specification file (required)")
+	configPath := fs.String("config", defaultLintConfig, "Lint configuration file setting the severity of rules, or off")
+	ignorePath := fs.String("ignore", defaultLintIgnore, "File of findings to ignore, one \"rule location\" per line")
+	writeIgnore := fs.Bool("write-ignore", false, "Write all current findings to the ignore file instead of reporting them")
+	listRules := fs.Bool("rules", false, "List the lint rules and their default severities")
+	format := fs.String("format", "text", "Output format: text or json")
+	fs.Parse(args)
+
+	if *listRules {
+		for _, rule := range openapi.LintRules {
+			fmt.Printf("%-28s %-8s %s\n", rule.Name, rule.Severity, rule.Description)
+		}
+		return exitValid
+	}
+
+	if *specPath == "" || (*format != "text" && *format != "json") {
+		fmt.Fprintf(os.Stderr, "Usage: specweaver lint -spec <path> [-config <path>] [-ignore <path>] [-write-ignore] [-format text|json]\n\n")
+		fs.PrintDefaults()
+		return exitLoadFail
+	}
+
+	config, err := loadLintConfig(*configPath, *configPath != defaultLintConfig)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error %v\n", err)
+		return exitLoadFail
+	}
+	doc, err := openapi.Load(*specPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading %s: %v\n", *specPath, err)
+		return exitLoadFail
+	}
+	findings, err := doc.Lint(config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error in %s: %v\n", *configPath, err)
+		return exitLoadFail
+	}
+
+	if *writeIgnore {
+		if err := writeLintIgnore(*ignorePath, findings); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing %s: %v\n", *ignorePath, err)
+			return exitLoadFail
+		}
+		fmt.Printf("✓ Wrote %d findings to %s\n", len(findings), *ignorePath)
+		return exitValid
+	}
+
+	ignore, err := loadLintIgnore(*ignorePath, *ignorePath != defaultLintIgnore)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error %v\n", err)
+		return exitLoadFail
+	}
+	var reported []openapi.Finding
+	for _, f := range findings {
+		if !ignore[lintIgnoreKey(f)] {
+			reported = append(reported, f)
+		}
+	}
+	ignored := len(findings) - len(reported)
+
+	code := exitValid
+	if openapi.HasErrors(reported) {
+		code = exitInvalid
+	}
+
+	if *format == "json" {
+		if reported == nil {
+			reported = []openapi.Finding{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(lintReport{Spec: *specPath, Findings: reported, Ignored: ignored})
+		return code
+	}
+
+	errorCount := 0
+	for _, f := range reported {
+		fmt.Println(f)
+		if f.Severity == openapi.SeverityError {
+			errorCount++
+		}
+	}
+	if code == exitValid {
+		fmt.Printf("✓ %s passes lint (%d findings, %d ignored)\n", *specPath, len(reported), ignored)
+	} else {
+		fmt.Printf("✗ %s has %d lint errors (%d findings, %d ignored)\n", *specPath, errorCount, len(reported), ignored)
+	}
+	return code
+}
+
+// loadLintConfig reads a lint configuration file such as
+//
+//	rules:
+//	  operation-tags: off
+//	  operation-summary: error
+//
+// A missing file is an empty configuration unless required is set.
+func loadLintConfig(path string, required bool) (openapi.LintConfig, error) {
+	var config openapi.LintConfig
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && !required {
+		return config, nil
+	}
+	if err != nil {
+		return config, fmt.Errorf("reading lint config: %w", err)
+	}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("parsing lint config %s: %w", path, err)
+	}
+	return config, nil
+}
+
+// lintIgnoreKey identifies a finding in the ignore file as "rule location"
+func lintIgnoreKey(f openapi.Finding) string {
+	return f.Rule + " " + f.Location
+}
+
+// loadLintIgnore reads the findings of an ignore file, skipping blank lines
+// and # comments. A missing file ignores nothing unless required is set.
+func loadLintIgnore(path string, required bool) (map[string]bool, error) {
+	ignore := make(map[string]bool)
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) && !required {
+		return ignore, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading lint ignore file: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			ignore[line] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading lint ignore file: %w", err)
+	}
+	return ignore, nil
+}
+
+// writeLintIgnore writes an ignore file grandfathering findings
+func writeLintIgnore(path string, findings []openapi.Finding) error {
+	var sb strings.Builder
+	sb.WriteString("# Lint findings ignored by specweaver lint, one \"rule location\" per line.\n")
+	sb.WriteString("# Regenerate with specweaver lint -write-ignore.\n")
+	for _, f := range findings {
+		sb.WriteString(lintIgnoreKey(f))
+		sb.WriteString("\n")
+	}
+	return os.WriteFile(path, []byte(sb.String()), 0644)
+}
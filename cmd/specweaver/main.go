@@ -1,59 +1,394 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
+	"go/token"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/christopherklint97/specweaver/pkg/generator"
+	"github.com/christopherklint97/specweaver/pkg/openapi"
 	"github.com/christopherklint97/specweaver/pkg/parser"
 )
 
 const version = "0.1.0"
 
 func main() {
-	// Define flags
-	specPath := flag.String("spec", "", "Path to OpenAPI specification file (required)")
-	outputDir := flag.String("output", "./generated", "Output directory for generated code")
-	packageName := flag.String("package", "api", "Package name for generated code")
-	showVersion := flag.Bool("version", false, "Show version information")
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "watch":
+			os.Exit(runWatch(os.Args[2:]))
+		case "validate":
+			os.Exit(runValidate(os.Args[2:]))
+		case "lint":
+			os.Exit(runLint(os.Args[2:]))
+		case "mock":
+			os.Exit(runMock(os.Args[2:]))
+		case "init":
+			os.Exit(runInit(os.Args[2:]))
+		}
+	}
+
+	os.Exit(runGenerate(os.Args[1:]))
+}
 
-	flag.Parse()
+// runGenerate generates code once, the default command
+func runGenerate(args []string) int {
+	fs := flag.NewFlagSet("specweaver", flag.ExitOnError)
+	opts := addGenerateFlags(fs)
+	goGenerate := fs.Bool("go-generate", false, "Also write generate.go with a //go:generate directive repeating this run")
+	check := fs.Bool("check", false, "Compare the generated code with the files on disk instead of writing it, exiting with 1 when they differ")
+	showVersion := fs.Bool("version", false, "Show version information")
+	fs.Parse(args)
 
 	// Show version
 	if *showVersion {
 		fmt.Printf("SpecWeaver version %s\n", version)
-		os.Exit(0)
+		return 0
 	}
 
 	// Validate required flags
-	if *specPath == "" {
+	if len(*opts.specs) == 0 {
 		fmt.Fprintf(os.Stderr, "Error: -spec flag is required\n\n")
-		fmt.Fprintf(os.Stderr, "Usage: specweaver -spec <path> [options]\n\n")
-		flag.PrintDefaults()
-		os.Exit(1)
+		fmt.Fprintf(os.Stderr, "Usage: specweaver -spec <path> [-spec <path> ...] [options]\n")
+		fmt.Fprintf(os.Stderr, "       specweaver watch -spec <path> [options]\n")
+		fmt.Fprintf(os.Stderr, "       specweaver validate -spec <path> [-format text|json]\n")
+		fmt.Fprintf(os.Stderr, "       specweaver lint -spec <path> [-config <path>] [-ignore <path>] [-write-ignore]\n")
+		fmt.Fprintf(os.Stderr, "       specweaver mock -spec <path> [-port <port>]\n")
+		fmt.Fprintf(os.Stderr, "       specweaver init [-dir <path>] [-module <path>] [-router <router>]\n\n")
+		fs.PrintDefaults()
+		return 1
+	}
+
+	if *check {
+		return checkGenerated(opts)
 	}
 
-	// Parse the OpenAPI specification
-	p := parser.New()
-	if err := p.ParseFile(*specPath); err != nil {
-		fmt.Fprintf(os.Stderr, "Error parsing OpenAPI spec: %v\n", err)
-		os.Exit(1)
+	if err := generate(opts); err != nil {
+		fmt.Fprintf(os.Stderr, "Error %v\n", err)
+		return 1
 	}
 
-	fmt.Printf("✓ Loaded OpenAPI %s specification: %s\n", p.GetVersion(), p.GetSpec().Info.Title)
+	if *goGenerate {
+		targets, _ := opts.targets()
+		for _, target := range targets {
+			if err := writeGoGenerate(target, fs, *opts.quiet || *opts.format == "json"); err != nil {
+				fmt.Fprintf(os.Stderr, "Error %v\n", err)
+				return 1
+			}
+		}
+	}
+	return 0
+}
 
-	// Generate code
-	config := generator.Config{
-		OutputDir:   *outputDir,
-		PackageName: *packageName,
+// checkGenerated reports whether the code generated from each spec matches
+// the files on disk, printing the files that differ
+func checkGenerated(f *generateFlags) int {
+	targets, err := f.targets()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error %v\n", err)
+		return 1
 	}
 
-	gen := generator.NewGenerator(p.GetSpec(), config)
-	if err := gen.Generate(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error generating code: %v\n", err)
-		os.Exit(1)
+	code := 0
+	for _, target := range targets {
+		p := parser.New()
+		if err := p.ParseFile(target.specPath); err != nil {
+			fmt.Fprintf(os.Stderr, "Error parsing OpenAPI spec %s: %v\n", target.specPath, err)
+			return 1
+		}
+
+		changes, err := generator.NewGenerator(p.GetSpec(), f.config(target)).Check()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error generating code for %s: %v\n", target.specPath, err)
+			return 1
+		}
+
+		if len(changes) == 0 {
+			fmt.Printf("✓ Generated code in %s is up to date with %s\n", target.outputDir, target.specPath)
+			continue
+		}
+		fmt.Printf("✗ Generated code in %s is out of date with %s:\n", target.outputDir, target.specPath)
+		for _, change := range changes {
+			fmt.Printf("  - %s\n", change)
+		}
+		code = 1
+	}
+
+	if code != 0 {
+		fmt.Printf("\nRun specweaver without -check to regenerate.\n")
+	}
+	return code
+}
+
+// generateFlags holds the flags shared by the commands that generate code
+type generateFlags struct {
+	specs         *specList
+	outputDir     *string
+	packageName   *string
+	routerTarget  *string
+	splitByTag    *bool
+	basePath      *string
+	embedSpec     *bool
+	docs          *bool
+	autoHead      *bool
+	cors          *bool
+	idempotency   *bool
+	thin          *bool
+	maxBodySize   *int64
+	timeout       *time.Duration
+	principalType *string
+	stubs         *bool
+	tagInterfaces *bool
+	only          *string
+	skip          *string
+	includeTags   *string
+	excludeTags   *string
+	includePaths  *string
+	excludePaths  *string
+	verbose       *bool
+	quiet         *bool
+	format        *string
+	typesOutput   *string
+}
+
+// addGenerateFlags defines the code generation flags on fs
+func addGenerateFlags(fs *flag.FlagSet) *generateFlags {
+	specs := &specList{}
+	fs.Var(specs, "spec", "Path or glob of OpenAPI specification files, repeatable (required)")
+	return &generateFlags{
+		specs:         specs,
+		outputDir:     fs.String("output", "./generated", "Output directory for generated code"),
+		packageName:   fs.String("package", "api", "Package name for generated code"),
+		routerTarget:  fs.String("router", generator.RouterBuiltin, "Router to register generated routes on (builtin, chi, stdlib)"),
+		splitByTag:    fs.Bool("split-by-tag", false, "Split generated server code into one file per tag"),
+		basePath:      fs.String("base-path", "", "Route prefix (default: path of the spec's first server URL; \"/\" for none)"),
+		embedSpec:     fs.Bool("embed-spec", false, "Embed the spec in the generated package and serve it at openapi.json and openapi.yaml"),
+		docs:          fs.Bool("docs", false, "Generate MountDocs serving Swagger UI and Redoc pages for the embedded spec (implies -embed-spec)"),
+		autoHead:      fs.Bool("auto-head", false, "Answer HEAD requests with the GET handler for paths that define GET but not HEAD"),
+		cors:          fs.Bool("cors", false, "Answer OPTIONS and CORS preflight requests and generate WithCORS for configuring a CORS policy"),
+		idempotency:   fs.Bool("idempotency", false, "Accept an Idempotency-Key header on all POST and PATCH operations and replay recorded responses"),
+		thin:          fs.Bool("thin", false, "Generate plain (w, r) handlers plus Bind<Operation>Request functions instead of typed handlers"),
+		maxBodySize:   fs.Int64("max-body-size", 0, "Limit JSON request bodies to this many bytes unless an operation sets x-max-body-size (0: unlimited)"),
+		timeout:       fs.Duration("timeout", 0, "Context deadline for handlers of operations without x-timeout; errors returned after it are answered with 504 (0: none)"),
+		principalType: fs.String("principal-type", "", "Go type of authenticated principals, e.g. \"*User\", used for SecurityContext.Principal (default: any)"),
+		stubs:         fs.Bool("stubs", false, "Generate unimplemented.go (501 for every operation) and a skeleton cmd/server/main.go"),
+		tagInterfaces: fs.Bool("tag-interfaces", false, "Generate one Server interface per tag plus a ComposeServer helper"),
+		only:          fs.String("only", "", "Comma-separated artifacts to generate: types, server, auth, stubs (default: all)"),
+		skip:          fs.String("skip", "", "Comma-separated artifacts not to generate: types, server, auth, stubs"),
+		includeTags:   fs.String("include-tags", "", "Comma-separated tags; generate only operations with one of them"),
+		excludeTags:   fs.String("exclude-tags", "", "Comma-separated tags; leave out operations with any of them"),
+		includePaths:  fs.String("include-paths", "", "Comma-separated path globs, e.g. '/v1/**'; generate only operations on matching paths"),
+		excludePaths:  fs.String("exclude-paths", "", "Comma-separated path globs; leave out operations on matching paths"),
+		typesOutput:   fs.String("types-output", "", "Write types.go to its own package in this directory, imported by the server code (must be inside a Go module)"),
+		verbose:       fs.Bool("v", false, "Also print infos, such as identifiers renamed for Go"),
+		quiet:         fs.Bool("q", false, "Print errors only"),
+		format:        fs.String("format", "text", "Output format: text, or json for a report of the diagnostics of each spec"),
+	}
+}
+
+// config returns the generator configuration the flags describe for target
+func (f *generateFlags) config(target generateTarget) generator.Config {
+	return generator.Config{
+		OutputDir:      target.outputDir,
+		PackageName:    target.packageName,
+		Router:         *f.routerTarget,
+		SplitByTag:     *f.splitByTag,
+		TagInterfaces:  *f.tagInterfaces,
+		Stubs:          *f.stubs,
+		BasePath:       *f.basePath,
+		EmbedSpec:      *f.embedSpec,
+		Docs:           *f.docs,
+		AutoHead:       *f.autoHead,
+		CORS:           *f.cors,
+		Idempotency:    *f.idempotency,
+		Thin:           *f.thin,
+		MaxBodySize:    *f.maxBodySize,
+		Timeout:        *f.timeout,
+		PrincipalType:  *f.principalType,
+		Only:           splitList(*f.only),
+		Skip:           splitList(*f.skip),
+		IncludeTags:    splitList(*f.includeTags),
+		ExcludeTags:    splitList(*f.excludeTags),
+		IncludePaths:   splitList(*f.includePaths),
+		ExcludePaths:   splitList(*f.excludePaths),
+		Quiet:          *f.quiet || *f.format == "json",
+		TypesOutputDir: target.typesDir,
+	}
+}
+
+// generate parses the specs and generates code as the flags describe
+func generate(f *generateFlags) error {
+	if *f.format != "text" && *f.format != "json" {
+		return fmt.Errorf("invalid -format %q (supported: text, json)", *f.format)
+	}
+	targets, err := f.targets()
+	if err != nil {
+		return err
+	}
+
+	reports := make([]generateReport, 0, len(targets))
+	for _, target := range targets {
+		config := f.config(target)
+
+		// Parse the OpenAPI specification
+		p := parser.New()
+		if err := p.ParseFile(target.specPath); err != nil {
+			return fmt.Errorf("parsing OpenAPI spec %s: %w", target.specPath, err)
+		}
+
+		if !config.Quiet {
+			fmt.Printf("✓ Loaded OpenAPI %s specification: %s\n", p.GetVersion(), p.GetSpec().Info.Title)
+		}
+
+		// Generate code
+		gen := generator.NewGenerator(p.GetSpec(), config)
+		if err := gen.Generate(); err != nil {
+			return fmt.Errorf("generating code for %s: %w", target.specPath, err)
+		}
+
+		diagnostics := gen.Diagnostics()
+		if diagnostics == nil {
+			diagnostics = []openapi.Finding{}
+		}
+		reports = append(reports, generateReport{Spec: target.specPath, Output: target.outputDir, Diagnostics: diagnostics})
+		if config.Quiet {
+			continue
+		}
+		for _, d := range diagnostics {
+			if d.Severity != openapi.SeverityInfo || *f.verbose {
+				fmt.Printf("  ! %s\n", d)
+			}
+		}
 	}
 
-	os.Exit(0)
+	if *f.format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(reports)
+	}
+	return nil
+}
+
+// generateReport is the JSON output of a generation run for one spec
+type generateReport struct {
+	Spec        string            `json:"spec"`
+	Output      string            `json:"output"`
+	Diagnostics []openapi.Finding `json:"diagnostics"`
+}
+
+// generateTarget is a spec and the package generated from it
+type generateTarget struct {
+	specPath    string
+	outputDir   string
+	packageName string
+	typesDir    string // directory of the separate types package, if any
+}
+
+// targets returns the package to generate for each spec. A single spec is
+// generated into -output as -package. Several specs are each generated into
+// a subdirectory of -output, named like the package, with the package name
+// derived from the spec file name and numbered if another spec took it.
+func (f *generateFlags) targets() ([]generateTarget, error) {
+	paths, err := f.specs.paths()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(paths) == 1 {
+		return []generateTarget{{specPath: paths[0], outputDir: *f.outputDir, packageName: *f.packageName, typesDir: *f.typesOutput}}, nil
+	}
+
+	targets := make([]generateTarget, 0, len(paths))
+	taken := make(map[string]bool)
+	for _, path := range paths {
+		base := packageNameOf(path)
+		name := base
+		for i := 2; taken[name]; i++ {
+			name = fmt.Sprintf("%s%d", base, i)
+		}
+		taken[name] = true
+		target := generateTarget{
+			specPath:    path,
+			outputDir:   filepath.Join(*f.outputDir, name),
+			packageName: name,
+		}
+		if *f.typesOutput != "" {
+			target.typesDir = filepath.Join(*f.typesOutput, name)
+		}
+		targets = append(targets, target)
+	}
+	return targets, nil
+}
+
+// specList collects the values of a repeated -spec flag
+type specList []string
+
+func (s *specList) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *specList) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+// paths expands the glob patterns of the list into spec paths, in flag order
+// and without duplicates. Values without glob characters are used as given.
+func (s *specList) paths() ([]string, error) {
+	var paths []string
+	seen := make(map[string]bool)
+	for _, value := range *s {
+		matches := []string{value}
+		if strings.ContainsAny(value, "*?[") {
+			var err error
+			if matches, err = filepath.Glob(value); err != nil {
+				return nil, fmt.Errorf("invalid -spec pattern %q: %w", value, err)
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("no spec matches %q", value)
+			}
+		}
+		for _, path := range matches {
+			if !seen[path] {
+				seen[path] = true
+				paths = append(paths, path)
+			}
+		}
+	}
+	return paths, nil
+}
+
+// packageNameOf derives a Go package name from the name of a spec file, e.g.
+// "pet-store.v2.yaml" becomes "petstorev2"
+func packageNameOf(specPath string) string {
+	base := filepath.Base(specPath)
+	base = strings.TrimSuffix(base, filepath.Ext(base))
+
+	var sb strings.Builder
+	for _, r := range strings.ToLower(base) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			sb.WriteRune(r)
+		}
+	}
+	name := sb.String()
+	if name == "" || (name[0] >= '0' && name[0] <= '9') || token.IsKeyword(name) {
+		name = "api" + name
+	}
+	return name
+}
+
+// splitList splits a comma-separated flag value, dropping empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
 }
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// parseGenerateFlags parses args as generation flags
+func parseGenerateFlags(t *testing.T, args ...string) *generateFlags {
+	t.Helper()
+	fs := flag.NewFlagSet("specweaver", flag.ContinueOnError)
+	opts := addGenerateFlags(fs)
+	require.NoError(t, fs.Parse(args))
+	return opts
+}
+
+func TestPackageNameOf(t *testing.T) {
+	tests := []struct {
+		specPath string
+		expected string
+	}{
+		{"api.yaml", "api"},
+		{"specs/Pet-Store.v2.yaml", "petstorev2"},
+		{"orders_service.json", "ordersservice"},
+		{"2024-billing.yaml", "api2024billing"},
+		{"type.yaml", "apitype"},
+		{"Func.yml", "apifunc"},
+		{"---.yaml", "api"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.specPath, func(t *testing.T) {
+			assert.Equal(t, tt.expected, packageNameOf(tt.specPath))
+		})
+	}
+}
+
+func TestTargets(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected []generateTarget
+	}{
+		{
+			name: "Single spec uses the flags as given",
+			args: []string{"-spec", "specs/pets.yaml", "-output", "out", "-package", "petapi", "-types-output", "types"},
+			expected: []generateTarget{
+				{specPath: "specs/pets.yaml", outputDir: "out", packageName: "petapi", typesDir: "types"},
+			},
+		},
+		{
+			name: "Several specs get a package each",
+			args: []string{"-spec", "pets.yaml", "-spec", "orders.yaml", "-output", "out", "-package", "ignored"},
+			expected: []generateTarget{
+				{specPath: "pets.yaml", outputDir: filepath.Join("out", "pets"), packageName: "pets"},
+				{specPath: "orders.yaml", outputDir: filepath.Join("out", "orders"), packageName: "orders"},
+			},
+		},
+		{
+			name: "Repeated names are numbered",
+			args: []string{"-spec", "v1/api.yaml", "-spec", "v2/api.yaml", "-spec", "v3/API.json", "-output", "out"},
+			expected: []generateTarget{
+				{specPath: "v1/api.yaml", outputDir: filepath.Join("out", "api"), packageName: "api"},
+				{specPath: "v2/api.yaml", outputDir: filepath.Join("out", "api2"), packageName: "api2"},
+				{specPath: "v3/API.json", outputDir: filepath.Join("out", "api3"), packageName: "api3"},
+			},
+		},
+		{
+			name: "Numbered names do not take derived ones",
+			args: []string{"-spec", "a/api.yaml", "-spec", "b/api.yaml", "-spec", "api2.yaml", "-output", "out"},
+			expected: []generateTarget{
+				{specPath: "a/api.yaml", outputDir: filepath.Join("out", "api"), packageName: "api"},
+				{specPath: "b/api.yaml", outputDir: filepath.Join("out", "api2"), packageName: "api2"},
+				{specPath: "api2.yaml", outputDir: filepath.Join("out", "api22"), packageName: "api22"},
+			},
+		},
+		{
+			name: "Digit and keyword names are prefixed",
+			args: []string{"-spec", "2024.yaml", "-spec", "type.yaml", "-output", "out"},
+			expected: []generateTarget{
+				{specPath: "2024.yaml", outputDir: filepath.Join("out", "api2024"), packageName: "api2024"},
+				{specPath: "type.yaml", outputDir: filepath.Join("out", "apitype"), packageName: "apitype"},
+			},
+		},
+		{
+			name: "Types packages per spec",
+			args: []string{"-spec", "pets.yaml", "-spec", "v2/pets.yaml", "-output", "out", "-types-output", "types"},
+			expected: []generateTarget{
+				{specPath: "pets.yaml", outputDir: filepath.Join("out", "pets"), packageName: "pets", typesDir: filepath.Join("types", "pets")},
+				{specPath: "v2/pets.yaml", outputDir: filepath.Join("out", "pets2"), packageName: "pets2", typesDir: filepath.Join("types", "pets2")},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := parseGenerateFlags(t, tt.args...)
+			targets, err := opts.targets()
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, targets)
+		})
+	}
+}
+
+func TestSpecListPaths(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.yaml", "b.yaml", "c.json"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("openapi: 3.1.0\n"), 0644))
+	}
+	a, b, c := filepath.Join(dir, "a.yaml"), filepath.Join(dir, "b.yaml"), filepath.Join(dir, "c.json")
+
+	tests := []struct {
+		name     string
+		specs    specList
+		expected []string
+		err      string
+	}{
+		{
+			name:     "Plain paths are used as given",
+			specs:    specList{"missing.yaml", c},
+			expected: []string{"missing.yaml", c},
+		},
+		{
+			name:     "Globs expand in name order",
+			specs:    specList{filepath.Join(dir, "*.yaml")},
+			expected: []string{a, b},
+		},
+		{
+			name:     "Duplicates keep their first position",
+			specs:    specList{b, filepath.Join(dir, "*"), a},
+			expected: []string{b, a, c},
+		},
+		{
+			name:  "Glob without matches",
+			specs: specList{filepath.Join(dir, "*.yml")},
+			err:   "no spec matches",
+		},
+		{
+			name:  "Invalid glob",
+			specs: specList{filepath.Join(dir, "[")},
+			err:   "invalid -spec pattern",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			paths, err := tt.specs.paths()
+			if tt.err != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, paths)
+		})
+	}
+}
+
+func TestGoGenerateDirective(t *testing.T) {
+	tests := []struct {
+		name     string
+		target   generateTarget
+		args     []string
+		expected string
+	}{
+		{
+			name:     "Spec in the parent directory",
+			target:   generateTarget{specPath: "api.yaml", outputDir: "api", packageName: "api"},
+			expected: "//go:generate specweaver -spec ../api.yaml -output . -package api",
+		},
+		{
+			name:     "Spec and types package elsewhere",
+			target:   generateTarget{specPath: "specs/pets.yaml", outputDir: "internal/api", packageName: "petapi", typesDir: "internal/types"},
+			args:     []string{"-router=chi", "-stubs"},
+			expected: "//go:generate specweaver -spec ../../specs/pets.yaml -output . -package petapi -types-output ../types -router=chi -stubs",
+		},
+		{
+			name:     "Spec in the output directory",
+			target:   generateTarget{specPath: "gen/openapi.yaml", outputDir: "gen/", packageName: "gen"},
+			expected: "//go:generate specweaver -spec openapi.yaml -output . -package gen",
+		},
+		{
+			name:     "Arguments with spaces and quotes are quoted",
+			target:   generateTarget{specPath: "my specs/api.yaml", outputDir: "api", packageName: "api"},
+			args:     []string{"-base-path=/a b", `-principal-type="User"`},
+			expected: `//go:generate specweaver -spec "../my specs/api.yaml" -output . -package api "-base-path=/a b" "-principal-type=\"User\""`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			directive, err := goGenerateDirective(tt.target, tt.args)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, directive)
+		})
+	}
+}
+
+func TestDirectiveArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected []string
+	}{
+		{
+			name:     "No flags",
+			args:     []string{"-spec", "api.yaml"},
+			expected: nil,
+		},
+		{
+			name: "Flags set by the directive or only for the command line are dropped",
+			args: []string{"-spec", "api.yaml", "-output", "out", "-package", "petapi", "-types-output", "types",
+				"-go-generate", "-check", "-version", "-format", "json", "-v", "-q"},
+			expected: nil,
+		},
+		{
+			name:     "Other flags are passed on in name order",
+			args:     []string{"-spec", "api.yaml", "-router", "chi", "-timeout", "5s", "-cors", "-only", "types,server", "-go-generate"},
+			expected: []string{"-cors", "-only=types,server", "-router=chi", "-timeout=5s"},
+		},
+		{
+			name:     "Bool flags set to false keep their value",
+			args:     []string{"-spec", "api.yaml", "-stubs=false", "-auto-head=true"},
+			expected: []string{"-auto-head", "-stubs=false"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("specweaver", flag.ContinueOnError)
+			addGenerateFlags(fs)
+			fs.Bool("go-generate", false, "")
+			fs.Bool("check", false, "")
+			fs.Bool("version", false, "")
+			require.NoError(t, fs.Parse(tt.args))
+			assert.Equal(t, tt.expected, directiveArgs(fs))
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/christopherklint97/specweaver/pkg/mock"
+	"github.com/christopherklint97/specweaver/pkg/openapi"
+	"github.com/christopherklint97/specweaver/pkg/router"
+)
+
+// runMock serves fake responses for the operations of a spec until interrupted
+func runMock(args []string) int {
+	fs := flag.NewFlagSet("specweaver mock", flag.ExitOnError)
+	specPath := fs.String("spec", "", "Path to OpenAPI specification file (required)")
+	port := fs.Int("port", 8081, "Port to listen on")
+	basePath := fs.String("base-path", "", "Prefix for all routes (default: path of the spec's first server URL; use / for none)")
+	cors := fs.Bool("cors", true, "Allow requests from any origin")
+	fs.Parse(args)
+
+	if *specPath == "" {
+		fmt.Fprintf(os.Stderr, "Usage: specweaver mock -spec <path> [-port <port>] [-base-path <path>] [-cors=false]\n\n")
+		fs.PrintDefaults()
+		return 1
+	}
+
+	doc, err := openapi.Load(*specPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading %s: %v\n", *specPath, err)
+		return 1
+	}
+	if findings := doc.Validate(); openapi.HasErrors(findings) {
+		for _, f := range findings {
+			if f.Severity == openapi.SeverityError {
+				fmt.Fprintln(os.Stderr, f)
+			}
+		}
+		return 1
+	}
+
+	handler := router.Logger(mock.NewHandler(doc, mock.Options{BasePath: *basePath, CORS: *cors}))
+	addr := fmt.Sprintf(":%d", *port)
+	fmt.Printf("✓ Mocking %s at http://localhost%s\n", doc.Info.Title, addr)
+	fmt.Printf("  Send \"Prefer: code=404\" or \"Prefer: example=<name>\" to choose a response\n")
+	if err := http.ListenAndServe(addr, handler); err != nil {
+		fmt.Fprintf(os.Stderr, "Error %v\n", err)
+		return 1
+	}
+	return 0
+}
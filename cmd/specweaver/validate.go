@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/christopherklint97/specweaver/pkg/generator"
+	"github.com/christopherklint97/specweaver/pkg/openapi"
+)
+
+// Exit codes of the validate command
+const (
+	exitValid    = 0 // no errors, possibly warnings
+	exitInvalid  = 1 // the spec has errors
+	exitLoadFail = 2 // the spec could not be read or parsed
+)
+
+// validateReport is the JSON output of the validate command
+type validateReport struct {
+	Spec     string            `json:"spec"`
+	Valid    bool              `json:"valid"`
+	Findings []openapi.Finding `json:"findings"`
+}
+
+// runValidate checks a spec without generating code
+func runValidate(args []string) int {
+	fs := flag.NewFlagSet("specweaver validate", flag.ExitOnError)
+	specPath := fs.String("spec", "", "Path to OpenAPI specification file (required)")
+	format := fs.String("format", "text", "Output format: text or json")
+	fs.Parse(args)
+
+	if *specPath == "" || (*format != "text" && *format != "json") {
+		fmt.Fprintf(os.Stderr, "Usage: specweaver validate -spec <path> [-format text|json]\n\n")
+		fs.PrintDefaults()
+		return exitLoadFail
+	}
+
+	findings, code := validateSpec(*specPath)
+
+	if *format == "json" {
+		if findings == nil {
+			findings = []openapi.Finding{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(validateReport{Spec: *specPath, Valid: code == exitValid, Findings: findings})
+		return code
+	}
+
+	errors, warnings := 0, 0
+	for _, f := range findings {
+		fmt.Println(f)
+		if f.Severity == openapi.SeverityError {
+			errors++
+		} else {
+			warnings++
+		}
+	}
+	if code == exitValid {
+		fmt.Printf("✓ %s is valid (%d warnings)\n", *specPath, warnings)
+	} else {
+		fmt.Printf("✗ %s has %d errors and %d warnings\n", *specPath, errors, warnings)
+	}
+	return code
+}
+
+// validateSpec loads and validates a spec, then checks that code can be
+// generated from it. It returns the findings and the exit code they call for.
+func validateSpec(specPath string) ([]openapi.Finding, int) {
+	doc, err := openapi.Load(specPath)
+	if err != nil {
+		return []openapi.Finding{{Severity: openapi.SeverityError, Location: specPath, Message: err.Error()}}, exitLoadFail
+	}
+
+	findings := doc.Validate()
+	if openapi.HasErrors(findings) {
+		return findings, exitInvalid
+	}
+
+	// Catch what only the generators reject, such as misplaced wildcards
+	if _, err := generator.NewTypeGenerator(doc).Generate(); err != nil {
+		findings = append(findings, openapi.Finding{Severity: openapi.SeverityError, Location: "types", Message: err.Error()})
+	}
+	if _, err := generator.NewServerGenerator(doc).GenerateFiles(); err != nil {
+		findings = append(findings, openapi.Finding{Severity: openapi.SeverityError, Location: "server", Message: err.Error()})
+	}
+	if openapi.HasErrors(findings) {
+		return findings, exitInvalid
+	}
+	return findings, exitValid
+}
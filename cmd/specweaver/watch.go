@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+)
+
+// runWatch regenerates code whenever the spec changes, until interrupted
+func runWatch(args []string) int {
+	fs := flag.NewFlagSet("specweaver watch", flag.ExitOnError)
+	opts := addGenerateFlags(fs)
+	interval := fs.Duration("interval", 500*time.Millisecond, "How often to check the spec for changes")
+	debounce := fs.Duration("debounce", 200*time.Millisecond, "How long the spec must stay unchanged before regenerating")
+	fs.Parse(args)
+
+	paths, err := opts.specs.paths()
+	if err != nil || len(paths) != 1 {
+		fmt.Fprintf(os.Stderr, "Error: watch takes a single -spec file\n\n")
+		fmt.Fprintf(os.Stderr, "Usage: specweaver watch -spec <path> [options]\n\n")
+		fs.PrintDefaults()
+		return 1
+	}
+	specPath := paths[0]
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	regenerate := func() {
+		if err := generate(opts); err != nil {
+			fmt.Fprintf(os.Stderr, "Error %v\n", err)
+		}
+	}
+
+	regenerate()
+	fmt.Printf("Watching %s for changes (Ctrl+C to stop)\n", specPath)
+
+	last := statFile(specPath)
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return 0
+		case <-ticker.C:
+		}
+
+		current := statFile(specPath)
+		if current == last {
+			continue
+		}
+
+		// Editors often write a file in several steps, so wait for it to settle
+		for {
+			select {
+			case <-ctx.Done():
+				return 0
+			case <-time.After(*debounce):
+			}
+			settled := statFile(specPath)
+			if settled == current {
+				break
+			}
+			current = settled
+		}
+
+		last = current
+		fmt.Printf("\n%s changed, regenerating\n", specPath)
+		regenerate()
+	}
+}
+
+// fileState identifies a version of a file by its size and modification time
+type fileState struct {
+	size    int64
+	modTime time.Time
+	exists  bool
+}
+
+// statFile returns the current state of the file at path
+func statFile(path string) fileState {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileState{}
+	}
+	return fileState{size: info.Size(), modTime: info.ModTime(), exists: true}
+}
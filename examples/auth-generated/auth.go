@@ -2,10 +2,17 @@ package api
 
 import (
 	"context"
+	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/base64"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"net/http"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 )
 
 // contextKey is a private type for context keys to avoid collisions
@@ -45,14 +52,48 @@ type OpenIDConnectCredentials struct {
 	Token string
 }
 
+// HTTPCredentials holds the credentials of an HTTP authentication scheme other
+// than Basic and Bearer, such as Digest
+type HTTPCredentials struct {
+	// Scheme is the authentication scheme named in the Authorization header
+	Scheme string
+	// Value is the rest of the Authorization header, e.g. the Digest parameters
+	Value string
+}
+
+// Principal is the type of authenticated users/entities. Authenticators return
+// values of this type, optionally wrapped in a ScopedPrincipal.
+type Principal = any
+
 // SecurityContext holds authentication information
 type SecurityContext struct {
-	// Principal is the authenticated user/entity (type depends on your implementation)
-	Principal any
-	// SchemeName is the name of the security scheme that was used
+	// Principal is the authenticated user/entity. When a requirement combines several
+	// schemes, it is the principal of the first scheme in alphabetical order.
+	Principal Principal
+	// SchemeName is the name of the security scheme Principal was authenticated with
 	SchemeName string
-	// Scopes are the OAuth2 scopes (if applicable)
+	// Principals holds the principal of every scheme of the satisfied requirement
+	Principals map[string]Principal
+	// Scopes are the OAuth2 scopes required by the operation (if applicable)
 	Scopes []string
+	// GrantedScopes are the scopes the authenticator reported for the principal
+	GrantedScopes []string
+	// Roles and Permissions are those the authenticator reported for the principal
+	Roles       []string
+	Permissions []string
+}
+
+// ScopedPrincipal is returned by an authenticator to report the scopes, roles and
+// permissions granted to a principal. Operations requiring scopes are only served
+// when every required scope has been granted, operations with x-roles when one of
+// their roles has been granted, and operations with x-permissions when every
+// permission has been granted. They fail with 403 Forbidden otherwise. Handlers
+// see the wrapped Principal in the SecurityContext.
+type ScopedPrincipal struct {
+	Principal   any
+	Scopes      []string
+	Roles       []string
+	Permissions []string
 }
 
 // GetSecurityContext retrieves the security context from the request context
@@ -97,110 +138,286 @@ type Authenticator interface {
 
 }
 
+// Authorizer is an optional interface for authorization policies. When the
+// Authenticator passed to the router implements it, Authorize is called after a
+// request has been authenticated, with the scopes granted to the principal.
+// GetSecurityContext and GetOperationInfo return the details of the request.
+// Returning an error refuses the request with 403 Forbidden, or with the status
+// of an HTTPError.
+type Authorizer interface {
+	Authorize(ctx context.Context, principal Principal, operationID string, scopes []string) error
+}
+
+// authorize calls the Authorize method of authenticators implementing Authorizer
+func authorize(ctx context.Context, authenticator Authenticator, secCtx *SecurityContext) error {
+	authorizer, ok := authenticator.(Authorizer)
+	if !ok {
+		return nil
+	}
+	var operationID string
+	if info := GetOperationInfo(ctx); info != nil {
+		operationID = info.ID
+	}
+	return authorizer.Authorize(ctx, secCtx.Principal, operationID, secCtx.GrantedScopes)
+}
+
+// AuthDecision describes a request allowed or refused by the auth middleware
+type AuthDecision struct {
+	Allowed bool
+	// Scheme is the security scheme that authenticated or refused the principal, if any
+	Scheme string
+	// Principal is the authenticated principal, if any
+	Principal Principal
+	// Operation is the operation ID of the request
+	Operation string
+	// Status is the response status of refused requests
+	Status int
+	Reason string
+}
+
+// AuthObserver is an optional interface for auditing. When the Authenticator
+// passed to the router implements it, ObserveAuth is called with every decision
+// of the auth middleware.
+type AuthObserver interface {
+	ObserveAuth(ctx context.Context, decision AuthDecision)
+}
+
+// observeAuth reports a decision to authenticators implementing AuthObserver
+func observeAuth(ctx context.Context, authenticator Authenticator, decision AuthDecision) {
+	observer, ok := authenticator.(AuthObserver)
+	if !ok {
+		return
+	}
+	if info := GetOperationInfo(ctx); info != nil {
+		decision.Operation = info.ID
+	}
+	observer.ObserveAuth(ctx, decision)
+}
+
+// refuseAuth writes the error response of a refused request and reports the decision
+func refuseAuth(w http.ResponseWriter, r *http.Request, authenticator Authenticator, err error, decision AuthDecision) {
+	decision.Reason = err.Error()
+	observeAuth(r.Context(), authenticator, decision)
+	WriteError(w, decision.Status, err)
+}
+
 // authMiddleware creates authentication middleware for an operation
 func authMiddleware(authenticator Authenticator, securityReqs []map[string][]string, schemes map[string]*SecuritySchemeInfo) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			ctx := r.Context()
 
+			// If no authenticator provided, skip authentication
+			if authenticator == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
 			// If no security requirements, continue without authentication
 			if len(securityReqs) == 0 {
 				next.ServeHTTP(w, r)
 				return
 			}
 
+			// Why an authenticated principal is not authorized, such as missing scopes
+			var forbidden error
+			// An empty requirement allows anonymous access when no credentials are presented
+			anonymous, presented := false, false
+			// The authenticator error deciding the response, preferring statuses other than 401
+			var denied *HTTPError
+			// The schemes and principal refused by forbidden and denied, for AuthObservers
+			var forbiddenScheme, deniedScheme string
+			var forbiddenPrincipal Principal
+
 			// Try each security requirement (OR logic)
 			for _, req := range securityReqs {
+				if len(req) == 0 {
+					anonymous = true
+					continue
+				}
+
 				// All schemes in a requirement must be satisfied (AND logic)
-				var secCtx *SecurityContext
-				var authErr error
+				secCtx := &SecurityContext{Principals: make(map[string]Principal, len(req))}
 				allSatisfied := true
 
-				for schemeName, scopes := range req {
+				for _, schemeName := range schemeNames(req) {
+					scopes := req[schemeName]
 					schemeInfo, exists := schemes[schemeName]
 					if !exists {
 						allSatisfied = false
 						break
 					}
 
-					// Authenticate based on scheme type
-					var principal any
-					switch schemeInfo.Type {
-					case "http":
-						if schemeInfo.Scheme == "basic" {
-							creds, err := extractBasicAuth(r)
-							if err != nil {
-								allSatisfied = false
-								authErr = err
-								break
-							}
-							principal, authErr = callAuthenticator(authenticator, schemeName, ctx, creds)
-						} else if schemeInfo.Scheme == "bearer" {
-							creds, err := extractBearerToken(r)
-							if err != nil {
-								allSatisfied = false
-								authErr = err
-								break
-							}
-							principal, authErr = callAuthenticator(authenticator, schemeName, ctx, creds)
-						}
-					case "apiKey":
-						creds, err := extractAPIKey(r, schemeInfo.In, schemeInfo.Name)
-						if err != nil {
-							allSatisfied = false
-							authErr = err
-							break
-						}
-						principal, authErr = callAuthenticator(authenticator, schemeName, ctx, creds)
-					case "oauth2":
-						creds, err := extractOAuth2Token(r, scopes)
-						if err != nil {
-							allSatisfied = false
-							authErr = err
-							break
-						}
-						principal, authErr = callAuthenticator(authenticator, schemeName, ctx, creds)
-					case "openIdConnect":
-						creds, err := extractOpenIDConnectToken(r)
-						if err != nil {
-							allSatisfied = false
-							authErr = err
-							break
+					principal, err := authenticateScheme(ctx, authenticator, r, schemeName, schemeInfo, scopes)
+					if !errors.Is(err, errMissingCredentials) {
+						presented = true
+					}
+					if err != nil {
+						var httpErr *HTTPError
+						if errors.As(err, &httpErr) && (denied == nil || denied.Code == http.StatusUnauthorized) {
+							denied, deniedScheme = httpErr, schemeName
 						}
-						principal, authErr = callAuthenticator(authenticator, schemeName, ctx, creds)
-					default:
 						allSatisfied = false
-						authErr = errors.New("unsupported security scheme type")
+						break
 					}
 
-					if authErr != nil {
+					// The principal must have been granted every required scope
+					typed, granted, err := principalScopes(principal)
+					if err != nil {
+						refuseAuth(w, r, authenticator, err, AuthDecision{Scheme: schemeName, Principal: typed, Status: http.StatusInternalServerError})
+						return
+					}
+					if missing := missingScopes(scopes, granted); len(missing) > 0 {
+						forbidden = fmt.Errorf("insufficient scope: missing %s", strings.Join(missing, ", "))
+						forbiddenScheme, forbiddenPrincipal = schemeName, typed
 						allSatisfied = false
 						break
 					}
 
-					// Create or update security context
-					secCtx = &SecurityContext{
-						Principal:  principal,
-						SchemeName: schemeName,
-						Scopes:     scopes,
+					// Record the principal of every scheme; the first one is the primary principal
+					secCtx.Principals[schemeName] = typed
+					if secCtx.SchemeName == "" {
+						secCtx.Principal, secCtx.SchemeName = typed, schemeName
+					}
+					secCtx.Scopes = append(secCtx.Scopes, scopes...)
+					secCtx.GrantedScopes = append(secCtx.GrantedScopes, granted...)
+					roles, permissions := principalRoles(principal)
+					secCtx.Roles = append(secCtx.Roles, roles...)
+					secCtx.Permissions = append(secCtx.Permissions, permissions...)
+				}
+
+				// The principal must hold the roles and permissions of the operation
+				if allSatisfied {
+					if err := authorizeRoles(GetOperationInfo(ctx), secCtx); err != nil {
+						forbidden = err
+						forbiddenScheme, forbiddenPrincipal = secCtx.SchemeName, secCtx.Principal
+						allSatisfied = false
 					}
 				}
 
 				// If all schemes in this requirement were satisfied, continue
-				if allSatisfied && secCtx != nil {
+				if allSatisfied {
 					ctx = context.WithValue(ctx, securityContextKey, secCtx)
 					r = r.WithContext(ctx)
+					if err := authorize(ctx, authenticator, secCtx); err != nil {
+						var httpErr *HTTPError
+						if errors.As(err, &httpErr) {
+							refuseAuth(w, r, authenticator, httpErr, AuthDecision{Scheme: secCtx.SchemeName, Principal: secCtx.Principal, Status: httpErr.Code})
+							return
+						}
+						refuseAuth(w, r, authenticator, err, AuthDecision{Scheme: secCtx.SchemeName, Principal: secCtx.Principal, Status: http.StatusForbidden})
+						return
+					}
+					observeAuth(ctx, authenticator, AuthDecision{Allowed: true, Scheme: secCtx.SchemeName, Principal: secCtx.Principal, Reason: "authenticated"})
 					next.ServeHTTP(w, r)
 					return
 				}
 			}
 
+			// Continue unauthenticated when anonymous access is allowed and the operation
+			// requires no roles or permissions
+			if anonymous && !presented && authorizeRoles(GetOperationInfo(ctx), &SecurityContext{}) == nil {
+				observeAuth(ctx, authenticator, AuthDecision{Allowed: true, Reason: "anonymous access"})
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// An authenticated principal lacking scopes, roles or permissions is not authorized
+			if forbidden != nil {
+				refuseAuth(w, r, authenticator, forbidden, AuthDecision{Scheme: forbiddenScheme, Principal: forbiddenPrincipal, Status: http.StatusForbidden})
+				return
+			}
+
+			// An authenticator refusing credentials with another status, such as 403, decides it
+			if denied != nil && denied.Code != http.StatusUnauthorized {
+				refuseAuth(w, r, authenticator, denied, AuthDecision{Scheme: deniedScheme, Status: denied.Code})
+				return
+			}
+
 			// None of the security requirements were satisfied
-			WriteError(w, http.StatusUnauthorized, errors.New("authentication required"))
+			for _, challenge := range authChallenges(securityReqs, schemes) {
+				w.Header().Add("WWW-Authenticate", challenge)
+			}
+			if denied != nil {
+				refuseAuth(w, r, authenticator, denied, AuthDecision{Scheme: deniedScheme, Status: http.StatusUnauthorized})
+				return
+			}
+			refuseAuth(w, r, authenticator, errors.New("authentication required"), AuthDecision{Status: http.StatusUnauthorized})
 		})
 	}
 }
 
+// authRealm is the realm of WWW-Authenticate challenges
+const authRealm = "Auth Example API"
+
+// authChallenges returns the WWW-Authenticate challenges of the HTTP
+// authentication schemes an operation accepts
+func authChallenges(securityReqs []map[string][]string, schemes map[string]*SecuritySchemeInfo) []string {
+	var challenges []string
+	for _, req := range securityReqs {
+		for _, name := range schemeNames(req) {
+			info, ok := schemes[name]
+			if !ok {
+				continue
+			}
+			var challenge string
+			switch {
+			case info.Type == "http" && info.Scheme == "basic":
+				challenge = fmt.Sprintf("Basic realm=%q", authRealm)
+			case info.Type == "http" && info.Scheme == "bearer", info.Type == "oauth2", info.Type == "openIdConnect":
+				challenge = fmt.Sprintf("Bearer realm=%q", authRealm)
+			}
+			if challenge != "" && !slices.Contains(challenges, challenge) {
+				challenges = append(challenges, challenge)
+			}
+		}
+	}
+	return challenges
+}
+
+// schemeNames returns the scheme names of a security requirement in sorted order
+func schemeNames(req map[string][]string) []string {
+	names := make([]string, 0, len(req))
+	for name := range req {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// authenticateScheme extracts the credentials of a security scheme from the request
+// and passes them to the authenticator
+func authenticateScheme(ctx context.Context, authenticator Authenticator, r *http.Request, schemeName string, schemeInfo *SecuritySchemeInfo, scopes []string) (any, error) {
+	var credentials any
+	var err error
+
+	// Extract credentials based on scheme type
+	switch schemeInfo.Type {
+	case "http":
+		switch schemeInfo.Scheme {
+		case "basic":
+			credentials, err = extractBasicAuth(r)
+		case "bearer":
+			credentials, err = extractBearerToken(r)
+		default:
+			credentials, err = extractHTTPCredentials(r, schemeInfo.Scheme)
+		}
+	case "apiKey":
+		credentials, err = extractAPIKey(r, schemeInfo.In, schemeInfo.Name)
+	case "oauth2":
+		credentials, err = extractOAuth2Token(r, scopes)
+	case "openIdConnect":
+		credentials, err = extractOpenIDConnectToken(r)
+	default:
+		err = errors.New("unsupported security scheme type")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return callAuthenticator(authenticator, schemeName, ctx, credentials)
+}
+
 // callAuthenticator calls the appropriate authenticator method based on scheme name
 func callAuthenticator(authenticator Authenticator, schemeName string, ctx context.Context, credentials any) (any, error) {
 	if authenticator == nil {
@@ -249,13 +466,236 @@ type SecuritySchemeInfo struct {
 	Name   string
 }
 
+// principalScopes unwraps a ScopedPrincipal into the principal and its granted
+// scopes, and checks that the authenticator returned a Principal
+func principalScopes(principal any) (Principal, []string, error) {
+	var scopes []string
+	if scoped, ok := principal.(ScopedPrincipal); ok {
+		principal, scopes = scoped.Principal, scoped.Scopes
+	}
+	typed, ok := principal.(Principal)
+	if !ok && principal != nil {
+		return typed, nil, errors.New("authenticator returned an unexpected principal type")
+	}
+	return typed, scopes, nil
+}
+
+// missingScopes returns the required scopes that were not granted
+func missingScopes(required, granted []string) []string {
+	var missing []string
+	for _, scope := range required {
+		if !slices.Contains(granted, scope) {
+			missing = append(missing, scope)
+		}
+	}
+	return missing
+}
+
+// principalRoles returns the roles and permissions reported with a ScopedPrincipal
+func principalRoles(principal any) (roles, permissions []string) {
+	if scoped, ok := principal.(ScopedPrincipal); ok {
+		return scoped.Roles, scoped.Permissions
+	}
+	return nil, nil
+}
+
+// authorizeRoles checks that a principal holds one of the x-roles and all of the
+// x-permissions of an operation
+func authorizeRoles(info *OperationInfo, secCtx *SecurityContext) error {
+	if info == nil {
+		return nil
+	}
+	if len(info.Roles) > 0 && !slices.ContainsFunc(info.Roles, func(role string) bool { return slices.Contains(secCtx.Roles, role) }) {
+		return fmt.Errorf("insufficient role: requires one of %s", strings.Join(info.Roles, ", "))
+	}
+	if missing := missingScopes(info.Permissions, secCtx.Permissions); len(missing) > 0 {
+		return fmt.Errorf("insufficient permissions: missing %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// CompareAPIKey reports whether key equals expected. The comparison takes
+// constant time, so response timing reveals neither the key nor its length.
+func CompareAPIKey(key, expected string) bool {
+	keySum := sha256.Sum256([]byte(key))
+	expectedSum := sha256.Sum256([]byte(expected))
+	return subtle.ConstantTimeCompare(keySum[:], expectedSum[:]) == 1
+}
+
+// HashAPIKey returns the hex-encoded SHA-256 hash of an API key. Store the
+// hash instead of the key and check presented keys with VerifyAPIKeyHash.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// VerifyAPIKeyHash reports whether key matches a hash returned by HashAPIKey,
+// in constant time
+func VerifyAPIKeyHash(key, hash string) bool {
+	want, err := hex.DecodeString(hash)
+	if err != nil {
+		return false
+	}
+	sum := sha256.Sum256([]byte(key))
+	return subtle.ConstantTimeCompare(sum[:], want) == 1
+}
+
+// CachingAuthenticator wraps an Authenticator and caches successful
+// authentications, so repeated requests with the same credentials skip token
+// validation and database lookups until the entry expires. Failures are not
+// cached. Only wrap authenticators whose result depends on the credentials
+// alone, and keep the TTL short enough for revoked credentials to stop working
+// in time.
+type CachingAuthenticator struct {
+	authenticator Authenticator
+	ttl           time.Duration
+	maxEntries    int
+
+	mu      sync.Mutex
+	entries map[[sha256.Size]byte]cachedPrincipal
+}
+
+// cachedPrincipal is a successful authentication held by a CachingAuthenticator
+type cachedPrincipal struct {
+	principal any
+	expires   time.Time
+}
+
+// NewCachingAuthenticator returns a CachingAuthenticator that caches principals
+// for ttl and holds at most maxEntries credentials. A maxEntries of 0 or less
+// leaves the cache unbounded.
+func NewCachingAuthenticator(authenticator Authenticator, ttl time.Duration, maxEntries int) *CachingAuthenticator {
+	return &CachingAuthenticator{
+		authenticator: authenticator,
+		ttl:           ttl,
+		maxEntries:    maxEntries,
+		entries:       make(map[[sha256.Size]byte]cachedPrincipal),
+	}
+}
+
+// AuthenticateApiKeyCookie authenticates with the wrapped authenticator unless the credentials are cached
+func (c *CachingAuthenticator) AuthenticateApiKeyCookie(ctx context.Context, credentials APIKeyCredentials) (any, error) {
+	return c.cached("apiKeyCookie", credentials.Key, func() (any, error) {
+		return c.authenticator.AuthenticateApiKeyCookie(ctx, credentials)
+	})
+}
+
+// AuthenticateApiKeyHeader authenticates with the wrapped authenticator unless the credentials are cached
+func (c *CachingAuthenticator) AuthenticateApiKeyHeader(ctx context.Context, credentials APIKeyCredentials) (any, error) {
+	return c.cached("apiKeyHeader", credentials.Key, func() (any, error) {
+		return c.authenticator.AuthenticateApiKeyHeader(ctx, credentials)
+	})
+}
+
+// AuthenticateApiKeyQuery authenticates with the wrapped authenticator unless the credentials are cached
+func (c *CachingAuthenticator) AuthenticateApiKeyQuery(ctx context.Context, credentials APIKeyCredentials) (any, error) {
+	return c.cached("apiKeyQuery", credentials.Key, func() (any, error) {
+		return c.authenticator.AuthenticateApiKeyQuery(ctx, credentials)
+	})
+}
+
+// AuthenticateBasicAuth authenticates with the wrapped authenticator unless the credentials are cached
+func (c *CachingAuthenticator) AuthenticateBasicAuth(ctx context.Context, credentials BasicAuthCredentials) (any, error) {
+	return c.cached("basicAuth", credentials.Username+"\x00"+credentials.Password, func() (any, error) {
+		return c.authenticator.AuthenticateBasicAuth(ctx, credentials)
+	})
+}
+
+// AuthenticateBearerAuth authenticates with the wrapped authenticator unless the credentials are cached
+func (c *CachingAuthenticator) AuthenticateBearerAuth(ctx context.Context, credentials BearerTokenCredentials) (any, error) {
+	return c.cached("bearerAuth", credentials.Token, func() (any, error) {
+		return c.authenticator.AuthenticateBearerAuth(ctx, credentials)
+	})
+}
+
+// AuthenticateOauth2Auth authenticates with the wrapped authenticator unless the credentials are cached
+func (c *CachingAuthenticator) AuthenticateOauth2Auth(ctx context.Context, credentials OAuth2Credentials) (any, error) {
+	return c.cached("oauth2Auth", credentials.Token+"\x00"+strings.Join(credentials.Scopes, " "), func() (any, error) {
+		return c.authenticator.AuthenticateOauth2Auth(ctx, credentials)
+	})
+}
+
+// AuthenticateOpenIdAuth authenticates with the wrapped authenticator unless the credentials are cached
+func (c *CachingAuthenticator) AuthenticateOpenIdAuth(ctx context.Context, credentials OpenIDConnectCredentials) (any, error) {
+	return c.cached("openIdAuth", credentials.Token, func() (any, error) {
+		return c.authenticator.AuthenticateOpenIdAuth(ctx, credentials)
+	})
+}
+
+// ObserveAuth calls the wrapped authenticator's ObserveAuth method if it
+// implements AuthObserver
+func (c *CachingAuthenticator) ObserveAuth(ctx context.Context, decision AuthDecision) {
+	if observer, ok := c.authenticator.(AuthObserver); ok {
+		observer.ObserveAuth(ctx, decision)
+	}
+}
+
+// Authorize calls the wrapped authenticator's Authorize method if it implements
+// Authorizer. Authorization decisions are not cached.
+func (c *CachingAuthenticator) Authorize(ctx context.Context, principal Principal, operationID string, scopes []string) error {
+	if authorizer, ok := c.authenticator.(Authorizer); ok {
+		return authorizer.Authorize(ctx, principal, operationID, scopes)
+	}
+	return nil
+}
+
+// cached returns the principal cached for the credentials of a scheme, or calls
+// authenticate and caches the principal when it succeeds
+func (c *CachingAuthenticator) cached(schemeName, credentials string, authenticate func() (any, error)) (any, error) {
+	// Entries are keyed by a hash so credentials are not kept in memory
+	key := sha256.Sum256([]byte(schemeName + "\x00" + credentials))
+	now := time.Now()
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok && now.Before(entry.expires) {
+		return entry.principal, nil
+	}
+
+	principal, err := authenticate()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.maxEntries > 0 && len(c.entries) >= c.maxEntries {
+		c.evict(now)
+	}
+	c.entries[key] = cachedPrincipal{principal: principal, expires: now.Add(c.ttl)}
+	return principal, nil
+}
+
+// evict removes the expired entries, or the entry closest to expiring when none
+// has expired
+func (c *CachingAuthenticator) evict(now time.Time) {
+	var oldest [sha256.Size]byte
+	var oldestExpires time.Time
+	for key, entry := range c.entries {
+		if !now.Before(entry.expires) {
+			delete(c.entries, key)
+			continue
+		}
+		if oldestExpires.IsZero() || entry.expires.Before(oldestExpires) {
+			oldest, oldestExpires = key, entry.expires
+		}
+	}
+	if len(c.entries) >= c.maxEntries {
+		delete(c.entries, oldest)
+	}
+}
+
 // Credential extraction helpers
 
+// errMissingCredentials is returned when a request carries no credentials for a scheme
+var errMissingCredentials = errors.New("missing credentials")
+
 // extractBasicAuth extracts HTTP Basic Auth credentials from request
 func extractBasicAuth(r *http.Request) (BasicAuthCredentials, error) {
 	auth := r.Header.Get("Authorization")
 	if auth == "" {
-		return BasicAuthCredentials{}, errors.New("missing Authorization header")
+		return BasicAuthCredentials{}, fmt.Errorf("%w: no Authorization header", errMissingCredentials)
 	}
 
 	const prefix = "Basic "
@@ -283,7 +723,7 @@ func extractBasicAuth(r *http.Request) (BasicAuthCredentials, error) {
 func extractBearerToken(r *http.Request) (BearerTokenCredentials, error) {
 	auth := r.Header.Get("Authorization")
 	if auth == "" {
-		return BearerTokenCredentials{}, errors.New("missing Authorization header")
+		return BearerTokenCredentials{}, fmt.Errorf("%w: no Authorization header", errMissingCredentials)
 	}
 
 	const prefix = "Bearer "
@@ -299,6 +739,22 @@ func extractBearerToken(r *http.Request) (BearerTokenCredentials, error) {
 	return BearerTokenCredentials{Token: token}, nil
 }
 
+// extractHTTPCredentials extracts the credentials of an HTTP authentication scheme
+// from the Authorization header. Scheme names are matched case-insensitively.
+func extractHTTPCredentials(r *http.Request, scheme string) (HTTPCredentials, error) {
+	auth := r.Header.Get("Authorization")
+	if auth == "" {
+		return HTTPCredentials{}, fmt.Errorf("%w: no Authorization header", errMissingCredentials)
+	}
+
+	name, value, _ := strings.Cut(auth, " ")
+	if !strings.EqualFold(name, scheme) {
+		return HTTPCredentials{}, errors.New("invalid Authorization header format")
+	}
+
+	return HTTPCredentials{Scheme: name, Value: strings.TrimSpace(value)}, nil
+}
+
 // extractAPIKey extracts API key from request (header, query, or cookie)
 func extractAPIKey(r *http.Request, location, name string) (APIKeyCredentials, error) {
 	var key string
@@ -317,7 +773,7 @@ func extractAPIKey(r *http.Request, location, name string) (APIKeyCredentials, e
 	}
 
 	if key == "" {
-		return APIKeyCredentials{}, errors.New("missing API key")
+		return APIKeyCredentials{}, fmt.Errorf("%w: no API key", errMissingCredentials)
 	}
 
 	return APIKeyCredentials{
@@ -0,0 +1,512 @@
+package api
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// JWTAuthenticator implements the Authenticator methods of the JWT bearer schemes
+// by validating tokens with a JWTValidator. Embed it in your Authenticator to
+// use it; handlers receive the *JWTClaims as SecurityContext.Principal, and the
+// scopes of the token are checked against the scopes operations require.
+type JWTAuthenticator struct {
+	Validator *JWTValidator
+}
+
+// AuthenticateBearerAuth validates the JWT of the bearerAuth scheme
+func (a JWTAuthenticator) AuthenticateBearerAuth(ctx context.Context, credentials BearerTokenCredentials) (any, error) {
+	return a.authenticateJWT(ctx, credentials.Token)
+}
+
+// authenticateJWT validates a token and reports its scopes
+func (a JWTAuthenticator) authenticateJWT(ctx context.Context, token string) (any, error) {
+	if a.Validator == nil {
+		return nil, errors.New("no JWT validator configured")
+	}
+	claims, err := a.Validator.Validate(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	return ScopedPrincipal{Principal: claims, Scopes: claims.Scopes}, nil
+}
+
+// OIDCAuthenticator implements the Authenticator methods of the OpenID Connect
+// schemes by validating tokens with the provider of each scheme. Embed it in your
+// Authenticator to use it; handlers receive the *JWTClaims as SecurityContext.Principal.
+type OIDCAuthenticator struct {
+	OpenIdAuth *OIDCProvider
+}
+
+// NewOIDCAuthenticator creates an OIDCAuthenticator discovering each provider from
+// the openIdConnectUrl of its scheme. audience is the client ID tokens must be issued for;
+// tokens are rejected while it is empty.
+func NewOIDCAuthenticator(audience string) OIDCAuthenticator {
+	return OIDCAuthenticator{
+		OpenIdAuth: &OIDCProvider{DiscoveryURL: "https://example.com/.well-known/openid-configuration", Audience: audience},
+	}
+}
+
+// AuthenticateOpenIdAuth validates the token of the openIdAuth scheme
+func (a OIDCAuthenticator) AuthenticateOpenIdAuth(ctx context.Context, credentials OpenIDConnectCredentials) (any, error) {
+	return authenticateOIDC(ctx, a.OpenIdAuth, credentials.Token)
+}
+
+// authenticateOIDC validates a token with a provider and reports its scopes
+func authenticateOIDC(ctx context.Context, provider *OIDCProvider, token string) (any, error) {
+	if provider == nil {
+		return nil, errors.New("no OpenID Connect provider configured")
+	}
+	claims, err := provider.Validate(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	return ScopedPrincipal{Principal: claims, Scopes: claims.Scopes}, nil
+}
+
+// OIDCProvider validates tokens issued by an OpenID Connect provider. It reads the
+// issuer and the location of the signing keys from the discovery document, and
+// caches the keys, refreshing them when a token is signed with an unknown key.
+// Tokens must name the discovered issuer and the Audience, so tokens the provider
+// issued for its other clients are rejected.
+type OIDCProvider struct {
+	// DiscoveryURL is the URL of the discovery document (openIdConnectUrl)
+	DiscoveryURL string
+	// Audience must be listed in the "aud" claim, typically the client ID. Every
+	// token is rejected while it is empty.
+	Audience string
+	// Client performs the HTTP requests, defaulting to http.DefaultClient
+	Client *http.Client
+	// RefreshInterval is the minimum time between key refreshes, defaulting to 5 minutes
+	RefreshInterval time.Duration
+
+	// fetchMu serializes the HTTP fetches, which run without holding mu so that
+	// a slow fetch does not hold up tokens signed with cached keys
+	fetchMu   sync.Mutex
+	mu        sync.Mutex
+	issuer    string
+	jwksURI   string
+	keys      map[string]any
+	fetchedAt time.Time
+}
+
+// Validate validates an ID or access token issued by the provider
+func (p *OIDCProvider) Validate(ctx context.Context, token string) (*JWTClaims, error) {
+	if p.Audience == "" {
+		return nil, errors.New("OpenID Connect provider has no audience to check tokens against")
+	}
+	issuer, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	validator := &JWTValidator{KeyFunc: p.key, Issuer: issuer, Audience: p.Audience}
+	return validator.Validate(ctx, token)
+}
+
+// discover fetches the discovery document once and returns the issuer
+func (p *OIDCProvider) discover(ctx context.Context) (string, error) {
+	if issuer, ok := p.discovered(); ok {
+		return issuer, nil
+	}
+
+	p.fetchMu.Lock()
+	defer p.fetchMu.Unlock()
+	if issuer, ok := p.discovered(); ok {
+		return issuer, nil
+	}
+
+	var doc struct {
+		Issuer  string `json:"issuer"`
+		JWKSURI string `json:"jwks_uri"`
+	}
+	if err := p.fetchJSON(ctx, p.DiscoveryURL, &doc); err != nil {
+		return "", fmt.Errorf("fetching OpenID Connect discovery document: %w", err)
+	}
+	if doc.Issuer == "" {
+		return "", errors.New("OpenID Connect discovery document has no issuer")
+	}
+	if doc.JWKSURI == "" {
+		return "", errors.New("OpenID Connect discovery document has no jwks_uri")
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.issuer = doc.Issuer
+	p.jwksURI = doc.JWKSURI
+	return p.issuer, nil
+}
+
+// discovered returns the issuer once the discovery document has been fetched
+func (p *OIDCProvider) discovered() (string, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.issuer, p.jwksURI != ""
+}
+
+// key returns the signing key with the given ID, refreshing the cached keys when
+// the ID is unknown and they have not been refreshed recently
+func (p *OIDCProvider) key(ctx context.Context, keyID string) (any, error) {
+	if key, _, ok := p.lookupKey(keyID); ok {
+		return key, nil
+	}
+
+	p.fetchMu.Lock()
+	defer p.fetchMu.Unlock()
+	key, fresh, ok := p.lookupKey(keyID)
+	if ok {
+		return key, nil
+	}
+	if fresh {
+		return nil, fmt.Errorf("unknown signing key %q", keyID)
+	}
+
+	p.mu.Lock()
+	jwksURI := p.jwksURI
+	p.mu.Unlock()
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := p.fetchJSON(ctx, jwksURI, &set); err != nil {
+		return nil, fmt.Errorf("fetching signing keys: %w", err)
+	}
+	keys := make(map[string]any, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		if key, err := jwk.publicKey(); err == nil {
+			keys[jwk.Kid] = key
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.keys = keys
+	p.fetchedAt = time.Now()
+	if key, ok := p.cachedKey(keyID); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", keyID)
+}
+
+// lookupKey looks up a cached key, also reporting whether the keys were refreshed
+// too recently to refresh them again
+func (p *OIDCProvider) lookupKey(keyID string) (key any, fresh, ok bool) {
+	interval := p.RefreshInterval
+	if interval <= 0 {
+		interval = 5 * time.Minute
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	key, ok = p.cachedKey(keyID)
+	return key, p.keys != nil && time.Since(p.fetchedAt) < interval, ok
+}
+
+// cachedKey looks up a cached key with p.mu held. Tokens without a key ID match a
+// single cached key.
+func (p *OIDCProvider) cachedKey(keyID string) (any, bool) {
+	if keyID == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+	key, ok := p.keys[keyID]
+	return key, ok
+}
+
+// fetchJSON decodes the JSON document at url
+func (p *OIDCProvider) fetchJSON(ctx context.Context, url string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// jsonWebKey is a public key of a JSON Web Key Set
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// publicKey converts an RSA or EC JSON Web Key into a key JWTValidator accepts
+func (k jsonWebKey) publicKey() (any, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+// JWTClaims holds the claims of a validated JSON Web Token
+type JWTClaims struct {
+	Issuer    string
+	Subject   string
+	Audience  []string
+	ExpiresAt time.Time
+	NotBefore time.Time
+	IssuedAt  time.Time
+	// Scopes are read from the "scope" or "scp" claim
+	Scopes []string
+	// Raw holds every claim of the token, including custom ones
+	Raw map[string]any
+}
+
+// JWTValidator validates JSON Web Tokens signed with HMAC (HS256, HS384, HS512),
+// RSA (RS256, RS384, RS512) or ECDSA (ES256, ES384, ES512)
+type JWTValidator struct {
+	// Key verifies signatures: a []byte secret for HMAC, an *rsa.PublicKey for RSA
+	// or an *ecdsa.PublicKey for ECDSA
+	Key any
+	// KeyFunc looks up the key by the "kid" header of the token, for issuers that
+	// rotate keys. It takes precedence over Key.
+	KeyFunc func(ctx context.Context, keyID string) (any, error)
+	// Issuer must match the "iss" claim when set
+	Issuer string
+	// Audience must be listed in the "aud" claim when set
+	Audience string
+	// Leeway allows for clock skew when checking "exp" and "nbf"
+	Leeway time.Duration
+	// Now returns the current time, defaulting to time.Now
+	Now func() time.Time
+}
+
+// Validate verifies the signature of a token and checks its registered claims
+func (v *JWTValidator) Validate(ctx context.Context, token string) (*JWTClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeJWTSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("invalid token header: %w", err)
+	}
+
+	key := v.Key
+	if v.KeyFunc != nil {
+		var err error
+		if key, err = v.KeyFunc(ctx, header.Kid); err != nil {
+			return nil, err
+		}
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.New("invalid token signature")
+	}
+	if err := verifyJWTSignature(header.Alg, key, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	var raw map[string]any
+	if err := decodeJWTSegment(parts[1], &raw); err != nil {
+		return nil, fmt.Errorf("invalid token claims: %w", err)
+	}
+	claims := newJWTClaims(raw)
+
+	now := time.Now()
+	if v.Now != nil {
+		now = v.Now()
+	}
+	if !claims.ExpiresAt.IsZero() && now.After(claims.ExpiresAt.Add(v.Leeway)) {
+		return nil, errors.New("token has expired")
+	}
+	if !claims.NotBefore.IsZero() && now.Add(v.Leeway).Before(claims.NotBefore) {
+		return nil, errors.New("token is not valid yet")
+	}
+	if v.Issuer != "" && claims.Issuer != v.Issuer {
+		return nil, errors.New("token has an unexpected issuer")
+	}
+	if v.Audience != "" && !slices.Contains(claims.Audience, v.Audience) {
+		return nil, errors.New("token has an unexpected audience")
+	}
+
+	return claims, nil
+}
+
+// decodeJWTSegment decodes a base64url encoded JSON segment of a token
+func decodeJWTSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// verifyJWTSignature checks the signature of a token with a key matching its algorithm
+func verifyJWTSignature(alg string, key any, signingInput string, signature []byte) error {
+	if len(alg) != 5 {
+		return fmt.Errorf("unsupported signing algorithm %q", alg)
+	}
+
+	var hash crypto.Hash
+	switch alg[2:] {
+	case "256":
+		hash = crypto.SHA256
+	case "384":
+		hash = crypto.SHA384
+	case "512":
+		hash = crypto.SHA512
+	default:
+		return fmt.Errorf("unsupported signing algorithm %q", alg)
+	}
+
+	switch alg[:2] {
+	case "HS":
+		secret, ok := key.([]byte)
+		if !ok {
+			return fmt.Errorf("%s tokens need a []byte key", alg)
+		}
+		mac := hmac.New(hash.New, secret)
+		mac.Write([]byte(signingInput))
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return errors.New("invalid token signature")
+		}
+		return nil
+	case "RS":
+		publicKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("%s tokens need an *rsa.PublicKey", alg)
+		}
+		if err := rsa.VerifyPKCS1v15(publicKey, hash, jwtDigest(hash, signingInput), signature); err != nil {
+			return errors.New("invalid token signature")
+		}
+		return nil
+	case "ES":
+		publicKey, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("%s tokens need an *ecdsa.PublicKey", alg)
+		}
+		size := (publicKey.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return errors.New("invalid token signature")
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(publicKey, jwtDigest(hash, signingInput), r, s) {
+			return errors.New("invalid token signature")
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported signing algorithm %q", alg)
+}
+
+// jwtDigest hashes the signing input of a token
+func jwtDigest(hash crypto.Hash, signingInput string) []byte {
+	h := hash.New()
+	h.Write([]byte(signingInput))
+	return h.Sum(nil)
+}
+
+// newJWTClaims reads the registered claims from the decoded claims of a token
+func newJWTClaims(raw map[string]any) *JWTClaims {
+	claims := &JWTClaims{Raw: raw}
+	claims.Issuer, _ = raw["iss"].(string)
+	claims.Subject, _ = raw["sub"].(string)
+	claims.Audience = jwtStrings(raw["aud"])
+	claims.ExpiresAt = jwtTime(raw["exp"])
+	claims.NotBefore = jwtTime(raw["nbf"])
+	claims.IssuedAt = jwtTime(raw["iat"])
+	if scope, ok := raw["scope"].(string); ok {
+		claims.Scopes = strings.Fields(scope)
+	} else if scp, ok := raw["scp"].(string); ok {
+		claims.Scopes = strings.Fields(scp)
+	} else {
+		claims.Scopes = jwtStrings(raw["scp"])
+	}
+	return claims
+}
+
+// jwtStrings reads a claim holding a string or an array of strings
+func jwtStrings(v any) []string {
+	switch v := v.(type) {
+	case string:
+		return []string{v}
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// jwtTime reads a claim holding seconds since the Unix epoch
+func jwtTime(v any) time.Time {
+	if seconds, ok := v.(float64); ok {
+		return time.Unix(int64(seconds), 0)
+	}
+	return time.Time{}
+}
+
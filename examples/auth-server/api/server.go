@@ -1,13 +1,18 @@
 package api
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"encoding/xml"
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/christopherklint97/specweaver/pkg/router"
 )
@@ -415,20 +420,182 @@ type Server interface {
 	GetCurrentUser(ctx context.Context, req GetCurrentUserRequest) (GetCurrentUserResponse, error)
 }
 
+// ServerHooks holds optional callbacks invoked around every operation.
+// Use them for cross-cutting concerns such as metrics and auditing.
+type ServerHooks struct {
+	// OnRequest is called before the request is parsed and the handler runs
+	OnRequest func(ctx context.Context, operationID string, r *http.Request)
+	// OnResponse is called after the response has been written, with status 500
+	// for handlers that panicked before writing a header
+	OnResponse func(ctx context.Context, operationID string, status int, duration time.Duration)
+	// OnError is called when request parsing or the handler fails
+	OnError func(ctx context.Context, operationID string, err error)
+}
+
 // ServerWrapper wraps the Server with HTTP handler logic
 type ServerWrapper struct {
-	Handler Server
+	Handler      Server
+	Hooks        ServerHooks
+	ErrorMappers []ErrorMapper
+	Logger       *slog.Logger
+	Encoders     map[string]ResponseEncoder
+}
+
+// ErrorMapper translates an error returned by a handler into an HTTP response.
+// It returns ok=false when it does not recognize the error.
+type ErrorMapper func(err error) (status int, body any, ok bool)
+
+// ServerOption configures the ServerWrapper created by ConfigureRouter and NewRouter
+type ServerOption func(*ServerWrapper)
+
+// WithHooks sets the lifecycle hooks invoked around every operation
+func WithHooks(hooks ServerHooks) ServerOption {
+	return func(w *ServerWrapper) {
+		w.Hooks = hooks
+	}
+}
+
+// WithLogger sets the logger requests to API routes are logged with, including
+// the operation ID, route pattern, path parameters, status and latency. A nil
+// logger disables request logging.
+func WithLogger(logger *slog.Logger) ServerOption {
+	return func(w *ServerWrapper) {
+		w.Logger = logger
+	}
+}
+
+// WithErrorMapper registers an ErrorMapper. Mappers are consulted in the order
+// they were registered, before the default HTTPError handling.
+func WithErrorMapper(mapper ErrorMapper) ServerOption {
+	return func(w *ServerWrapper) {
+		w.ErrorMappers = append(w.ErrorMappers, mapper)
+	}
+}
+
+// WithEncoder registers the encoder for responses of a media type, such as
+// "application/msgpack" or "text/csv". It takes precedence over the built-in
+// JSON, XML and raw encoding for operations offering that media type.
+func WithEncoder(mediaType string, encoder ResponseEncoder) ServerOption {
+	return func(w *ServerWrapper) {
+		if w.Encoders == nil {
+			w.Encoders = make(map[string]ResponseEncoder)
+		}
+		w.Encoders[baseMediaType(mediaType)] = encoder
+	}
+}
+
+// NewServerWrapper creates a ServerWrapper for the given Server
+func NewServerWrapper(si Server, opts ...ServerOption) *ServerWrapper {
+	w := &ServerWrapper{Handler: si}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+// statusRecorder wraps http.ResponseWriter to capture the status code, which
+// stays zero until a header or body is written
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (sr *statusRecorder) WriteHeader(code int) {
+	// Informational responses precede the final status
+	if !sr.wroteHeader && code >= 200 {
+		sr.status = code
+		sr.wroteHeader = true
+	}
+	sr.ResponseWriter.WriteHeader(code)
+}
+
+func (sr *statusRecorder) Write(b []byte) (int, error) {
+	if !sr.wroteHeader {
+		sr.status = http.StatusOK
+		sr.wroteHeader = true
+	}
+	return sr.ResponseWriter.Write(b)
+}
+
+// startOperation runs the OnRequest hook and returns the response writer to use
+// for the operation, plus a function that runs the OnResponse hook and logs the
+// request when done. A panic of the handler is reported as 500 and passed on to
+// recovery middleware.
+func (w *ServerWrapper) startOperation(ctx context.Context, operationID, route string, rw http.ResponseWriter, r *http.Request) (http.ResponseWriter, func()) {
+	if w.Hooks.OnRequest != nil {
+		w.Hooks.OnRequest(ctx, operationID, r)
+	}
+	if w.Hooks.OnResponse == nil && w.Logger == nil {
+		return rw, func() {}
+	}
+
+	start := time.Now()
+	sr := &statusRecorder{ResponseWriter: rw}
+	return sr, func() {
+		duration := time.Since(start)
+		// Handlers writing nothing are answered with 200 by net/http, and panicking
+		// ones with 500 by recovery middleware, unless they wrote a header already
+		panicked := recover()
+		status := sr.status
+		if !sr.wroteHeader {
+			status = http.StatusOK
+			if panicked != nil {
+				status = http.StatusInternalServerError
+			}
+		}
+		if w.Hooks.OnResponse != nil {
+			w.Hooks.OnResponse(ctx, operationID, status, duration)
+		}
+		if w.Logger != nil {
+			level := slog.LevelInfo
+			if status >= http.StatusInternalServerError || panicked != nil {
+				level = slog.LevelError
+			}
+			w.Logger.LogAttrs(ctx, level, "request",
+				slog.String("operation_id", operationID),
+				slog.String("method", r.Method),
+				slog.String("route", route),
+				slog.Any("params", routeParams(r, route)),
+				slog.Int("status", status),
+				slog.Duration("latency", duration),
+			)
+		}
+		if panicked != nil {
+			panic(panicked)
+		}
+	}
+}
+
+// routeParams returns the path parameters matched for a route pattern
+func routeParams(r *http.Request, route string) map[string]string {
+	params := make(map[string]string)
+	for _, segment := range strings.Split(route, "/") {
+		if !strings.HasPrefix(segment, "{") || !strings.HasSuffix(segment, "}") {
+			continue
+		}
+		name := strings.TrimSuffix(segment[1:len(segment)-1], "...")
+		if name == "$" {
+			continue
+		}
+		params[name] = router.URLParam(r, name)
+	}
+	return params
 }
 
 // handleListUsers adapts HTTP request to ListUsers handler
 func (w *ServerWrapper) handleListUsers(rw http.ResponseWriter, r *http.Request) {
+	const operationID = "listUsers"
 	ctx := r.Context()
+	rw, finish := w.startOperation(ctx, operationID, "/api/v1/admin/users", rw, r)
+	defer finish()
+
 	req := ListUsersRequest{}
 
 	// Call handler
 	resp, err := w.Handler.ListUsers(ctx, req)
 	if err != nil {
-		w.handleError(rw, err)
+		w.handleError(ctx, operationID, rw, err)
 		return
 	}
 
@@ -438,13 +605,17 @@ func (w *ServerWrapper) handleListUsers(rw http.ResponseWriter, r *http.Request)
 
 // handleGetFlexible adapts HTTP request to GetFlexible handler
 func (w *ServerWrapper) handleGetFlexible(rw http.ResponseWriter, r *http.Request) {
+	const operationID = "getFlexible"
 	ctx := r.Context()
+	rw, finish := w.startOperation(ctx, operationID, "/api/v1/flexible", rw, r)
+	defer finish()
+
 	req := GetFlexibleRequest{}
 
 	// Call handler
 	resp, err := w.Handler.GetFlexible(ctx, req)
 	if err != nil {
-		w.handleError(rw, err)
+		w.handleError(ctx, operationID, rw, err)
 		return
 	}
 
@@ -454,13 +625,17 @@ func (w *ServerWrapper) handleGetFlexible(rw http.ResponseWriter, r *http.Reques
 
 // handleGetLegacyData adapts HTTP request to GetLegacyData handler
 func (w *ServerWrapper) handleGetLegacyData(rw http.ResponseWriter, r *http.Request) {
+	const operationID = "getLegacyData"
 	ctx := r.Context()
+	rw, finish := w.startOperation(ctx, operationID, "/api/v1/legacy/data", rw, r)
+	defer finish()
+
 	req := GetLegacyDataRequest{}
 
 	// Call handler
 	resp, err := w.Handler.GetLegacyData(ctx, req)
 	if err != nil {
-		w.handleError(rw, err)
+		w.handleError(ctx, operationID, rw, err)
 		return
 	}
 
@@ -470,13 +645,17 @@ func (w *ServerWrapper) handleGetLegacyData(rw http.ResponseWriter, r *http.Requ
 
 // handleGetProfile adapts HTTP request to GetProfile handler
 func (w *ServerWrapper) handleGetProfile(rw http.ResponseWriter, r *http.Request) {
+	const operationID = "getProfile"
 	ctx := r.Context()
+	rw, finish := w.startOperation(ctx, operationID, "/api/v1/profile", rw, r)
+	defer finish()
+
 	req := GetProfileRequest{}
 
 	// Call handler
 	resp, err := w.Handler.GetProfile(ctx, req)
 	if err != nil {
-		w.handleError(rw, err)
+		w.handleError(ctx, operationID, rw, err)
 		return
 	}
 
@@ -486,13 +665,17 @@ func (w *ServerWrapper) handleGetProfile(rw http.ResponseWriter, r *http.Request
 
 // handleGetHealth adapts HTTP request to GetHealth handler
 func (w *ServerWrapper) handleGetHealth(rw http.ResponseWriter, r *http.Request) {
+	const operationID = "getHealth"
 	ctx := r.Context()
+	rw, finish := w.startOperation(ctx, operationID, "/api/v1/public/health", rw, r)
+	defer finish()
+
 	req := GetHealthRequest{}
 
 	// Call handler
 	resp, err := w.Handler.GetHealth(ctx, req)
 	if err != nil {
-		w.handleError(rw, err)
+		w.handleError(ctx, operationID, rw, err)
 		return
 	}
 
@@ -502,7 +685,11 @@ func (w *ServerWrapper) handleGetHealth(rw http.ResponseWriter, r *http.Request)
 
 // handleListResources adapts HTTP request to ListResources handler
 func (w *ServerWrapper) handleListResources(rw http.ResponseWriter, r *http.Request) {
+	const operationID = "listResources"
 	ctx := r.Context()
+	rw, finish := w.startOperation(ctx, operationID, "/api/v1/resources", rw, r)
+	defer finish()
+
 	req := ListResourcesRequest{}
 
 	// Parse query parameter: limit
@@ -518,7 +705,7 @@ func (w *ServerWrapper) handleListResources(rw http.ResponseWriter, r *http.Requ
 	// Call handler
 	resp, err := w.Handler.ListResources(ctx, req)
 	if err != nil {
-		w.handleError(rw, err)
+		w.handleError(ctx, operationID, rw, err)
 		return
 	}
 
@@ -528,19 +715,23 @@ func (w *ServerWrapper) handleListResources(rw http.ResponseWriter, r *http.Requ
 
 // handleCreateResource adapts HTTP request to CreateResource handler
 func (w *ServerWrapper) handleCreateResource(rw http.ResponseWriter, r *http.Request) {
+	const operationID = "createResource"
 	ctx := r.Context()
+	rw, finish := w.startOperation(ctx, operationID, "/api/v1/resources", rw, r)
+	defer finish()
+
 	req := CreateResourceRequest{}
 
 	// Parse request body
 	if err := ReadJSON(r, &req.Body); err != nil {
-		w.handleError(rw, NewHTTPError(http.StatusBadRequest, "invalid request body"))
+		w.handleError(ctx, operationID, rw, NewHTTPError(http.StatusBadRequest, "invalid request body"))
 		return
 	}
 
 	// Call handler
 	resp, err := w.Handler.CreateResource(ctx, req)
 	if err != nil {
-		w.handleError(rw, err)
+		w.handleError(ctx, operationID, rw, err)
 		return
 	}
 
@@ -550,22 +741,25 @@ func (w *ServerWrapper) handleCreateResource(rw http.ResponseWriter, r *http.Req
 
 // handleGetResource adapts HTTP request to GetResource handler
 func (w *ServerWrapper) handleGetResource(rw http.ResponseWriter, r *http.Request) {
+	const operationID = "getResource"
 	ctx := r.Context()
+	rw, finish := w.startOperation(ctx, operationID, "/api/v1/resources/{resourceId}", rw, r)
+	defer finish()
+
 	req := GetResourceRequest{}
 
 	// Parse path parameter: resourceId
-	resourceIdStr := router.URLParam(r, "resourceId")
-	resourceIdVal, err := strconv.ParseInt(resourceIdStr, 10, 64)
+	resourceIdVal, err := router.URLParamInt64(r, "resourceId")
 	if err != nil {
-		w.handleError(rw, NewHTTPError(http.StatusBadRequest, "invalid resourceId parameter"))
+		w.handleError(ctx, operationID, rw, NewHTTPError(http.StatusBadRequest, "invalid resourceId parameter"))
 		return
 	}
-	req.ResourceId = int64(resourceIdVal)
+	req.ResourceId = resourceIdVal
 
 	// Call handler
 	resp, err := w.Handler.GetResource(ctx, req)
 	if err != nil {
-		w.handleError(rw, err)
+		w.handleError(ctx, operationID, rw, err)
 		return
 	}
 
@@ -575,28 +769,31 @@ func (w *ServerWrapper) handleGetResource(rw http.ResponseWriter, r *http.Reques
 
 // handleUpdateResource adapts HTTP request to UpdateResource handler
 func (w *ServerWrapper) handleUpdateResource(rw http.ResponseWriter, r *http.Request) {
+	const operationID = "updateResource"
 	ctx := r.Context()
+	rw, finish := w.startOperation(ctx, operationID, "/api/v1/resources/{resourceId}", rw, r)
+	defer finish()
+
 	req := UpdateResourceRequest{}
 
 	// Parse path parameter: resourceId
-	resourceIdStr := router.URLParam(r, "resourceId")
-	resourceIdVal, err := strconv.ParseInt(resourceIdStr, 10, 64)
+	resourceIdVal, err := router.URLParamInt64(r, "resourceId")
 	if err != nil {
-		w.handleError(rw, NewHTTPError(http.StatusBadRequest, "invalid resourceId parameter"))
+		w.handleError(ctx, operationID, rw, NewHTTPError(http.StatusBadRequest, "invalid resourceId parameter"))
 		return
 	}
-	req.ResourceId = int64(resourceIdVal)
+	req.ResourceId = resourceIdVal
 
 	// Parse request body
 	if err := ReadJSON(r, &req.Body); err != nil {
-		w.handleError(rw, NewHTTPError(http.StatusBadRequest, "invalid request body"))
+		w.handleError(ctx, operationID, rw, NewHTTPError(http.StatusBadRequest, "invalid request body"))
 		return
 	}
 
 	// Call handler
 	resp, err := w.Handler.UpdateResource(ctx, req)
 	if err != nil {
-		w.handleError(rw, err)
+		w.handleError(ctx, operationID, rw, err)
 		return
 	}
 
@@ -606,22 +803,25 @@ func (w *ServerWrapper) handleUpdateResource(rw http.ResponseWriter, r *http.Req
 
 // handleDeleteResource adapts HTTP request to DeleteResource handler
 func (w *ServerWrapper) handleDeleteResource(rw http.ResponseWriter, r *http.Request) {
+	const operationID = "deleteResource"
 	ctx := r.Context()
+	rw, finish := w.startOperation(ctx, operationID, "/api/v1/resources/{resourceId}", rw, r)
+	defer finish()
+
 	req := DeleteResourceRequest{}
 
 	// Parse path parameter: resourceId
-	resourceIdStr := router.URLParam(r, "resourceId")
-	resourceIdVal, err := strconv.ParseInt(resourceIdStr, 10, 64)
+	resourceIdVal, err := router.URLParamInt64(r, "resourceId")
 	if err != nil {
-		w.handleError(rw, NewHTTPError(http.StatusBadRequest, "invalid resourceId parameter"))
+		w.handleError(ctx, operationID, rw, NewHTTPError(http.StatusBadRequest, "invalid resourceId parameter"))
 		return
 	}
-	req.ResourceId = int64(resourceIdVal)
+	req.ResourceId = resourceIdVal
 
 	// Call handler
 	resp, err := w.Handler.DeleteResource(ctx, req)
 	if err != nil {
-		w.handleError(rw, err)
+		w.handleError(ctx, operationID, rw, err)
 		return
 	}
 
@@ -631,13 +831,17 @@ func (w *ServerWrapper) handleDeleteResource(rw http.ResponseWriter, r *http.Req
 
 // handleGetCurrentUser adapts HTTP request to GetCurrentUser handler
 func (w *ServerWrapper) handleGetCurrentUser(rw http.ResponseWriter, r *http.Request) {
+	const operationID = "getCurrentUser"
 	ctx := r.Context()
+	rw, finish := w.startOperation(ctx, operationID, "/api/v1/users/me", rw, r)
+	defer finish()
+
 	req := GetCurrentUserRequest{}
 
 	// Call handler
 	resp, err := w.Handler.GetCurrentUser(ctx, req)
 	if err != nil {
-		w.handleError(rw, err)
+		w.handleError(ctx, operationID, rw, err)
 		return
 	}
 
@@ -646,7 +850,22 @@ func (w *ServerWrapper) handleGetCurrentUser(rw http.ResponseWriter, r *http.Req
 }
 
 // handleError handles errors and writes appropriate HTTP responses
-func (w *ServerWrapper) handleError(rw http.ResponseWriter, err error) {
+func (w *ServerWrapper) handleError(ctx context.Context, operationID string, rw http.ResponseWriter, err error) {
+	if w.Hooks.OnError != nil {
+		w.Hooks.OnError(ctx, operationID, err)
+	}
+
+	for _, mapper := range w.ErrorMappers {
+		if status, body, ok := mapper(err); ok {
+			if body == nil {
+				rw.WriteHeader(status)
+				return
+			}
+			WriteJSON(rw, status, body)
+			return
+		}
+	}
+
 	var httpErr *HTTPError
 	if errors.As(err, &httpErr) {
 		WriteError(rw, httpErr.Code, httpErr)
@@ -689,12 +908,115 @@ var securitySchemeInfoMap = map[string]*SecuritySchemeInfo{
 	},
 }
 
+// BasePath is the path prefix all routes are registered under
+const BasePath = "/api/v1"
+
+// OperationInfo describes the operation a request was routed to
+type OperationInfo struct {
+	// ID is the operationId, or the handler name when the spec has none
+	ID     string
+	Method string
+	// Route is the route pattern, e.g. /pets/{petId}
+	Route string
+	Tags  []string
+	// Scopes lists the scopes named by the operation's security requirements
+	Scopes []string
+	// Roles lists the x-roles of the operation, one of which the principal must hold
+	Roles []string
+	// Permissions lists the x-permissions of the operation, all of which the
+	// principal must hold
+	Permissions []string
+}
+
+// operationInfos describes every operation, keyed by operation ID
+var operationInfos = map[string]*OperationInfo{
+	"listUsers": {
+		ID:     "listUsers",
+		Method: "GET",
+		Route:  "/api/v1/admin/users",
+		Roles:  []string{"admin"},
+	},
+	"getFlexible": {
+		ID:     "getFlexible",
+		Method: "GET",
+		Route:  "/api/v1/flexible",
+	},
+	"getLegacyData": {
+		ID:     "getLegacyData",
+		Method: "GET",
+		Route:  "/api/v1/legacy/data",
+	},
+	"getProfile": {
+		ID:     "getProfile",
+		Method: "GET",
+		Route:  "/api/v1/profile",
+	},
+	"getHealth": {
+		ID:     "getHealth",
+		Method: "GET",
+		Route:  "/api/v1/public/health",
+	},
+	"listResources": {
+		ID:     "listResources",
+		Method: "GET",
+		Route:  "/api/v1/resources",
+	},
+	"createResource": {
+		ID:     "createResource",
+		Method: "POST",
+		Route:  "/api/v1/resources",
+	},
+	"getResource": {
+		ID:     "getResource",
+		Method: "GET",
+		Route:  "/api/v1/resources/{resourceId}",
+		Scopes: []string{"read"},
+	},
+	"updateResource": {
+		ID:     "updateResource",
+		Method: "PUT",
+		Route:  "/api/v1/resources/{resourceId}",
+		Scopes: []string{"write"},
+	},
+	"deleteResource": {
+		ID:     "deleteResource",
+		Method: "DELETE",
+		Route:  "/api/v1/resources/{resourceId}",
+		Scopes: []string{"admin"},
+	},
+	"getCurrentUser": {
+		ID:     "getCurrentUser",
+		Method: "GET",
+		Route:  "/api/v1/users/me",
+	},
+}
+
+type operationInfoKey struct{}
+
+// GetOperationInfo returns the operation a request was routed to, or nil when
+// the request did not go through a generated route. It is available to
+// authenticators and handlers.
+func GetOperationInfo(ctx context.Context) *OperationInfo {
+	info, _ := ctx.Value(operationInfoKey{}).(*OperationInfo)
+	return info
+}
+
+// withOperation adds the operation to the request context
+func withOperation(info *OperationInfo, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		h(w, r.WithContext(context.WithValue(r.Context(), operationInfoKey{}, info)))
+	}
+}
+
 // ConfigureRouter configures the given router with all routes.
 // This function allows you to use any router that implements the router.Router interface.
 //
 // The authenticator parameter is optional. If nil, no authentication will be performed.
 // If provided, authentication will be enforced for routes that require it.
 //
+// Options such as WithHooks configure the ServerWrapper that adapts HTTP requests
+// to the Server methods.
+//
 // Example with built-in router:
 //
 //	r := router.NewRouter()
@@ -704,79 +1026,81 @@ var securitySchemeInfoMap = map[string]*SecuritySchemeInfo{
 //
 //	r := myCustomRouter.New() // Must implement router.Router interface
 //	ConfigureRouter(r, myServer, myAuthenticator)
-func ConfigureRouter(r router.Router, si Server, authenticator Authenticator) {
-	wrapper := &ServerWrapper{Handler: si}
+func ConfigureRouter(r router.Router, si Server, authenticator Authenticator, opts ...ServerOption) {
+	wrapper := NewServerWrapper(si, opts...)
 
-	r.Get("/admin/users", authMiddleware(authenticator, []map[string][]string{
+	r.Get("/api/v1/admin/users", withOperation(operationInfos["listUsers"], authMiddleware(authenticator, []map[string][]string{
 		{
 			"basicAuth": []string{},
 		},
-	}, securitySchemeInfoMap)(http.HandlerFunc(wrapper.handleListUsers)).ServeHTTP)
-	r.Get("/flexible", authMiddleware(authenticator, []map[string][]string{
+	}, securitySchemeInfoMap)(http.HandlerFunc(wrapper.handleListUsers)).ServeHTTP))
+	r.Get("/api/v1/flexible", withOperation(operationInfos["getFlexible"], authMiddleware(authenticator, []map[string][]string{
 		{
 			"bearerAuth": []string{},
 		},
 		{
 			"apiKeyHeader": []string{},
 		},
-	}, securitySchemeInfoMap)(http.HandlerFunc(wrapper.handleGetFlexible)).ServeHTTP)
-	r.Get("/legacy/data", authMiddleware(authenticator, []map[string][]string{
+	}, securitySchemeInfoMap)(http.HandlerFunc(wrapper.handleGetFlexible)).ServeHTTP))
+	r.Get("/api/v1/legacy/data", withOperation(operationInfos["getLegacyData"], authMiddleware(authenticator, []map[string][]string{
 		{
 			"apiKeyQuery": []string{},
 		},
-	}, securitySchemeInfoMap)(http.HandlerFunc(wrapper.handleGetLegacyData)).ServeHTTP)
-	r.Get("/profile", authMiddleware(authenticator, []map[string][]string{
+	}, securitySchemeInfoMap)(http.HandlerFunc(wrapper.handleGetLegacyData)).ServeHTTP))
+	r.Get("/api/v1/profile", withOperation(operationInfos["getProfile"], authMiddleware(authenticator, []map[string][]string{
 		{
 			"openIdAuth": []string{},
 		},
-	}, securitySchemeInfoMap)(http.HandlerFunc(wrapper.handleGetProfile)).ServeHTTP)
-	r.Get("/public/health", wrapper.handleGetHealth)
-	r.Get("/resources", authMiddleware(authenticator, []map[string][]string{
+	}, securitySchemeInfoMap)(http.HandlerFunc(wrapper.handleGetProfile)).ServeHTTP))
+	r.Get("/api/v1/public/health", withOperation(operationInfos["getHealth"], wrapper.handleGetHealth))
+	r.Get("/api/v1/resources", withOperation(operationInfos["listResources"], authMiddleware(authenticator, []map[string][]string{
 		{
 			"apiKeyHeader": []string{},
 		},
-	}, securitySchemeInfoMap)(http.HandlerFunc(wrapper.handleListResources)).ServeHTTP)
-	r.Post("/resources", authMiddleware(authenticator, []map[string][]string{
+	}, securitySchemeInfoMap)(http.HandlerFunc(wrapper.handleListResources)).ServeHTTP))
+	r.Post("/api/v1/resources", withOperation(operationInfos["createResource"], authMiddleware(authenticator, []map[string][]string{
 		{
 			"apiKeyHeader": []string{},
 		},
-	}, securitySchemeInfoMap)(http.HandlerFunc(wrapper.handleCreateResource)).ServeHTTP)
-	r.Get("/resources/{resourceId}", authMiddleware(authenticator, []map[string][]string{
+	}, securitySchemeInfoMap)(http.HandlerFunc(wrapper.handleCreateResource)).ServeHTTP))
+	r.Get("/api/v1/resources/{resourceId:-?[0-9]+}", withOperation(operationInfos["getResource"], authMiddleware(authenticator, []map[string][]string{
 		{
 			"oauth2Auth": []string{"read"},
 		},
-	}, securitySchemeInfoMap)(http.HandlerFunc(wrapper.handleGetResource)).ServeHTTP)
-	r.Put("/resources/{resourceId}", authMiddleware(authenticator, []map[string][]string{
+	}, securitySchemeInfoMap)(http.HandlerFunc(wrapper.handleGetResource)).ServeHTTP))
+	r.Put("/api/v1/resources/{resourceId:-?[0-9]+}", withOperation(operationInfos["updateResource"], authMiddleware(authenticator, []map[string][]string{
 		{
 			"oauth2Auth": []string{"write"},
 		},
-	}, securitySchemeInfoMap)(http.HandlerFunc(wrapper.handleUpdateResource)).ServeHTTP)
-	r.Delete("/resources/{resourceId}", authMiddleware(authenticator, []map[string][]string{
+	}, securitySchemeInfoMap)(http.HandlerFunc(wrapper.handleUpdateResource)).ServeHTTP))
+	r.Delete("/api/v1/resources/{resourceId:-?[0-9]+}", withOperation(operationInfos["deleteResource"], authMiddleware(authenticator, []map[string][]string{
 		{
 			"oauth2Auth": []string{"admin"},
 		},
-	}, securitySchemeInfoMap)(http.HandlerFunc(wrapper.handleDeleteResource)).ServeHTTP)
-	r.Get("/users/me", authMiddleware(authenticator, []map[string][]string{
+	}, securitySchemeInfoMap)(http.HandlerFunc(wrapper.handleDeleteResource)).ServeHTTP))
+	r.Get("/api/v1/users/me", withOperation(operationInfos["getCurrentUser"], authMiddleware(authenticator, []map[string][]string{
 		{
 			"bearerAuth": []string{},
 		},
-	}, securitySchemeInfoMap)(http.HandlerFunc(wrapper.handleGetCurrentUser)).ServeHTTP)
+	}, securitySchemeInfoMap)(http.HandlerFunc(wrapper.handleGetCurrentUser)).ServeHTTP))
 }
 
 // NewRouter creates a new router with all routes configured using the built-in router.
 // For using a custom router, use ConfigureRouter instead.
 //
+// Requests are logged with slog.Default() unless WithLogger is passed.
+//
 // The authenticator parameter is optional. If nil, no authentication will be performed.
-func NewRouter(si Server, authenticator Authenticator) *router.Mux {
+func NewRouter(si Server, authenticator Authenticator, opts ...ServerOption) *router.Mux {
 	r := router.NewRouter()
 
 	// Default middleware
-	r.Use(router.Logger)
 	r.Use(router.Recoverer)
 	r.Use(router.RequestID)
 	r.Use(router.RealIP)
 
-	ConfigureRouter(r, si, authenticator)
+	opts = append([]ServerOption{WithLogger(slog.Default())}, opts...)
+	ConfigureRouter(r, si, authenticator, opts...)
 	return r
 }
 
@@ -811,6 +1135,158 @@ func WriteResponse(w http.ResponseWriter, resp any) error {
 	return WriteJSON(w, http.StatusOK, resp)
 }
 
+// ResponseEncoder encodes a response body for a media type
+type ResponseEncoder func(w io.Writer, v any) error
+
+// WriteResponseAs writes a response encoded as the given content type
+func WriteResponseAs(w http.ResponseWriter, resp any, contentType string) error {
+	return writeResponseWith(w, resp, contentType, nil)
+}
+
+// writeResponseWith writes a response encoded as the given content type, using
+// the encoder registered for its media type if there is one
+func writeResponseWith(w http.ResponseWriter, resp any, contentType string, encoders map[string]ResponseEncoder) error {
+	statusCode := http.StatusOK
+	body := resp
+	if rw, ok := resp.(interface {
+		StatusCode() int
+		ResponseBody() any
+	}); ok {
+		statusCode = rw.StatusCode()
+		body = rw.ResponseBody()
+	}
+
+	// For 204 No Content or nil body, don't write a body
+	if statusCode == http.StatusNoContent || body == nil {
+		w.WriteHeader(statusCode)
+		return nil
+	}
+
+	// Encode before writing headers so encoding errors can still be reported
+	var buf bytes.Buffer
+	encode := func(w io.Writer, v any) error {
+		return encodeBody(w, contentType, v)
+	}
+	if encoder, ok := encoders[baseMediaType(contentType)]; ok {
+		encode = encoder
+	}
+	if err := encode(&buf, body); err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(statusCode)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// encodeBody encodes v into w according to the media type
+func encodeBody(w io.Writer, contentType string, v any) error {
+	mediaType := baseMediaType(contentType)
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		return json.NewEncoder(w).Encode(v)
+	case mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml"):
+		return xml.NewEncoder(w).Encode(v)
+	}
+
+	// Raw payloads are written as-is for any other media type
+	switch b := v.(type) {
+	case []byte:
+		_, err := w.Write(b)
+		return err
+	case string:
+		_, err := io.WriteString(w, b)
+		return err
+	case io.Reader:
+		_, err := io.Copy(w, b)
+		return err
+	}
+	if strings.HasPrefix(mediaType, "text/") {
+		_, err := fmt.Fprint(w, v)
+		return err
+	}
+	return fmt.Errorf("no encoder for content type %q", contentType)
+}
+
+// baseMediaType returns the lower-cased media type of a content type without parameters
+func baseMediaType(contentType string) string {
+	return strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+}
+
+// NegotiateContentType selects the best of the offered media types for the
+// request's Accept header. A missing Accept header selects the first offer.
+// Returns an empty string if none of the offered types is acceptable.
+func NegotiateContentType(r *http.Request, offered []string) string {
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		if len(offered) > 0 {
+			return offered[0]
+		}
+		return ""
+	}
+
+	ranges := parseAccept(accept)
+	best, bestQ := "", 0.0
+	for _, offer := range offered {
+		// The most specific matching range determines the quality of an offer
+		q, specificity := 0.0, -1
+		for _, ar := range ranges {
+			if s := ar.match(offer); s > specificity {
+				q, specificity = ar.q, s
+			}
+		}
+		if q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best
+}
+
+// acceptRange is a single media range from an Accept header
+type acceptRange struct {
+	mediaType string
+	subType   string
+	q         float64
+}
+
+// match reports how specifically the range matches a media type:
+// 2 for an exact match, 1 for type/*, 0 for */*, and -1 for no match
+func (ar acceptRange) match(offer string) int {
+	mediaType, subType, _ := strings.Cut(strings.ToLower(offer), "/")
+	switch {
+	case ar.mediaType == "*" && ar.subType == "*":
+		return 0
+	case ar.mediaType == mediaType && ar.subType == "*":
+		return 1
+	case ar.mediaType == mediaType && ar.subType == subType:
+		return 2
+	}
+	return -1
+}
+
+// parseAccept parses an Accept header into media ranges
+func parseAccept(header string) []acceptRange {
+	var ranges []acceptRange
+	for _, part := range strings.Split(header, ",") {
+		params := strings.Split(part, ";")
+		mediaType, subType, ok := strings.Cut(strings.ToLower(strings.TrimSpace(params[0])), "/")
+		if !ok {
+			continue
+		}
+		ar := acceptRange{mediaType: mediaType, subType: subType, q: 1}
+		for _, param := range params[1:] {
+			key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(key, "q") {
+				if q, err := strconv.ParseFloat(value, 64); err == nil {
+					ar.q = q
+				}
+			}
+		}
+		ranges = append(ranges, ar)
+	}
+	return ranges
+}
+
 // ErrorResponse represents an error response
 type ErrorResponse struct {
 	Error   string `json:"error"`
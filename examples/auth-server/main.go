@@ -16,13 +16,17 @@ type MyAuthenticator struct {
 // AuthenticateBasicAuth validates HTTP Basic Auth credentials
 func (a *MyAuthenticator) AuthenticateBasicAuth(ctx context.Context, credentials api.BasicAuthCredentials) (any, error) {
 	// In a real app, check against database
-	if credentials.Username == "admin" && credentials.Password == "secret" {
-		return &api.User{
-			Id:       1,
-			Username: "admin",
-			Email:    "admin@example.com",
-			Role:     "admin",
-		}, nil
+	users := map[string]*api.User{
+		"admin": {Id: 1, Username: "admin", Email: "admin@example.com", Role: "admin"},
+		"user1": {Id: 2, Username: "user1", Email: "user1@example.com", Role: "user"},
+	}
+	if user, ok := users[credentials.Username]; ok && credentials.Password == "secret" {
+		// Report the role checked against the x-roles of operations
+		return api.ScopedPrincipal{Principal: user, Roles: []string{user.Role}}, nil
+	}
+	// Valid credentials of a disabled account are refused with 403 instead of 401
+	if credentials.Username == "disabled" && credentials.Password == "secret" {
+		return nil, api.NewHTTPError(http.StatusForbidden, "account disabled")
 	}
 	return nil, api.NewHTTPError(http.StatusUnauthorized, "invalid credentials")
 }
@@ -44,7 +48,7 @@ func (a *MyAuthenticator) AuthenticateBearerAuth(ctx context.Context, credential
 // AuthenticateApiKeyHeader validates API key from header
 func (a *MyAuthenticator) AuthenticateApiKeyHeader(ctx context.Context, credentials api.APIKeyCredentials) (any, error) {
 	// In a real app, check against database
-	if credentials.Key == "valid-api-key" {
+	if api.CompareAPIKey(credentials.Key, "valid-api-key") {
 		return &api.User{
 			Id:       3,
 			Username: "api-user",
@@ -55,10 +59,13 @@ func (a *MyAuthenticator) AuthenticateApiKeyHeader(ctx context.Context, credenti
 	return nil, api.NewHTTPError(http.StatusUnauthorized, "invalid API key")
 }
 
+// legacyKeyHash is the SHA-256 hash of the legacy API key, as returned by api.HashAPIKey
+const legacyKeyHash = "48e7f6e3d36a9ceb582e66e49c1b4bc77c1a90a063a72fc43411572bcbdab17a"
+
 // AuthenticateApiKeyQuery validates API key from query
 func (a *MyAuthenticator) AuthenticateApiKeyQuery(ctx context.Context, credentials api.APIKeyCredentials) (any, error) {
-	// Similar to header validation
-	if credentials.Key == "legacy-key-456" {
+	// Only the hash of the key is kept, as a database would store it
+	if api.VerifyAPIKeyHash(credentials.Key, legacyKeyHash) {
 		return &api.User{
 			Id:       4,
 			Username: "legacy-user",
@@ -85,14 +92,17 @@ func (a *MyAuthenticator) AuthenticateApiKeyCookie(ctx context.Context, credenti
 
 // AuthenticateOauth2Auth validates OAuth2 token and scopes
 func (a *MyAuthenticator) AuthenticateOauth2Auth(ctx context.Context, credentials api.OAuth2Credentials) (any, error) {
-	// In a real app, validate OAuth2 token and check scopes
+	// In a real app, validate OAuth2 token and look up the scopes granted to it
 	if credentials.Token == "oauth-token-789" {
-		// Check if user has required scopes
-		return &api.User{
-			Id:       6,
-			Username: "oauth-user",
-			Email:    "oauth@example.com",
-			Role:     "user",
+		// The generated middleware rejects operations requiring scopes not granted here
+		return api.ScopedPrincipal{
+			Principal: &api.User{
+				Id:       6,
+				Username: "oauth-user",
+				Email:    "oauth@example.com",
+				Role:     "user",
+			},
+			Scopes: []string{"read", "write"},
 		}, nil
 	}
 	return nil, api.NewHTTPError(http.StatusUnauthorized, "invalid OAuth2 token")
@@ -141,17 +151,7 @@ func (s *MyServer) GetCurrentUser(ctx context.Context, req api.GetCurrentUserReq
 }
 
 func (s *MyServer) ListUsers(ctx context.Context, req api.ListUsersRequest) (api.ListUsersResponse, error) {
-	// Check if user is admin
-	secCtx := api.GetSecurityContext(ctx)
-	if secCtx == nil {
-		return nil, api.NewHTTPError(http.StatusUnauthorized, "not authenticated")
-	}
-
-	user, ok := secCtx.Principal.(*api.User)
-	if !ok || user.Role != "admin" {
-		return nil, api.NewHTTPError(http.StatusForbidden, "admin access required")
-	}
-
+	// Only admins reach this handler, as required by the operation's x-roles
 	users := []api.User{
 		{Id: 1, Username: "admin", Email: "admin@example.com", Role: "admin"},
 		{Id: 2, Username: "user1", Email: "user1@example.com", Role: "user"},
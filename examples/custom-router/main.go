@@ -177,7 +177,7 @@ func main() {
 	// Start server
 	port := ":8080"
 	log.Printf("Starting Pet Store API server with Chi router on http://localhost%s", port)
-	log.Printf("Try: curl http://localhost%s/pets", port)
+	log.Printf("Try: curl http://localhost%s%s/pets", port, api.BasePath)
 	if err := http.ListenAndServe(port, chiRouter); err != nil {
 		log.Fatal(err)
 	}
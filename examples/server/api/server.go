@@ -1,13 +1,18 @@
 package api
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"encoding/xml"
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/christopherklint97/specweaver/pkg/router"
 )
@@ -217,14 +222,176 @@ type Server interface {
 	DeletePet(ctx context.Context, req DeletePetRequest) (DeletePetResponse, error)
 }
 
+// ServerHooks holds optional callbacks invoked around every operation.
+// Use them for cross-cutting concerns such as metrics and auditing.
+type ServerHooks struct {
+	// OnRequest is called before the request is parsed and the handler runs
+	OnRequest func(ctx context.Context, operationID string, r *http.Request)
+	// OnResponse is called after the response has been written, with status 500
+	// for handlers that panicked before writing a header
+	OnResponse func(ctx context.Context, operationID string, status int, duration time.Duration)
+	// OnError is called when request parsing or the handler fails
+	OnError func(ctx context.Context, operationID string, err error)
+}
+
 // ServerWrapper wraps the Server with HTTP handler logic
 type ServerWrapper struct {
-	Handler Server
+	Handler      Server
+	Hooks        ServerHooks
+	ErrorMappers []ErrorMapper
+	Logger       *slog.Logger
+	Encoders     map[string]ResponseEncoder
+}
+
+// ErrorMapper translates an error returned by a handler into an HTTP response.
+// It returns ok=false when it does not recognize the error.
+type ErrorMapper func(err error) (status int, body any, ok bool)
+
+// ServerOption configures the ServerWrapper created by ConfigureRouter and NewRouter
+type ServerOption func(*ServerWrapper)
+
+// WithHooks sets the lifecycle hooks invoked around every operation
+func WithHooks(hooks ServerHooks) ServerOption {
+	return func(w *ServerWrapper) {
+		w.Hooks = hooks
+	}
+}
+
+// WithLogger sets the logger requests to API routes are logged with, including
+// the operation ID, route pattern, path parameters, status and latency. A nil
+// logger disables request logging.
+func WithLogger(logger *slog.Logger) ServerOption {
+	return func(w *ServerWrapper) {
+		w.Logger = logger
+	}
+}
+
+// WithErrorMapper registers an ErrorMapper. Mappers are consulted in the order
+// they were registered, before the default HTTPError handling.
+func WithErrorMapper(mapper ErrorMapper) ServerOption {
+	return func(w *ServerWrapper) {
+		w.ErrorMappers = append(w.ErrorMappers, mapper)
+	}
+}
+
+// WithEncoder registers the encoder for responses of a media type, such as
+// "application/msgpack" or "text/csv". It takes precedence over the built-in
+// JSON, XML and raw encoding for operations offering that media type.
+func WithEncoder(mediaType string, encoder ResponseEncoder) ServerOption {
+	return func(w *ServerWrapper) {
+		if w.Encoders == nil {
+			w.Encoders = make(map[string]ResponseEncoder)
+		}
+		w.Encoders[baseMediaType(mediaType)] = encoder
+	}
+}
+
+// NewServerWrapper creates a ServerWrapper for the given Server
+func NewServerWrapper(si Server, opts ...ServerOption) *ServerWrapper {
+	w := &ServerWrapper{Handler: si}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+// statusRecorder wraps http.ResponseWriter to capture the status code, which
+// stays zero until a header or body is written
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (sr *statusRecorder) WriteHeader(code int) {
+	// Informational responses precede the final status
+	if !sr.wroteHeader && code >= 200 {
+		sr.status = code
+		sr.wroteHeader = true
+	}
+	sr.ResponseWriter.WriteHeader(code)
+}
+
+func (sr *statusRecorder) Write(b []byte) (int, error) {
+	if !sr.wroteHeader {
+		sr.status = http.StatusOK
+		sr.wroteHeader = true
+	}
+	return sr.ResponseWriter.Write(b)
+}
+
+// startOperation runs the OnRequest hook and returns the response writer to use
+// for the operation, plus a function that runs the OnResponse hook and logs the
+// request when done. A panic of the handler is reported as 500 and passed on to
+// recovery middleware.
+func (w *ServerWrapper) startOperation(ctx context.Context, operationID, route string, rw http.ResponseWriter, r *http.Request) (http.ResponseWriter, func()) {
+	if w.Hooks.OnRequest != nil {
+		w.Hooks.OnRequest(ctx, operationID, r)
+	}
+	if w.Hooks.OnResponse == nil && w.Logger == nil {
+		return rw, func() {}
+	}
+
+	start := time.Now()
+	sr := &statusRecorder{ResponseWriter: rw}
+	return sr, func() {
+		duration := time.Since(start)
+		// Handlers writing nothing are answered with 200 by net/http, and panicking
+		// ones with 500 by recovery middleware, unless they wrote a header already
+		panicked := recover()
+		status := sr.status
+		if !sr.wroteHeader {
+			status = http.StatusOK
+			if panicked != nil {
+				status = http.StatusInternalServerError
+			}
+		}
+		if w.Hooks.OnResponse != nil {
+			w.Hooks.OnResponse(ctx, operationID, status, duration)
+		}
+		if w.Logger != nil {
+			level := slog.LevelInfo
+			if status >= http.StatusInternalServerError || panicked != nil {
+				level = slog.LevelError
+			}
+			w.Logger.LogAttrs(ctx, level, "request",
+				slog.String("operation_id", operationID),
+				slog.String("method", r.Method),
+				slog.String("route", route),
+				slog.Any("params", routeParams(r, route)),
+				slog.Int("status", status),
+				slog.Duration("latency", duration),
+			)
+		}
+		if panicked != nil {
+			panic(panicked)
+		}
+	}
+}
+
+// routeParams returns the path parameters matched for a route pattern
+func routeParams(r *http.Request, route string) map[string]string {
+	params := make(map[string]string)
+	for _, segment := range strings.Split(route, "/") {
+		if !strings.HasPrefix(segment, "{") || !strings.HasSuffix(segment, "}") {
+			continue
+		}
+		name := strings.TrimSuffix(segment[1:len(segment)-1], "...")
+		if name == "$" {
+			continue
+		}
+		params[name] = router.URLParam(r, name)
+	}
+	return params
 }
 
 // handleListPets adapts HTTP request to ListPets handler
 func (w *ServerWrapper) handleListPets(rw http.ResponseWriter, r *http.Request) {
+	const operationID = "listPets"
 	ctx := r.Context()
+	rw, finish := w.startOperation(ctx, operationID, "/api/v1/pets", rw, r)
+	defer finish()
+
 	req := ListPetsRequest{}
 
 	// Parse query parameter: limit
@@ -246,7 +413,7 @@ func (w *ServerWrapper) handleListPets(rw http.ResponseWriter, r *http.Request)
 	// Call handler
 	resp, err := w.Handler.ListPets(ctx, req)
 	if err != nil {
-		w.handleError(rw, err)
+		w.handleError(ctx, operationID, rw, err)
 		return
 	}
 
@@ -256,19 +423,23 @@ func (w *ServerWrapper) handleListPets(rw http.ResponseWriter, r *http.Request)
 
 // handleCreatePet adapts HTTP request to CreatePet handler
 func (w *ServerWrapper) handleCreatePet(rw http.ResponseWriter, r *http.Request) {
+	const operationID = "createPet"
 	ctx := r.Context()
+	rw, finish := w.startOperation(ctx, operationID, "/api/v1/pets", rw, r)
+	defer finish()
+
 	req := CreatePetRequest{}
 
 	// Parse request body
 	if err := ReadJSON(r, &req.Body); err != nil {
-		w.handleError(rw, NewHTTPError(http.StatusBadRequest, "invalid request body"))
+		w.handleError(ctx, operationID, rw, NewHTTPError(http.StatusBadRequest, "invalid request body"))
 		return
 	}
 
 	// Call handler
 	resp, err := w.Handler.CreatePet(ctx, req)
 	if err != nil {
-		w.handleError(rw, err)
+		w.handleError(ctx, operationID, rw, err)
 		return
 	}
 
@@ -278,22 +449,25 @@ func (w *ServerWrapper) handleCreatePet(rw http.ResponseWriter, r *http.Request)
 
 // handleGetPetById adapts HTTP request to GetPetById handler
 func (w *ServerWrapper) handleGetPetById(rw http.ResponseWriter, r *http.Request) {
+	const operationID = "getPetById"
 	ctx := r.Context()
+	rw, finish := w.startOperation(ctx, operationID, "/api/v1/pets/{petId}", rw, r)
+	defer finish()
+
 	req := GetPetByIdRequest{}
 
 	// Parse path parameter: petId
-	petIdStr := router.URLParam(r, "petId")
-	petIdVal, err := strconv.ParseInt(petIdStr, 10, 64)
+	petIdVal, err := router.URLParamInt64(r, "petId")
 	if err != nil {
-		w.handleError(rw, NewHTTPError(http.StatusBadRequest, "invalid petId parameter"))
+		w.handleError(ctx, operationID, rw, NewHTTPError(http.StatusBadRequest, "invalid petId parameter"))
 		return
 	}
-	req.PetId = int64(petIdVal)
+	req.PetId = petIdVal
 
 	// Call handler
 	resp, err := w.Handler.GetPetById(ctx, req)
 	if err != nil {
-		w.handleError(rw, err)
+		w.handleError(ctx, operationID, rw, err)
 		return
 	}
 
@@ -303,28 +477,31 @@ func (w *ServerWrapper) handleGetPetById(rw http.ResponseWriter, r *http.Request
 
 // handleUpdatePet adapts HTTP request to UpdatePet handler
 func (w *ServerWrapper) handleUpdatePet(rw http.ResponseWriter, r *http.Request) {
+	const operationID = "updatePet"
 	ctx := r.Context()
+	rw, finish := w.startOperation(ctx, operationID, "/api/v1/pets/{petId}", rw, r)
+	defer finish()
+
 	req := UpdatePetRequest{}
 
 	// Parse path parameter: petId
-	petIdStr := router.URLParam(r, "petId")
-	petIdVal, err := strconv.ParseInt(petIdStr, 10, 64)
+	petIdVal, err := router.URLParamInt64(r, "petId")
 	if err != nil {
-		w.handleError(rw, NewHTTPError(http.StatusBadRequest, "invalid petId parameter"))
+		w.handleError(ctx, operationID, rw, NewHTTPError(http.StatusBadRequest, "invalid petId parameter"))
 		return
 	}
-	req.PetId = int64(petIdVal)
+	req.PetId = petIdVal
 
 	// Parse request body
 	if err := ReadJSON(r, &req.Body); err != nil {
-		w.handleError(rw, NewHTTPError(http.StatusBadRequest, "invalid request body"))
+		w.handleError(ctx, operationID, rw, NewHTTPError(http.StatusBadRequest, "invalid request body"))
 		return
 	}
 
 	// Call handler
 	resp, err := w.Handler.UpdatePet(ctx, req)
 	if err != nil {
-		w.handleError(rw, err)
+		w.handleError(ctx, operationID, rw, err)
 		return
 	}
 
@@ -334,22 +511,25 @@ func (w *ServerWrapper) handleUpdatePet(rw http.ResponseWriter, r *http.Request)
 
 // handleDeletePet adapts HTTP request to DeletePet handler
 func (w *ServerWrapper) handleDeletePet(rw http.ResponseWriter, r *http.Request) {
+	const operationID = "deletePet"
 	ctx := r.Context()
+	rw, finish := w.startOperation(ctx, operationID, "/api/v1/pets/{petId}", rw, r)
+	defer finish()
+
 	req := DeletePetRequest{}
 
 	// Parse path parameter: petId
-	petIdStr := router.URLParam(r, "petId")
-	petIdVal, err := strconv.ParseInt(petIdStr, 10, 64)
+	petIdVal, err := router.URLParamInt64(r, "petId")
 	if err != nil {
-		w.handleError(rw, NewHTTPError(http.StatusBadRequest, "invalid petId parameter"))
+		w.handleError(ctx, operationID, rw, NewHTTPError(http.StatusBadRequest, "invalid petId parameter"))
 		return
 	}
-	req.PetId = int64(petIdVal)
+	req.PetId = petIdVal
 
 	// Call handler
 	resp, err := w.Handler.DeletePet(ctx, req)
 	if err != nil {
-		w.handleError(rw, err)
+		w.handleError(ctx, operationID, rw, err)
 		return
 	}
 
@@ -358,7 +538,22 @@ func (w *ServerWrapper) handleDeletePet(rw http.ResponseWriter, r *http.Request)
 }
 
 // handleError handles errors and writes appropriate HTTP responses
-func (w *ServerWrapper) handleError(rw http.ResponseWriter, err error) {
+func (w *ServerWrapper) handleError(ctx context.Context, operationID string, rw http.ResponseWriter, err error) {
+	if w.Hooks.OnError != nil {
+		w.Hooks.OnError(ctx, operationID, err)
+	}
+
+	for _, mapper := range w.ErrorMappers {
+		if status, body, ok := mapper(err); ok {
+			if body == nil {
+				rw.WriteHeader(status)
+				return
+			}
+			WriteJSON(rw, status, body)
+			return
+		}
+	}
+
 	var httpErr *HTTPError
 	if errors.As(err, &httpErr) {
 		WriteError(rw, httpErr.Code, httpErr)
@@ -368,12 +563,81 @@ func (w *ServerWrapper) handleError(rw http.ResponseWriter, err error) {
 	WriteError(rw, http.StatusInternalServerError, err)
 }
 
+// BasePath is the path prefix all routes are registered under
+const BasePath = "/api/v1"
+
+// OperationInfo describes the operation a request was routed to
+type OperationInfo struct {
+	// ID is the operationId, or the handler name when the spec has none
+	ID     string
+	Method string
+	// Route is the route pattern, e.g. /pets/{petId}
+	Route string
+	Tags  []string
+	// Scopes lists the scopes named by the operation's security requirements
+	Scopes []string
+	// Roles lists the x-roles of the operation, one of which the principal must hold
+	Roles []string
+	// Permissions lists the x-permissions of the operation, all of which the
+	// principal must hold
+	Permissions []string
+}
+
+// operationInfos describes every operation, keyed by operation ID
+var operationInfos = map[string]*OperationInfo{
+	"listPets": {
+		ID:     "listPets",
+		Method: "GET",
+		Route:  "/api/v1/pets",
+	},
+	"createPet": {
+		ID:     "createPet",
+		Method: "POST",
+		Route:  "/api/v1/pets",
+	},
+	"getPetById": {
+		ID:     "getPetById",
+		Method: "GET",
+		Route:  "/api/v1/pets/{petId}",
+	},
+	"updatePet": {
+		ID:     "updatePet",
+		Method: "PUT",
+		Route:  "/api/v1/pets/{petId}",
+	},
+	"deletePet": {
+		ID:     "deletePet",
+		Method: "DELETE",
+		Route:  "/api/v1/pets/{petId}",
+	},
+}
+
+type operationInfoKey struct{}
+
+// GetOperationInfo returns the operation a request was routed to, or nil when
+// the request did not go through a generated route. It is available to
+// authenticators and handlers.
+func GetOperationInfo(ctx context.Context) *OperationInfo {
+	info, _ := ctx.Value(operationInfoKey{}).(*OperationInfo)
+	return info
+}
+
+// withOperation adds the operation to the request context
+func withOperation(info *OperationInfo, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		h(w, r.WithContext(context.WithValue(r.Context(), operationInfoKey{}, info)))
+	}
+}
+
 // ConfigureRouter configures the given router with all routes.
 // This function allows you to use any router that implements the router.Router interface.
 //
 // The authenticator parameter is optional. If nil, no authentication will be performed.
 // If provided, authentication will be enforced for routes that require it.
 //
+// Options such as WithHooks configure the ServerWrapper that adapts HTTP requests
+// to the Server methods.
+//
 // Example with built-in router:
 //
 //	r := router.NewRouter()
@@ -383,28 +647,30 @@ func (w *ServerWrapper) handleError(rw http.ResponseWriter, err error) {
 //
 //	r := myCustomRouter.New() // Must implement router.Router interface
 //	ConfigureRouter(r, myServer, myAuthenticator)
-func ConfigureRouter(r router.Router, si Server) {
-	wrapper := &ServerWrapper{Handler: si}
+func ConfigureRouter(r router.Router, si Server, opts ...ServerOption) {
+	wrapper := NewServerWrapper(si, opts...)
 
-	r.Get("/pets", wrapper.handleListPets)
-	r.Post("/pets", wrapper.handleCreatePet)
-	r.Get("/pets/{petId}", wrapper.handleGetPetById)
-	r.Put("/pets/{petId}", wrapper.handleUpdatePet)
-	r.Delete("/pets/{petId}", wrapper.handleDeletePet)
+	r.Get("/api/v1/pets", withOperation(operationInfos["listPets"], wrapper.handleListPets))
+	r.Post("/api/v1/pets", withOperation(operationInfos["createPet"], wrapper.handleCreatePet))
+	r.Get("/api/v1/pets/{petId:-?[0-9]+}", withOperation(operationInfos["getPetById"], wrapper.handleGetPetById))
+	r.Put("/api/v1/pets/{petId:-?[0-9]+}", withOperation(operationInfos["updatePet"], wrapper.handleUpdatePet))
+	r.Delete("/api/v1/pets/{petId:-?[0-9]+}", withOperation(operationInfos["deletePet"], wrapper.handleDeletePet))
 }
 
 // NewRouter creates a new router with all routes configured using the built-in router.
 // For using a custom router, use ConfigureRouter instead.
-func NewRouter(si Server) *router.Mux {
+//
+// Requests are logged with slog.Default() unless WithLogger is passed.
+func NewRouter(si Server, opts ...ServerOption) *router.Mux {
 	r := router.NewRouter()
 
 	// Default middleware
-	r.Use(router.Logger)
 	r.Use(router.Recoverer)
 	r.Use(router.RequestID)
 	r.Use(router.RealIP)
 
-	ConfigureRouter(r, si)
+	opts = append([]ServerOption{WithLogger(slog.Default())}, opts...)
+	ConfigureRouter(r, si, opts...)
 	return r
 }
 
@@ -439,6 +705,158 @@ func WriteResponse(w http.ResponseWriter, resp any) error {
 	return WriteJSON(w, http.StatusOK, resp)
 }
 
+// ResponseEncoder encodes a response body for a media type
+type ResponseEncoder func(w io.Writer, v any) error
+
+// WriteResponseAs writes a response encoded as the given content type
+func WriteResponseAs(w http.ResponseWriter, resp any, contentType string) error {
+	return writeResponseWith(w, resp, contentType, nil)
+}
+
+// writeResponseWith writes a response encoded as the given content type, using
+// the encoder registered for its media type if there is one
+func writeResponseWith(w http.ResponseWriter, resp any, contentType string, encoders map[string]ResponseEncoder) error {
+	statusCode := http.StatusOK
+	body := resp
+	if rw, ok := resp.(interface {
+		StatusCode() int
+		ResponseBody() any
+	}); ok {
+		statusCode = rw.StatusCode()
+		body = rw.ResponseBody()
+	}
+
+	// For 204 No Content or nil body, don't write a body
+	if statusCode == http.StatusNoContent || body == nil {
+		w.WriteHeader(statusCode)
+		return nil
+	}
+
+	// Encode before writing headers so encoding errors can still be reported
+	var buf bytes.Buffer
+	encode := func(w io.Writer, v any) error {
+		return encodeBody(w, contentType, v)
+	}
+	if encoder, ok := encoders[baseMediaType(contentType)]; ok {
+		encode = encoder
+	}
+	if err := encode(&buf, body); err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(statusCode)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// encodeBody encodes v into w according to the media type
+func encodeBody(w io.Writer, contentType string, v any) error {
+	mediaType := baseMediaType(contentType)
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		return json.NewEncoder(w).Encode(v)
+	case mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml"):
+		return xml.NewEncoder(w).Encode(v)
+	}
+
+	// Raw payloads are written as-is for any other media type
+	switch b := v.(type) {
+	case []byte:
+		_, err := w.Write(b)
+		return err
+	case string:
+		_, err := io.WriteString(w, b)
+		return err
+	case io.Reader:
+		_, err := io.Copy(w, b)
+		return err
+	}
+	if strings.HasPrefix(mediaType, "text/") {
+		_, err := fmt.Fprint(w, v)
+		return err
+	}
+	return fmt.Errorf("no encoder for content type %q", contentType)
+}
+
+// baseMediaType returns the lower-cased media type of a content type without parameters
+func baseMediaType(contentType string) string {
+	return strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+}
+
+// NegotiateContentType selects the best of the offered media types for the
+// request's Accept header. A missing Accept header selects the first offer.
+// Returns an empty string if none of the offered types is acceptable.
+func NegotiateContentType(r *http.Request, offered []string) string {
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		if len(offered) > 0 {
+			return offered[0]
+		}
+		return ""
+	}
+
+	ranges := parseAccept(accept)
+	best, bestQ := "", 0.0
+	for _, offer := range offered {
+		// The most specific matching range determines the quality of an offer
+		q, specificity := 0.0, -1
+		for _, ar := range ranges {
+			if s := ar.match(offer); s > specificity {
+				q, specificity = ar.q, s
+			}
+		}
+		if q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best
+}
+
+// acceptRange is a single media range from an Accept header
+type acceptRange struct {
+	mediaType string
+	subType   string
+	q         float64
+}
+
+// match reports how specifically the range matches a media type:
+// 2 for an exact match, 1 for type/*, 0 for */*, and -1 for no match
+func (ar acceptRange) match(offer string) int {
+	mediaType, subType, _ := strings.Cut(strings.ToLower(offer), "/")
+	switch {
+	case ar.mediaType == "*" && ar.subType == "*":
+		return 0
+	case ar.mediaType == mediaType && ar.subType == "*":
+		return 1
+	case ar.mediaType == mediaType && ar.subType == subType:
+		return 2
+	}
+	return -1
+}
+
+// parseAccept parses an Accept header into media ranges
+func parseAccept(header string) []acceptRange {
+	var ranges []acceptRange
+	for _, part := range strings.Split(header, ",") {
+		params := strings.Split(part, ";")
+		mediaType, subType, ok := strings.Cut(strings.ToLower(strings.TrimSpace(params[0])), "/")
+		if !ok {
+			continue
+		}
+		ar := acceptRange{mediaType: mediaType, subType: subType, q: 1}
+		for _, param := range params[1:] {
+			key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(key, "q") {
+				if q, err := strconv.ParseFloat(value, 64); err == nil {
+					ar.q = q
+				}
+			}
+		}
+		ranges = append(ranges, ar)
+	}
+	return ranges
+}
+
 // ErrorResponse represents an error response
 type ErrorResponse struct {
 	Error   string `json:"error"`
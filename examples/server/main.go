@@ -170,7 +170,7 @@ func main() {
 	// Start server
 	port := ":8080"
 	log.Printf("Starting Pet Store API server on http://localhost%s", port)
-	log.Printf("Try: curl http://localhost%s/pets", port)
+	log.Printf("Try: curl http://localhost%s%s/pets", port, api.BasePath)
 	if err := http.ListenAndServe(port, router); err != nil {
 		log.Fatal(err)
 	}
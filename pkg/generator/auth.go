@@ -10,13 +10,30 @@ import (
 
 // AuthGenerator generates authentication code from OpenAPI security schemes
 type AuthGenerator struct {
-	spec *openapi.Document
+	spec          *openapi.Document
+	packageName   string
+	principalType string
 }
 
 // NewAuthGenerator creates a new AuthGenerator instance
 func NewAuthGenerator(spec *openapi.Document) *AuthGenerator {
+	return NewAuthGeneratorWithConfig(spec, Config{})
+}
+
+// NewAuthGeneratorWithConfig creates a new AuthGenerator instance using the
+// authentication options of the configuration
+func NewAuthGeneratorWithConfig(spec *openapi.Document, config Config) *AuthGenerator {
+	principalType := config.PrincipalType
+	if principalType == "" {
+		principalType = "any"
+	}
+	if config.PackageName == "" {
+		config.PackageName = "api"
+	}
 	return &AuthGenerator{
-		spec: spec,
+		spec:          spec,
+		packageName:   config.PackageName,
+		principalType: principalType,
 	}
 }
 
@@ -24,13 +41,20 @@ func NewAuthGenerator(spec *openapi.Document) *AuthGenerator {
 func (g *AuthGenerator) Generate() (string, error) {
 	var sb strings.Builder
 
-	sb.WriteString("package api\n\n")
+	sb.WriteString(fmt.Sprintf("package %s\n\n", g.packageName))
 	sb.WriteString("import (\n")
 	sb.WriteString("\t\"context\"\n")
+	sb.WriteString("\t\"crypto/sha256\"\n")
+	sb.WriteString("\t\"crypto/subtle\"\n")
 	sb.WriteString("\t\"encoding/base64\"\n")
+	sb.WriteString("\t\"encoding/hex\"\n")
 	sb.WriteString("\t\"errors\"\n")
+	sb.WriteString("\t\"fmt\"\n")
 	sb.WriteString("\t\"net/http\"\n")
+	sb.WriteString("\t\"slices\"\n")
 	sb.WriteString("\t\"strings\"\n")
+	sb.WriteString("\t\"sync\"\n")
+	sb.WriteString("\t\"time\"\n")
 	sb.WriteString(")\n\n")
 
 	// Generate context key
@@ -45,6 +69,15 @@ func (g *AuthGenerator) Generate() (string, error) {
 	// Generate authentication middleware
 	g.generateAuthMiddleware(&sb)
 
+	// Generate scope checking helpers
+	g.generateScopeHelpers(&sb)
+
+	// Generate API key comparison helpers
+	g.generateKeyHelpers(&sb)
+
+	// Generate the caching authenticator decorator
+	g.generateCachingAuthenticator(&sb)
+
 	// Generate credential extraction helpers
 	g.generateCredentialExtractors(&sb)
 
@@ -57,6 +90,10 @@ func (g *AuthGenerator) generateContextKey(sb *strings.Builder) {
 	sb.WriteString("type contextKey string\n\n")
 	sb.WriteString("// securityContextKey is the context key for security information\n")
 	sb.WriteString("const securityContextKey contextKey = \"security\"\n\n")
+	if usesTenants(g.spec) {
+		sb.WriteString("// tenantContextKey is the context key for the tenant credentials are authenticated for\n")
+		sb.WriteString("const tenantContextKey contextKey = \"tenant\"\n\n")
+	}
 }
 
 // generateCredentialTypes generates types for different credential types
@@ -97,15 +134,56 @@ func (g *AuthGenerator) generateCredentialTypes(sb *strings.Builder) {
 	sb.WriteString("\tToken string\n")
 	sb.WriteString("}\n\n")
 
+	// HTTPCredentials
+	sb.WriteString("// HTTPCredentials holds the credentials of an HTTP authentication scheme other\n")
+	sb.WriteString("// than Basic and Bearer, such as Digest\n")
+	sb.WriteString("type HTTPCredentials struct {\n")
+	sb.WriteString("\t// Scheme is the authentication scheme named in the Authorization header\n")
+	sb.WriteString("\tScheme string\n")
+	sb.WriteString("\t// Value is the rest of the Authorization header, e.g. the Digest parameters\n")
+	sb.WriteString("\tValue string\n")
+	sb.WriteString("}\n\n")
+
+	// Principal
+	sb.WriteString("// Principal is the type of authenticated users/entities. Authenticators return\n")
+	sb.WriteString("// values of this type, optionally wrapped in a ScopedPrincipal.\n")
+	sb.WriteString(fmt.Sprintf("type Principal = %s\n\n", g.principalType))
+
 	// SecurityContext
 	sb.WriteString("// SecurityContext holds authentication information\n")
 	sb.WriteString("type SecurityContext struct {\n")
-	sb.WriteString("\t// Principal is the authenticated user/entity (type depends on your implementation)\n")
-	sb.WriteString("\tPrincipal any\n")
-	sb.WriteString("\t// SchemeName is the name of the security scheme that was used\n")
+	sb.WriteString("\t// Principal is the authenticated user/entity. When a requirement combines several\n")
+	sb.WriteString("\t// schemes, it is the principal of the first scheme in alphabetical order.\n")
+	sb.WriteString("\tPrincipal Principal\n")
+	sb.WriteString("\t// SchemeName is the name of the security scheme Principal was authenticated with\n")
 	sb.WriteString("\tSchemeName string\n")
-	sb.WriteString("\t// Scopes are the OAuth2 scopes (if applicable)\n")
+	sb.WriteString("\t// Principals holds the principal of every scheme of the satisfied requirement\n")
+	sb.WriteString("\tPrincipals map[string]Principal\n")
+	sb.WriteString("\t// Scopes are the OAuth2 scopes required by the operation (if applicable)\n")
 	sb.WriteString("\tScopes []string\n")
+	sb.WriteString("\t// GrantedScopes are the scopes the authenticator reported for the principal\n")
+	sb.WriteString("\tGrantedScopes []string\n")
+	sb.WriteString("\t// Roles and Permissions are those the authenticator reported for the principal\n")
+	sb.WriteString("\tRoles       []string\n")
+	sb.WriteString("\tPermissions []string\n")
+	if usesTenants(g.spec) {
+		sb.WriteString("\t// Tenant is the tenant Principal was authenticated for, if its scheme declares one\n")
+		sb.WriteString("\tTenant string\n")
+	}
+	sb.WriteString("}\n\n")
+
+	// ScopedPrincipal
+	sb.WriteString("// ScopedPrincipal is returned by an authenticator to report the scopes, roles and\n")
+	sb.WriteString("// permissions granted to a principal. Operations requiring scopes are only served\n")
+	sb.WriteString("// when every required scope has been granted, operations with x-roles when one of\n")
+	sb.WriteString("// their roles has been granted, and operations with x-permissions when every\n")
+	sb.WriteString("// permission has been granted. They fail with 403 Forbidden otherwise. Handlers\n")
+	sb.WriteString("// see the wrapped Principal in the SecurityContext.\n")
+	sb.WriteString("type ScopedPrincipal struct {\n")
+	sb.WriteString("\tPrincipal   any\n")
+	sb.WriteString("\tScopes      []string\n")
+	sb.WriteString("\tRoles       []string\n")
+	sb.WriteString("\tPermissions []string\n")
 	sb.WriteString("}\n\n")
 
 	// Helper to get security context from request context
@@ -117,6 +195,21 @@ func (g *AuthGenerator) generateCredentialTypes(sb *strings.Builder) {
 	sb.WriteString("\t}\n")
 	sb.WriteString("\treturn nil\n")
 	sb.WriteString("}\n\n")
+
+	if usesTenants(g.spec) {
+		sb.WriteString("// GetTenant returns the tenant of a request. Authenticators see the tenant the\n")
+		sb.WriteString("// credentials were presented for, and handlers the tenant of the principal.\n")
+		sb.WriteString("// Returns \"\" for security schemes without an x-tenant extension.\n")
+		sb.WriteString("func GetTenant(ctx context.Context) string {\n")
+		sb.WriteString("\tif tenant, ok := ctx.Value(tenantContextKey).(string); ok {\n")
+		sb.WriteString("\t\treturn tenant\n")
+		sb.WriteString("\t}\n")
+		sb.WriteString("\tif sc := GetSecurityContext(ctx); sc != nil {\n")
+		sb.WriteString("\t\treturn sc.Tenant\n")
+		sb.WriteString("\t}\n")
+		sb.WriteString("\treturn \"\"\n")
+		sb.WriteString("}\n\n")
+	}
 }
 
 // generateAuthenticatorInterface generates the Authenticator interface
@@ -152,6 +245,10 @@ func (g *AuthGenerator) generateAuthenticatorInterface(sb *strings.Builder) {
 					sb.WriteString(fmt.Sprintf("\t// Authenticate%s authenticates using HTTP Bearer token\n", methodName))
 					sb.WriteString("\t// Returns the authenticated principal or an error\n")
 					sb.WriteString(fmt.Sprintf("\tAuthenticate%s(ctx context.Context, credentials BearerTokenCredentials) (any, error)\n\n", methodName))
+				} else {
+					sb.WriteString(fmt.Sprintf("\t// Authenticate%s authenticates using HTTP %s authentication\n", methodName, scheme.Scheme))
+					sb.WriteString("\t// Returns the authenticated principal or an error\n")
+					sb.WriteString(fmt.Sprintf("\tAuthenticate%s(ctx context.Context, credentials HTTPCredentials) (any, error)\n\n", methodName))
 				}
 			case "apiKey":
 				sb.WriteString(fmt.Sprintf("\t// Authenticate%s authenticates using API Key\n", methodName))
@@ -170,6 +267,69 @@ func (g *AuthGenerator) generateAuthenticatorInterface(sb *strings.Builder) {
 	}
 
 	sb.WriteString("}\n\n")
+
+	sb.WriteString("// Authorizer is an optional interface for authorization policies. When the\n")
+	sb.WriteString("// Authenticator passed to the router implements it, Authorize is called after a\n")
+	sb.WriteString("// request has been authenticated, with the scopes granted to the principal.\n")
+	sb.WriteString("// GetSecurityContext and GetOperationInfo return the details of the request.\n")
+	sb.WriteString("// Returning an error refuses the request with 403 Forbidden, or with the status\n")
+	sb.WriteString("// of an HTTPError.\n")
+	sb.WriteString("type Authorizer interface {\n")
+	sb.WriteString("\tAuthorize(ctx context.Context, principal Principal, operationID string, scopes []string) error\n")
+	sb.WriteString("}\n\n")
+
+	sb.WriteString("// authorize calls the Authorize method of authenticators implementing Authorizer\n")
+	sb.WriteString("func authorize(ctx context.Context, authenticator Authenticator, secCtx *SecurityContext) error {\n")
+	sb.WriteString("\tauthorizer, ok := authenticator.(Authorizer)\n")
+	sb.WriteString("\tif !ok {\n")
+	sb.WriteString("\t\treturn nil\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\tvar operationID string\n")
+	sb.WriteString("\tif info := GetOperationInfo(ctx); info != nil {\n")
+	sb.WriteString("\t\toperationID = info.ID\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\treturn authorizer.Authorize(ctx, secCtx.Principal, operationID, secCtx.GrantedScopes)\n")
+	sb.WriteString("}\n\n")
+
+	sb.WriteString("// AuthDecision describes a request allowed or refused by the auth middleware\n")
+	sb.WriteString("type AuthDecision struct {\n")
+	sb.WriteString("\tAllowed bool\n")
+	sb.WriteString("\t// Scheme is the security scheme that authenticated or refused the principal, if any\n")
+	sb.WriteString("\tScheme string\n")
+	sb.WriteString("\t// Principal is the authenticated principal, if any\n")
+	sb.WriteString("\tPrincipal Principal\n")
+	sb.WriteString("\t// Operation is the operation ID of the request\n")
+	sb.WriteString("\tOperation string\n")
+	sb.WriteString("\t// Status is the response status of refused requests\n")
+	sb.WriteString("\tStatus int\n")
+	sb.WriteString("\tReason string\n")
+	sb.WriteString("}\n\n")
+
+	sb.WriteString("// AuthObserver is an optional interface for auditing. When the Authenticator\n")
+	sb.WriteString("// passed to the router implements it, ObserveAuth is called with every decision\n")
+	sb.WriteString("// of the auth middleware.\n")
+	sb.WriteString("type AuthObserver interface {\n")
+	sb.WriteString("\tObserveAuth(ctx context.Context, decision AuthDecision)\n")
+	sb.WriteString("}\n\n")
+
+	sb.WriteString("// observeAuth reports a decision to authenticators implementing AuthObserver\n")
+	sb.WriteString("func observeAuth(ctx context.Context, authenticator Authenticator, decision AuthDecision) {\n")
+	sb.WriteString("\tobserver, ok := authenticator.(AuthObserver)\n")
+	sb.WriteString("\tif !ok {\n")
+	sb.WriteString("\t\treturn\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\tif info := GetOperationInfo(ctx); info != nil {\n")
+	sb.WriteString("\t\tdecision.Operation = info.ID\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\tobserver.ObserveAuth(ctx, decision)\n")
+	sb.WriteString("}\n\n")
+
+	sb.WriteString("// refuseAuth writes the error response of a refused request and reports the decision\n")
+	sb.WriteString("func refuseAuth(w http.ResponseWriter, r *http.Request, authenticator Authenticator, err error, decision AuthDecision) {\n")
+	sb.WriteString("\tdecision.Reason = err.Error()\n")
+	sb.WriteString("\tobserveAuth(r.Context(), authenticator, decision)\n")
+	sb.WriteString("\tWriteError(w, decision.Status, err)\n")
+	sb.WriteString("}\n\n")
 }
 
 // generateAuthMiddleware generates the authentication middleware
@@ -192,98 +352,244 @@ func (g *AuthGenerator) generateAuthMiddleware(sb *strings.Builder) {
 	sb.WriteString("\t\t\t\treturn\n")
 	sb.WriteString("\t\t\t}\n\n")
 
+	sb.WriteString("\t\t\t// Why an authenticated principal is not authorized, such as missing scopes\n")
+	sb.WriteString("\t\t\tvar forbidden error\n")
+	sb.WriteString("\t\t\t// An empty requirement allows anonymous access when no credentials are presented\n")
+	sb.WriteString("\t\t\tanonymous, presented := false, false\n")
+	sb.WriteString("\t\t\t// The authenticator error deciding the response, preferring statuses other than 401\n")
+	sb.WriteString("\t\t\tvar denied *HTTPError\n")
+	sb.WriteString("\t\t\t// The schemes and principal refused by forbidden and denied, for AuthObservers\n")
+	sb.WriteString("\t\t\tvar forbiddenScheme, deniedScheme string\n")
+	sb.WriteString("\t\t\tvar forbiddenPrincipal Principal\n\n")
+
 	sb.WriteString("\t\t\t// Try each security requirement (OR logic)\n")
 	sb.WriteString("\t\t\tfor _, req := range securityReqs {\n")
+	sb.WriteString("\t\t\t\tif len(req) == 0 {\n")
+	sb.WriteString("\t\t\t\t\tanonymous = true\n")
+	sb.WriteString("\t\t\t\t\tcontinue\n")
+	sb.WriteString("\t\t\t\t}\n\n")
 	sb.WriteString("\t\t\t\t// All schemes in a requirement must be satisfied (AND logic)\n")
-	sb.WriteString("\t\t\t\tvar secCtx *SecurityContext\n")
-	sb.WriteString("\t\t\t\tvar authErr error\n")
+	sb.WriteString("\t\t\t\tsecCtx := &SecurityContext{Principals: make(map[string]Principal, len(req))}\n")
 	sb.WriteString("\t\t\t\tallSatisfied := true\n\n")
 
-	sb.WriteString("\t\t\t\tfor schemeName, scopes := range req {\n")
+	sb.WriteString("\t\t\t\tfor _, schemeName := range schemeNames(req) {\n")
+	sb.WriteString("\t\t\t\t\tscopes := req[schemeName]\n")
 	sb.WriteString("\t\t\t\t\tschemeInfo, exists := schemes[schemeName]\n")
 	sb.WriteString("\t\t\t\t\tif !exists {\n")
 	sb.WriteString("\t\t\t\t\t\tallSatisfied = false\n")
 	sb.WriteString("\t\t\t\t\t\tbreak\n")
 	sb.WriteString("\t\t\t\t\t}\n\n")
 
-	sb.WriteString("\t\t\t\t\t// Authenticate based on scheme type\n")
-	sb.WriteString("\t\t\t\t\tvar principal any\n")
-	sb.WriteString("\t\t\t\t\tswitch schemeInfo.Type {\n")
-	sb.WriteString("\t\t\t\t\tcase \"http\":\n")
-	sb.WriteString("\t\t\t\t\t\tif schemeInfo.Scheme == \"basic\" {\n")
-	sb.WriteString("\t\t\t\t\t\t\tcreds, err := extractBasicAuth(r)\n")
-	sb.WriteString("\t\t\t\t\t\t\tif err != nil {\n")
-	sb.WriteString("\t\t\t\t\t\t\t\tallSatisfied = false\n")
-	sb.WriteString("\t\t\t\t\t\t\t\tauthErr = err\n")
-	sb.WriteString("\t\t\t\t\t\t\t\tbreak\n")
-	sb.WriteString("\t\t\t\t\t\t\t}\n")
-	sb.WriteString("\t\t\t\t\t\t\tprincipal, authErr = callAuthenticator(authenticator, schemeName, ctx, creds)\n")
-	sb.WriteString("\t\t\t\t\t\t} else if schemeInfo.Scheme == \"bearer\" {\n")
-	sb.WriteString("\t\t\t\t\t\t\tcreds, err := extractBearerToken(r)\n")
-	sb.WriteString("\t\t\t\t\t\t\tif err != nil {\n")
-	sb.WriteString("\t\t\t\t\t\t\t\tallSatisfied = false\n")
-	sb.WriteString("\t\t\t\t\t\t\t\tauthErr = err\n")
-	sb.WriteString("\t\t\t\t\t\t\t\tbreak\n")
-	sb.WriteString("\t\t\t\t\t\t\t}\n")
-	sb.WriteString("\t\t\t\t\t\t\tprincipal, authErr = callAuthenticator(authenticator, schemeName, ctx, creds)\n")
-	sb.WriteString("\t\t\t\t\t\t}\n")
-	sb.WriteString("\t\t\t\t\tcase \"apiKey\":\n")
-	sb.WriteString("\t\t\t\t\t\tcreds, err := extractAPIKey(r, schemeInfo.In, schemeInfo.Name)\n")
-	sb.WriteString("\t\t\t\t\t\tif err != nil {\n")
-	sb.WriteString("\t\t\t\t\t\t\tallSatisfied = false\n")
-	sb.WriteString("\t\t\t\t\t\t\tauthErr = err\n")
-	sb.WriteString("\t\t\t\t\t\t\tbreak\n")
-	sb.WriteString("\t\t\t\t\t\t}\n")
-	sb.WriteString("\t\t\t\t\t\tprincipal, authErr = callAuthenticator(authenticator, schemeName, ctx, creds)\n")
-	sb.WriteString("\t\t\t\t\tcase \"oauth2\":\n")
-	sb.WriteString("\t\t\t\t\t\tcreds, err := extractOAuth2Token(r, scopes)\n")
-	sb.WriteString("\t\t\t\t\t\tif err != nil {\n")
-	sb.WriteString("\t\t\t\t\t\t\tallSatisfied = false\n")
-	sb.WriteString("\t\t\t\t\t\t\tauthErr = err\n")
-	sb.WriteString("\t\t\t\t\t\t\tbreak\n")
-	sb.WriteString("\t\t\t\t\t\t}\n")
-	sb.WriteString("\t\t\t\t\t\tprincipal, authErr = callAuthenticator(authenticator, schemeName, ctx, creds)\n")
-	sb.WriteString("\t\t\t\t\tcase \"openIdConnect\":\n")
-	sb.WriteString("\t\t\t\t\t\tcreds, err := extractOpenIDConnectToken(r)\n")
-	sb.WriteString("\t\t\t\t\t\tif err != nil {\n")
-	sb.WriteString("\t\t\t\t\t\t\tallSatisfied = false\n")
-	sb.WriteString("\t\t\t\t\t\t\tauthErr = err\n")
-	sb.WriteString("\t\t\t\t\t\t\tbreak\n")
+	sb.WriteString("\t\t\t\t\tprincipal, err := authenticateScheme(ctx, authenticator, r, schemeName, schemeInfo, scopes)\n")
+	sb.WriteString("\t\t\t\t\tif !errors.Is(err, errMissingCredentials) {\n")
+	sb.WriteString("\t\t\t\t\t\tpresented = true\n")
+	sb.WriteString("\t\t\t\t\t}\n")
+	sb.WriteString("\t\t\t\t\tif err != nil {\n")
+	sb.WriteString("\t\t\t\t\t\tvar httpErr *HTTPError\n")
+	sb.WriteString("\t\t\t\t\t\tif errors.As(err, &httpErr) && (denied == nil || denied.Code == http.StatusUnauthorized) {\n")
+	sb.WriteString("\t\t\t\t\t\t\tdenied, deniedScheme = httpErr, schemeName\n")
 	sb.WriteString("\t\t\t\t\t\t}\n")
-	sb.WriteString("\t\t\t\t\t\tprincipal, authErr = callAuthenticator(authenticator, schemeName, ctx, creds)\n")
-	sb.WriteString("\t\t\t\t\tdefault:\n")
 	sb.WriteString("\t\t\t\t\t\tallSatisfied = false\n")
-	sb.WriteString("\t\t\t\t\t\tauthErr = errors.New(\"unsupported security scheme type\")\n")
+	sb.WriteString("\t\t\t\t\t\tbreak\n")
 	sb.WriteString("\t\t\t\t\t}\n\n")
 
-	sb.WriteString("\t\t\t\t\tif authErr != nil {\n")
+	sb.WriteString("\t\t\t\t\t// The principal must have been granted every required scope\n")
+	sb.WriteString("\t\t\t\t\ttyped, granted, err := principalScopes(principal)\n")
+	sb.WriteString("\t\t\t\t\tif err != nil {\n")
+	sb.WriteString("\t\t\t\t\t\trefuseAuth(w, r, authenticator, err, AuthDecision{Scheme: schemeName, Principal: typed, Status: http.StatusInternalServerError})\n")
+	sb.WriteString("\t\t\t\t\t\treturn\n")
+	sb.WriteString("\t\t\t\t\t}\n")
+	sb.WriteString("\t\t\t\t\tif missing := missingScopes(scopes, granted); len(missing) > 0 {\n")
+	sb.WriteString("\t\t\t\t\t\tforbidden = fmt.Errorf(\"insufficient scope: missing %s\", strings.Join(missing, \", \"))\n")
+	sb.WriteString("\t\t\t\t\t\tforbiddenScheme, forbiddenPrincipal = schemeName, typed\n")
 	sb.WriteString("\t\t\t\t\t\tallSatisfied = false\n")
 	sb.WriteString("\t\t\t\t\t\tbreak\n")
 	sb.WriteString("\t\t\t\t\t}\n\n")
 
-	sb.WriteString("\t\t\t\t\t// Create or update security context\n")
-	sb.WriteString("\t\t\t\t\tsecCtx = &SecurityContext{\n")
-	sb.WriteString("\t\t\t\t\t\tPrincipal:  principal,\n")
-	sb.WriteString("\t\t\t\t\t\tSchemeName: schemeName,\n")
-	sb.WriteString("\t\t\t\t\t\tScopes:     scopes,\n")
+	sb.WriteString("\t\t\t\t\t// Record the principal of every scheme; the first one is the primary principal\n")
+	sb.WriteString("\t\t\t\t\tsecCtx.Principals[schemeName] = typed\n")
+	sb.WriteString("\t\t\t\t\tif secCtx.SchemeName == \"\" {\n")
+	sb.WriteString("\t\t\t\t\t\tsecCtx.Principal, secCtx.SchemeName = typed, schemeName\n")
+	if usesTenants(g.spec) {
+		sb.WriteString("\t\t\t\t\t\tsecCtx.Tenant = requestTenant(r, schemeInfo)\n")
+	}
+	sb.WriteString("\t\t\t\t\t}\n")
+	sb.WriteString("\t\t\t\t\tsecCtx.Scopes = append(secCtx.Scopes, scopes...)\n")
+	sb.WriteString("\t\t\t\t\tsecCtx.GrantedScopes = append(secCtx.GrantedScopes, granted...)\n")
+	sb.WriteString("\t\t\t\t\troles, permissions := principalRoles(principal)\n")
+	sb.WriteString("\t\t\t\t\tsecCtx.Roles = append(secCtx.Roles, roles...)\n")
+	sb.WriteString("\t\t\t\t\tsecCtx.Permissions = append(secCtx.Permissions, permissions...)\n")
+	sb.WriteString("\t\t\t\t}\n\n")
+
+	sb.WriteString("\t\t\t\t// The principal must hold the roles and permissions of the operation\n")
+	sb.WriteString("\t\t\t\tif allSatisfied {\n")
+	sb.WriteString("\t\t\t\t\tif err := authorizeRoles(GetOperationInfo(ctx), secCtx); err != nil {\n")
+	sb.WriteString("\t\t\t\t\t\tforbidden = err\n")
+	sb.WriteString("\t\t\t\t\t\tforbiddenScheme, forbiddenPrincipal = secCtx.SchemeName, secCtx.Principal\n")
+	sb.WriteString("\t\t\t\t\t\tallSatisfied = false\n")
 	sb.WriteString("\t\t\t\t\t}\n")
 	sb.WriteString("\t\t\t\t}\n\n")
 
 	sb.WriteString("\t\t\t\t// If all schemes in this requirement were satisfied, continue\n")
-	sb.WriteString("\t\t\t\tif allSatisfied && secCtx != nil {\n")
+	sb.WriteString("\t\t\t\tif allSatisfied {\n")
 	sb.WriteString("\t\t\t\t\tctx = context.WithValue(ctx, securityContextKey, secCtx)\n")
 	sb.WriteString("\t\t\t\t\tr = r.WithContext(ctx)\n")
+	sb.WriteString("\t\t\t\t\tif err := authorize(ctx, authenticator, secCtx); err != nil {\n")
+	sb.WriteString("\t\t\t\t\t\tvar httpErr *HTTPError\n")
+	sb.WriteString("\t\t\t\t\t\tif errors.As(err, &httpErr) {\n")
+	sb.WriteString("\t\t\t\t\t\t\trefuseAuth(w, r, authenticator, httpErr, AuthDecision{Scheme: secCtx.SchemeName, Principal: secCtx.Principal, Status: httpErr.Code})\n")
+	sb.WriteString("\t\t\t\t\t\t\treturn\n")
+	sb.WriteString("\t\t\t\t\t\t}\n")
+	sb.WriteString("\t\t\t\t\t\trefuseAuth(w, r, authenticator, err, AuthDecision{Scheme: secCtx.SchemeName, Principal: secCtx.Principal, Status: http.StatusForbidden})\n")
+	sb.WriteString("\t\t\t\t\t\treturn\n")
+	sb.WriteString("\t\t\t\t\t}\n")
+	sb.WriteString("\t\t\t\t\tobserveAuth(ctx, authenticator, AuthDecision{Allowed: true, Scheme: secCtx.SchemeName, Principal: secCtx.Principal, Reason: \"authenticated\"})\n")
 	sb.WriteString("\t\t\t\t\tnext.ServeHTTP(w, r)\n")
 	sb.WriteString("\t\t\t\t\treturn\n")
 	sb.WriteString("\t\t\t\t}\n")
 	sb.WriteString("\t\t\t}\n\n")
 
+	sb.WriteString("\t\t\t// Continue unauthenticated when anonymous access is allowed and the operation\n")
+	sb.WriteString("\t\t\t// requires no roles or permissions\n")
+	sb.WriteString("\t\t\tif anonymous && !presented && authorizeRoles(GetOperationInfo(ctx), &SecurityContext{}) == nil {\n")
+	sb.WriteString("\t\t\t\tobserveAuth(ctx, authenticator, AuthDecision{Allowed: true, Reason: \"anonymous access\"})\n")
+	sb.WriteString("\t\t\t\tnext.ServeHTTP(w, r)\n")
+	sb.WriteString("\t\t\t\treturn\n")
+	sb.WriteString("\t\t\t}\n\n")
+
+	sb.WriteString("\t\t\t// An authenticated principal lacking scopes, roles or permissions is not authorized\n")
+	sb.WriteString("\t\t\tif forbidden != nil {\n")
+	sb.WriteString("\t\t\t\trefuseAuth(w, r, authenticator, forbidden, AuthDecision{Scheme: forbiddenScheme, Principal: forbiddenPrincipal, Status: http.StatusForbidden})\n")
+	sb.WriteString("\t\t\t\treturn\n")
+	sb.WriteString("\t\t\t}\n\n")
+
+	sb.WriteString("\t\t\t// An authenticator refusing credentials with another status, such as 403, decides it\n")
+	sb.WriteString("\t\t\tif denied != nil && denied.Code != http.StatusUnauthorized {\n")
+	sb.WriteString("\t\t\t\trefuseAuth(w, r, authenticator, denied, AuthDecision{Scheme: deniedScheme, Status: denied.Code})\n")
+	sb.WriteString("\t\t\t\treturn\n")
+	sb.WriteString("\t\t\t}\n\n")
+
 	sb.WriteString("\t\t\t// None of the security requirements were satisfied\n")
-	sb.WriteString("\t\t\tWriteError(w, http.StatusUnauthorized, errors.New(\"authentication required\"))\n")
+	sb.WriteString("\t\t\tfor _, challenge := range authChallenges(securityReqs, schemes) {\n")
+	sb.WriteString("\t\t\t\tw.Header().Add(\"WWW-Authenticate\", challenge)\n")
+	sb.WriteString("\t\t\t}\n")
+	sb.WriteString("\t\t\tif denied != nil {\n")
+	sb.WriteString("\t\t\t\trefuseAuth(w, r, authenticator, denied, AuthDecision{Scheme: deniedScheme, Status: http.StatusUnauthorized})\n")
+	sb.WriteString("\t\t\t\treturn\n")
+	sb.WriteString("\t\t\t}\n")
+	sb.WriteString("\t\t\trefuseAuth(w, r, authenticator, errors.New(\"authentication required\"), AuthDecision{Status: http.StatusUnauthorized})\n")
 	sb.WriteString("\t\t})\n")
 	sb.WriteString("\t}\n")
 	sb.WriteString("}\n\n")
 
+	// Helper to build the WWW-Authenticate challenges of 401 responses
+	realm := "api"
+	if g.spec.Info != nil && g.spec.Info.Title != "" {
+		realm = g.spec.Info.Title
+	}
+	sb.WriteString("// authRealm is the realm of WWW-Authenticate challenges\n")
+	sb.WriteString(fmt.Sprintf("const authRealm = %q\n\n", realm))
+
+	sb.WriteString("// authChallenges returns the WWW-Authenticate challenges of the HTTP\n")
+	sb.WriteString("// authentication schemes an operation accepts\n")
+	sb.WriteString("func authChallenges(securityReqs []map[string][]string, schemes map[string]*SecuritySchemeInfo) []string {\n")
+	sb.WriteString("\tvar challenges []string\n")
+	sb.WriteString("\tfor _, req := range securityReqs {\n")
+	sb.WriteString("\t\tfor _, name := range schemeNames(req) {\n")
+	sb.WriteString("\t\t\tinfo, ok := schemes[name]\n")
+	sb.WriteString("\t\t\tif !ok {\n")
+	sb.WriteString("\t\t\t\tcontinue\n")
+	sb.WriteString("\t\t\t}\n")
+	sb.WriteString("\t\t\tvar challenge string\n")
+	sb.WriteString("\t\t\tswitch {\n")
+	sb.WriteString("\t\t\tcase info.Type == \"http\" && info.Scheme == \"basic\":\n")
+	sb.WriteString("\t\t\t\tchallenge = fmt.Sprintf(\"Basic realm=%q\", authRealm)\n")
+	sb.WriteString("\t\t\tcase info.Type == \"http\" && info.Scheme == \"bearer\", info.Type == \"oauth2\", info.Type == \"openIdConnect\":\n")
+	sb.WriteString("\t\t\t\tchallenge = fmt.Sprintf(\"Bearer realm=%q\", authRealm)\n")
+	sb.WriteString("\t\t\t}\n")
+	sb.WriteString("\t\t\tif challenge != \"\" && !slices.Contains(challenges, challenge) {\n")
+	sb.WriteString("\t\t\t\tchallenges = append(challenges, challenge)\n")
+	sb.WriteString("\t\t\t}\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\treturn challenges\n")
+	sb.WriteString("}\n\n")
+
+	// Helper to iterate the schemes of a requirement deterministically
+	sb.WriteString("// schemeNames returns the scheme names of a security requirement in sorted order\n")
+	sb.WriteString("func schemeNames(req map[string][]string) []string {\n")
+	sb.WriteString("\tnames := make([]string, 0, len(req))\n")
+	sb.WriteString("\tfor name := range req {\n")
+	sb.WriteString("\t\tnames = append(names, name)\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\tslices.Sort(names)\n")
+	sb.WriteString("\treturn names\n")
+	sb.WriteString("}\n\n")
+
+	// Helper to extract credentials and authenticate a single scheme
+	sb.WriteString("// authenticateScheme extracts the credentials of a security scheme from the request\n")
+	sb.WriteString("// and passes them to the authenticator\n")
+	sb.WriteString("func authenticateScheme(ctx context.Context, authenticator Authenticator, r *http.Request, schemeName string, schemeInfo *SecuritySchemeInfo, scopes []string) (any, error) {\n")
+	sb.WriteString("\tvar credentials any\n")
+	sb.WriteString("\tvar err error\n\n")
+	sb.WriteString("\t// Extract credentials based on scheme type\n")
+	sb.WriteString("\tswitch schemeInfo.Type {\n")
+	sb.WriteString("\tcase \"http\":\n")
+	sb.WriteString("\t\tswitch schemeInfo.Scheme {\n")
+	sb.WriteString("\t\tcase \"basic\":\n")
+	sb.WriteString("\t\t\tcredentials, err = extractBasicAuth(r)\n")
+	sb.WriteString("\t\tcase \"bearer\":\n")
+	sb.WriteString("\t\t\tcredentials, err = extractBearerToken(r)\n")
+	sb.WriteString("\t\tdefault:\n")
+	sb.WriteString("\t\t\tcredentials, err = extractHTTPCredentials(r, schemeInfo.Scheme)\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\tcase \"apiKey\":\n")
+	sb.WriteString("\t\tcredentials, err = extractAPIKey(r, schemeInfo.In, schemeInfo.Name)\n")
+	sb.WriteString("\tcase \"oauth2\":\n")
+	sb.WriteString("\t\tcredentials, err = extractOAuth2Token(r, scopes)\n")
+	sb.WriteString("\tcase \"openIdConnect\":\n")
+	sb.WriteString("\t\tcredentials, err = extractOpenIDConnectToken(r)\n")
+	sb.WriteString("\tdefault:\n")
+	sb.WriteString("\t\terr = errors.New(\"unsupported security scheme type\")\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\tif err != nil {\n")
+	sb.WriteString("\t\treturn nil, err\n")
+	sb.WriteString("\t}\n\n")
+	if usesTenants(g.spec) {
+		sb.WriteString("\t// Authenticate the credentials for the tenant of the request\n")
+		sb.WriteString("\tif schemeInfo.TenantIn != \"\" {\n")
+		sb.WriteString("\t\ttenant := requestTenant(r, schemeInfo)\n")
+		sb.WriteString("\t\tif tenant == \"\" {\n")
+		sb.WriteString("\t\t\treturn nil, NewHTTPError(http.StatusUnauthorized, \"missing tenant\")\n")
+		sb.WriteString("\t\t}\n")
+		sb.WriteString("\t\tctx = context.WithValue(ctx, tenantContextKey, tenant)\n")
+		sb.WriteString("\t}\n\n")
+	}
+	sb.WriteString("\treturn callAuthenticator(authenticator, schemeName, ctx, credentials)\n")
+	sb.WriteString("}\n\n")
+
+	if usesTenants(g.spec) {
+		sb.WriteString("// requestTenant returns the tenant of a request from where the x-tenant extension\n")
+		sb.WriteString("// of its security scheme locates it, or \"\" when the request names no tenant\n")
+		sb.WriteString("func requestTenant(r *http.Request, schemeInfo *SecuritySchemeInfo) string {\n")
+		sb.WriteString("\tswitch schemeInfo.TenantIn {\n")
+		sb.WriteString("\tcase \"header\":\n")
+		sb.WriteString("\t\treturn r.Header.Get(schemeInfo.TenantName)\n")
+		sb.WriteString("\tcase \"host\":\n")
+		sb.WriteString("\t\t// The first label of the host name, e.g. acme in acme.api.example.com\n")
+		sb.WriteString("\t\tif tenant, _, ok := strings.Cut(r.Host, \".\"); ok {\n")
+		sb.WriteString("\t\t\treturn tenant\n")
+		sb.WriteString("\t\t}\n")
+		sb.WriteString("\tcase \"path\":\n")
+		sb.WriteString("\t\t// The first path segment after BasePath, e.g. acme in /acme/orders\n")
+		sb.WriteString("\t\tpath := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, BasePath), \"/\")\n")
+		sb.WriteString("\t\ttenant, _, _ := strings.Cut(path, \"/\")\n")
+		sb.WriteString("\t\treturn tenant\n")
+		sb.WriteString("\t}\n")
+		sb.WriteString("\treturn \"\"\n")
+		sb.WriteString("}\n\n")
+	}
+
 	// Helper to call the right authenticator method
 	sb.WriteString("// callAuthenticator calls the appropriate authenticator method based on scheme name\n")
 	sb.WriteString("func callAuthenticator(authenticator Authenticator, schemeName string, ctx context.Context, credentials any) (any, error) {\n")
@@ -320,6 +626,10 @@ func (g *AuthGenerator) generateAuthMiddleware(sb *strings.Builder) {
 					sb.WriteString("\t\tif creds, ok := credentials.(BearerTokenCredentials); ok {\n")
 					sb.WriteString(fmt.Sprintf("\t\t\treturn authenticator.Authenticate%s(ctx, creds)\n", methodName))
 					sb.WriteString("\t\t}\n")
+				} else {
+					sb.WriteString("\t\tif creds, ok := credentials.(HTTPCredentials); ok {\n")
+					sb.WriteString(fmt.Sprintf("\t\t\treturn authenticator.Authenticate%s(ctx, creds)\n", methodName))
+					sb.WriteString("\t\t}\n")
 				}
 			case "apiKey":
 				sb.WriteString("\t\tif creds, ok := credentials.(APIKeyCredentials); ok {\n")
@@ -349,6 +659,270 @@ func (g *AuthGenerator) generateAuthMiddleware(sb *strings.Builder) {
 	sb.WriteString("\tScheme string\n")
 	sb.WriteString("\tIn     string\n")
 	sb.WriteString("\tName   string\n")
+	if usesTenants(g.spec) {
+		sb.WriteString("\t// TenantIn and TenantName locate the tenant declared by the x-tenant extension\n")
+		sb.WriteString("\tTenantIn   string\n")
+		sb.WriteString("\tTenantName string\n")
+	}
+	sb.WriteString("}\n\n")
+}
+
+// schemeTenant returns where the x-tenant extension of a security scheme locates
+// the tenant of a request: in a header with the given name, in the "host" name or
+// in the first "path" segment. An empty location means the scheme has no tenants.
+func schemeTenant(scheme *openapi.SecurityScheme) (in, name string, err error) {
+	value, ok := scheme.Extensions["x-tenant"]
+	if !ok {
+		return "", "", nil
+	}
+	fields, ok := value.(map[string]any)
+	if !ok {
+		return "", "", fmt.Errorf("x-tenant must be an object with in and name")
+	}
+
+	in, _ = fields["in"].(string)
+	name, _ = fields["name"].(string)
+	switch in {
+	case "header":
+		if name == "" {
+			return "", "", fmt.Errorf("x-tenant in header requires a name")
+		}
+	case "host", "path":
+	default:
+		return "", "", fmt.Errorf("x-tenant in must be header, host or path, got %q", in)
+	}
+	return in, name, nil
+}
+
+// usesTenants reports whether any security scheme declares an x-tenant extension
+func usesTenants(spec *openapi.Document) bool {
+	if spec.Components == nil {
+		return false
+	}
+	for _, scheme := range spec.Components.SecuritySchemes {
+		if scheme == nil {
+			continue
+		}
+		if in, _, _ := schemeTenant(scheme); in != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// generateScopeHelpers generates the helpers comparing required and granted scopes
+func (g *AuthGenerator) generateScopeHelpers(sb *strings.Builder) {
+	sb.WriteString("// principalScopes unwraps a ScopedPrincipal into the principal and its granted\n")
+	sb.WriteString("// scopes, and checks that the authenticator returned a Principal\n")
+	sb.WriteString("func principalScopes(principal any) (Principal, []string, error) {\n")
+	sb.WriteString("\tvar scopes []string\n")
+	sb.WriteString("\tif scoped, ok := principal.(ScopedPrincipal); ok {\n")
+	sb.WriteString("\t\tprincipal, scopes = scoped.Principal, scoped.Scopes\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\ttyped, ok := principal.(Principal)\n")
+	sb.WriteString("\tif !ok && principal != nil {\n")
+	sb.WriteString("\t\treturn typed, nil, errors.New(\"authenticator returned an unexpected principal type\")\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\treturn typed, scopes, nil\n")
+	sb.WriteString("}\n\n")
+
+	sb.WriteString("// missingScopes returns the required scopes that were not granted\n")
+	sb.WriteString("func missingScopes(required, granted []string) []string {\n")
+	sb.WriteString("\tvar missing []string\n")
+	sb.WriteString("\tfor _, scope := range required {\n")
+	sb.WriteString("\t\tif !slices.Contains(granted, scope) {\n")
+	sb.WriteString("\t\t\tmissing = append(missing, scope)\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\treturn missing\n")
+	sb.WriteString("}\n\n")
+
+	sb.WriteString("// principalRoles returns the roles and permissions reported with a ScopedPrincipal\n")
+	sb.WriteString("func principalRoles(principal any) (roles, permissions []string) {\n")
+	sb.WriteString("\tif scoped, ok := principal.(ScopedPrincipal); ok {\n")
+	sb.WriteString("\t\treturn scoped.Roles, scoped.Permissions\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\treturn nil, nil\n")
+	sb.WriteString("}\n\n")
+
+	sb.WriteString("// authorizeRoles checks that a principal holds one of the x-roles and all of the\n")
+	sb.WriteString("// x-permissions of an operation\n")
+	sb.WriteString("func authorizeRoles(info *OperationInfo, secCtx *SecurityContext) error {\n")
+	sb.WriteString("\tif info == nil {\n")
+	sb.WriteString("\t\treturn nil\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\tif len(info.Roles) > 0 && !slices.ContainsFunc(info.Roles, func(role string) bool { return slices.Contains(secCtx.Roles, role) }) {\n")
+	sb.WriteString("\t\treturn fmt.Errorf(\"insufficient role: requires one of %s\", strings.Join(info.Roles, \", \"))\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\tif missing := missingScopes(info.Permissions, secCtx.Permissions); len(missing) > 0 {\n")
+	sb.WriteString("\t\treturn fmt.Errorf(\"insufficient permissions: missing %s\", strings.Join(missing, \", \"))\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\treturn nil\n")
+	sb.WriteString("}\n\n")
+}
+
+// generateKeyHelpers generates the helpers authenticators compare API keys with
+func (g *AuthGenerator) generateKeyHelpers(sb *strings.Builder) {
+	sb.WriteString("// CompareAPIKey reports whether key equals expected. The comparison takes\n")
+	sb.WriteString("// constant time, so response timing reveals neither the key nor its length.\n")
+	sb.WriteString("func CompareAPIKey(key, expected string) bool {\n")
+	sb.WriteString("\tkeySum := sha256.Sum256([]byte(key))\n")
+	sb.WriteString("\texpectedSum := sha256.Sum256([]byte(expected))\n")
+	sb.WriteString("\treturn subtle.ConstantTimeCompare(keySum[:], expectedSum[:]) == 1\n")
+	sb.WriteString("}\n\n")
+
+	sb.WriteString("// HashAPIKey returns the hex-encoded SHA-256 hash of an API key. Store the\n")
+	sb.WriteString("// hash instead of the key and check presented keys with VerifyAPIKeyHash.\n")
+	sb.WriteString("func HashAPIKey(key string) string {\n")
+	sb.WriteString("\tsum := sha256.Sum256([]byte(key))\n")
+	sb.WriteString("\treturn hex.EncodeToString(sum[:])\n")
+	sb.WriteString("}\n\n")
+
+	sb.WriteString("// VerifyAPIKeyHash reports whether key matches a hash returned by HashAPIKey,\n")
+	sb.WriteString("// in constant time\n")
+	sb.WriteString("func VerifyAPIKeyHash(key, hash string) bool {\n")
+	sb.WriteString("\twant, err := hex.DecodeString(hash)\n")
+	sb.WriteString("\tif err != nil {\n")
+	sb.WriteString("\t\treturn false\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\tsum := sha256.Sum256([]byte(key))\n")
+	sb.WriteString("\treturn subtle.ConstantTimeCompare(sum[:], want) == 1\n")
+	sb.WriteString("}\n\n")
+}
+
+// generateCachingAuthenticator generates CachingAuthenticator, which wraps an
+// Authenticator and caches the principals it returns
+func (g *AuthGenerator) generateCachingAuthenticator(sb *strings.Builder) {
+	sb.WriteString("// CachingAuthenticator wraps an Authenticator and caches successful\n")
+	sb.WriteString("// authentications, so repeated requests with the same credentials skip token\n")
+	sb.WriteString("// validation and database lookups until the entry expires. Failures are not\n")
+	sb.WriteString("// cached. Only wrap authenticators whose result depends on the credentials\n")
+	sb.WriteString("// alone, and keep the TTL short enough for revoked credentials to stop working\n")
+	sb.WriteString("// in time.\n")
+	sb.WriteString("type CachingAuthenticator struct {\n")
+	sb.WriteString("\tauthenticator Authenticator\n")
+	sb.WriteString("\tttl           time.Duration\n")
+	sb.WriteString("\tmaxEntries    int\n\n")
+	sb.WriteString("\tmu      sync.Mutex\n")
+	sb.WriteString("\tentries map[[sha256.Size]byte]cachedPrincipal\n")
+	sb.WriteString("}\n\n")
+
+	sb.WriteString("// cachedPrincipal is a successful authentication held by a CachingAuthenticator\n")
+	sb.WriteString("type cachedPrincipal struct {\n")
+	sb.WriteString("\tprincipal any\n")
+	sb.WriteString("\texpires   time.Time\n")
+	sb.WriteString("}\n\n")
+	sb.WriteString("// NewCachingAuthenticator returns a CachingAuthenticator that caches principals\n")
+	sb.WriteString("// for ttl and holds at most maxEntries credentials. A maxEntries of 0 or less\n")
+	sb.WriteString("// leaves the cache unbounded.\n")
+	sb.WriteString("func NewCachingAuthenticator(authenticator Authenticator, ttl time.Duration, maxEntries int) *CachingAuthenticator {\n")
+	sb.WriteString("\treturn &CachingAuthenticator{\n")
+	sb.WriteString("\t\tauthenticator: authenticator,\n")
+	sb.WriteString("\t\tttl:           ttl,\n")
+	sb.WriteString("\t\tmaxEntries:    maxEntries,\n")
+	sb.WriteString("\t\tentries:       make(map[[sha256.Size]byte]cachedPrincipal),\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("}\n\n")
+
+	if g.spec.Components != nil {
+		for _, name := range sortedKeys(g.spec.Components.SecuritySchemes) {
+			scheme := g.spec.Components.SecuritySchemes[name]
+			if scheme == nil {
+				continue
+			}
+
+			// The cache key covers everything the authenticator is given
+			var credentialsType, key string
+			switch {
+			case scheme.Type == "http" && scheme.Scheme == "basic":
+				credentialsType, key = "BasicAuthCredentials", "credentials.Username+\"\\x00\"+credentials.Password"
+			case scheme.Type == "http" && scheme.Scheme == "bearer":
+				credentialsType, key = "BearerTokenCredentials", "credentials.Token"
+			case scheme.Type == "http":
+				credentialsType, key = "HTTPCredentials", "credentials.Scheme+\"\\x00\"+credentials.Value"
+			case scheme.Type == "apiKey":
+				credentialsType, key = "APIKeyCredentials", "credentials.Key"
+			case scheme.Type == "oauth2":
+				credentialsType, key = "OAuth2Credentials", "credentials.Token+\"\\x00\"+strings.Join(credentials.Scopes, \" \")"
+			case scheme.Type == "openIdConnect":
+				credentialsType, key = "OpenIDConnectCredentials", "credentials.Token"
+			default:
+				continue
+			}
+
+			// The same credentials may belong to different principals in different tenants
+			if in, _, _ := schemeTenant(scheme); in != "" {
+				key = "GetTenant(ctx)+\"\\x00\"+" + key
+			}
+
+			methodName := "Authenticate" + toPascalCase(name)
+			sb.WriteString(fmt.Sprintf("// %s authenticates with the wrapped authenticator unless the credentials are cached\n", methodName))
+			sb.WriteString(fmt.Sprintf("func (c *CachingAuthenticator) %s(ctx context.Context, credentials %s) (any, error) {\n", methodName, credentialsType))
+			sb.WriteString(fmt.Sprintf("\treturn c.cached(%q, %s, func() (any, error) {\n", name, key))
+			sb.WriteString(fmt.Sprintf("\t\treturn c.authenticator.%s(ctx, credentials)\n", methodName))
+			sb.WriteString("\t})\n")
+			sb.WriteString("}\n\n")
+		}
+	}
+
+	sb.WriteString("// ObserveAuth calls the wrapped authenticator's ObserveAuth method if it\n")
+	sb.WriteString("// implements AuthObserver\n")
+	sb.WriteString("func (c *CachingAuthenticator) ObserveAuth(ctx context.Context, decision AuthDecision) {\n")
+	sb.WriteString("\tif observer, ok := c.authenticator.(AuthObserver); ok {\n")
+	sb.WriteString("\t\tobserver.ObserveAuth(ctx, decision)\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("}\n\n")
+
+	sb.WriteString("// Authorize calls the wrapped authenticator's Authorize method if it implements\n")
+	sb.WriteString("// Authorizer. Authorization decisions are not cached.\n")
+	sb.WriteString("func (c *CachingAuthenticator) Authorize(ctx context.Context, principal Principal, operationID string, scopes []string) error {\n")
+	sb.WriteString("\tif authorizer, ok := c.authenticator.(Authorizer); ok {\n")
+	sb.WriteString("\t\treturn authorizer.Authorize(ctx, principal, operationID, scopes)\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\treturn nil\n")
+	sb.WriteString("}\n\n")
+
+	sb.WriteString("// cached returns the principal cached for the credentials of a scheme, or calls\n")
+	sb.WriteString("// authenticate and caches the principal when it succeeds\n")
+	sb.WriteString("func (c *CachingAuthenticator) cached(schemeName, credentials string, authenticate func() (any, error)) (any, error) {\n")
+	sb.WriteString("\t// Entries are keyed by a hash so credentials are not kept in memory\n")
+	sb.WriteString("\tkey := sha256.Sum256([]byte(schemeName + \"\\x00\" + credentials))\n")
+	sb.WriteString("\tnow := time.Now()\n\n")
+	sb.WriteString("\tc.mu.Lock()\n")
+	sb.WriteString("\tentry, ok := c.entries[key]\n")
+	sb.WriteString("\tc.mu.Unlock()\n")
+	sb.WriteString("\tif ok && now.Before(entry.expires) {\n")
+	sb.WriteString("\t\treturn entry.principal, nil\n")
+	sb.WriteString("\t}\n\n")
+	sb.WriteString("\tprincipal, err := authenticate()\n")
+	sb.WriteString("\tif err != nil {\n")
+	sb.WriteString("\t\treturn nil, err\n")
+	sb.WriteString("\t}\n\n")
+	sb.WriteString("\tc.mu.Lock()\n")
+	sb.WriteString("\tdefer c.mu.Unlock()\n")
+	sb.WriteString("\tif c.maxEntries > 0 && len(c.entries) >= c.maxEntries {\n")
+	sb.WriteString("\t\tc.evict(now)\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\tc.entries[key] = cachedPrincipal{principal: principal, expires: now.Add(c.ttl)}\n")
+	sb.WriteString("\treturn principal, nil\n")
+	sb.WriteString("}\n\n")
+	sb.WriteString("// evict removes the expired entries, or the entry closest to expiring when none\n")
+	sb.WriteString("// has expired\n")
+	sb.WriteString("func (c *CachingAuthenticator) evict(now time.Time) {\n")
+	sb.WriteString("\tvar oldest [sha256.Size]byte\n")
+	sb.WriteString("\tvar oldestExpires time.Time\n")
+	sb.WriteString("\tfor key, entry := range c.entries {\n")
+	sb.WriteString("\t\tif !now.Before(entry.expires) {\n")
+	sb.WriteString("\t\t\tdelete(c.entries, key)\n")
+	sb.WriteString("\t\t\tcontinue\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t\tif oldestExpires.IsZero() || entry.expires.Before(oldestExpires) {\n")
+	sb.WriteString("\t\t\toldest, oldestExpires = key, entry.expires\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\tif len(c.entries) >= c.maxEntries {\n")
+	sb.WriteString("\t\tdelete(c.entries, oldest)\n")
+	sb.WriteString("\t}\n")
 	sb.WriteString("}\n\n")
 }
 
@@ -356,12 +930,15 @@ func (g *AuthGenerator) generateAuthMiddleware(sb *strings.Builder) {
 func (g *AuthGenerator) generateCredentialExtractors(sb *strings.Builder) {
 	sb.WriteString("// Credential extraction helpers\n\n")
 
+	sb.WriteString("// errMissingCredentials is returned when a request carries no credentials for a scheme\n")
+	sb.WriteString("var errMissingCredentials = errors.New(\"missing credentials\")\n\n")
+
 	// extractBasicAuth
 	sb.WriteString("// extractBasicAuth extracts HTTP Basic Auth credentials from request\n")
 	sb.WriteString("func extractBasicAuth(r *http.Request) (BasicAuthCredentials, error) {\n")
 	sb.WriteString("\tauth := r.Header.Get(\"Authorization\")\n")
 	sb.WriteString("\tif auth == \"\" {\n")
-	sb.WriteString("\t\treturn BasicAuthCredentials{}, errors.New(\"missing Authorization header\")\n")
+	sb.WriteString("\t\treturn BasicAuthCredentials{}, fmt.Errorf(\"%w: no Authorization header\", errMissingCredentials)\n")
 	sb.WriteString("\t}\n\n")
 	sb.WriteString("\tconst prefix = \"Basic \"\n")
 	sb.WriteString("\tif !strings.HasPrefix(auth, prefix) {\n")
@@ -386,7 +963,7 @@ func (g *AuthGenerator) generateCredentialExtractors(sb *strings.Builder) {
 	sb.WriteString("func extractBearerToken(r *http.Request) (BearerTokenCredentials, error) {\n")
 	sb.WriteString("\tauth := r.Header.Get(\"Authorization\")\n")
 	sb.WriteString("\tif auth == \"\" {\n")
-	sb.WriteString("\t\treturn BearerTokenCredentials{}, errors.New(\"missing Authorization header\")\n")
+	sb.WriteString("\t\treturn BearerTokenCredentials{}, fmt.Errorf(\"%w: no Authorization header\", errMissingCredentials)\n")
 	sb.WriteString("\t}\n\n")
 	sb.WriteString("\tconst prefix = \"Bearer \"\n")
 	sb.WriteString("\tif !strings.HasPrefix(auth, prefix) {\n")
@@ -400,6 +977,20 @@ func (g *AuthGenerator) generateCredentialExtractors(sb *strings.Builder) {
 	sb.WriteString("}\n\n")
 
 	// extractAPIKey
+	sb.WriteString("// extractHTTPCredentials extracts the credentials of an HTTP authentication scheme\n")
+	sb.WriteString("// from the Authorization header. Scheme names are matched case-insensitively.\n")
+	sb.WriteString("func extractHTTPCredentials(r *http.Request, scheme string) (HTTPCredentials, error) {\n")
+	sb.WriteString("\tauth := r.Header.Get(\"Authorization\")\n")
+	sb.WriteString("\tif auth == \"\" {\n")
+	sb.WriteString("\t\treturn HTTPCredentials{}, fmt.Errorf(\"%w: no Authorization header\", errMissingCredentials)\n")
+	sb.WriteString("\t}\n\n")
+	sb.WriteString("\tname, value, _ := strings.Cut(auth, \" \")\n")
+	sb.WriteString("\tif !strings.EqualFold(name, scheme) {\n")
+	sb.WriteString("\t\treturn HTTPCredentials{}, errors.New(\"invalid Authorization header format\")\n")
+	sb.WriteString("\t}\n\n")
+	sb.WriteString("\treturn HTTPCredentials{Scheme: name, Value: strings.TrimSpace(value)}, nil\n")
+	sb.WriteString("}\n\n")
+
 	sb.WriteString("// extractAPIKey extracts API key from request (header, query, or cookie)\n")
 	sb.WriteString("func extractAPIKey(r *http.Request, location, name string) (APIKeyCredentials, error) {\n")
 	sb.WriteString("\tvar key string\n\n")
@@ -416,7 +1007,7 @@ func (g *AuthGenerator) generateCredentialExtractors(sb *strings.Builder) {
 	sb.WriteString("\t\treturn APIKeyCredentials{}, errors.New(\"invalid API key location\")\n")
 	sb.WriteString("\t}\n\n")
 	sb.WriteString("\tif key == \"\" {\n")
-	sb.WriteString("\t\treturn APIKeyCredentials{}, errors.New(\"missing API key\")\n")
+	sb.WriteString("\t\treturn APIKeyCredentials{}, fmt.Errorf(\"%w: no API key\", errMissingCredentials)\n")
 	sb.WriteString("\t}\n\n")
 	sb.WriteString("\treturn APIKeyCredentials{\n")
 	sb.WriteString("\t\tKey:      key,\n")
@@ -291,6 +291,382 @@ func TestAuthGeneratorMiddleware(t *testing.T) {
 	assert.Contains(t, code, "All schemes in a requirement must be satisfied (AND logic)")
 }
 
+func TestAuthGeneratorScopeEnforcement(t *testing.T) {
+	spec := &openapi.Document{
+		OpenAPI: "3.1.0",
+		Info: &openapi.Info{
+			Title:   "Test API",
+			Version: "1.0.0",
+		},
+		Components: &openapi.Components{
+			SecuritySchemes: map[string]*openapi.SecurityScheme{
+				"oauth2": {
+					Type: "oauth2",
+				},
+			},
+		},
+	}
+
+	gen := NewAuthGenerator(spec)
+	code, err := gen.Generate()
+	require.NoError(t, err, "Generate should not fail")
+
+	assert.Contains(t, code, "type ScopedPrincipal struct", "Should let authenticators report granted scopes")
+	assert.Contains(t, code, "\tGrantedScopes []string\n", "Should expose granted scopes in the security context")
+	assert.Contains(t, code, "if missing := missingScopes(scopes, granted); len(missing) > 0 {",
+		"Should compare required scopes to granted scopes")
+	assert.Contains(t, code, "forbidden = fmt.Errorf(\"insufficient scope: missing %s\", strings.Join(missing, \", \"))",
+		"Should report the missing scopes")
+	assert.Contains(t, code, "refuseAuth(w, r, authenticator, forbidden, AuthDecision{Scheme: forbiddenScheme, Principal: forbiddenPrincipal, Status: http.StatusForbidden})",
+		"Should answer missing scopes with 403")
+	assert.Contains(t, code, "func missingScopes(required, granted []string) []string", "Should have missingScopes helper")
+}
+
+func TestAuthGeneratorOptionalAuthentication(t *testing.T) {
+	spec := &openapi.Document{
+		OpenAPI: "3.1.0",
+		Info: &openapi.Info{
+			Title:   "Test API",
+			Version: "1.0.0",
+		},
+		Components: &openapi.Components{
+			SecuritySchemes: map[string]*openapi.SecurityScheme{
+				"apiKey": {
+					Type: "apiKey",
+					In:   "header",
+					Name: "X-API-Key",
+				},
+			},
+		},
+	}
+
+	code, err := NewAuthGenerator(spec).Generate()
+	require.NoError(t, err, "Generate should not fail")
+
+	assert.Contains(t, code, "var errMissingCredentials = errors.New(\"missing credentials\")",
+		"Should distinguish missing credentials")
+	assert.Contains(t, code, "return APIKeyCredentials{}, fmt.Errorf(\"%w: no API key\", errMissingCredentials)",
+		"Should report missing API keys as missing credentials")
+	assert.Contains(t, code, "\t\t\t\tif len(req) == 0 {\n\t\t\t\t\tanonymous = true\n",
+		"Should treat an empty requirement as anonymous access")
+	assert.Contains(t, code, "\t\t\tif anonymous && !presented && authorizeRoles(GetOperationInfo(ctx), &SecurityContext{}) == nil {\n\t\t\t\tobserveAuth(ctx, authenticator, AuthDecision{Allowed: true, Reason: \"anonymous access\"})\n\t\t\t\tnext.ServeHTTP(w, r)\n",
+		"Should continue unauthenticated without credentials")
+}
+
+func TestAuthGeneratorUnauthorizedAndForbidden(t *testing.T) {
+	spec := &openapi.Document{
+		OpenAPI: "3.1.0",
+		Info: &openapi.Info{
+			Title:   "Test API",
+			Version: "1.0.0",
+		},
+		Components: &openapi.Components{
+			SecuritySchemes: map[string]*openapi.SecurityScheme{
+				"basic": {
+					Type:   "http",
+					Scheme: "basic",
+				},
+			},
+		},
+	}
+
+	code, err := NewAuthGenerator(spec).Generate()
+	require.NoError(t, err, "Generate should not fail")
+
+	assert.Contains(t, code, "const authRealm = \"Test API\"", "Should use the API title as realm")
+	assert.Contains(t, code, "challenge = fmt.Sprintf(\"Basic realm=%q\", authRealm)", "Should challenge basic schemes")
+	assert.Contains(t, code, "w.Header().Add(\"WWW-Authenticate\", challenge)", "Should send challenges with 401")
+	assert.Contains(t, code, "if denied != nil && denied.Code != http.StatusUnauthorized {\n\t\t\t\trefuseAuth(w, r, authenticator, denied, AuthDecision{Scheme: deniedScheme, Status: denied.Code})\n",
+		"Should answer with the status of authenticator errors such as 403")
+}
+
+func TestAuthGeneratorCombinedPrincipals(t *testing.T) {
+	spec := &openapi.Document{
+		OpenAPI: "3.1.0",
+		Info: &openapi.Info{
+			Title:   "Test API",
+			Version: "1.0.0",
+		},
+		Components: &openapi.Components{
+			SecuritySchemes: map[string]*openapi.SecurityScheme{
+				"apiKey": {
+					Type: "apiKey",
+					In:   "header",
+					Name: "X-API-Key",
+				},
+				"bearer": {
+					Type:   "http",
+					Scheme: "bearer",
+				},
+			},
+		},
+	}
+
+	code, err := NewAuthGenerator(spec).Generate()
+	require.NoError(t, err, "Generate should not fail")
+
+	assert.Contains(t, code, "\tPrincipals map[string]Principal\n", "Should expose the principal of every scheme")
+	assert.Contains(t, code, "for _, schemeName := range schemeNames(req) {", "Should iterate schemes deterministically")
+	assert.Contains(t, code, "secCtx.Principals[schemeName] = typed", "Should record each principal")
+}
+
+func TestAuthGeneratorPrincipalType(t *testing.T) {
+	spec := &openapi.Document{
+		OpenAPI: "3.1.0",
+		Info: &openapi.Info{
+			Title:   "Test API",
+			Version: "1.0.0",
+		},
+		Components: &openapi.Components{
+			SecuritySchemes: map[string]*openapi.SecurityScheme{
+				"bearer": {
+					Type:   "http",
+					Scheme: "bearer",
+				},
+			},
+		},
+	}
+
+	code, err := NewAuthGenerator(spec).Generate()
+	require.NoError(t, err, "Generate should not fail")
+	assert.Contains(t, code, "type Principal = any\n", "Should default to untyped principals")
+
+	code, err = NewAuthGeneratorWithConfig(spec, Config{PrincipalType: "*User"}).Generate()
+	require.NoError(t, err, "Generate should not fail")
+	assert.Contains(t, code, "type Principal = *User\n", "Should alias the configured principal type")
+	assert.Contains(t, code, "\tPrincipal Principal\n", "Should type SecurityContext.Principal")
+	assert.Contains(t, code, "typed, ok := principal.(Principal)", "Should check the principal type")
+}
+
+func TestAuthGeneratorCustomHTTPScheme(t *testing.T) {
+	spec := &openapi.Document{
+		OpenAPI: "3.1.0",
+		Info: &openapi.Info{
+			Title:   "Test API",
+			Version: "1.0.0",
+		},
+		Components: &openapi.Components{
+			SecuritySchemes: map[string]*openapi.SecurityScheme{
+				"digestAuth": {Type: "http", Scheme: "digest"},
+			},
+		},
+	}
+
+	code, err := NewAuthGenerator(spec).Generate()
+	require.NoError(t, err, "Generate should not fail")
+
+	assert.Contains(t, code, "type HTTPCredentials struct {", "Should generate HTTPCredentials")
+	assert.Contains(t, code, "\tAuthenticateDigestAuth(ctx context.Context, credentials HTTPCredentials) (any, error)\n",
+		"Should generate an authenticator method for the scheme")
+	assert.Contains(t, code, "credentials, err = extractHTTPCredentials(r, schemeInfo.Scheme)", "Should extract generic HTTP credentials")
+	assert.Contains(t, code, "if creds, ok := credentials.(HTTPCredentials); ok {\n\t\t\treturn authenticator.AuthenticateDigestAuth(ctx, creds)\n",
+		"Should dispatch generic HTTP credentials")
+	assert.Contains(t, code, "if !strings.EqualFold(name, scheme) {", "Should match the scheme case-insensitively")
+}
+
+func TestAuthGeneratorRoleEnforcement(t *testing.T) {
+	spec := &openapi.Document{
+		OpenAPI: "3.1.0",
+		Info: &openapi.Info{
+			Title:   "Test API",
+			Version: "1.0.0",
+		},
+		Components: &openapi.Components{
+			SecuritySchemes: map[string]*openapi.SecurityScheme{
+				"basicAuth": {Type: "http", Scheme: "basic"},
+			},
+		},
+	}
+
+	code, err := NewAuthGenerator(spec).Generate()
+	require.NoError(t, err, "Generate should not fail")
+
+	assert.Contains(t, code, "\tRoles       []string\n\tPermissions []string\n", "Should let authenticators report roles and permissions")
+	assert.Contains(t, code, "roles, permissions := principalRoles(principal)", "Should collect the granted roles")
+	assert.Contains(t, code, "if err := authorizeRoles(GetOperationInfo(ctx), secCtx); err != nil {",
+		"Should check the roles of the operation")
+	assert.Contains(t, code, "return fmt.Errorf(\"insufficient role: requires one of %s\", strings.Join(info.Roles, \", \"))",
+		"Should require one of the roles")
+	assert.Contains(t, code, "return fmt.Errorf(\"insufficient permissions: missing %s\", strings.Join(missing, \", \"))",
+		"Should require every permission")
+}
+
+func TestAuthGeneratorAuthorizer(t *testing.T) {
+	spec := &openapi.Document{
+		OpenAPI: "3.1.0",
+		Info: &openapi.Info{
+			Title:   "Test API",
+			Version: "1.0.0",
+		},
+		Components: &openapi.Components{
+			SecuritySchemes: map[string]*openapi.SecurityScheme{
+				"bearerAuth": {Type: "http", Scheme: "bearer"},
+			},
+		},
+	}
+
+	code, err := NewAuthGenerator(spec).Generate()
+	require.NoError(t, err, "Generate should not fail")
+
+	assert.Contains(t, code, "type Authorizer interface {\n\tAuthorize(ctx context.Context, principal Principal, operationID string, scopes []string) error\n}",
+		"Should generate the Authorizer interface")
+	assert.Contains(t, code, "authorizer, ok := authenticator.(Authorizer)", "Should detect authenticators implementing Authorizer")
+	assert.Contains(t, code, "return authorizer.Authorize(ctx, secCtx.Principal, operationID, secCtx.GrantedScopes)",
+		"Should pass the principal, operation ID and granted scopes")
+	assert.Contains(t, code, "if err := authorize(ctx, authenticator, secCtx); err != nil {", "Should authorize authenticated requests")
+	assert.Contains(t, code, "AuthDecision{Scheme: secCtx.SchemeName, Principal: secCtx.Principal, Status: http.StatusForbidden}",
+		"Should refuse denied requests with 403")
+	assert.Contains(t, code, "func (c *CachingAuthenticator) Authorize(", "Should forward authorization through the cache")
+}
+
+func TestAuthGeneratorAuthObserver(t *testing.T) {
+	spec := &openapi.Document{
+		OpenAPI: "3.1.0",
+		Info: &openapi.Info{
+			Title:   "Test API",
+			Version: "1.0.0",
+		},
+		Components: &openapi.Components{
+			SecuritySchemes: map[string]*openapi.SecurityScheme{
+				"bearerAuth": {Type: "http", Scheme: "bearer"},
+			},
+		},
+	}
+
+	code, err := NewAuthGenerator(spec).Generate()
+	require.NoError(t, err, "Generate should not fail")
+
+	assert.Contains(t, code, "type AuthDecision struct {", "Should generate the decision type")
+	assert.Contains(t, code, "type AuthObserver interface {\n\tObserveAuth(ctx context.Context, decision AuthDecision)\n}",
+		"Should generate the AuthObserver interface")
+	assert.Contains(t, code, "observer, ok := authenticator.(AuthObserver)", "Should detect authenticators implementing AuthObserver")
+	assert.Contains(t, code, "observeAuth(ctx, authenticator, AuthDecision{Allowed: true, Scheme: secCtx.SchemeName, Principal: secCtx.Principal, Reason: \"authenticated\"})",
+		"Should report allowed requests")
+	assert.Contains(t, code, "refuseAuth(w, r, authenticator, errors.New(\"authentication required\"), AuthDecision{Status: http.StatusUnauthorized})",
+		"Should report refused requests")
+	assert.Contains(t, code, "func (c *CachingAuthenticator) ObserveAuth(", "Should forward decisions through the cache")
+}
+
+func TestAuthGeneratorTenants(t *testing.T) {
+	spec := &openapi.Document{
+		OpenAPI: "3.1.0",
+		Info: &openapi.Info{
+			Title:   "Test API",
+			Version: "1.0.0",
+		},
+		Components: &openapi.Components{
+			SecuritySchemes: map[string]*openapi.SecurityScheme{
+				"apiKey": {
+					Type:       "apiKey",
+					In:         "header",
+					Name:       "X-API-Key",
+					Extensions: map[string]any{"x-tenant": map[string]any{"in": "header", "name": "X-Tenant-ID"}},
+				},
+				"bearerAuth": {Type: "http", Scheme: "bearer"},
+			},
+		},
+	}
+
+	code, err := NewAuthGenerator(spec).Generate()
+	require.NoError(t, err, "Generate should not fail")
+
+	assert.Contains(t, code, "func GetTenant(ctx context.Context) string {", "Should generate GetTenant")
+	assert.Contains(t, code, "\tTenant string\n", "Should record the tenant in the SecurityContext")
+	assert.Contains(t, code, "\tTenantIn   string\n\tTenantName string\n", "Should describe where schemes find tenants")
+	assert.Contains(t, code, "ctx = context.WithValue(ctx, tenantContextKey, tenant)", "Should pass the tenant to authenticators")
+	assert.Contains(t, code, "return nil, NewHTTPError(http.StatusUnauthorized, \"missing tenant\")", "Should refuse requests without a tenant")
+	assert.Contains(t, code, "return c.cached(\"apiKey\", GetTenant(ctx)+\"\\x00\"+credentials.Key, ", "Should cache credentials per tenant")
+	assert.Contains(t, code, "return c.cached(\"bearerAuth\", credentials.Token, ", "Should cache schemes without tenants by credentials alone")
+
+	t.Run("without tenants", func(t *testing.T) {
+		spec.Components.SecuritySchemes["apiKey"].Extensions = nil
+
+		code, err := NewAuthGenerator(spec).Generate()
+		require.NoError(t, err, "Generate should not fail")
+
+		assert.NotContains(t, code, "Tenant", "Should not generate tenant support")
+	})
+}
+
+func TestSchemeTenant(t *testing.T) {
+	tenant := func(value any) (string, string, error) {
+		return schemeTenant(&openapi.SecurityScheme{Extensions: map[string]any{"x-tenant": value}})
+	}
+
+	in, name, err := tenant(map[string]any{"in": "header", "name": "X-Tenant-ID"})
+	require.NoError(t, err)
+	assert.Equal(t, "header", in)
+	assert.Equal(t, "X-Tenant-ID", name)
+
+	in, _, err = tenant(map[string]any{"in": "host"})
+	require.NoError(t, err)
+	assert.Equal(t, "host", in)
+
+	_, _, err = tenant(map[string]any{"in": "header"})
+	assert.Error(t, err, "Headers need a name")
+	_, _, err = tenant(map[string]any{"in": "cookie"})
+	assert.Error(t, err)
+	_, _, err = tenant("host")
+	assert.Error(t, err)
+
+	in, _, err = schemeTenant(&openapi.SecurityScheme{})
+	require.NoError(t, err)
+	assert.Empty(t, in)
+}
+
+func TestAuthGeneratorKeyHelpers(t *testing.T) {
+	spec := &openapi.Document{
+		OpenAPI: "3.1.0",
+		Info: &openapi.Info{
+			Title:   "Test API",
+			Version: "1.0.0",
+		},
+		Components: &openapi.Components{
+			SecuritySchemes: map[string]*openapi.SecurityScheme{
+				"apiKey": {Type: "apiKey", In: "header", Name: "X-API-Key"},
+			},
+		},
+	}
+
+	code, err := NewAuthGenerator(spec).Generate()
+	require.NoError(t, err, "Generate should not fail")
+
+	assert.Contains(t, code, "func CompareAPIKey(key, expected string) bool {", "Should generate CompareAPIKey")
+	assert.Contains(t, code, "subtle.ConstantTimeCompare(keySum[:], expectedSum[:]) == 1", "Should compare in constant time")
+	assert.Contains(t, code, "func HashAPIKey(key string) string {", "Should generate HashAPIKey")
+	assert.Contains(t, code, "func VerifyAPIKeyHash(key, hash string) bool {", "Should generate VerifyAPIKeyHash")
+	assert.Contains(t, code, "\t\"crypto/subtle\"\n", "Should import crypto/subtle")
+}
+
+func TestAuthGeneratorCachingAuthenticator(t *testing.T) {
+	spec := &openapi.Document{
+		OpenAPI: "3.1.0",
+		Info: &openapi.Info{
+			Title:   "Test API",
+			Version: "1.0.0",
+		},
+		Components: &openapi.Components{
+			SecuritySchemes: map[string]*openapi.SecurityScheme{
+				"basicAuth": {Type: "http", Scheme: "basic"},
+				"oauth":     {Type: "oauth2"},
+			},
+		},
+	}
+
+	code, err := NewAuthGenerator(spec).Generate()
+	require.NoError(t, err, "Generate should not fail")
+
+	assert.Contains(t, code, "func NewCachingAuthenticator(authenticator Authenticator, ttl time.Duration, maxEntries int) *CachingAuthenticator {",
+		"Should generate the caching authenticator constructor")
+	assert.Contains(t, code, "func (c *CachingAuthenticator) AuthenticateBasicAuth(ctx context.Context, credentials BasicAuthCredentials) (any, error) {",
+		"Should implement the Authenticator methods")
+	assert.Contains(t, code, "\treturn c.cached(\"basicAuth\", credentials.Username+\"\\x00\"+credentials.Password, func() (any, error) {\n",
+		"Should key basic auth by username and password")
+	assert.Contains(t, code, "\treturn c.cached(\"oauth\", credentials.Token+\"\\x00\"+strings.Join(credentials.Scopes, \" \"), func() (any, error) {\n",
+		"Should key OAuth2 by token and required scopes")
+	assert.Contains(t, code, "key := sha256.Sum256([]byte(schemeName + \"\\x00\" + credentials))", "Should key entries by hash")
+}
+
 func TestAuthGeneratorDeterministicOutput(t *testing.T) {
 	spec := &openapi.Document{
 		OpenAPI: "3.1.0",
@@ -0,0 +1,76 @@
+package generator
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// FileChange describes a generated file whose copy in the output directory
+// is missing or out of date
+type FileChange struct {
+	Name    string // path relative to the output directory
+	Missing bool   // the file does not exist in the output directory
+	Added   int    // lines generated but not in the file on disk
+	Removed int    // lines in the file on disk but no longer generated
+}
+
+// String summarizes the change, e.g. "server.go: +12 -3 lines"
+func (c FileChange) String() string {
+	if c.Missing {
+		return c.Name + ": missing"
+	}
+	return fmt.Sprintf("%s: +%d -%d lines", c.Name, c.Added, c.Removed)
+}
+
+// Check generates the files in memory and compares them with those in the
+// output directory, without writing anything. It returns the files that are
+// missing or differ, in name order, so no changes means the generated code is
+// current. Files that are no longer generated, such as the server file of a
+// removed tag, are not detected.
+func (g *Generator) Check() ([]FileChange, error) {
+	files, err := g.GenerateFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []FileChange
+	for _, name := range sortedKeys(files) {
+		current, err := os.ReadFile(filepath.Join(g.outputDir, name))
+		if errors.Is(err, os.ErrNotExist) {
+			changes = append(changes, FileChange{Name: name, Missing: true})
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		if !bytes.Equal(current, files[name]) {
+			added, removed := countLineChanges(current, files[name])
+			changes = append(changes, FileChange{Name: name, Added: added, Removed: removed})
+		}
+	}
+	return changes, nil
+}
+
+// countLineChanges counts the lines of to missing from from, and the lines of
+// from missing from to, comparing the files as sets of lines with duplicates.
+// Moved lines are not counted.
+func countLineChanges(from, to []byte) (added, removed int) {
+	lines := make(map[string]int)
+	for _, line := range bytes.Split(from, []byte("\n")) {
+		lines[string(line)]++
+	}
+	for _, line := range bytes.Split(to, []byte("\n")) {
+		if lines[string(line)] > 0 {
+			lines[string(line)]--
+		} else {
+			added++
+		}
+	}
+	for _, count := range lines {
+		removed += count
+	}
+	return added, removed
+}
@@ -0,0 +1,58 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheck(t *testing.T) {
+	tmpDir := t.TempDir()
+	gen := NewGenerator(filterTestSpec(), Config{OutputDir: tmpDir})
+
+	changes, err := gen.Check()
+	require.NoError(t, err)
+	assert.Equal(t, []FileChange{
+		{Name: "server.go", Missing: true},
+		{Name: "types.go", Missing: true},
+	}, changes)
+	assert.NoFileExists(t, filepath.Join(tmpDir, "types.go"), "Should not write anything")
+
+	require.NoError(t, gen.Generate())
+	changes, err = gen.Check()
+	require.NoError(t, err)
+	assert.Empty(t, changes, "Should be current right after generating")
+
+	// A spec change shows up as a stale server.go
+	gen = NewGenerator(filterTestSpec(), Config{OutputDir: tmpDir, ExcludeTags: []string{"admin"}})
+	changes, err = gen.Check()
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	assert.Equal(t, "server.go", changes[0].Name)
+	assert.False(t, changes[0].Missing)
+	assert.Zero(t, changes[0].Added)
+	assert.Positive(t, changes[0].Removed)
+
+	// So does a hand edit
+	serverPath := filepath.Join(tmpDir, "server.go")
+	content, err := os.ReadFile(serverPath)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(serverPath, append(content, "// edited\n"...), 0644))
+	changes, err = NewGenerator(filterTestSpec(), Config{OutputDir: tmpDir}).Check()
+	require.NoError(t, err)
+	assert.Equal(t, []FileChange{{Name: "server.go", Added: 0, Removed: 1}}, changes)
+}
+
+func TestFileChangeString(t *testing.T) {
+	assert.Equal(t, "types.go: missing", FileChange{Name: "types.go", Missing: true}.String())
+	assert.Equal(t, "server.go: +12 -3 lines", FileChange{Name: "server.go", Added: 12, Removed: 3}.String())
+}
+
+func TestCountLineChanges(t *testing.T) {
+	added, removed := countLineChanges([]byte("a\nb\nb\nc\n"), []byte("a\nb\nd\ne\nc\n"))
+	assert.Equal(t, 2, added)
+	assert.Equal(t, 1, removed)
+}
@@ -0,0 +1,117 @@
+package generator
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/christopherklint97/specweaver/pkg/openapi"
+)
+
+// supportedSchemeTypes are the security scheme types the auth generator handles
+var supportedSchemeTypes = []string{"http", "apiKey", "oauth2", "openIdConnect"}
+
+// Diagnostics reports what generation leaves out or changes about the spec
+// after the tag and path filters, as findings sorted by location:
+//
+//   - warnings for parts of the spec that are not generated, such as TRACE
+//     operations, the not keyword and unsupported security scheme types, and
+//     for schemas or operations whose Go names collide
+//   - infos for Go names that differ from the names in the spec, such as
+//     handlers named after the path of operations without an operationId
+//
+// Generation errors are returned by Generate instead.
+func (g *Generator) Diagnostics() []openapi.Finding {
+	if keep := g.operationFilter(); keep != nil {
+		g.spec = filterOperations(g.spec, keep)
+	}
+
+	var findings []openapi.Finding
+	add := func(severity openapi.Severity, location, format string, args ...any) {
+		findings = append(findings, openapi.Finding{Severity: severity, Location: location, Message: fmt.Sprintf(format, args...)})
+	}
+
+	handlers := make(map[string]string)
+	for _, path := range sortedKeys(g.spec.Paths) {
+		item := g.spec.Paths[path]
+		if item == nil {
+			continue
+		}
+		if item.Trace != nil {
+			add(openapi.SeverityWarning, "TRACE "+path, "TRACE operations are not generated")
+		}
+		for _, methodOp := range getOperationsInOrder(item) {
+			location := methodOp.Method + " " + path
+			name := generateHandlerName(methodOp.Method, path, methodOp.Operation.OperationID)
+			if other, taken := handlers[name]; taken {
+				add(openapi.SeverityWarning, location, "handler %s is also generated for %s", name, other)
+			} else {
+				handlers[name] = location
+			}
+			if methodOp.Operation.OperationID == "" {
+				add(openapi.SeverityInfo, location, "no operationId, handler named %s", name)
+			} else if name != exportedName(methodOp.Operation.OperationID) {
+				add(openapi.SeverityInfo, location, "operationId %q generates handler %s", methodOp.Operation.OperationID, name)
+			}
+		}
+	}
+
+	if g.spec.Components != nil {
+		types := make(map[string]string)
+		for _, name := range sortedKeys(g.spec.Components.Schemas) {
+			location := "components.schemas." + name
+			typeName := toGoTypeName(name)
+			if other, taken := types[typeName]; taken {
+				add(openapi.SeverityWarning, location, "type %s is also generated for %s", typeName, other)
+			} else {
+				types[typeName] = location
+			}
+			if typeName != exportedName(name) {
+				add(openapi.SeverityInfo, location, "generated as type %s", typeName)
+			}
+			if usesNot(g.spec.Components.Schemas[name]) {
+				add(openapi.SeverityWarning, location, "the not keyword is ignored")
+			}
+		}
+
+		for _, name := range sortedKeys(g.spec.Components.SecuritySchemes) {
+			scheme := g.spec.Components.SecuritySchemes[name]
+			if scheme != nil && !slices.Contains(supportedSchemeTypes, scheme.Type) {
+				add(openapi.SeverityWarning, "components.securitySchemes."+name,
+					"security scheme type %q is not supported, so the Authenticator has no method for it", scheme.Type)
+			}
+		}
+	}
+
+	slices.SortStableFunc(findings, func(a, b openapi.Finding) int {
+		return cmp.Compare(a.Location, b.Location)
+	})
+	return findings
+}
+
+// exportedName returns name with its first letter upper-cased, the Go name a
+// spec name keeps when nothing else about it needs changing
+func exportedName(name string) string {
+	if name == "" {
+		return ""
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// usesNot reports whether a schema, or a schema nested in it without a
+// reference, uses the not keyword
+func usesNot(ref *openapi.SchemaRef) bool {
+	if ref == nil || ref.Ref != "" || ref.Value == nil {
+		return false
+	}
+	schema := ref.Value
+	if schema.Not != nil {
+		return true
+	}
+	nested := slices.Concat(schema.AllOf, schema.OneOf, schema.AnyOf, []*openapi.SchemaRef{schema.Items, schema.AdditionalProperties})
+	for _, name := range sortedKeys(schema.Properties) {
+		nested = append(nested, schema.Properties[name])
+	}
+	return slices.ContainsFunc(nested, usesNot)
+}
@@ -0,0 +1,58 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/christopherklint97/specweaver/pkg/openapi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiagnostics(t *testing.T) {
+	ok := map[string]*openapi.Response{"200": {Description: "Success"}}
+	spec := &openapi.Document{
+		OpenAPI: "3.1.0",
+		Info: &openapi.Info{
+			Title:   "Test",
+			Version: "1.0.0",
+		},
+		Paths: map[string]*openapi.PathItem{
+			"/items": {
+				Get:   &openapi.Operation{Responses: ok},
+				Trace: &openapi.Operation{Responses: ok},
+			},
+			"/items/{id}": {
+				Get: &openapi.Operation{OperationID: "get_item", Tags: []string{"items"}, Responses: ok},
+			},
+			"/admin": {
+				Trace: &openapi.Operation{Tags: []string{"admin"}, Responses: ok},
+			},
+		},
+		Components: &openapi.Components{
+			Schemas: map[string]*openapi.SchemaRef{
+				"pet_store": {Value: &openapi.Schema{
+					Type: []string{"object"},
+					Properties: map[string]*openapi.SchemaRef{
+						"name": {Value: &openapi.Schema{Type: []string{"string"}, Not: &openapi.SchemaRef{Value: &openapi.Schema{}}}},
+					},
+				}},
+				"PetStore": {Value: &openapi.Schema{Type: []string{"object"}}},
+				"pet":      {Value: &openapi.Schema{Type: []string{"object"}}},
+			},
+			SecuritySchemes: map[string]*openapi.SecurityScheme{
+				"mtls":       {Type: "mutualTLS"},
+				"bearerAuth": {Type: "http", Scheme: "bearer"},
+			},
+		},
+	}
+
+	gen := NewGenerator(spec, Config{ExcludeTags: []string{"admin"}})
+	assert.Equal(t, []openapi.Finding{
+		{Severity: openapi.SeverityInfo, Location: "GET /items", Message: "no operationId, handler named GetItems"},
+		{Severity: openapi.SeverityInfo, Location: "GET /items/{id}", Message: `operationId "get_item" generates handler GetItem`},
+		{Severity: openapi.SeverityWarning, Location: "TRACE /items", Message: "TRACE operations are not generated"},
+		{Severity: openapi.SeverityWarning, Location: "components.schemas.pet_store", Message: "type PetStore is also generated for components.schemas.PetStore"},
+		{Severity: openapi.SeverityInfo, Location: "components.schemas.pet_store", Message: "generated as type PetStore"},
+		{Severity: openapi.SeverityWarning, Location: "components.schemas.pet_store", Message: "the not keyword is ignored"},
+		{Severity: openapi.SeverityWarning, Location: "components.securitySchemes.mtls", Message: `security scheme type "mutualTLS" is not supported, so the Authenticator has no method for it`},
+	}, gen.Diagnostics(), "Should skip filtered operations and names that only gain a capital letter")
+}
@@ -0,0 +1,117 @@
+package generator
+
+import (
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/christopherklint97/specweaver/pkg/openapi"
+)
+
+// filterOperations returns a copy of spec holding only the operations keep
+// accepts. Paths left without operations are dropped. Components are shared
+// with spec, so schemas only used by dropped operations are still generated.
+func filterOperations(spec *openapi.Document, keep func(path, method string, op *openapi.Operation) bool) *openapi.Document {
+	filtered := *spec
+	filtered.Paths = make(map[string]*openapi.PathItem, len(spec.Paths))
+
+	for path, item := range spec.Paths {
+		if item == nil {
+			continue
+		}
+		kept := *item
+		for _, op := range []struct {
+			method string
+			field  **openapi.Operation
+		}{
+			{http.MethodGet, &kept.Get},
+			{http.MethodPut, &kept.Put},
+			{http.MethodPost, &kept.Post},
+			{http.MethodDelete, &kept.Delete},
+			{http.MethodOptions, &kept.Options},
+			{http.MethodHead, &kept.Head},
+			{http.MethodPatch, &kept.Patch},
+			{http.MethodTrace, &kept.Trace},
+		} {
+			if *op.field != nil && !keep(path, op.method, *op.field) {
+				*op.field = nil
+			}
+		}
+		if len(getOperationsInOrder(&kept)) > 0 {
+			filtered.Paths[path] = &kept
+		}
+	}
+
+	return &filtered
+}
+
+// operationFilter returns the filterOperations predicate combining the tag
+// and path filters of the configuration, or nil if there are none
+func (g *Generator) operationFilter() func(path, method string, op *openapi.Operation) bool {
+	if len(g.includeTags) == 0 && len(g.excludeTags) == 0 && len(g.includePaths) == 0 && len(g.excludePaths) == 0 {
+		return nil
+	}
+	byTag := tagFilter(g.includeTags, g.excludeTags)
+	byPath := pathFilter(g.includePaths, g.excludePaths)
+	return func(path, method string, op *openapi.Operation) bool {
+		return byTag(path, method, op) && byPath(path, method, op)
+	}
+}
+
+// tagFilter returns a filterOperations predicate keeping operations tagged
+// with one of include, unless include is empty, and with none of exclude
+func tagFilter(include, exclude []string) func(path, method string, op *openapi.Operation) bool {
+	return func(path, method string, op *openapi.Operation) bool {
+		if len(include) > 0 && !slices.ContainsFunc(op.Tags, func(tag string) bool { return slices.Contains(include, tag) }) {
+			return false
+		}
+		return !slices.ContainsFunc(op.Tags, func(tag string) bool { return slices.Contains(exclude, tag) })
+	}
+}
+
+// pathFilter returns a filterOperations predicate keeping operations whose
+// path matches one of the include globs, unless there are none, and none of
+// the exclude globs
+func pathFilter(include, exclude []string) func(path, method string, op *openapi.Operation) bool {
+	includes := compilePathGlobs(include)
+	excludes := compilePathGlobs(exclude)
+	return func(path, method string, op *openapi.Operation) bool {
+		matches := func(glob *regexp.Regexp) bool { return glob.MatchString(path) }
+		if len(includes) > 0 && !slices.ContainsFunc(includes, matches) {
+			return false
+		}
+		return !slices.ContainsFunc(excludes, matches)
+	}
+}
+
+// compilePathGlobs compiles path globs into regular expressions matching
+// whole paths. In a glob, * matches within a segment, ** matches across
+// segments, and a trailing /** also matches the path without it, so /v1/**
+// matches /v1 and everything below it.
+func compilePathGlobs(globs []string) []*regexp.Regexp {
+	compiled := make([]*regexp.Regexp, 0, len(globs))
+	for _, glob := range globs {
+		var sb strings.Builder
+		sb.WriteString("^")
+		for i := 0; i < len(glob); i++ {
+			switch {
+			case strings.HasPrefix(glob[i:], "/**") && i+3 == len(glob):
+				sb.WriteString("(/.*)?")
+				i += 2
+			case strings.HasPrefix(glob[i:], "**"):
+				sb.WriteString(".*")
+				i++
+			case glob[i] == '*':
+				sb.WriteString("[^/]*")
+			case glob[i] == '?':
+				sb.WriteString("[^/]")
+			default:
+				sb.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+			}
+		}
+		sb.WriteString("$")
+		compiled = append(compiled, regexp.MustCompile(sb.String()))
+	}
+	return compiled
+}
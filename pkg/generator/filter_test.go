@@ -0,0 +1,133 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/christopherklint97/specweaver/pkg/openapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// filterTestSpec returns a spec with public, admin and internal operations
+func filterTestSpec() *openapi.Document {
+	ok := map[string]*openapi.Response{"200": {Description: "Success"}}
+	return &openapi.Document{
+		OpenAPI: "3.1.0",
+		Info: &openapi.Info{
+			Title:   "Test",
+			Version: "1.0.0",
+		},
+		Paths: map[string]*openapi.PathItem{
+			"/pets": {
+				Get:  &openapi.Operation{OperationID: "listPets", Tags: []string{"pets"}, Responses: ok},
+				Post: &openapi.Operation{OperationID: "createPet", Tags: []string{"pets", "admin"}, Responses: ok},
+			},
+			"/admin/stats": {
+				Get: &openapi.Operation{OperationID: "getStats", Tags: []string{"admin"}, Responses: ok},
+			},
+			"/health": {
+				Get: &openapi.Operation{OperationID: "getHealth", Responses: ok},
+			},
+		},
+	}
+}
+
+// operationIDs returns the operation IDs of spec in generation order
+func operationIDs(spec *openapi.Document) []string {
+	var ids []string
+	for _, info := range getOperations(spec) {
+		ids = append(ids, info.Operation.OperationID)
+	}
+	return ids
+}
+
+func TestFilterOperationsByTag(t *testing.T) {
+	spec := filterTestSpec()
+
+	tests := []struct {
+		name     string
+		include  []string
+		exclude  []string
+		expected []string
+	}{
+		{"No filters", nil, nil, []string{"getStats", "getHealth", "listPets", "createPet"}},
+		{"Include", []string{"pets"}, nil, []string{"listPets", "createPet"}},
+		{"Exclude", nil, []string{"admin"}, []string{"getHealth", "listPets"}},
+		{"Exclude wins", []string{"pets"}, []string{"admin"}, []string{"listPets"}},
+		{"Unknown tag", []string{"users"}, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filtered := filterOperations(spec, tagFilter(tt.include, tt.exclude))
+			assert.Equal(t, tt.expected, operationIDs(filtered))
+		})
+	}
+
+	assert.Len(t, spec.Paths, 3, "Should leave the original spec alone")
+	assert.NotNil(t, spec.Paths["/pets"].Post)
+}
+
+func TestFilterOperationsByPath(t *testing.T) {
+	spec := filterTestSpec()
+
+	tests := []struct {
+		name     string
+		include  []string
+		exclude  []string
+		expected []string
+	}{
+		{"Include subtree", []string{"/admin/**"}, nil, []string{"getStats"}},
+		{"Include several", []string{"/pets", "/health"}, nil, []string{"getHealth", "listPets", "createPet"}},
+		{"Exclude", nil, []string{"/admin/*"}, []string{"getHealth", "listPets", "createPet"}},
+		{"Exclude everything", nil, []string{"/**"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filtered := filterOperations(spec, pathFilter(tt.include, tt.exclude))
+			assert.Equal(t, tt.expected, operationIDs(filtered))
+		})
+	}
+}
+
+func TestCompilePathGlobs(t *testing.T) {
+	tests := []struct {
+		glob    string
+		path    string
+		matches bool
+	}{
+		{"/pets", "/pets", true},
+		{"/pets", "/pets/{petId}", false},
+		{"/pets/*", "/pets/{petId}", true},
+		{"/pets/*", "/pets/{petId}/photos", false},
+		{"/pets/**", "/pets", true},
+		{"/pets/**", "/pets/{petId}/photos", true},
+		{"/pets/**", "/petstore", false},
+		{"/**/photos", "/pets/{petId}/photos", true},
+		{"/v?/pets", "/v1/pets", true},
+		{"/pets.{format}", "/pets.{format}", true},
+		{"/pets.{format}", "/petsx{format}", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.glob+" "+tt.path, func(t *testing.T) {
+			globs := compilePathGlobs([]string{tt.glob})
+			assert.Equal(t, tt.matches, globs[0].MatchString(tt.path))
+		})
+	}
+}
+
+func TestGenerateWithTagFilters(t *testing.T) {
+	tmpDir := t.TempDir()
+	gen := NewGenerator(filterTestSpec(), Config{OutputDir: tmpDir, ExcludeTags: []string{"admin"}})
+	require.NoError(t, gen.Generate())
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, "server.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "ListPets(")
+	assert.NotContains(t, string(content), "CreatePet(")
+	assert.NotContains(t, string(content), "/admin/stats")
+}
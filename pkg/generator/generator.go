@@ -1,24 +1,160 @@
 package generator
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"go/token"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/christopherklint97/specweaver/pkg/openapi"
+	"gopkg.in/yaml.v3"
 )
 
 // Generator coordinates the generation of Go code from OpenAPI specs
 type Generator struct {
-	spec       *openapi.Document
-	outputDir  string
-	packageName string
+	spec          *openapi.Document
+	outputDir     string
+	packageName   string
+	router        string
+	splitByTag    bool
+	tagInterfaces bool
+	stubs         bool
+	basePath      string
+	embedSpec     bool
+	docs          bool
+	autoHead      bool
+	cors          bool
+	idempotency   bool
+	thin          bool
+	maxBodySize   int64
+	timeout       time.Duration
+	principalType string
+	only          []string
+	skip          []string
+	includeTags   []string
+	excludeTags   []string
+	includePaths  []string
+	excludePaths  []string
+	quiet         bool
+	typesDir      string
 }
 
+// Artifacts the generator can be limited to with Config.Only and Config.Skip
+const (
+	// ArtifactTypes is types.go
+	ArtifactTypes = "types"
+	// ArtifactServer is the server code, plus the spec files it embeds
+	ArtifactServer = "server"
+	// ArtifactAuth is auth.go, jwt.go and session.go
+	ArtifactAuth = "auth"
+	// ArtifactStubs is unimplemented.go and cmd/server/main.go
+	ArtifactStubs = "stubs"
+)
+
+// artifacts lists the artifacts in the order they are generated
+var artifacts = []string{ArtifactTypes, ArtifactServer, ArtifactAuth, ArtifactStubs}
+
+// Router targets supported by the server generator
+const (
+	// RouterBuiltin registers routes on the bundled router.Router interface
+	RouterBuiltin = "builtin"
+	// RouterChi registers routes on a chi.Router
+	RouterChi = "chi"
+	// RouterStdlib registers routes on a net/http ServeMux using Go 1.22 patterns
+	RouterStdlib = "stdlib"
+)
+
 // Config holds generator configuration
 type Config struct {
 	OutputDir   string
 	PackageName string
+	// Router selects the router the generated server registers its routes on.
+	// Default: RouterBuiltin
+	Router string
+	// SplitByTag writes server code into one file per operation tag plus a
+	// shared server_common.go instead of a single server.go
+	SplitByTag bool
+	// TagInterfaces generates one interface per operation tag (e.g. PetsServer)
+	// and composes Server from them
+	TagInterfaces bool
+	// Stubs additionally writes unimplemented.go and a skeleton
+	// cmd/server/main.go so a server compiles before handlers exist
+	Stubs bool
+	// BasePath overrides the route prefix otherwise taken from the path of
+	// the spec's first server URL. Use "/" to register routes without a prefix.
+	BasePath string
+	// EmbedSpec writes openapi.json and openapi.yaml next to the generated code,
+	// embeds them in the package and serves them under BasePath
+	EmbedSpec bool
+	// Docs generates MountDocs, which serves Swagger UI and Redoc pages backed
+	// by the embedded spec. Implies EmbedSpec.
+	Docs bool
+	// AutoHead registers a HEAD route running the GET handler, without a body,
+	// for every path that defines GET but not HEAD
+	AutoHead bool
+	// CORS registers OPTIONS routes listing each path's allowed methods and
+	// generates WithCORS to apply a CORS policy to responses and preflight requests
+	CORS bool
+	// Idempotency accepts an Idempotency-Key header on all POST and PATCH
+	// operations, in addition to operations declaring it, and replays recorded
+	// responses for repeated keys through an IdempotencyStore
+	Idempotency bool
+	// Thin generates a ServerInterface of plain http.HandlerFunc-style methods
+	// plus Bind<Operation>Request functions instead of typed handlers, the
+	// ServerWrapper and response types. Cannot be combined with CORS or Idempotency.
+	Thin bool
+	// MaxBodySize limits JSON request bodies to this many bytes, answering
+	// larger bodies with 413. Operations override it with an x-max-body-size
+	// extension. Zero means unlimited.
+	MaxBodySize int64
+	// Timeout is the deadline of the context handlers run with. Handlers are not
+	// cut off: they should pass the context on, and adapters answer errors
+	// returned once it has passed with 504. Operations override it with an x-timeout
+	// extension. Zero means no deadline. Neither the option nor the
+	// extension is supported in thin mode.
+	Timeout time.Duration
+	// PrincipalType is the Go type of authenticated principals, such as "*User"
+	// for a schema of the spec. It becomes the type of SecurityContext.Principal
+	// so handlers need no type assertion. Default: any
+	PrincipalType string
+	// Only limits generation to the listed artifacts (ArtifactTypes,
+	// ArtifactServer, ArtifactAuth, ArtifactStubs). Empty means all of them.
+	// Generation fails if the selection leaves out an artifact a selected one
+	// depends on: the server needs the types, unless TypesOutputDir moves them
+	// to a package of their own, and with security schemes the server and
+	// auth need each other. Stubs need the server.
+	Only []string
+	// Skip leaves out the listed artifacts, with the same dependency checks as Only
+	Skip []string
+	// IncludeTags limits generation to operations with at least one of the
+	// listed tags. Empty means all operations.
+	IncludeTags []string
+	// ExcludeTags leaves out operations with any of the listed tags, even
+	// when IncludeTags lists another of their tags
+	ExcludeTags []string
+	// IncludePaths limits generation to operations whose path matches one of
+	// the listed globs, such as "/v1/**". Paths are matched as written in the
+	// spec, e.g. /pets/{petId}, without the base path. In a glob, * matches
+	// within a path segment and ** across segments. Empty means all paths.
+	IncludePaths []string
+	// ExcludePaths leaves out operations whose path matches any of the listed globs
+	ExcludePaths []string
+	// Quiet stops Generate from printing the files it wrote
+	Quiet bool
+	// TypesOutputDir writes types.go to a package of its own in this
+	// directory instead of the output directory. The package is named after
+	// the directory, and the server code imports it with the import path
+	// derived from the enclosing go.mod.
+	TypesOutputDir string
+	// TypesImport is the import path of the package holding the types when
+	// they are generated separately, for the server generator to refer to
+	// schema types through. Generate sets it from TypesOutputDir.
+	TypesImport string
 }
 
 // NewGenerator creates a new Generator instance
@@ -29,94 +165,401 @@ func NewGenerator(spec *openapi.Document, config Config) *Generator {
 	if config.OutputDir == "" {
 		config.OutputDir = "./generated"
 	}
+	if config.Router == "" {
+		config.Router = RouterBuiltin
+	}
 
 	return &Generator{
-		spec:        spec,
-		outputDir:   config.OutputDir,
-		packageName: config.PackageName,
+		spec:          spec,
+		outputDir:     config.OutputDir,
+		packageName:   config.PackageName,
+		router:        config.Router,
+		splitByTag:    config.SplitByTag,
+		tagInterfaces: config.TagInterfaces,
+		stubs:         config.Stubs,
+		basePath:      config.BasePath,
+		embedSpec:     config.EmbedSpec || config.Docs,
+		docs:          config.Docs,
+		autoHead:      config.AutoHead,
+		cors:          config.CORS,
+		idempotency:   config.Idempotency,
+		thin:          config.Thin,
+		maxBodySize:   config.MaxBodySize,
+		timeout:       config.Timeout,
+		principalType: config.PrincipalType,
+		only:          config.Only,
+		skip:          config.Skip,
+		includeTags:   config.IncludeTags,
+		excludeTags:   config.ExcludeTags,
+		includePaths:  config.IncludePaths,
+		excludePaths:  config.ExcludePaths,
+		quiet:         config.Quiet,
+		typesDir:      config.TypesOutputDir,
 	}
 }
 
 // Generate generates all code (types, server, and auth)
 func (g *Generator) Generate() error {
+	files, err := g.GenerateFiles()
+	if err != nil {
+		return err
+	}
+
 	// Create output directory
 	if err := os.MkdirAll(g.outputDir, 0755); err != nil {
 		return fmt.Errorf("failed to create output directory: %w", err)
 	}
 
-	// Generate types
-	if err := g.generateTypes(); err != nil {
-		return fmt.Errorf("failed to generate types: %w", err)
+	for _, name := range sortedKeys(files) {
+		outputPath := filepath.Join(g.outputDir, name)
+		if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", name, err)
+		}
+		if err := os.WriteFile(outputPath, files[name], 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
 	}
 
-	// Generate server
-	if err := g.generateServer(); err != nil {
-		return fmt.Errorf("failed to generate server: %w", err)
+	// Write the skeleton main package (if requested)
+	if g.stubs && g.generates(ArtifactStubs) {
+		if err := g.generateMain(); err != nil {
+			return fmt.Errorf("failed to generate stubs: %w", err)
+		}
 	}
 
-	// Generate auth (if security schemes are defined)
-	if err := g.generateAuth(); err != nil {
-		return fmt.Errorf("failed to generate auth: %w", err)
+	if g.quiet {
+		return nil
 	}
 
 	fmt.Printf("✓ Code generated successfully in %s/\n", g.outputDir)
-	fmt.Printf("  - types.go: Type definitions\n")
-	fmt.Printf("  - server.go: Server handlers and router\n")
-	if g.hasSecuritySchemes() {
+	if g.generates(ArtifactTypes) {
+		types, _ := g.typesPackage()
+		fmt.Printf("  - %s: Type definitions\n", filepath.ToSlash(types.file))
+	}
+	if g.generates(ArtifactServer) {
+		if g.splitByTag {
+			fmt.Printf("  - server_*.go: Server handlers and router, split by tag\n")
+		} else {
+			fmt.Printf("  - server.go: Server handlers and router\n")
+		}
+	}
+	if g.hasSecuritySchemes() && g.generates(ArtifactAuth) {
 		fmt.Printf("  - auth.go: Authentication middleware and types\n")
+		if NewAuthGenerator(g.spec).generatesJWT() {
+			fmt.Printf("  - jwt.go: JWT and OpenID Connect token validation\n")
+		}
+		if len(NewAuthGenerator(g.spec).cookieSchemes()) > 0 {
+			fmt.Printf("  - session.go: Session cookie helpers and CSRF protection\n")
+		}
+	}
+	if g.embedSpec && g.generates(ArtifactServer) {
+		fmt.Printf("  - openapi.json, openapi.yaml: Embedded specification\n")
+	}
+	if g.stubs && g.generates(ArtifactStubs) {
+		fmt.Printf("  - unimplemented.go: UnimplementedServer returning 501 for every operation\n")
+		fmt.Printf("  - cmd/server/main.go: Skeleton server (only written if missing)\n")
 	}
 
 	return nil
 }
 
+// GenerateFiles generates the code and spec files selected by the
+// configuration without writing them, keyed by their path relative to the
+// output directory. The skeleton cmd/server/main.go written by Generate is not
+// included, since it belongs to the user once written.
+func (g *Generator) GenerateFiles() (map[string][]byte, error) {
+	for _, name := range slices.Concat(g.only, g.skip) {
+		if !slices.Contains(artifacts, name) {
+			return nil, fmt.Errorf("unknown artifact %q (supported: %s)", name, strings.Join(artifacts, ", "))
+		}
+	}
+	for _, artifact := range artifacts {
+		if !g.generates(artifact) {
+			continue
+		}
+		for _, dependency := range g.artifactDependencies(artifact) {
+			if !g.generates(dependency) {
+				return nil, fmt.Errorf("artifact %s depends on %s, which the selection leaves out", artifact, dependency)
+			}
+		}
+	}
+
+	if keep := g.operationFilter(); keep != nil {
+		g.spec = filterOperations(g.spec, keep)
+	}
+
+	types, err := g.typesPackage()
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string][]byte)
+
+	// Generate types
+	if g.generates(ArtifactTypes) {
+		if err := g.generateTypes(files, types); err != nil {
+			return nil, fmt.Errorf("failed to generate types: %w", err)
+		}
+	}
+
+	if g.generates(ArtifactServer) {
+		// Generate server
+		if err := g.generateServer(files, types.importPath); err != nil {
+			return nil, fmt.Errorf("failed to generate server: %w", err)
+		}
+
+		// Add the spec documents to embed (if requested)
+		if err := g.generateSpecFiles(files); err != nil {
+			return nil, fmt.Errorf("failed to write spec files: %w", err)
+		}
+	}
+
+	// Generate auth (if security schemes are defined)
+	if g.generates(ArtifactAuth) {
+		if err := g.generateAuth(files); err != nil {
+			return nil, fmt.Errorf("failed to generate auth: %w", err)
+		}
+	}
+
+	// Generate handler stubs (if requested)
+	if g.generates(ArtifactStubs) {
+		if err := g.generateStubs(files); err != nil {
+			return nil, fmt.Errorf("failed to generate stubs: %w", err)
+		}
+	}
+
+	return files, nil
+}
+
+// generates reports whether an artifact is selected by Config.Only and Config.Skip
+func (g *Generator) generates(artifact string) bool {
+	return (len(g.only) == 0 || slices.Contains(g.only, artifact)) && !slices.Contains(g.skip, artifact)
+}
+
+// artifactDependencies returns the artifacts whose declarations the code of
+// artifact uses, so that it does not compile without them
+func (g *Generator) artifactDependencies(artifact string) []string {
+	var dependencies []string
+	switch artifact {
+	case ArtifactServer:
+		if g.typesDir == "" {
+			dependencies = append(dependencies, ArtifactTypes)
+		}
+		if g.hasSecuritySchemes() {
+			dependencies = append(dependencies, ArtifactAuth)
+		}
+	case ArtifactAuth:
+		if g.hasSecuritySchemes() {
+			dependencies = append(dependencies, ArtifactServer)
+		}
+	case ArtifactStubs:
+		if g.stubs {
+			dependencies = append(dependencies, ArtifactServer)
+		}
+	}
+	return dependencies
+}
+
 // generateTypes generates type definitions
-func (g *Generator) generateTypes() error {
-	typeGen := NewTypeGenerator(g.spec)
+func (g *Generator) generateTypes(files map[string][]byte, types typesPackage) error {
+	typeGen := NewTypeGeneratorWithConfig(g.spec, Config{PackageName: types.name})
 	code, err := typeGen.Generate()
 	if err != nil {
 		return err
 	}
 
-	outputPath := filepath.Join(g.outputDir, "types.go")
-	if err := os.WriteFile(outputPath, []byte(code), 0644); err != nil {
-		return fmt.Errorf("failed to write types file: %w", err)
+	files[types.file] = []byte(code)
+	return nil
+}
+
+// typesPackage is where the generated types go
+type typesPackage struct {
+	name       string // package name
+	file       string // path of types.go relative to the output directory
+	importPath string // import path, if the types have a package of their own
+}
+
+// typesPackage returns where the types go: types.go in the output directory,
+// or in the package of Config.TypesOutputDir, which must be inside a module
+// for the server code to import it
+func (g *Generator) typesPackage() (typesPackage, error) {
+	if g.typesDir == "" {
+		return typesPackage{name: g.packageName, file: "types.go"}, nil
 	}
 
-	return nil
+	name := filepath.Base(g.typesDir)
+	if !token.IsIdentifier(name) {
+		return typesPackage{}, fmt.Errorf("types output directory %s is not a valid package name", g.typesDir)
+	}
+	importPath, ok := ImportPath(g.typesDir)
+	if !ok {
+		return typesPackage{}, fmt.Errorf("types output directory %s is not inside a Go module, so the server cannot import it", g.typesDir)
+	}
+	rel, err := filepath.Rel(g.outputDir, filepath.Join(g.typesDir, "types.go"))
+	if err != nil {
+		return typesPackage{}, fmt.Errorf("failed to locate types output directory: %w", err)
+	}
+	return typesPackage{name: name, file: rel, importPath: importPath}, nil
 }
 
 // generateServer generates server code
-func (g *Generator) generateServer() error {
-	serverGen := NewServerGenerator(g.spec)
-	code, err := serverGen.Generate()
+func (g *Generator) generateServer(files map[string][]byte, typesImport string) error {
+	serverGen := NewServerGeneratorWithConfig(g.spec, Config{
+		PackageName:   g.packageName,
+		TypesImport:   typesImport,
+		Router:        g.router,
+		SplitByTag:    g.splitByTag,
+		TagInterfaces: g.tagInterfaces,
+		BasePath:      g.basePath,
+		EmbedSpec:     g.embedSpec,
+		Docs:          g.docs,
+		AutoHead:      g.autoHead,
+		CORS:          g.cors,
+		Idempotency:   g.idempotency,
+		Thin:          g.thin,
+		MaxBodySize:   g.maxBodySize,
+		Timeout:       g.timeout,
+	})
+	serverFiles, err := serverGen.GenerateFiles()
 	if err != nil {
 		return err
 	}
 
-	outputPath := filepath.Join(g.outputDir, "server.go")
-	if err := os.WriteFile(outputPath, []byte(code), 0644); err != nil {
-		return fmt.Errorf("failed to write server file: %w", err)
+	for fileName, code := range serverFiles {
+		files[fileName] = []byte(code)
 	}
-
 	return nil
 }
 
 // generateAuth generates authentication code
-func (g *Generator) generateAuth() error {
+func (g *Generator) generateAuth(files map[string][]byte) error {
 	// Only generate auth.go if there are security schemes
 	if !g.hasSecuritySchemes() {
 		return nil
 	}
 
-	authGen := NewAuthGenerator(g.spec)
+	authGen := NewAuthGeneratorWithConfig(g.spec, Config{PackageName: g.packageName, PrincipalType: g.principalType})
 	code, err := authGen.Generate()
 	if err != nil {
 		return err
 	}
 
-	outputPath := filepath.Join(g.outputDir, "auth.go")
-	if err := os.WriteFile(outputPath, []byte(code), 0644); err != nil {
-		return fmt.Errorf("failed to write auth file: %w", err)
+	files["auth.go"] = []byte(code)
+
+	// Generate JWT validation (if any bearer scheme uses JWTs)
+	jwtCode, err := authGen.GenerateJWT()
+	if err != nil {
+		return err
+	}
+	if jwtCode != "" {
+		files["jwt.go"] = []byte(jwtCode)
+	}
+
+	// Generate session cookie helpers (if any API key is read from a cookie)
+	sessionCode, err := authGen.GenerateSession()
+	if err != nil {
+		return err
+	}
+	if sessionCode != "" {
+		files["session.go"] = []byte(sessionCode)
+	}
+
+	return nil
+}
+
+// generateSpecFiles adds the spec as JSON and YAML for the generated code to embed
+func (g *Generator) generateSpecFiles(files map[string][]byte) error {
+	if !g.embedSpec {
+		return nil
+	}
+
+	jsonData, yamlData, err := marshalSpec(g.spec)
+	if err != nil {
+		return err
+	}
+
+	files["openapi.json"] = jsonData
+	files["openapi.yaml"] = yamlData
+	return nil
+}
+
+// marshalSpec serializes the spec as indented JSON and as block-style YAML
+func marshalSpec(spec *openapi.Document) (jsonData, yamlData []byte, err error) {
+	jsonData, err = json.MarshalIndent(spec, "", "  ")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal spec as JSON: %w", err)
+	}
+	jsonData = append(jsonData, '\n')
+
+	// Decoding JSON into a yaml.Node keeps the key order of the JSON document
+	var node yaml.Node
+	if err := yaml.Unmarshal(jsonData, &node); err != nil {
+		return nil, nil, fmt.Errorf("failed to convert spec to YAML: %w", err)
+	}
+	resetYAMLStyle(&node)
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal spec as YAML: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal spec as YAML: %w", err)
+	}
+
+	return jsonData, buf.Bytes(), nil
+}
+
+// resetYAMLStyle clears the flow and quoting styles inherited from JSON so the
+// document is written in idiomatic block style
+func resetYAMLStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		resetYAMLStyle(child)
+	}
+}
+
+// generateStubs generates handler stubs
+func (g *Generator) generateStubs(files map[string][]byte) error {
+	if !g.stubs {
+		return nil
+	}
+
+	stubGen := NewStubGeneratorWithConfig(g.spec, Config{PackageName: g.packageName, Thin: g.thin})
+	code, err := stubGen.Generate()
+	if err != nil {
+		return err
+	}
+
+	files["unimplemented.go"] = []byte(code)
+	return nil
+}
+
+// generateMain writes a skeleton main package serving the handler stubs
+func (g *Generator) generateMain() error {
+	stubGen := NewStubGeneratorWithConfig(g.spec, Config{PackageName: g.packageName, Thin: g.thin})
+
+	// The skeleton main.go belongs to the user once written, so never overwrite it
+	mainDir := filepath.Join(g.outputDir, "cmd", "server")
+	mainPath := filepath.Join(mainDir, "main.go")
+	if _, err := os.Stat(mainPath); err == nil {
+		return nil
+	}
+
+	importPath, ok := ImportPath(g.outputDir)
+	if !ok {
+		importPath = "example.com/yourmodule/" + filepath.Base(g.outputDir)
+		if !g.quiet {
+			fmt.Printf("  ! no go.mod found, update the import path in %s\n", mainPath)
+		}
+	}
+
+	if err := os.MkdirAll(mainDir, 0755); err != nil {
+		return fmt.Errorf("failed to create main directory: %w", err)
+	}
+	if err := os.WriteFile(mainPath, []byte(stubGen.GenerateMain(importPath, g.hasSecuritySchemes())), 0644); err != nil {
+		return fmt.Errorf("failed to write main file: %w", err)
 	}
 
 	return nil
@@ -3,6 +3,7 @@ package generator
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/christopherklint97/specweaver/pkg/openapi"
@@ -24,18 +25,21 @@ func TestNewGenerator(t *testing.T) {
 
 		assert.Equal(t, "api", gen.packageName, "Expected default package name 'api'")
 		assert.Equal(t, "./generated", gen.outputDir, "Expected default output dir './generated'")
+		assert.Equal(t, RouterBuiltin, gen.router, "Expected default router 'builtin'")
 	})
 
 	t.Run("With custom config", func(t *testing.T) {
 		config := Config{
 			OutputDir:   "./custom",
 			PackageName: "myapi",
+			Router:      RouterChi,
 		}
 
 		gen := NewGenerator(spec, config)
 
 		assert.Equal(t, "myapi", gen.packageName, "Expected package name 'myapi'")
 		assert.Equal(t, "./custom", gen.outputDir, "Expected output dir './custom'")
+		assert.Equal(t, RouterChi, gen.router, "Expected router 'chi'")
 	})
 }
 
@@ -171,9 +175,10 @@ func TestGenerateTypes(t *testing.T) {
 		PackageName: "api",
 	}
 
+	config.Only = []string{ArtifactTypes}
 	gen := NewGenerator(spec, config)
-	err := gen.generateTypes()
-	require.NoError(t, err, "generateTypes should not fail")
+	err := gen.Generate()
+	require.NoError(t, err, "Generate should not fail")
 
 	// Check that types.go was created
 	typesPath := filepath.Join(tmpDir, "types.go")
@@ -220,9 +225,10 @@ func TestGenerateServer(t *testing.T) {
 		PackageName: "api",
 	}
 
+	config.Only = []string{ArtifactTypes, ArtifactServer}
 	gen := NewGenerator(spec, config)
-	err := gen.generateServer()
-	require.NoError(t, err, "generateServer should not fail")
+	err := gen.Generate()
+	require.NoError(t, err, "Generate should not fail")
 
 	// Check that server.go was created
 	serverPath := filepath.Join(tmpDir, "server.go")
@@ -235,6 +241,77 @@ func TestGenerateServer(t *testing.T) {
 	assert.NotEmpty(t, content, "Expected server.go to have content")
 }
 
+func TestGenerateServerSplitByTag(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	spec := &openapi.Document{
+		OpenAPI: "3.1.0",
+		Info: &openapi.Info{
+			Title:   "Test",
+			Version: "1.0.0",
+		},
+		Paths: map[string]*openapi.PathItem{
+			"/pets": {
+				Get: &openapi.Operation{
+					OperationID: "listPets",
+					Tags:        []string{"Pets"},
+					Responses: map[string]*openapi.Response{
+						"200": {Description: "Success"},
+					},
+				},
+			},
+			"/users": {
+				Get: &openapi.Operation{
+					OperationID: "listUsers",
+					Tags:        []string{"User Accounts"},
+					Responses: map[string]*openapi.Response{
+						"200": {Description: "Success"},
+					},
+				},
+			},
+			"/health": {
+				Get: &openapi.Operation{
+					OperationID: "getHealth",
+					Responses: map[string]*openapi.Response{
+						"204": {Description: "Healthy"},
+					},
+				},
+			},
+		},
+	}
+
+	config := Config{
+		OutputDir:  tmpDir,
+		SplitByTag: true,
+	}
+
+	config.Only = []string{ArtifactTypes, ArtifactServer}
+	gen := NewGenerator(spec, config)
+	err := gen.Generate()
+	require.NoError(t, err, "Generate should not fail")
+
+	assert.NoFileExists(t, filepath.Join(tmpDir, "server.go"))
+
+	pets, err := os.ReadFile(filepath.Join(tmpDir, "server_pets.go"))
+	require.NoError(t, err, "Expected server_pets.go to be created")
+	assert.Contains(t, string(pets), "type ListPetsRequest struct")
+	assert.Contains(t, string(pets), "func (w *ServerWrapper) handleListPets(")
+	assert.NotContains(t, string(pets), "type ListUsersRequest struct")
+
+	users, err := os.ReadFile(filepath.Join(tmpDir, "server_user_accounts.go"))
+	require.NoError(t, err, "Expected server_user_accounts.go to be created")
+	assert.Contains(t, string(users), "type ListUsersRequest struct")
+
+	// Shared code and untagged operations live in server_common.go
+	common, err := os.ReadFile(filepath.Join(tmpDir, "server_common.go"))
+	require.NoError(t, err, "Expected server_common.go to be created")
+	assert.Contains(t, string(common), "type Server interface {")
+	assert.Contains(t, string(common), "ListPets(ctx context.Context, req ListPetsRequest) (ListPetsResponse, error)")
+	assert.Contains(t, string(common), "type GetHealthRequest struct")
+	assert.Contains(t, string(common), "func ConfigureRouter(")
+	assert.NotContains(t, string(common), "type ListPetsRequest struct")
+}
+
 func TestGenerateWithComplexSpec(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -491,9 +568,10 @@ func TestGenerateAuth(t *testing.T) {
 		PackageName: "api",
 	}
 
+	config.Only = []string{ArtifactTypes, ArtifactServer, ArtifactAuth}
 	gen := NewGenerator(spec, config)
-	err := gen.generateAuth()
-	require.NoError(t, err, "generateAuth should not fail")
+	err := gen.Generate()
+	require.NoError(t, err, "Generate should not fail")
 
 	// Check that auth.go was created
 	authPath := filepath.Join(tmpDir, "auth.go")
@@ -534,9 +612,10 @@ func TestGenerateAuthNotCreatedWithoutSecuritySchemes(t *testing.T) {
 		PackageName: "api",
 	}
 
+	config.Only = []string{ArtifactAuth}
 	gen := NewGenerator(spec, config)
-	err := gen.generateAuth()
-	require.NoError(t, err, "generateAuth should not fail even without security schemes")
+	err := gen.Generate()
+	require.NoError(t, err, "Generate should not fail even without security schemes")
 
 	// auth.go should NOT be created
 	authPath := filepath.Join(tmpDir, "auth.go")
@@ -706,3 +785,260 @@ func TestGenerateWithAuthIntegration(t *testing.T) {
 	assert.Contains(t, serverStr, "authMiddleware", "Server should use auth middleware")
 }
 
+
+func TestGenerateEmbedSpecFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	spec := &openapi.Document{
+		OpenAPI: "3.1.0",
+		Info: &openapi.Info{
+			Title:   "Test",
+			Version: "1.0.0",
+		},
+		Paths: map[string]*openapi.PathItem{
+			"/test": {
+				Get: &openapi.Operation{
+					OperationID: "getTest",
+					Responses: map[string]*openapi.Response{
+						"200": {Description: "Success"},
+					},
+				},
+			},
+		},
+	}
+
+	gen := NewGenerator(spec, Config{OutputDir: tmpDir, EmbedSpec: true})
+	require.NoError(t, gen.Generate())
+
+	jsonData, err := os.ReadFile(filepath.Join(tmpDir, "openapi.json"))
+	require.NoError(t, err, "Expected openapi.json to be created")
+	parsed, err := openapi.LoadFromData(jsonData, "openapi.json")
+	require.NoError(t, err)
+	assert.Equal(t, "getTest", parsed.Paths["/test"].Get.OperationID)
+
+	yamlData, err := os.ReadFile(filepath.Join(tmpDir, "openapi.yaml"))
+	require.NoError(t, err, "Expected openapi.yaml to be created")
+	assert.Contains(t, string(yamlData), "openapi: 3.1.0\n")
+	assert.Contains(t, string(yamlData), "\"200\":")
+}
+
+func TestGenerateStubs(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "go.mod"), []byte("module example.com/app\n"), 0644))
+	tmpDir := filepath.Join(root, "api")
+
+	spec := &openapi.Document{
+		OpenAPI: "3.1.0",
+		Info: &openapi.Info{
+			Title:   "Test",
+			Version: "1.0.0",
+		},
+		Paths: map[string]*openapi.PathItem{
+			"/test": {
+				Get: &openapi.Operation{
+					OperationID: "getTest",
+					Responses: map[string]*openapi.Response{
+						"200": {Description: "Success"},
+					},
+				},
+			},
+		},
+	}
+
+	gen := NewGenerator(spec, Config{OutputDir: tmpDir, Stubs: true})
+	require.NoError(t, gen.Generate())
+
+	assert.FileExists(t, filepath.Join(tmpDir, "unimplemented.go"))
+
+	mainPath := filepath.Join(tmpDir, "cmd", "server", "main.go")
+	content, err := os.ReadFile(mainPath)
+	require.NoError(t, err, "Expected cmd/server/main.go to be created")
+	assert.Contains(t, string(content), "api \"example.com/app/api\"")
+
+	// An existing main.go belongs to the user and is left alone
+	require.NoError(t, os.WriteFile(mainPath, []byte("package main\n"), 0644))
+	require.NoError(t, gen.Generate())
+	content, err = os.ReadFile(mainPath)
+	require.NoError(t, err)
+	assert.Equal(t, "package main\n", string(content))
+}
+
+func TestGenerateOnlyAndSkip(t *testing.T) {
+	spec := &openapi.Document{
+		OpenAPI: "3.1.0",
+		Info: &openapi.Info{
+			Title:   "Test",
+			Version: "1.0.0",
+		},
+		Components: &openapi.Components{
+			SecuritySchemes: map[string]*openapi.SecurityScheme{
+				"bearerAuth": {Type: "http", Scheme: "bearer"},
+			},
+		},
+		Paths: map[string]*openapi.PathItem{
+			"/test": {
+				Get: &openapi.Operation{
+					OperationID: "getTest",
+					Responses: map[string]*openapi.Response{
+						"200": {Description: "Success"},
+					},
+				},
+			},
+		},
+	}
+
+	t.Run("Only types", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		gen := NewGenerator(spec, Config{OutputDir: tmpDir, EmbedSpec: true, Only: []string{ArtifactTypes}})
+		require.NoError(t, gen.Generate())
+
+		assert.FileExists(t, filepath.Join(tmpDir, "types.go"))
+		assert.NoFileExists(t, filepath.Join(tmpDir, "server.go"))
+		assert.NoFileExists(t, filepath.Join(tmpDir, "openapi.json"), "Should leave out the spec files the server embeds")
+		assert.NoFileExists(t, filepath.Join(tmpDir, "auth.go"))
+	})
+
+	t.Run("Skip stubs", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		gen := NewGenerator(spec, Config{OutputDir: tmpDir, Stubs: true, Skip: []string{ArtifactStubs}})
+		require.NoError(t, gen.Generate())
+
+		assert.FileExists(t, filepath.Join(tmpDir, "types.go"))
+		assert.FileExists(t, filepath.Join(tmpDir, "server.go"))
+		assert.FileExists(t, filepath.Join(tmpDir, "auth.go"))
+		assert.NoFileExists(t, filepath.Join(tmpDir, "unimplemented.go"))
+	})
+
+	t.Run("Selection missing a dependency", func(t *testing.T) {
+		tests := []struct {
+			config   Config
+			expected string
+		}{
+			{Config{Skip: []string{ArtifactTypes}}, "artifact server depends on types, which the selection leaves out"},
+			{Config{Only: []string{ArtifactTypes, ArtifactServer}}, "artifact server depends on auth, which the selection leaves out"},
+			{Config{Only: []string{ArtifactTypes, ArtifactAuth}}, "artifact auth depends on server, which the selection leaves out"},
+			{Config{Stubs: true, Only: []string{ArtifactTypes, ArtifactStubs}}, "artifact stubs depends on server, which the selection leaves out"},
+		}
+		for _, tt := range tests {
+			tmpDir := t.TempDir()
+			tt.config.OutputDir = tmpDir
+			err := NewGenerator(spec, tt.config).Generate()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expected)
+			assert.NoFileExists(t, filepath.Join(tmpDir, "types.go"))
+		}
+	})
+
+	t.Run("Unknown artifact", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		gen := NewGenerator(spec, Config{OutputDir: tmpDir, Only: []string{"client"}})
+		err := gen.Generate()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `unknown artifact "client" (supported: types, server, auth, stubs)`)
+		assert.NoFileExists(t, filepath.Join(tmpDir, "types.go"))
+	})
+}
+
+func TestGeneratePackageName(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "go.mod"), []byte("module example.com/app\n"), 0644))
+	tmpDir := filepath.Join(root, "petstore")
+
+	spec := &openapi.Document{
+		OpenAPI: "3.1.0",
+		Info: &openapi.Info{
+			Title:   "Test",
+			Version: "1.0.0",
+		},
+		Components: &openapi.Components{
+			Schemas: map[string]*openapi.SchemaRef{
+				"Pet": {Value: &openapi.Schema{Type: []string{"object"}}},
+			},
+			SecuritySchemes: map[string]*openapi.SecurityScheme{
+				"bearerAuth":  {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+				"sessionAuth": {Type: "apiKey", In: "cookie", Name: "session"},
+			},
+		},
+		Paths: map[string]*openapi.PathItem{
+			"/test": {
+				Get: &openapi.Operation{
+					OperationID: "getTest",
+					Responses: map[string]*openapi.Response{
+						"200": {Description: "Success"},
+					},
+				},
+			},
+		},
+	}
+
+	gen := NewGenerator(spec, Config{OutputDir: tmpDir, PackageName: "petstore", Stubs: true})
+	require.NoError(t, gen.Generate())
+
+	files, err := filepath.Glob(filepath.Join(tmpDir, "*.go"))
+	require.NoError(t, err)
+	assert.Len(t, files, 6, "Expected types, server, auth, jwt, session and stubs files")
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(string(content), "package petstore\n"), "Expected %s to declare package petstore", filepath.Base(file))
+	}
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, "cmd", "server", "main.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "api \"example.com/app/petstore\"")
+}
+
+func TestGenerateTypesOutputDir(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "go.mod"), []byte("module example.com/app\n"), 0644))
+
+	spec := &openapi.Document{
+		OpenAPI: "3.1.0",
+		Info: &openapi.Info{
+			Title:   "Test",
+			Version: "1.0.0",
+		},
+		Components: &openapi.Components{
+			Schemas: map[string]*openapi.SchemaRef{
+				"Pet": {Value: &openapi.Schema{Type: []string{"object"}}},
+			},
+		},
+		Paths: map[string]*openapi.PathItem{
+			"/pets": {
+				Get: &openapi.Operation{
+					OperationID: "listPets",
+					Responses: map[string]*openapi.Response{
+						"200": {
+							Description: "Success",
+							Content: map[string]*openapi.MediaType{
+								"application/json": {Schema: &openapi.SchemaRef{Ref: "#/components/schemas/Pet"}},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	gen := NewGenerator(spec, Config{
+		OutputDir:      filepath.Join(root, "api"),
+		TypesOutputDir: filepath.Join(root, "models"),
+	})
+	require.NoError(t, gen.Generate())
+
+	assert.NoFileExists(t, filepath.Join(root, "api", "types.go"))
+	types, err := os.ReadFile(filepath.Join(root, "models", "types.go"))
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(types), "package models\n"))
+
+	server, err := os.ReadFile(filepath.Join(root, "api", "server.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(server), `"example.com/app/models"`)
+	assert.Contains(t, string(server), "models.Pet")
+
+	gen = NewGenerator(spec, Config{
+		OutputDir:      filepath.Join(root, "api"),
+		TypesOutputDir: filepath.Join(root, "my-models"),
+	})
+	assert.Error(t, gen.Generate(), "Should reject a directory name that is not a package name")
+}
@@ -0,0 +1,582 @@
+package generator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// GenerateJWT generates JWT validation for the bearer schemes declaring
+// bearerFormat JWT and for the OpenID Connect schemes. It returns an empty
+// string when there are none.
+func (g *AuthGenerator) GenerateJWT() (string, error) {
+	if !g.generatesJWT() {
+		return "", nil
+	}
+	schemes := g.jwtSchemes()
+	oidcSchemes := g.schemesOfType("openIdConnect")
+
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("package %s\n\n", g.packageName))
+	sb.WriteString("import (\n")
+	sb.WriteString("\t\"context\"\n")
+	sb.WriteString("\t\"crypto\"\n")
+	sb.WriteString("\t\"crypto/ecdsa\"\n")
+	if len(oidcSchemes) > 0 {
+		sb.WriteString("\t\"crypto/elliptic\"\n")
+	}
+	sb.WriteString("\t\"crypto/hmac\"\n")
+	sb.WriteString("\t\"crypto/rsa\"\n")
+	sb.WriteString("\t_ \"crypto/sha256\"\n")
+	sb.WriteString("\t_ \"crypto/sha512\"\n")
+	sb.WriteString("\t\"encoding/base64\"\n")
+	sb.WriteString("\t\"encoding/json\"\n")
+	sb.WriteString("\t\"errors\"\n")
+	sb.WriteString("\t\"fmt\"\n")
+	sb.WriteString("\t\"math/big\"\n")
+	if len(oidcSchemes) > 0 {
+		sb.WriteString("\t\"net/http\"\n")
+	}
+	sb.WriteString("\t\"slices\"\n")
+	sb.WriteString("\t\"strings\"\n")
+	if len(oidcSchemes) > 0 {
+		sb.WriteString("\t\"sync\"\n")
+	}
+	sb.WriteString("\t\"time\"\n")
+	sb.WriteString(")\n\n")
+
+	// Generate the authenticator for the JWT schemes
+	if len(schemes) > 0 {
+		g.generateJWTAuthenticator(&sb, schemes)
+	}
+
+	// Generate the authenticator and provider for the OpenID Connect schemes
+	if len(oidcSchemes) > 0 {
+		g.generateOIDCAuthenticator(&sb, oidcSchemes)
+		g.generateOIDCProvider(&sb)
+	}
+
+	// Generate the validator
+	g.generateJWTValidator(&sb)
+
+	return sb.String(), nil
+}
+
+// generatesJWT reports whether any security scheme needs JWT validation
+func (g *AuthGenerator) generatesJWT() bool {
+	return len(g.jwtSchemes()) > 0 || len(g.schemesOfType("openIdConnect")) > 0
+}
+
+// jwtSchemes returns the sorted names of the bearer schemes declaring bearerFormat JWT
+func (g *AuthGenerator) jwtSchemes() []string {
+	if g.spec.Components == nil {
+		return nil
+	}
+
+	var schemes []string
+	for name, scheme := range g.spec.Components.SecuritySchemes {
+		if scheme != nil && scheme.Type == "http" && scheme.Scheme == "bearer" && strings.EqualFold(scheme.BearerFormat, "JWT") {
+			schemes = append(schemes, name)
+		}
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+// schemesOfType returns the sorted names of the security schemes of a type
+func (g *AuthGenerator) schemesOfType(schemeType string) []string {
+	if g.spec.Components == nil {
+		return nil
+	}
+
+	var schemes []string
+	for name, scheme := range g.spec.Components.SecuritySchemes {
+		if scheme != nil && scheme.Type == schemeType {
+			schemes = append(schemes, name)
+		}
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+// generateJWTAuthenticator generates JWTAuthenticator, which implements the
+// Authenticator methods of the JWT schemes
+func (g *AuthGenerator) generateJWTAuthenticator(sb *strings.Builder, schemes []string) {
+	sb.WriteString("// JWTAuthenticator implements the Authenticator methods of the JWT bearer schemes\n")
+	sb.WriteString("// by validating tokens with a JWTValidator. Embed it in your Authenticator to\n")
+	sb.WriteString("// use it; handlers receive the *JWTClaims as SecurityContext.Principal, and the\n")
+	sb.WriteString("// scopes of the token are checked against the scopes operations require.\n")
+	sb.WriteString("type JWTAuthenticator struct {\n")
+	sb.WriteString("\tValidator *JWTValidator\n")
+	sb.WriteString("}\n\n")
+
+	for _, name := range schemes {
+		methodName := "Authenticate" + toPascalCase(name)
+		sb.WriteString(fmt.Sprintf("// %s validates the JWT of the %s scheme\n", methodName, name))
+		sb.WriteString(fmt.Sprintf("func (a JWTAuthenticator) %s(ctx context.Context, credentials BearerTokenCredentials) (any, error) {\n", methodName))
+		sb.WriteString("\treturn a.authenticateJWT(ctx, credentials.Token)\n")
+		sb.WriteString("}\n\n")
+	}
+
+	sb.WriteString("// authenticateJWT validates a token and reports its scopes\n")
+	sb.WriteString("func (a JWTAuthenticator) authenticateJWT(ctx context.Context, token string) (any, error) {\n")
+	sb.WriteString("\tif a.Validator == nil {\n")
+	sb.WriteString("\t\treturn nil, errors.New(\"no JWT validator configured\")\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\tclaims, err := a.Validator.Validate(ctx, token)\n")
+	sb.WriteString("\tif err != nil {\n")
+	sb.WriteString("\t\treturn nil, err\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\treturn ScopedPrincipal{Principal: claims, Scopes: claims.Scopes}, nil\n")
+	sb.WriteString("}\n\n")
+}
+
+// generateOIDCAuthenticator generates OIDCAuthenticator, which implements the
+// Authenticator methods of the OpenID Connect schemes with one provider per scheme
+func (g *AuthGenerator) generateOIDCAuthenticator(sb *strings.Builder, schemes []string) {
+	sb.WriteString("// OIDCAuthenticator implements the Authenticator methods of the OpenID Connect\n")
+	sb.WriteString("// schemes by validating tokens with the provider of each scheme. Embed it in your\n")
+	sb.WriteString("// Authenticator to use it; handlers receive the *JWTClaims as SecurityContext.Principal.\n")
+	sb.WriteString("type OIDCAuthenticator struct {\n")
+	for _, name := range schemes {
+		sb.WriteString(fmt.Sprintf("\t%s *OIDCProvider\n", toPascalCase(name)))
+	}
+	sb.WriteString("}\n\n")
+
+	sb.WriteString("// NewOIDCAuthenticator creates an OIDCAuthenticator discovering each provider from\n")
+	sb.WriteString("// the openIdConnectUrl of its scheme. audience is the client ID tokens must be issued for;\n")
+	sb.WriteString("// tokens are rejected while it is empty.\n")
+	sb.WriteString("func NewOIDCAuthenticator(audience string) OIDCAuthenticator {\n")
+	sb.WriteString("\treturn OIDCAuthenticator{\n")
+	for _, name := range schemes {
+		scheme := g.spec.Components.SecuritySchemes[name]
+		sb.WriteString(fmt.Sprintf("\t\t%s: &OIDCProvider{DiscoveryURL: %q, Audience: audience},\n", toPascalCase(name), scheme.OpenIDConnectURL))
+	}
+	sb.WriteString("\t}\n")
+	sb.WriteString("}\n\n")
+
+	for _, name := range schemes {
+		fieldName := toPascalCase(name)
+		sb.WriteString(fmt.Sprintf("// Authenticate%s validates the token of the %s scheme\n", fieldName, name))
+		sb.WriteString(fmt.Sprintf("func (a OIDCAuthenticator) Authenticate%s(ctx context.Context, credentials OpenIDConnectCredentials) (any, error) {\n", fieldName))
+		sb.WriteString(fmt.Sprintf("\treturn authenticateOIDC(ctx, a.%s, credentials.Token)\n", fieldName))
+		sb.WriteString("}\n\n")
+	}
+
+	sb.WriteString("// authenticateOIDC validates a token with a provider and reports its scopes\n")
+	sb.WriteString("func authenticateOIDC(ctx context.Context, provider *OIDCProvider, token string) (any, error) {\n")
+	sb.WriteString("\tif provider == nil {\n")
+	sb.WriteString("\t\treturn nil, errors.New(\"no OpenID Connect provider configured\")\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\tclaims, err := provider.Validate(ctx, token)\n")
+	sb.WriteString("\tif err != nil {\n")
+	sb.WriteString("\t\treturn nil, err\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\treturn ScopedPrincipal{Principal: claims, Scopes: claims.Scopes}, nil\n")
+	sb.WriteString("}\n\n")
+}
+
+// generateOIDCProvider generates OIDCProvider, which discovers an OpenID Connect
+// provider and caches its signing keys
+func (g *AuthGenerator) generateOIDCProvider(sb *strings.Builder) {
+	sb.WriteString("// OIDCProvider validates tokens issued by an OpenID Connect provider. It reads the\n")
+	sb.WriteString("// issuer and the location of the signing keys from the discovery document, and\n")
+	sb.WriteString("// caches the keys, refreshing them when a token is signed with an unknown key.\n")
+	sb.WriteString("// Tokens must name the discovered issuer and the Audience, so tokens the provider\n")
+	sb.WriteString("// issued for its other clients are rejected.\n")
+	sb.WriteString("type OIDCProvider struct {\n")
+	sb.WriteString("\t// DiscoveryURL is the URL of the discovery document (openIdConnectUrl)\n")
+	sb.WriteString("\tDiscoveryURL string\n")
+	sb.WriteString("\t// Audience must be listed in the \"aud\" claim, typically the client ID. Every\n")
+	sb.WriteString("\t// token is rejected while it is empty.\n")
+	sb.WriteString("\tAudience string\n")
+	sb.WriteString("\t// Client performs the HTTP requests, defaulting to http.DefaultClient\n")
+	sb.WriteString("\tClient *http.Client\n")
+	sb.WriteString("\t// RefreshInterval is the minimum time between key refreshes, defaulting to 5 minutes\n")
+	sb.WriteString("\tRefreshInterval time.Duration\n\n")
+	sb.WriteString("\t// fetchMu serializes the HTTP fetches, which run without holding mu so that\n")
+	sb.WriteString("\t// a slow fetch does not hold up tokens signed with cached keys\n")
+	sb.WriteString("\tfetchMu   sync.Mutex\n")
+	sb.WriteString("\tmu        sync.Mutex\n")
+	sb.WriteString("\tissuer    string\n")
+	sb.WriteString("\tjwksURI   string\n")
+	sb.WriteString("\tkeys      map[string]any\n")
+	sb.WriteString("\tfetchedAt time.Time\n")
+	sb.WriteString("}\n\n")
+	sb.WriteString("// Validate validates an ID or access token issued by the provider\n")
+	sb.WriteString("func (p *OIDCProvider) Validate(ctx context.Context, token string) (*JWTClaims, error) {\n")
+	sb.WriteString("\tif p.Audience == \"\" {\n")
+	sb.WriteString("\t\treturn nil, errors.New(\"OpenID Connect provider has no audience to check tokens against\")\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\tissuer, err := p.discover(ctx)\n")
+	sb.WriteString("\tif err != nil {\n")
+	sb.WriteString("\t\treturn nil, err\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\tvalidator := &JWTValidator{KeyFunc: p.key, Issuer: issuer, Audience: p.Audience}\n")
+	sb.WriteString("\treturn validator.Validate(ctx, token)\n")
+	sb.WriteString("}\n\n")
+	sb.WriteString("// discover fetches the discovery document once and returns the issuer\n")
+	sb.WriteString("func (p *OIDCProvider) discover(ctx context.Context) (string, error) {\n")
+	sb.WriteString("\tif issuer, ok := p.discovered(); ok {\n")
+	sb.WriteString("\t\treturn issuer, nil\n")
+	sb.WriteString("\t}\n\n")
+	sb.WriteString("\tp.fetchMu.Lock()\n")
+	sb.WriteString("\tdefer p.fetchMu.Unlock()\n")
+	sb.WriteString("\tif issuer, ok := p.discovered(); ok {\n")
+	sb.WriteString("\t\treturn issuer, nil\n")
+	sb.WriteString("\t}\n\n")
+	sb.WriteString("\tvar doc struct {\n")
+	sb.WriteString("\t\tIssuer  string `json:\"issuer\"`\n")
+	sb.WriteString("\t\tJWKSURI string `json:\"jwks_uri\"`\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\tif err := p.fetchJSON(ctx, p.DiscoveryURL, &doc); err != nil {\n")
+	sb.WriteString("\t\treturn \"\", fmt.Errorf(\"fetching OpenID Connect discovery document: %w\", err)\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\tif doc.Issuer == \"\" {\n")
+	sb.WriteString("\t\treturn \"\", errors.New(\"OpenID Connect discovery document has no issuer\")\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\tif doc.JWKSURI == \"\" {\n")
+	sb.WriteString("\t\treturn \"\", errors.New(\"OpenID Connect discovery document has no jwks_uri\")\n")
+	sb.WriteString("\t}\n\n")
+	sb.WriteString("\tp.mu.Lock()\n")
+	sb.WriteString("\tdefer p.mu.Unlock()\n")
+	sb.WriteString("\tp.issuer = doc.Issuer\n")
+	sb.WriteString("\tp.jwksURI = doc.JWKSURI\n")
+	sb.WriteString("\treturn p.issuer, nil\n")
+	sb.WriteString("}\n\n")
+	sb.WriteString("// discovered returns the issuer once the discovery document has been fetched\n")
+	sb.WriteString("func (p *OIDCProvider) discovered() (string, bool) {\n")
+	sb.WriteString("\tp.mu.Lock()\n")
+	sb.WriteString("\tdefer p.mu.Unlock()\n")
+	sb.WriteString("\treturn p.issuer, p.jwksURI != \"\"\n")
+	sb.WriteString("}\n\n")
+	sb.WriteString("// key returns the signing key with the given ID, refreshing the cached keys when\n")
+	sb.WriteString("// the ID is unknown and they have not been refreshed recently\n")
+	sb.WriteString("func (p *OIDCProvider) key(ctx context.Context, keyID string) (any, error) {\n")
+	sb.WriteString("\tif key, _, ok := p.lookupKey(keyID); ok {\n")
+	sb.WriteString("\t\treturn key, nil\n")
+	sb.WriteString("\t}\n\n")
+	sb.WriteString("\tp.fetchMu.Lock()\n")
+	sb.WriteString("\tdefer p.fetchMu.Unlock()\n")
+	sb.WriteString("\tkey, fresh, ok := p.lookupKey(keyID)\n")
+	sb.WriteString("\tif ok {\n")
+	sb.WriteString("\t\treturn key, nil\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\tif fresh {\n")
+	sb.WriteString("\t\treturn nil, fmt.Errorf(\"unknown signing key %q\", keyID)\n")
+	sb.WriteString("\t}\n\n")
+	sb.WriteString("\tp.mu.Lock()\n")
+	sb.WriteString("\tjwksURI := p.jwksURI\n")
+	sb.WriteString("\tp.mu.Unlock()\n")
+	sb.WriteString("\tvar set struct {\n")
+	sb.WriteString("\t\tKeys []jsonWebKey `json:\"keys\"`\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\tif err := p.fetchJSON(ctx, jwksURI, &set); err != nil {\n")
+	sb.WriteString("\t\treturn nil, fmt.Errorf(\"fetching signing keys: %w\", err)\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\tkeys := make(map[string]any, len(set.Keys))\n")
+	sb.WriteString("\tfor _, jwk := range set.Keys {\n")
+	sb.WriteString("\t\tif jwk.Use != \"\" && jwk.Use != \"sig\" {\n")
+	sb.WriteString("\t\t\tcontinue\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t\tif key, err := jwk.publicKey(); err == nil {\n")
+	sb.WriteString("\t\t\tkeys[jwk.Kid] = key\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t}\n\n")
+	sb.WriteString("\tp.mu.Lock()\n")
+	sb.WriteString("\tdefer p.mu.Unlock()\n")
+	sb.WriteString("\tp.keys = keys\n")
+	sb.WriteString("\tp.fetchedAt = time.Now()\n")
+	sb.WriteString("\tif key, ok := p.cachedKey(keyID); ok {\n")
+	sb.WriteString("\t\treturn key, nil\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\treturn nil, fmt.Errorf(\"unknown signing key %q\", keyID)\n")
+	sb.WriteString("}\n\n")
+	sb.WriteString("// lookupKey looks up a cached key, also reporting whether the keys were refreshed\n")
+	sb.WriteString("// too recently to refresh them again\n")
+	sb.WriteString("func (p *OIDCProvider) lookupKey(keyID string) (key any, fresh, ok bool) {\n")
+	sb.WriteString("\tinterval := p.RefreshInterval\n")
+	sb.WriteString("\tif interval <= 0 {\n")
+	sb.WriteString("\t\tinterval = 5 * time.Minute\n")
+	sb.WriteString("\t}\n\n")
+	sb.WriteString("\tp.mu.Lock()\n")
+	sb.WriteString("\tdefer p.mu.Unlock()\n")
+	sb.WriteString("\tkey, ok = p.cachedKey(keyID)\n")
+	sb.WriteString("\treturn key, p.keys != nil && time.Since(p.fetchedAt) < interval, ok\n")
+	sb.WriteString("}\n\n")
+	sb.WriteString("// cachedKey looks up a cached key with p.mu held. Tokens without a key ID match a\n")
+	sb.WriteString("// single cached key.\n")
+	sb.WriteString("func (p *OIDCProvider) cachedKey(keyID string) (any, bool) {\n")
+	sb.WriteString("\tif keyID == \"\" && len(p.keys) == 1 {\n")
+	sb.WriteString("\t\tfor _, key := range p.keys {\n")
+	sb.WriteString("\t\t\treturn key, true\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\tkey, ok := p.keys[keyID]\n")
+	sb.WriteString("\treturn key, ok\n")
+	sb.WriteString("}\n\n")
+	sb.WriteString("// fetchJSON decodes the JSON document at url\n")
+	sb.WriteString("func (p *OIDCProvider) fetchJSON(ctx context.Context, url string, v any) error {\n")
+	sb.WriteString("\treq, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)\n")
+	sb.WriteString("\tif err != nil {\n")
+	sb.WriteString("\t\treturn err\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\tclient := p.Client\n")
+	sb.WriteString("\tif client == nil {\n")
+	sb.WriteString("\t\tclient = http.DefaultClient\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\tresp, err := client.Do(req)\n")
+	sb.WriteString("\tif err != nil {\n")
+	sb.WriteString("\t\treturn err\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\tdefer resp.Body.Close()\n\n")
+	sb.WriteString("\tif resp.StatusCode != http.StatusOK {\n")
+	sb.WriteString("\t\treturn fmt.Errorf(\"unexpected status %d\", resp.StatusCode)\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\treturn json.NewDecoder(resp.Body).Decode(v)\n")
+	sb.WriteString("}\n\n")
+	sb.WriteString("// jsonWebKey is a public key of a JSON Web Key Set\n")
+	sb.WriteString("type jsonWebKey struct {\n")
+	sb.WriteString("\tKty string `json:\"kty\"`\n")
+	sb.WriteString("\tKid string `json:\"kid\"`\n")
+	sb.WriteString("\tUse string `json:\"use\"`\n")
+	sb.WriteString("\tN   string `json:\"n\"`\n")
+	sb.WriteString("\tE   string `json:\"e\"`\n")
+	sb.WriteString("\tCrv string `json:\"crv\"`\n")
+	sb.WriteString("\tX   string `json:\"x\"`\n")
+	sb.WriteString("\tY   string `json:\"y\"`\n")
+	sb.WriteString("}\n\n")
+	sb.WriteString("// publicKey converts an RSA or EC JSON Web Key into a key JWTValidator accepts\n")
+	sb.WriteString("func (k jsonWebKey) publicKey() (any, error) {\n")
+	sb.WriteString("\tswitch k.Kty {\n")
+	sb.WriteString("\tcase \"RSA\":\n")
+	sb.WriteString("\t\tn, err := base64.RawURLEncoding.DecodeString(k.N)\n")
+	sb.WriteString("\t\tif err != nil {\n")
+	sb.WriteString("\t\t\treturn nil, err\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t\te, err := base64.RawURLEncoding.DecodeString(k.E)\n")
+	sb.WriteString("\t\tif err != nil {\n")
+	sb.WriteString("\t\t\treturn nil, err\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t\treturn &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil\n")
+	sb.WriteString("\tcase \"EC\":\n")
+	sb.WriteString("\t\tvar curve elliptic.Curve\n")
+	sb.WriteString("\t\tswitch k.Crv {\n")
+	sb.WriteString("\t\tcase \"P-256\":\n")
+	sb.WriteString("\t\t\tcurve = elliptic.P256()\n")
+	sb.WriteString("\t\tcase \"P-384\":\n")
+	sb.WriteString("\t\t\tcurve = elliptic.P384()\n")
+	sb.WriteString("\t\tcase \"P-521\":\n")
+	sb.WriteString("\t\t\tcurve = elliptic.P521()\n")
+	sb.WriteString("\t\tdefault:\n")
+	sb.WriteString("\t\t\treturn nil, fmt.Errorf(\"unsupported curve %q\", k.Crv)\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t\tx, err := base64.RawURLEncoding.DecodeString(k.X)\n")
+	sb.WriteString("\t\tif err != nil {\n")
+	sb.WriteString("\t\t\treturn nil, err\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t\ty, err := base64.RawURLEncoding.DecodeString(k.Y)\n")
+	sb.WriteString("\t\tif err != nil {\n")
+	sb.WriteString("\t\t\treturn nil, err\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t\treturn &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\treturn nil, fmt.Errorf(\"unsupported key type %q\", k.Kty)\n")
+	sb.WriteString("}\n\n")
+}
+
+// generateJWTValidator generates JWTValidator and the helpers verifying signatures and claims
+func (g *AuthGenerator) generateJWTValidator(sb *strings.Builder) {
+	sb.WriteString("// JWTClaims holds the claims of a validated JSON Web Token\n")
+	sb.WriteString("type JWTClaims struct {\n")
+	sb.WriteString("\tIssuer    string\n")
+	sb.WriteString("\tSubject   string\n")
+	sb.WriteString("\tAudience  []string\n")
+	sb.WriteString("\tExpiresAt time.Time\n")
+	sb.WriteString("\tNotBefore time.Time\n")
+	sb.WriteString("\tIssuedAt  time.Time\n")
+	sb.WriteString("\t// Scopes are read from the \"scope\" or \"scp\" claim\n")
+	sb.WriteString("\tScopes []string\n")
+	sb.WriteString("\t// Raw holds every claim of the token, including custom ones\n")
+	sb.WriteString("\tRaw map[string]any\n")
+	sb.WriteString("}\n\n")
+	sb.WriteString("// JWTValidator validates JSON Web Tokens signed with HMAC (HS256, HS384, HS512),\n")
+	sb.WriteString("// RSA (RS256, RS384, RS512) or ECDSA (ES256, ES384, ES512)\n")
+	sb.WriteString("type JWTValidator struct {\n")
+	sb.WriteString("\t// Key verifies signatures: a []byte secret for HMAC, an *rsa.PublicKey for RSA\n")
+	sb.WriteString("\t// or an *ecdsa.PublicKey for ECDSA\n")
+	sb.WriteString("\tKey any\n")
+	sb.WriteString("\t// KeyFunc looks up the key by the \"kid\" header of the token, for issuers that\n")
+	sb.WriteString("\t// rotate keys. It takes precedence over Key.\n")
+	sb.WriteString("\tKeyFunc func(ctx context.Context, keyID string) (any, error)\n")
+	sb.WriteString("\t// Issuer must match the \"iss\" claim when set\n")
+	sb.WriteString("\tIssuer string\n")
+	sb.WriteString("\t// Audience must be listed in the \"aud\" claim when set\n")
+	sb.WriteString("\tAudience string\n")
+	sb.WriteString("\t// Leeway allows for clock skew when checking \"exp\" and \"nbf\"\n")
+	sb.WriteString("\tLeeway time.Duration\n")
+	sb.WriteString("\t// Now returns the current time, defaulting to time.Now\n")
+	sb.WriteString("\tNow func() time.Time\n")
+	sb.WriteString("}\n\n")
+	sb.WriteString("// Validate verifies the signature of a token and checks its registered claims\n")
+	sb.WriteString("func (v *JWTValidator) Validate(ctx context.Context, token string) (*JWTClaims, error) {\n")
+	sb.WriteString("\tparts := strings.Split(token, \".\")\n")
+	sb.WriteString("\tif len(parts) != 3 {\n")
+	sb.WriteString("\t\treturn nil, errors.New(\"malformed token\")\n")
+	sb.WriteString("\t}\n\n")
+	sb.WriteString("\tvar header struct {\n")
+	sb.WriteString("\t\tAlg string `json:\"alg\"`\n")
+	sb.WriteString("\t\tKid string `json:\"kid\"`\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\tif err := decodeJWTSegment(parts[0], &header); err != nil {\n")
+	sb.WriteString("\t\treturn nil, fmt.Errorf(\"invalid token header: %w\", err)\n")
+	sb.WriteString("\t}\n\n")
+	sb.WriteString("\tkey := v.Key\n")
+	sb.WriteString("\tif v.KeyFunc != nil {\n")
+	sb.WriteString("\t\tvar err error\n")
+	sb.WriteString("\t\tif key, err = v.KeyFunc(ctx, header.Kid); err != nil {\n")
+	sb.WriteString("\t\t\treturn nil, err\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t}\n\n")
+	sb.WriteString("\tsignature, err := base64.RawURLEncoding.DecodeString(parts[2])\n")
+	sb.WriteString("\tif err != nil {\n")
+	sb.WriteString("\t\treturn nil, errors.New(\"invalid token signature\")\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\tif err := verifyJWTSignature(header.Alg, key, parts[0]+\".\"+parts[1], signature); err != nil {\n")
+	sb.WriteString("\t\treturn nil, err\n")
+	sb.WriteString("\t}\n\n")
+	sb.WriteString("\tvar raw map[string]any\n")
+	sb.WriteString("\tif err := decodeJWTSegment(parts[1], &raw); err != nil {\n")
+	sb.WriteString("\t\treturn nil, fmt.Errorf(\"invalid token claims: %w\", err)\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\tclaims := newJWTClaims(raw)\n\n")
+	sb.WriteString("\tnow := time.Now()\n")
+	sb.WriteString("\tif v.Now != nil {\n")
+	sb.WriteString("\t\tnow = v.Now()\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\tif !claims.ExpiresAt.IsZero() && now.After(claims.ExpiresAt.Add(v.Leeway)) {\n")
+	sb.WriteString("\t\treturn nil, errors.New(\"token has expired\")\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\tif !claims.NotBefore.IsZero() && now.Add(v.Leeway).Before(claims.NotBefore) {\n")
+	sb.WriteString("\t\treturn nil, errors.New(\"token is not valid yet\")\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\tif v.Issuer != \"\" && claims.Issuer != v.Issuer {\n")
+	sb.WriteString("\t\treturn nil, errors.New(\"token has an unexpected issuer\")\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\tif v.Audience != \"\" && !slices.Contains(claims.Audience, v.Audience) {\n")
+	sb.WriteString("\t\treturn nil, errors.New(\"token has an unexpected audience\")\n")
+	sb.WriteString("\t}\n\n")
+	sb.WriteString("\treturn claims, nil\n")
+	sb.WriteString("}\n\n")
+	sb.WriteString("// decodeJWTSegment decodes a base64url encoded JSON segment of a token\n")
+	sb.WriteString("func decodeJWTSegment(segment string, v any) error {\n")
+	sb.WriteString("\tdata, err := base64.RawURLEncoding.DecodeString(segment)\n")
+	sb.WriteString("\tif err != nil {\n")
+	sb.WriteString("\t\treturn err\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\treturn json.Unmarshal(data, v)\n")
+	sb.WriteString("}\n\n")
+	sb.WriteString("// verifyJWTSignature checks the signature of a token with a key matching its algorithm\n")
+	sb.WriteString("func verifyJWTSignature(alg string, key any, signingInput string, signature []byte) error {\n")
+	sb.WriteString("\tif len(alg) != 5 {\n")
+	sb.WriteString("\t\treturn fmt.Errorf(\"unsupported signing algorithm %q\", alg)\n")
+	sb.WriteString("\t}\n\n")
+	sb.WriteString("\tvar hash crypto.Hash\n")
+	sb.WriteString("\tswitch alg[2:] {\n")
+	sb.WriteString("\tcase \"256\":\n")
+	sb.WriteString("\t\thash = crypto.SHA256\n")
+	sb.WriteString("\tcase \"384\":\n")
+	sb.WriteString("\t\thash = crypto.SHA384\n")
+	sb.WriteString("\tcase \"512\":\n")
+	sb.WriteString("\t\thash = crypto.SHA512\n")
+	sb.WriteString("\tdefault:\n")
+	sb.WriteString("\t\treturn fmt.Errorf(\"unsupported signing algorithm %q\", alg)\n")
+	sb.WriteString("\t}\n\n")
+	sb.WriteString("\tswitch alg[:2] {\n")
+	sb.WriteString("\tcase \"HS\":\n")
+	sb.WriteString("\t\tsecret, ok := key.([]byte)\n")
+	sb.WriteString("\t\tif !ok {\n")
+	sb.WriteString("\t\t\treturn fmt.Errorf(\"%s tokens need a []byte key\", alg)\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t\tmac := hmac.New(hash.New, secret)\n")
+	sb.WriteString("\t\tmac.Write([]byte(signingInput))\n")
+	sb.WriteString("\t\tif !hmac.Equal(signature, mac.Sum(nil)) {\n")
+	sb.WriteString("\t\t\treturn errors.New(\"invalid token signature\")\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t\treturn nil\n")
+	sb.WriteString("\tcase \"RS\":\n")
+	sb.WriteString("\t\tpublicKey, ok := key.(*rsa.PublicKey)\n")
+	sb.WriteString("\t\tif !ok {\n")
+	sb.WriteString("\t\t\treturn fmt.Errorf(\"%s tokens need an *rsa.PublicKey\", alg)\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t\tif err := rsa.VerifyPKCS1v15(publicKey, hash, jwtDigest(hash, signingInput), signature); err != nil {\n")
+	sb.WriteString("\t\t\treturn errors.New(\"invalid token signature\")\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t\treturn nil\n")
+	sb.WriteString("\tcase \"ES\":\n")
+	sb.WriteString("\t\tpublicKey, ok := key.(*ecdsa.PublicKey)\n")
+	sb.WriteString("\t\tif !ok {\n")
+	sb.WriteString("\t\t\treturn fmt.Errorf(\"%s tokens need an *ecdsa.PublicKey\", alg)\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t\tsize := (publicKey.Curve.Params().BitSize + 7) / 8\n")
+	sb.WriteString("\t\tif len(signature) != 2*size {\n")
+	sb.WriteString("\t\t\treturn errors.New(\"invalid token signature\")\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t\tr := new(big.Int).SetBytes(signature[:size])\n")
+	sb.WriteString("\t\ts := new(big.Int).SetBytes(signature[size:])\n")
+	sb.WriteString("\t\tif !ecdsa.Verify(publicKey, jwtDigest(hash, signingInput), r, s) {\n")
+	sb.WriteString("\t\t\treturn errors.New(\"invalid token signature\")\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t\treturn nil\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\treturn fmt.Errorf(\"unsupported signing algorithm %q\", alg)\n")
+	sb.WriteString("}\n\n")
+	sb.WriteString("// jwtDigest hashes the signing input of a token\n")
+	sb.WriteString("func jwtDigest(hash crypto.Hash, signingInput string) []byte {\n")
+	sb.WriteString("\th := hash.New()\n")
+	sb.WriteString("\th.Write([]byte(signingInput))\n")
+	sb.WriteString("\treturn h.Sum(nil)\n")
+	sb.WriteString("}\n\n")
+	sb.WriteString("// newJWTClaims reads the registered claims from the decoded claims of a token\n")
+	sb.WriteString("func newJWTClaims(raw map[string]any) *JWTClaims {\n")
+	sb.WriteString("\tclaims := &JWTClaims{Raw: raw}\n")
+	sb.WriteString("\tclaims.Issuer, _ = raw[\"iss\"].(string)\n")
+	sb.WriteString("\tclaims.Subject, _ = raw[\"sub\"].(string)\n")
+	sb.WriteString("\tclaims.Audience = jwtStrings(raw[\"aud\"])\n")
+	sb.WriteString("\tclaims.ExpiresAt = jwtTime(raw[\"exp\"])\n")
+	sb.WriteString("\tclaims.NotBefore = jwtTime(raw[\"nbf\"])\n")
+	sb.WriteString("\tclaims.IssuedAt = jwtTime(raw[\"iat\"])\n")
+	sb.WriteString("\tif scope, ok := raw[\"scope\"].(string); ok {\n")
+	sb.WriteString("\t\tclaims.Scopes = strings.Fields(scope)\n")
+	sb.WriteString("\t} else if scp, ok := raw[\"scp\"].(string); ok {\n")
+	sb.WriteString("\t\tclaims.Scopes = strings.Fields(scp)\n")
+	sb.WriteString("\t} else {\n")
+	sb.WriteString("\t\tclaims.Scopes = jwtStrings(raw[\"scp\"])\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\treturn claims\n")
+	sb.WriteString("}\n\n")
+	sb.WriteString("// jwtStrings reads a claim holding a string or an array of strings\n")
+	sb.WriteString("func jwtStrings(v any) []string {\n")
+	sb.WriteString("\tswitch v := v.(type) {\n")
+	sb.WriteString("\tcase string:\n")
+	sb.WriteString("\t\treturn []string{v}\n")
+	sb.WriteString("\tcase []any:\n")
+	sb.WriteString("\t\tvalues := make([]string, 0, len(v))\n")
+	sb.WriteString("\t\tfor _, item := range v {\n")
+	sb.WriteString("\t\t\tif s, ok := item.(string); ok {\n")
+	sb.WriteString("\t\t\t\tvalues = append(values, s)\n")
+	sb.WriteString("\t\t\t}\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t\treturn values\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\treturn nil\n")
+	sb.WriteString("}\n\n")
+	sb.WriteString("// jwtTime reads a claim holding seconds since the Unix epoch\n")
+	sb.WriteString("func jwtTime(v any) time.Time {\n")
+	sb.WriteString("\tif seconds, ok := v.(float64); ok {\n")
+	sb.WriteString("\t\treturn time.Unix(int64(seconds), 0)\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\treturn time.Time{}\n")
+	sb.WriteString("}\n\n")
+}
@@ -0,0 +1,104 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/christopherklint97/specweaver/pkg/openapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthGeneratorGenerateJWT(t *testing.T) {
+	spec := &openapi.Document{
+		OpenAPI: "3.1.0",
+		Info: &openapi.Info{
+			Title:   "Test API",
+			Version: "1.0.0",
+		},
+		Components: &openapi.Components{
+			SecuritySchemes: map[string]*openapi.SecurityScheme{
+				"bearerAuth": {
+					Type:         "http",
+					Scheme:       "bearer",
+					BearerFormat: "JWT",
+				},
+				"opaqueAuth": {
+					Type:   "http",
+					Scheme: "bearer",
+				},
+			},
+		},
+	}
+
+	gen := NewAuthGenerator(spec)
+	code, err := gen.GenerateJWT()
+	require.NoError(t, err, "GenerateJWT should not fail")
+
+	assert.Contains(t, code, "package api", "Should have package declaration")
+	assert.Contains(t, code, "type JWTValidator struct", "Should have JWTValidator type")
+	assert.Contains(t, code, "func (v *JWTValidator) Validate(ctx context.Context, token string) (*JWTClaims, error)",
+		"Should have Validate method")
+	assert.Contains(t, code, "func (a JWTAuthenticator) AuthenticateBearerAuth(ctx context.Context, credentials BearerTokenCredentials) (any, error)",
+		"Should implement the JWT scheme")
+	assert.NotContains(t, code, "AuthenticateOpaqueAuth", "Should skip bearer schemes without bearerFormat JWT")
+	assert.Contains(t, code, "return ScopedPrincipal{Principal: claims, Scopes: claims.Scopes}, nil",
+		"Should report the scopes of the token")
+}
+
+func TestAuthGeneratorGenerateJWTWithoutJWTSchemes(t *testing.T) {
+	spec := &openapi.Document{
+		OpenAPI: "3.1.0",
+		Info: &openapi.Info{
+			Title:   "Test API",
+			Version: "1.0.0",
+		},
+		Components: &openapi.Components{
+			SecuritySchemes: map[string]*openapi.SecurityScheme{
+				"apiKey": {
+					Type: "apiKey",
+					In:   "header",
+					Name: "X-API-Key",
+				},
+			},
+		},
+	}
+
+	code, err := NewAuthGenerator(spec).GenerateJWT()
+	require.NoError(t, err, "GenerateJWT should not fail")
+	assert.Empty(t, code, "Should not generate JWT validation without JWT schemes")
+}
+
+func TestAuthGeneratorGenerateJWTWithOpenIDConnect(t *testing.T) {
+	spec := &openapi.Document{
+		OpenAPI: "3.1.0",
+		Info: &openapi.Info{
+			Title:   "Test API",
+			Version: "1.0.0",
+		},
+		Components: &openapi.Components{
+			SecuritySchemes: map[string]*openapi.SecurityScheme{
+				"oidc": {
+					Type:             "openIdConnect",
+					OpenIDConnectURL: "https://auth.example.com/.well-known/openid-configuration",
+				},
+			},
+		},
+	}
+
+	code, err := NewAuthGenerator(spec).GenerateJWT()
+	require.NoError(t, err, "GenerateJWT should not fail")
+
+	assert.Contains(t, code, "type OIDCProvider struct", "Should have OIDCProvider type")
+	assert.Contains(t, code, "\tOidc *OIDCProvider\n", "Should have a provider per scheme")
+	assert.Contains(t, code, `Oidc: &OIDCProvider{DiscoveryURL: "https://auth.example.com/.well-known/openid-configuration", Audience: audience},`,
+		"Should discover the provider from openIdConnectUrl")
+	assert.Contains(t, code, "func (a OIDCAuthenticator) AuthenticateOidc(ctx context.Context, credentials OpenIDConnectCredentials) (any, error)",
+		"Should implement the OpenID Connect scheme")
+	assert.Contains(t, code, "func (k jsonWebKey) publicKey() (any, error)", "Should parse JSON Web Keys")
+	assert.Contains(t, code, "\tif p.Audience == \"\" {\n\t\treturn nil, errors.New(", "Should reject tokens without an audience to check")
+	assert.Contains(t, code, "\tif doc.Issuer == \"\" {\n\t\treturn \"\", errors.New(\"OpenID Connect discovery document has no issuer\")",
+		"Should require an issuer to check tokens against")
+	assert.Contains(t, code, "\tp.fetchMu.Lock()\n\tdefer p.fetchMu.Unlock()\n", "Should fetch without holding the cache lock")
+	assert.Contains(t, code, "\t\"sync\"\n", "Should import sync for the key cache")
+	assert.NotContains(t, code, "type JWTAuthenticator struct", "Should not generate JWTAuthenticator without JWT bearer schemes")
+}
@@ -2,63 +2,108 @@ package generator
 
 import (
 	"fmt"
+	"go/token"
+	"html"
 	"net/http"
+	"regexp"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
+	"unicode"
 
 	"github.com/christopherklint97/specweaver/pkg/openapi"
 )
 
 // ServerGenerator generates Go server code from OpenAPI paths
 type ServerGenerator struct {
-	spec *openapi.Document
+	spec          *openapi.Document
+	packageName   string
+	typesImport   string
+	typesName     string // package name of typesImport
+	router        string
+	splitByTag    bool
+	tagInterfaces bool
+	basePath      string
+	embedSpec     bool
+	docs          bool
+	autoHead      bool
+	cors          bool
+	idempotency   bool
+	thin          bool
+	maxBodySize   int64
+	timeout       time.Duration
 }
 
 // NewServerGenerator creates a new ServerGenerator instance
 func NewServerGenerator(spec *openapi.Document) *ServerGenerator {
+	return NewServerGeneratorWithConfig(spec, Config{})
+}
+
+// NewServerGeneratorWithConfig creates a new ServerGenerator instance using the
+// router target and file layout from the given configuration
+func NewServerGeneratorWithConfig(spec *openapi.Document, config Config) *ServerGenerator {
+	if config.Router == "" {
+		config.Router = RouterBuiltin
+	}
+	if config.PackageName == "" {
+		config.PackageName = "api"
+	}
+
 	return &ServerGenerator{
-		spec: spec,
+		spec:          spec,
+		packageName:   config.PackageName,
+		typesImport:   config.TypesImport,
+		typesName:     config.TypesImport[strings.LastIndex(config.TypesImport, "/")+1:],
+		router:        config.Router,
+		splitByTag:    config.SplitByTag,
+		tagInterfaces: config.TagInterfaces,
+		basePath:      config.BasePath,
+		embedSpec:     config.EmbedSpec || config.Docs,
+		docs:          config.Docs,
+		autoHead:      config.AutoHead,
+		cors:          config.CORS,
+		idempotency:   config.Idempotency,
+		thin:          config.Thin,
+		maxBodySize:   config.MaxBodySize,
+		timeout:       config.Timeout,
 	}
 }
 
 // Generate generates server code including handlers and router
 func (g *ServerGenerator) Generate() (string, error) {
-	var sb strings.Builder
+	if err := g.validateConfig(); err != nil {
+		return "", err
+	}
 
-	sb.WriteString("package api\n\n")
-	sb.WriteString("import (\n")
-	sb.WriteString("\t\"context\"\n")
-	sb.WriteString("\t\"encoding/json\"\n")
-	sb.WriteString("\t\"errors\"\n")
-	sb.WriteString("\t\"fmt\"\n")
-	sb.WriteString("\t\"io\"\n")
-	sb.WriteString("\t\"net/http\"\n")
-	sb.WriteString("\t\"strconv\"\n")
-	sb.WriteString("\n")
-	sb.WriteString("\t\"github.com/christopherklint97/specweaver/pkg/router\"\n")
-	sb.WriteString(")\n\n")
+	operations := getOperations(g.spec)
+
+	var sb strings.Builder
 
 	// Generate HTTPError type
 	g.generateHTTPError(&sb)
 
 	// Generate request types for each operation
-	if err := g.generateRequestTypes(&sb); err != nil {
-		return "", err
-	}
+	g.generateRequestTypes(&sb, operations)
 
-	// Generate response types for each operation
-	if err := g.generateResponseTypes(&sb); err != nil {
-		return "", err
-	}
+	if g.thin {
+		// Generate the binding helpers and the plain handler interface
+		g.generateBindFunctions(&sb, operations)
+		g.generateThinServerInterface(&sb, operations)
+	} else {
+		// Generate response types for each operation
+		g.generateResponseTypes(&sb, operations)
 
-	// Generate the main server interface
-	if err := g.generateServerInterface(&sb); err != nil {
-		return "", err
-	}
+		// Generate the main server interface
+		g.generateTagInterfaces(&sb, operations)
+		g.generateServerInterface(&sb, operations)
 
-	// Generate the handler wrapper
-	g.generateHandlerWrapper(&sb)
+		// Generate the handler wrapper
+		g.generateHandlerWrapper(&sb)
+		g.generateAdapterMethods(&sb, operations)
+		g.generateErrorHandler(&sb)
+	}
 
 	// Generate the router setup
 	g.generateRouter(&sb)
@@ -66,7 +111,237 @@ func (g *ServerGenerator) Generate() (string, error) {
 	// Generate helper functions
 	g.generateHelpers(&sb)
 
-	return sb.String(), nil
+	return g.withFileHeader(sb.String()), nil
+}
+
+// GenerateFiles generates server code as a set of files keyed by file name.
+// Without SplitByTag this is a single server.go. With SplitByTag, the request,
+// response and adapter code for each tag goes into server_<tag>.go while shared
+// code and untagged operations go into server_common.go.
+func (g *ServerGenerator) GenerateFiles() (map[string]string, error) {
+	if !g.splitByTag {
+		code, err := g.Generate()
+		if err != nil {
+			return nil, err
+		}
+		return map[string]string{"server.go": code}, nil
+	}
+
+	if err := g.validateConfig(); err != nil {
+		return nil, err
+	}
+
+	operations := getOperations(g.spec)
+
+	// Group operations by the file their first tag maps to
+	groups := make(map[string][]operationInfo)
+	var untagged []operationInfo
+	for _, op := range operations {
+		if len(op.Operation.Tags) == 0 {
+			untagged = append(untagged, op)
+			continue
+		}
+		fileName := "server_" + toFileNamePart(op.Operation.Tags[0]) + ".go"
+		groups[fileName] = append(groups[fileName], op)
+	}
+
+	files := make(map[string]string, len(groups)+1)
+	for fileName, ops := range groups {
+		var sb strings.Builder
+		g.generateRequestTypes(&sb, ops)
+		if g.thin {
+			g.generateBindFunctions(&sb, ops)
+		} else {
+			g.generateResponseTypes(&sb, ops)
+			g.generateTagInterfaces(&sb, ops)
+			g.generateAdapterMethods(&sb, ops)
+		}
+		files[fileName] = g.withFileHeader(sb.String())
+	}
+
+	var sb strings.Builder
+	g.generateHTTPError(&sb)
+	g.generateRequestTypes(&sb, untagged)
+	if g.thin {
+		g.generateBindFunctions(&sb, untagged)
+		g.generateThinServerInterface(&sb, operations)
+	} else {
+		g.generateResponseTypes(&sb, untagged)
+		g.generateTagInterfaces(&sb, untagged)
+		g.generateServerInterface(&sb, operations)
+		g.generateHandlerWrapper(&sb)
+		g.generateAdapterMethods(&sb, untagged)
+		g.generateErrorHandler(&sb)
+	}
+	g.generateRouter(&sb)
+	g.generateHelpers(&sb)
+	files["server_common.go"] = g.withFileHeader(sb.String())
+
+	return files, nil
+}
+
+// validateConfig checks that the configured router target is supported and
+// that the enabled options can be combined
+func (g *ServerGenerator) validateConfig() error {
+	switch g.router {
+	case RouterBuiltin, RouterChi, RouterStdlib:
+	default:
+		return fmt.Errorf("unsupported router %q", g.router)
+	}
+
+	if g.typesImport != "" && (!token.IsIdentifier(g.typesName) || slices.ContainsFunc(generatedImports, func(imp generatedImport) bool {
+		return imp.name == g.typesName
+	})) {
+		return fmt.Errorf("types package name %q is not a valid identifier or clashes with an import of the server code", g.typesName)
+	}
+
+	// CORS and idempotency are implemented by the ServerWrapper, which thin mode omits
+	if g.thin && g.cors {
+		return fmt.Errorf("CORS is not supported in thin mode")
+	}
+	if g.thin && g.idempotency {
+		return fmt.Errorf("idempotency is not supported in thin mode")
+	}
+	if g.thin && g.timeout > 0 {
+		return fmt.Errorf("timeouts are not supported in thin mode")
+	}
+	if g.thin {
+		for _, info := range getOperations(g.spec) {
+			if g.operationTimeout(info.Operation) > 0 {
+				return fmt.Errorf("operation %s: x-timeout is not supported in thin mode", getOperationID(info.HandlerName, info.Operation))
+			}
+		}
+	}
+
+	// Roles and permissions are enforced by the auth middleware, which only wraps
+	// operations with security requirements
+	for _, info := range getOperations(g.spec) {
+		op := info.Operation
+		if len(extensionStrings(op, "x-roles")) == 0 && len(extensionStrings(op, "x-permissions")) == 0 {
+			continue
+		}
+		if !g.hasSecurityRequirements(op) || g.spec.Components == nil || len(g.spec.Components.SecuritySchemes) == 0 {
+			return fmt.Errorf("operation %s declares x-roles or x-permissions but has no security requirements", getOperationID(info.HandlerName, op))
+		}
+	}
+
+	if g.spec.Components != nil {
+		for _, name := range sortedKeys(g.spec.Components.SecuritySchemes) {
+			scheme := g.spec.Components.SecuritySchemes[name]
+			if scheme == nil {
+				continue
+			}
+			if _, _, err := schemeTenant(scheme); err != nil {
+				return fmt.Errorf("security scheme %s: %w", name, err)
+			}
+		}
+	}
+
+	for _, info := range getOperations(g.spec) {
+		if _, _, err := operationRateLimit(info.Operation); err != nil {
+			return fmt.Errorf("operation %s: %w", getOperationID(info.HandlerName, info.Operation), err)
+		}
+	}
+
+	// A wildcard parameter captures the rest of the path, so nothing may follow it
+	for _, path := range sortedKeys(g.spec.Paths) {
+		wildcard := pathWildcard(g.spec.Paths[path])
+		if wildcard != "" && !strings.HasSuffix(path, "/{"+wildcard+"}") {
+			return fmt.Errorf("path %s: wildcard parameter %s must be the last path segment", path, wildcard)
+		}
+	}
+	return nil
+}
+
+// generatedImport is a package generated code may import
+type generatedImport struct {
+	name string // identifier the code refers to the package with
+	path string
+}
+
+// generatedImports lists the packages generated server code may use, keyed by
+// the identifier the code refers to them with, in import block order
+var generatedImports = []generatedImport{
+	{"bytes", "bytes"},
+	{"context", "context"},
+	{"sha256", "crypto/sha256"},
+	{"hex", "encoding/hex"},
+	{"json", "encoding/json"},
+	{"xml", "encoding/xml"},
+	{"errors", "errors"},
+	{"fmt", "fmt"},
+	{"html", "html"},
+	{"io", "io"},
+	{"slog", "log/slog"},
+	{"http", "net/http"},
+	{"netip", "net/netip"},
+	{"slices", "slices"},
+	{"strconv", "strconv"},
+	{"strings", "strings"},
+	{"sync", "sync"},
+	{"time", "time"},
+	{"chi", "github.com/go-chi/chi/v5"},
+	{"middleware", "github.com/go-chi/chi/v5/middleware"},
+	{"router", "github.com/christopherklint97/specweaver/pkg/router"},
+}
+
+// withFileHeader prepends the package clause and an import block containing
+// only the packages the code actually refers to
+func (g *ServerGenerator) withFileHeader(code string) string {
+	// Comments may mention package names without using them
+	var stripped strings.Builder
+	for _, line := range strings.Split(code, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "//") {
+			stripped.WriteString(line)
+			stripped.WriteString("\n")
+		}
+	}
+	body := stripped.String()
+
+	var stdImports, otherImports []string
+	if strings.Contains(code, "//go:embed ") {
+		stdImports = append(stdImports, "_ \"embed\"")
+	}
+	imports := generatedImports
+	if g.typesImport != "" {
+		imports = append(slices.Clip(imports), generatedImport{g.typesName, g.typesImport})
+	}
+	for _, imp := range imports {
+		if !regexp.MustCompile(`\b` + imp.name + `\.`).MatchString(body) {
+			continue
+		}
+		if strings.Contains(imp.path, ".") {
+			otherImports = append(otherImports, strconv.Quote(imp.path))
+		} else {
+			stdImports = append(stdImports, strconv.Quote(imp.path))
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("package %s\n\n", g.packageName))
+	if len(stdImports) > 0 || len(otherImports) > 0 {
+		sb.WriteString("import (\n")
+		sort.Slice(stdImports, func(i, j int) bool {
+			return importPath(stdImports[i]) < importPath(stdImports[j])
+		})
+		for _, spec := range stdImports {
+			sb.WriteString("\t" + spec + "\n")
+		}
+		if len(stdImports) > 0 && len(otherImports) > 0 {
+			sb.WriteString("\n")
+		}
+		for _, spec := range otherImports {
+			sb.WriteString("\t" + spec + "\n")
+		}
+		sb.WriteString(")\n\n")
+	}
+	sb.WriteString(code)
+	return sb.String()
+}
+
+// importPath returns the path of an import spec, skipping any name
+func importPath(spec string) string {
+	return spec[strings.Index(spec, "\""):]
 }
 
 // generateHTTPError generates the HTTPError type for error handling
@@ -105,282 +380,1007 @@ func (g *ServerGenerator) generateHTTPError(sb *strings.Builder) {
 	sb.WriteString("}\n\n")
 }
 
-// generateRequestTypes generates request structs for each operation
-func (g *ServerGenerator) generateRequestTypes(sb *strings.Builder) error {
-	if g.spec.Paths == nil {
-		return nil
-	}
-
-	// Sort paths for deterministic output
-	paths := make([]string, 0, len(g.spec.Paths))
-	for path := range g.spec.Paths {
-		paths = append(paths, path)
-	}
-	sort.Strings(paths)
-
-	for _, path := range paths {
-		pathItem := g.spec.Paths[path]
-		operations := getOperationsInOrder(pathItem)
-
-		for _, methodOp := range operations {
-			method := methodOp.Method
-			op := methodOp.Operation
-
-			handlerName := generateHandlerName(method, path, op.OperationID)
-			requestTypeName := handlerName + "Request"
-
-			sb.WriteString(fmt.Sprintf("// %s represents the request for %s\n", requestTypeName, handlerName))
-			sb.WriteString(fmt.Sprintf("type %s struct {\n", requestTypeName))
-
-			// Add path parameters
-			if op.Parameters != nil {
-				for _, param := range op.Parameters {
-					if param == nil {
-						continue
-					}
+// generateRequestTypes generates request structs for the given operations
+func (g *ServerGenerator) generateRequestTypes(sb *strings.Builder, operations []operationInfo) {
+	for _, info := range operations {
+		op := info.Operation
+		handlerName := info.HandlerName
+		requestTypeName := handlerName + "Request"
+
+		sb.WriteString(fmt.Sprintf("// %s represents the request for %s\n", requestTypeName, handlerName))
+		sb.WriteString(fmt.Sprintf("type %s struct {\n", requestTypeName))
+
+		// Add path parameters
+		if op.Parameters != nil {
+			for _, param := range op.Parameters {
+				if param == nil {
+					continue
+				}
 
-					if param.In == "path" {
-						fieldName := toPascalCase(param.Name)
-						fieldType := g.getParamType(param)
-						if param.Description != "" {
-							sb.WriteString(fmt.Sprintf("\t// %s\n", param.Description))
-						}
-						sb.WriteString(fmt.Sprintf("\t%s %s `json:\"%s\"`\n", fieldName, fieldType, param.Name))
+				if param.In == "path" {
+					fieldName := toPascalCase(param.Name)
+					fieldType := g.getParamType(param)
+					if param.Description != "" {
+						sb.WriteString(fmt.Sprintf("\t// %s\n", param.Description))
 					}
+					sb.WriteString(fmt.Sprintf("\t%s %s `json:\"%s\"`\n", fieldName, fieldType, param.Name))
 				}
 			}
+		}
 
-			// Add query parameters
-			if op.Parameters != nil {
-				for _, param := range op.Parameters {
-					if param == nil {
-						continue
-					}
+		// Add query parameters
+		if op.Parameters != nil {
+			for _, param := range op.Parameters {
+				if param == nil {
+					continue
+				}
 
-					if param.In == "query" {
-						fieldName := toPascalCase(param.Name)
-						fieldType := g.getParamType(param)
+				if param.In == "query" {
+					fieldName := toPascalCase(param.Name)
+					fieldType := g.getParamType(param)
 
-						// Query params are optional by default
-						if !param.Required && !strings.HasPrefix(fieldType, "*") {
-							fieldType = "*" + fieldType
-						}
+					// Query params are optional by default
+					if !param.Required && !strings.HasPrefix(fieldType, "*") {
+						fieldType = "*" + fieldType
+					}
 
-						jsonTag := param.Name + ",omitempty"
-						if param.Description != "" {
-							sb.WriteString(fmt.Sprintf("\t// %s\n", param.Description))
-						}
-						sb.WriteString(fmt.Sprintf("\t%s %s `json:\"%s\"`\n", fieldName, fieldType, jsonTag))
+					jsonTag := param.Name + ",omitempty"
+					if param.Description != "" {
+						sb.WriteString(fmt.Sprintf("\t// %s\n", param.Description))
 					}
+					sb.WriteString(fmt.Sprintf("\t%s %s `json:\"%s\"`\n", fieldName, fieldType, jsonTag))
 				}
 			}
+		}
 
-			// Add request body if present
-			if op.RequestBody != nil {
-				content := op.RequestBody.Content
-				if jsonContent, ok := content["application/json"]; ok && jsonContent.Schema != nil {
-					bodyType := g.resolveSchemaType(jsonContent.Schema)
-					sb.WriteString("\t// Request body\n")
-					sb.WriteString(fmt.Sprintf("\tBody %s `json:\"body\"`\n", bodyType))
-				}
+		// Add the idempotency key
+		if g.usesIdempotencyKey(info.Method, op) {
+			sb.WriteString("\t// IdempotencyKey identifies retries of the same request (Idempotency-Key header)\n")
+			sb.WriteString("\tIdempotencyKey string `json:\"Idempotency-Key,omitempty\"`\n")
+		}
+
+		// Add conditional request headers
+		for _, param := range conditionalHeaderParams(op) {
+			if param.Description != "" {
+				sb.WriteString(fmt.Sprintf("\t// %s\n", param.Description))
 			}
+			sb.WriteString(fmt.Sprintf("\t%s string `json:\"%s,omitempty\"`\n", toPascalCase(param.Name), param.Name))
+		}
 
-			sb.WriteString("}\n\n")
+		// Add request body if present
+		if op.RequestBody != nil {
+			content := op.RequestBody.Content
+			if jsonContent, ok := content["application/json"]; ok && jsonContent.Schema != nil {
+				bodyType := g.resolveSchemaType(jsonContent.Schema)
+				sb.WriteString("\t// Request body\n")
+				sb.WriteString(fmt.Sprintf("\tBody %s `json:\"body\"`\n", bodyType))
+			}
 		}
-	}
 
-	return nil
+		sb.WriteString("}\n\n")
+	}
 }
 
-// generateResponseTypes generates response types for each operation
-func (g *ServerGenerator) generateResponseTypes(sb *strings.Builder) error {
-	if g.spec.Paths == nil {
-		return nil
-	}
+// generateResponseTypes generates response types for the given operations
+func (g *ServerGenerator) generateResponseTypes(sb *strings.Builder, operations []operationInfo) {
+	for _, info := range operations {
+		op := info.Operation
+		handlerName := info.HandlerName
+		responseTypeName := handlerName + "Response"
+
+		// Generate response interface
+		sb.WriteString(fmt.Sprintf("// %s represents possible responses for %s\n", responseTypeName, handlerName))
+		sb.WriteString(fmt.Sprintf("type %s interface {\n", responseTypeName))
+		sb.WriteString(fmt.Sprintf("\tis%s()\n", responseTypeName))
+		sb.WriteString("\tStatusCode() int\n")
+		sb.WriteString("\tResponseBody() any\n")
+		sb.WriteString("}\n\n")
 
-	// Sort paths for deterministic output
-	paths := make([]string, 0, len(g.spec.Paths))
-	for path := range g.spec.Paths {
-		paths = append(paths, path)
-	}
-	sort.Strings(paths)
+		// Generate concrete response types for each status code (in sorted order)
+		if op.Responses != nil {
+			statusCodes := make([]string, 0, len(op.Responses))
+			for statusCode := range op.Responses {
+				statusCodes = append(statusCodes, statusCode)
+			}
+			sort.Strings(statusCodes)
 
-	for _, path := range paths {
-		pathItem := g.spec.Paths[path]
-		operations := getOperationsInOrder(pathItem)
+			for _, statusCode := range statusCodes {
+				response := op.Responses[statusCode]
+				if response == nil {
+					continue
+				}
 
-		for _, methodOp := range operations {
-			method := methodOp.Method
-			op := methodOp.Operation
+				// "default" responses carry a caller-chosen status code
+				if statusCode == "default" {
+					g.generateDefaultResponseType(sb, handlerName, response)
+					continue
+				}
 
-			handlerName := generateHandlerName(method, path, op.OperationID)
-			responseTypeName := handlerName + "Response"
-
-			// Generate response interface
-			sb.WriteString(fmt.Sprintf("// %s represents possible responses for %s\n", responseTypeName, handlerName))
-			sb.WriteString(fmt.Sprintf("type %s interface {\n", responseTypeName))
-			sb.WriteString(fmt.Sprintf("\tis%s()\n", responseTypeName))
-			sb.WriteString("\tStatusCode() int\n")
-			sb.WriteString("\tResponseBody() any\n")
-			sb.WriteString("}\n\n")
-
-			// Generate concrete response types for each status code (in sorted order)
-			if op.Responses != nil {
-				statusCodes := make([]string, 0, len(op.Responses))
-				for statusCode := range op.Responses {
-					statusCodes = append(statusCodes, statusCode)
+				// Ranged responses such as "2XX" carry a caller-chosen code within the range
+				if class := parseStatusCodeRange(statusCode); class != 0 {
+					g.generateRangeResponseType(sb, handlerName, class, response)
+					continue
 				}
-				sort.Strings(statusCodes)
 
-				for _, statusCode := range statusCodes {
-					response := op.Responses[statusCode]
-					if response == nil {
-						continue
-					}
+				// Parse status code
+				statusCodeInt := parseStatusCode(statusCode)
+				if statusCodeInt == 0 {
+					// Skip invalid status codes
+					continue
+				}
 
-					// Skip "default" responses - these should be handled by error mechanism
-					if statusCode == "default" {
-						continue
-					}
+				// Informational responses are sent ahead of the final response
+				if statusCodeInt < 200 {
+					g.generateInformationalResponseType(sb, handlerName, statusCodeInt)
+					continue
+				}
+				concreteTypeName := fmt.Sprintf("%s%dResponse", handlerName, statusCodeInt)
 
-					// Parse status code
-					statusCodeInt := parseStatusCode(statusCode)
-					if statusCodeInt == 0 {
-						// Skip invalid status codes
-						continue
-					}
-					concreteTypeName := fmt.Sprintf("%s%dResponse", handlerName, statusCodeInt)
-
-					sb.WriteString(fmt.Sprintf("// %s represents a %d response\n", concreteTypeName, statusCodeInt))
-					sb.WriteString(fmt.Sprintf("type %s struct {\n", concreteTypeName))
-
-					// Check if response has content
-					hasBody := false
-					if response.Content != nil {
-						if jsonContent, ok := response.Content["application/json"]; ok && jsonContent.Schema != nil {
-							bodyType := g.resolveSchemaType(jsonContent.Schema)
-							sb.WriteString(fmt.Sprintf("\tBody %s `json:\"body\"`\n", bodyType))
-							hasBody = true
-						}
-					}
+				sb.WriteString(fmt.Sprintf("// %s represents a %d response\n", concreteTypeName, statusCodeInt))
+				sb.WriteString(fmt.Sprintf("type %s struct {\n", concreteTypeName))
 
-					sb.WriteString("}\n\n")
+				// Check if response has content
+				hasBody := false
+				if bodySchema := getResponseBodySchema(response); bodySchema != nil {
+					bodyType := g.resolveSchemaType(bodySchema)
+					sb.WriteString(fmt.Sprintf("\tBody %s `json:\"body\"`\n", bodyType))
+					hasBody = true
+				}
+				g.generateETagField(sb, response)
+				g.generateTrailersField(sb, response)
 
-					// Generate interface implementation methods
-					sb.WriteString(fmt.Sprintf("func (r %s) is%s() {}\n", concreteTypeName, responseTypeName))
-					sb.WriteString(fmt.Sprintf("func (r %s) StatusCode() int { return %d }\n", concreteTypeName, statusCodeInt))
+				sb.WriteString("}\n\n")
 
-					// Generate ResponseBody method
-					if hasBody {
-						sb.WriteString(fmt.Sprintf("func (r %s) ResponseBody() any { return r.Body }\n\n", concreteTypeName))
-					} else {
-						sb.WriteString(fmt.Sprintf("func (r %s) ResponseBody() any { return nil }\n\n", concreteTypeName))
-					}
+				// Generate interface implementation methods
+				sb.WriteString(fmt.Sprintf("func (r %s) is%s() {}\n", concreteTypeName, responseTypeName))
+				sb.WriteString(fmt.Sprintf("func (r %s) StatusCode() int { return %d }\n", concreteTypeName, statusCodeInt))
+				g.generateETagMethod(sb, concreteTypeName, response)
+				g.generateTrailersMethod(sb, concreteTypeName, response)
+
+				// Generate ResponseBody method
+				if hasBody {
+					sb.WriteString(fmt.Sprintf("func (r %s) ResponseBody() any { return r.Body }\n\n", concreteTypeName))
+				} else {
+					sb.WriteString(fmt.Sprintf("func (r %s) ResponseBody() any { return nil }\n\n", concreteTypeName))
 				}
 			}
 		}
 	}
+}
 
-	return nil
+// generateInformationalResponseType generates the type of a 1xx response such as
+// 103 Early Hints. It is not a final response, so handlers send it with
+// SendInformational instead of returning it.
+func (g *ServerGenerator) generateInformationalResponseType(sb *strings.Builder, handlerName string, statusCode int) {
+	concreteTypeName := fmt.Sprintf("%s%dResponse", handlerName, statusCode)
+
+	sb.WriteString(fmt.Sprintf("// %s represents a %d informational response, sent with\n", concreteTypeName, statusCode))
+	sb.WriteString("// SendInformational before the final response\n")
+	sb.WriteString(fmt.Sprintf("type %s struct {\n", concreteTypeName))
+	sb.WriteString("\t// Header holds the headers of the informational response, such as Link\n")
+	sb.WriteString("\tHeader http.Header\n")
+	sb.WriteString("}\n\n")
+
+	sb.WriteString(fmt.Sprintf("func (r %s) StatusCode() int { return %d }\n", concreteTypeName, statusCode))
+	sb.WriteString(fmt.Sprintf("func (r %s) ResponseHeaders() http.Header { return r.Header }\n\n", concreteTypeName))
 }
 
-// generateServerInterface generates the interface that users need to implement
-func (g *ServerGenerator) generateServerInterface(sb *strings.Builder) error {
-	sb.WriteString("// Server represents all server handlers\n")
-	sb.WriteString("type Server interface {\n")
+// generateDefaultResponseType generates the catch-all response type for a "default" response.
+// The status code is supplied by the handler, falling back to 500 when unset.
+func (g *ServerGenerator) generateDefaultResponseType(sb *strings.Builder, handlerName string, response *openapi.Response) {
+	responseTypeName := handlerName + "Response"
+	concreteTypeName := handlerName + "DefaultResponse"
+
+	sb.WriteString(fmt.Sprintf("// %s represents the default response\n", concreteTypeName))
+	sb.WriteString(fmt.Sprintf("type %s struct {\n", concreteTypeName))
+	sb.WriteString("\t// Code is the HTTP status code to send (defaults to 500 if zero)\n")
+	sb.WriteString("\tCode int `json:\"-\"`\n")
+
+	hasBody := false
+	if bodySchema := getResponseBodySchema(response); bodySchema != nil {
+		bodyType := g.resolveSchemaType(bodySchema)
+		sb.WriteString(fmt.Sprintf("\tBody %s `json:\"body\"`\n", bodyType))
+		hasBody = true
+	}
+	g.generateETagField(sb, response)
+	g.generateTrailersField(sb, response)
 
-	if g.spec.Paths == nil {
-		sb.WriteString("}\n\n")
-		return nil
+	sb.WriteString("}\n\n")
+
+	sb.WriteString(fmt.Sprintf("func (r %s) is%s() {}\n", concreteTypeName, responseTypeName))
+	g.generateETagMethod(sb, concreteTypeName, response)
+	g.generateTrailersMethod(sb, concreteTypeName, response)
+	sb.WriteString(fmt.Sprintf("func (r %s) StatusCode() int {\n", concreteTypeName))
+	sb.WriteString("\tif r.Code == 0 {\n")
+	sb.WriteString("\t\treturn http.StatusInternalServerError\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\treturn r.Code\n")
+	sb.WriteString("}\n")
+
+	if hasBody {
+		sb.WriteString(fmt.Sprintf("func (r %s) ResponseBody() any { return r.Body }\n\n", concreteTypeName))
+	} else {
+		sb.WriteString(fmt.Sprintf("func (r %s) ResponseBody() any { return nil }\n\n", concreteTypeName))
 	}
+}
 
-	// Sort paths for deterministic output
-	paths := make([]string, 0, len(g.spec.Paths))
-	for path := range g.spec.Paths {
-		paths = append(paths, path)
+// generateRangeResponseType generates the response type for a ranged status code
+// such as "2XX". The status code is supplied by the handler and falls back to the
+// first code of the range when unset or outside of it.
+func (g *ServerGenerator) generateRangeResponseType(sb *strings.Builder, handlerName string, class int, response *openapi.Response) {
+	responseTypeName := handlerName + "Response"
+	concreteTypeName := fmt.Sprintf("%s%dXXResponse", handlerName, class)
+	low, high := class*100, class*100+99
+
+	sb.WriteString(fmt.Sprintf("// %s represents a %dXX response\n", concreteTypeName, class))
+	sb.WriteString(fmt.Sprintf("type %s struct {\n", concreteTypeName))
+	sb.WriteString(fmt.Sprintf("\t// Code is the HTTP status code to send, between %d and %d (defaults to %d)\n", low, high, low))
+	sb.WriteString("\tCode int `json:\"-\"`\n")
+
+	hasBody := false
+	if bodySchema := getResponseBodySchema(response); bodySchema != nil {
+		bodyType := g.resolveSchemaType(bodySchema)
+		sb.WriteString(fmt.Sprintf("\tBody %s `json:\"body\"`\n", bodyType))
+		hasBody = true
 	}
-	sort.Strings(paths)
+	g.generateETagField(sb, response)
+	g.generateTrailersField(sb, response)
 
-	for _, path := range paths {
-		pathItem := g.spec.Paths[path]
-		operations := getOperationsInOrder(pathItem)
+	sb.WriteString("}\n\n")
 
-		for _, methodOp := range operations {
-			method := methodOp.Method
-			op := methodOp.Operation
+	sb.WriteString(fmt.Sprintf("func (r %s) is%s() {}\n", concreteTypeName, responseTypeName))
+	g.generateETagMethod(sb, concreteTypeName, response)
+	g.generateTrailersMethod(sb, concreteTypeName, response)
+	sb.WriteString(fmt.Sprintf("func (r %s) StatusCode() int {\n", concreteTypeName))
+	sb.WriteString(fmt.Sprintf("\tif r.Code < %d || r.Code > %d {\n", low, high))
+	sb.WriteString(fmt.Sprintf("\t\treturn %d\n", low))
+	sb.WriteString("\t}\n")
+	sb.WriteString("\treturn r.Code\n")
+	sb.WriteString("}\n")
 
-			handlerName := generateHandlerName(method, path, op.OperationID)
-			requestTypeName := handlerName + "Request"
-			responseTypeName := handlerName + "Response"
+	if hasBody {
+		sb.WriteString(fmt.Sprintf("func (r %s) ResponseBody() any { return r.Body }\n\n", concreteTypeName))
+	} else {
+		sb.WriteString(fmt.Sprintf("func (r %s) ResponseBody() any { return nil }\n\n", concreteTypeName))
+	}
+}
 
-			// Add comment with operation summary
-			if op.Summary != "" {
-				sb.WriteString(fmt.Sprintf("\t// %s %s\n", handlerName, op.Summary))
-			}
+// generateServerInterface generates the interface that users need to implement
+func (g *ServerGenerator) generateServerInterface(sb *strings.Builder, operations []operationInfo) {
+	if g.tagInterfaces {
+		g.generateComposedServerInterface(sb, operations)
+		return
+	}
+
+	sb.WriteString("// Server represents all server handlers\n")
+	sb.WriteString("type Server interface {\n")
+
+	for _, info := range operations {
+		handlerName := info.HandlerName
+		requestTypeName := handlerName + "Request"
+		responseTypeName := handlerName + "Response"
 
-			sb.WriteString(fmt.Sprintf("\t%s(ctx context.Context, req %s) (%s, error)\n", handlerName, requestTypeName, responseTypeName))
+		// Add comment with operation summary
+		if info.Operation.Summary != "" {
+			sb.WriteString(fmt.Sprintf("\t// %s %s\n", handlerName, info.Operation.Summary))
 		}
+
+		sb.WriteString(fmt.Sprintf("\t%s(ctx context.Context, req %s) (%s, error)\n", handlerName, requestTypeName, responseTypeName))
 	}
 
 	sb.WriteString("}\n\n")
-	return nil
 }
 
-// generateHandlerWrapper generates the HTTP handler wrapper with adapter functions
-func (g *ServerGenerator) generateHandlerWrapper(sb *strings.Builder) {
-	sb.WriteString("// ServerWrapper wraps the Server with HTTP handler logic\n")
-	sb.WriteString("type ServerWrapper struct {\n")
-	sb.WriteString("\tHandler Server\n")
+// generateThinServerInterface generates the interface of plain HTTP handlers
+// that users implement in thin mode
+func (g *ServerGenerator) generateThinServerInterface(sb *strings.Builder, operations []operationInfo) {
+	sb.WriteString("// ServerInterface represents all server handlers. Handlers write their own\n")
+	sb.WriteString("// responses and parse requests with the matching Bind<Operation>Request function.\n")
+	sb.WriteString("type ServerInterface interface {\n")
+
+	for _, info := range operations {
+		// Add comment with operation summary
+		if info.Operation.Summary != "" {
+			sb.WriteString(fmt.Sprintf("\t// %s %s\n", info.HandlerName, info.Operation.Summary))
+		}
+
+		sb.WriteString(fmt.Sprintf("\t%s(w http.ResponseWriter, r *http.Request)\n", info.HandlerName))
+	}
+
 	sb.WriteString("}\n\n")
+}
 
-	if g.spec.Paths == nil {
+// generateBindFunctions generates the functions that parse the request of each
+// operation in thin mode
+func (g *ServerGenerator) generateBindFunctions(sb *strings.Builder, operations []operationInfo) {
+	for _, info := range operations {
+		requestTypeName := info.HandlerName + "Request"
+		bindFuncName := "Bind" + requestTypeName
+
+		sb.WriteString(fmt.Sprintf("// %s parses the parameters and body of the request for %s.\n", bindFuncName, info.HandlerName))
+		sb.WriteString("// Invalid input is reported as an *HTTPError with status 400.\n")
+		sb.WriteString(fmt.Sprintf("func %s(r *http.Request) (%s, error) {\n", bindFuncName, requestTypeName))
+		sb.WriteString(fmt.Sprintf("\treq := %s{}\n\n", requestTypeName))
+		g.generateRequestParsing(sb, info.Method, info.Operation)
+		sb.WriteString("\treturn req, nil\n")
+		sb.WriteString("}\n\n")
+	}
+}
+
+// generateTagInterfaces generates one interface per tag for the given operations
+// when tag interfaces are enabled
+func (g *ServerGenerator) generateTagInterfaces(sb *strings.Builder, operations []operationInfo) {
+	if !g.tagInterfaces {
 		return
 	}
 
-	// Sort paths for deterministic output
-	paths := make([]string, 0, len(g.spec.Paths))
-	for path := range g.spec.Paths {
-		paths = append(paths, path)
+	groups := make(map[string][]operationInfo)
+	tags := make(map[string]string)
+	for _, info := range operations {
+		name := tagInterfaceName(info.Operation)
+		groups[name] = append(groups[name], info)
+		if len(info.Operation.Tags) > 0 {
+			tags[name] = info.Operation.Tags[0]
+		}
 	}
-	sort.Strings(paths)
 
-	// Generate adapter methods for each operation
-	for _, path := range paths {
-		pathItem := g.spec.Paths[path]
-		operations := getOperationsInOrder(pathItem)
+	for _, name := range sortedKeys(groups) {
+		if tag, ok := tags[name]; ok {
+			sb.WriteString(fmt.Sprintf("// %s handles the operations tagged %q\n", name, tag))
+		} else {
+			sb.WriteString(fmt.Sprintf("// %s handles the operations without a tag\n", name))
+		}
+		sb.WriteString(fmt.Sprintf("type %s interface {\n", name))
+		for _, info := range groups[name] {
+			handlerName := info.HandlerName
+			if info.Operation.Summary != "" {
+				sb.WriteString(fmt.Sprintf("\t// %s %s\n", handlerName, info.Operation.Summary))
+			}
+			sb.WriteString(fmt.Sprintf("\t%s(ctx context.Context, req %sRequest) (%sResponse, error)\n", handlerName, handlerName, handlerName))
+		}
+		sb.WriteString("}\n\n")
+	}
+}
 
-		for _, methodOp := range operations {
-			method := methodOp.Method
-			op := methodOp.Operation
+// generateComposedServerInterface generates a Server interface embedding the
+// per-tag interfaces, plus ComposeServer to build a Server from their implementations
+func (g *ServerGenerator) generateComposedServerInterface(sb *strings.Builder, operations []operationInfo) {
+	seen := make(map[string]bool)
+	for _, info := range operations {
+		seen[tagInterfaceName(info.Operation)] = true
+	}
+	names := sortedKeys(seen)
 
-			handlerName := generateHandlerName(method, path, op.OperationID)
-			g.generateAdapterMethod(sb, handlerName, path, op)
-		}
+	sb.WriteString("// Server represents all server handlers\n")
+	sb.WriteString("type Server interface {\n")
+	for _, name := range names {
+		sb.WriteString(fmt.Sprintf("\t%s\n", name))
 	}
+	sb.WriteString("}\n\n")
 
-	// Generate error handler
-	sb.WriteString("// handleError handles errors and writes appropriate HTTP responses\n")
-	sb.WriteString("func (w *ServerWrapper) handleError(rw http.ResponseWriter, err error) {\n")
-	sb.WriteString("\tvar httpErr *HTTPError\n")
-	sb.WriteString("\tif errors.As(err, &httpErr) {\n")
-	sb.WriteString("\t\tWriteError(rw, httpErr.Code, httpErr)\n")
-	sb.WriteString("\t\treturn\n")
-	sb.WriteString("\t}\n")
-	sb.WriteString("\t// Default to 500 Internal Server Error\n")
-	sb.WriteString("\tWriteError(rw, http.StatusInternalServerError, err)\n")
+	params := make([]string, len(names))
+	args := make([]string, len(names))
+	for i, name := range names {
+		args[i] = strings.ToLower(name[:1]) + name[1:]
+		params[i] = args[i] + " " + name
+	}
+
+	sb.WriteString("// ComposeServer combines per-tag implementations into a single Server\n")
+	sb.WriteString(fmt.Sprintf("func ComposeServer(%s) Server {\n", strings.Join(params, ", ")))
+	sb.WriteString("\treturn struct {\n")
+	for _, name := range names {
+		sb.WriteString(fmt.Sprintf("\t\t%s\n", name))
+	}
+	sb.WriteString(fmt.Sprintf("\t}{%s}\n", strings.Join(args, ", ")))
+	sb.WriteString("}\n\n")
+}
+
+// generateHandlerWrapper generates the HTTP handler wrapper and its options
+func (g *ServerGenerator) generateHandlerWrapper(sb *strings.Builder) {
+	sb.WriteString("// ServerHooks holds optional callbacks invoked around every operation.\n")
+	sb.WriteString("// Use them for cross-cutting concerns such as metrics and auditing.\n")
+	sb.WriteString("type ServerHooks struct {\n")
+	sb.WriteString("\t// OnRequest is called before the request is parsed and the handler runs\n")
+	sb.WriteString("\tOnRequest func(ctx context.Context, operationID string, r *http.Request)\n")
+	sb.WriteString("\t// OnResponse is called after the response has been written, with status 500\n")
+	sb.WriteString("\t// for handlers that panicked before writing a header\n")
+	sb.WriteString("\tOnResponse func(ctx context.Context, operationID string, status int, duration time.Duration)\n")
+	sb.WriteString("\t// OnError is called when request parsing or the handler fails\n")
+	sb.WriteString("\tOnError func(ctx context.Context, operationID string, err error)\n")
+	sb.WriteString("}\n\n")
+
+	sb.WriteString("// ServerWrapper wraps the Server with HTTP handler logic\n")
+	sb.WriteString("type ServerWrapper struct {\n")
+	fields := [][2]string{
+		{"Handler", "Server"},
+		{"Hooks", "ServerHooks"},
+		{"ErrorMappers", "[]ErrorMapper"},
+		{"Logger", "*slog.Logger"},
+		{"Encoders", "map[string]ResponseEncoder"},
+	}
+	if g.cors {
+		fields = append(fields, [2]string{"CORS", "*CORSPolicy"})
+	}
+	if g.usesIdempotency() {
+		fields = append(fields, [2]string{"IdempotencyStore", "IdempotencyStore"})
+	}
+	writeStructFields(sb, fields)
+	sb.WriteString("}\n\n")
+
+	sb.WriteString("// ErrorMapper translates an error returned by a handler into an HTTP response.\n")
+	sb.WriteString("// It returns ok=false when it does not recognize the error.\n")
+	sb.WriteString("type ErrorMapper func(err error) (status int, body any, ok bool)\n\n")
+
+	sb.WriteString("// ServerOption configures the ServerWrapper created by ConfigureRouter and NewRouter\n")
+	sb.WriteString("type ServerOption func(*ServerWrapper)\n\n")
+
+	sb.WriteString("// WithHooks sets the lifecycle hooks invoked around every operation\n")
+	sb.WriteString("func WithHooks(hooks ServerHooks) ServerOption {\n")
+	sb.WriteString("\treturn func(w *ServerWrapper) {\n")
+	sb.WriteString("\t\tw.Hooks = hooks\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("}\n\n")
+
+	sb.WriteString("// WithLogger sets the logger requests to API routes are logged with, including\n")
+	sb.WriteString("// the operation ID, route pattern, path parameters, status and latency. A nil\n")
+	sb.WriteString("// logger disables request logging.\n")
+	sb.WriteString("func WithLogger(logger *slog.Logger) ServerOption {\n")
+	sb.WriteString("\treturn func(w *ServerWrapper) {\n")
+	sb.WriteString("\t\tw.Logger = logger\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("}\n\n")
+
+	sb.WriteString("// WithErrorMapper registers an ErrorMapper. Mappers are consulted in the order\n")
+	sb.WriteString("// they were registered, before the default HTTPError handling.\n")
+	sb.WriteString("func WithErrorMapper(mapper ErrorMapper) ServerOption {\n")
+	sb.WriteString("\treturn func(w *ServerWrapper) {\n")
+	sb.WriteString("\t\tw.ErrorMappers = append(w.ErrorMappers, mapper)\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("}\n\n")
+
+	sb.WriteString("// WithEncoder registers the encoder for responses of a media type, such as\n")
+	sb.WriteString("// \"application/msgpack\" or \"text/csv\". It takes precedence over the built-in\n")
+	sb.WriteString("// JSON, XML and raw encoding for operations offering that media type.\n")
+	sb.WriteString("func WithEncoder(mediaType string, encoder ResponseEncoder) ServerOption {\n")
+	sb.WriteString("\treturn func(w *ServerWrapper) {\n")
+	sb.WriteString("\t\tif w.Encoders == nil {\n")
+	sb.WriteString("\t\t\tw.Encoders = make(map[string]ResponseEncoder)\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t\tw.Encoders[baseMediaType(mediaType)] = encoder\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("}\n\n")
+
+	sb.WriteString("// NewServerWrapper creates a ServerWrapper for the given Server\n")
+	sb.WriteString("func NewServerWrapper(si Server, opts ...ServerOption) *ServerWrapper {\n")
+	sb.WriteString("\tw := &ServerWrapper{Handler: si}\n")
+	sb.WriteString("\tfor _, opt := range opts {\n")
+	sb.WriteString("\t\topt(w)\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\treturn w\n")
+	sb.WriteString("}\n\n")
+
+	// Generate operation lifecycle helpers
+	sb.WriteString("// statusRecorder wraps http.ResponseWriter to capture the status code, which\n")
+	sb.WriteString("// stays zero until a header or body is written\n")
+	sb.WriteString("type statusRecorder struct {\n")
+	sb.WriteString("\thttp.ResponseWriter\n")
+	sb.WriteString("\tstatus      int\n")
+	sb.WriteString("\twroteHeader bool\n")
+	sb.WriteString("}\n\n")
+	sb.WriteString("func (sr *statusRecorder) WriteHeader(code int) {\n")
+	sb.WriteString("\t// Informational responses precede the final status\n")
+	sb.WriteString("\tif !sr.wroteHeader && code >= 200 {\n")
+	sb.WriteString("\t\tsr.status = code\n")
+	sb.WriteString("\t\tsr.wroteHeader = true\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\tsr.ResponseWriter.WriteHeader(code)\n")
+	sb.WriteString("}\n\n")
+	sb.WriteString("func (sr *statusRecorder) Write(b []byte) (int, error) {\n")
+	sb.WriteString("\tif !sr.wroteHeader {\n")
+	sb.WriteString("\t\tsr.status = http.StatusOK\n")
+	sb.WriteString("\t\tsr.wroteHeader = true\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\treturn sr.ResponseWriter.Write(b)\n")
+	sb.WriteString("}\n\n")
+
+	sb.WriteString("// startOperation runs the OnRequest hook and returns the response writer to use\n")
+	sb.WriteString("// for the operation, plus a function that runs the OnResponse hook and logs the\n")
+	sb.WriteString("// request when done. A panic of the handler is reported as 500 and passed on to\n")
+	sb.WriteString("// recovery middleware.\n")
+	sb.WriteString("func (w *ServerWrapper) startOperation(ctx context.Context, operationID, route string, rw http.ResponseWriter, r *http.Request) (http.ResponseWriter, func()) {\n")
+	sb.WriteString("\tif w.Hooks.OnRequest != nil {\n")
+	sb.WriteString("\t\tw.Hooks.OnRequest(ctx, operationID, r)\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\tif w.Hooks.OnResponse == nil && w.Logger == nil {\n")
+	sb.WriteString("\t\treturn rw, func() {}\n")
+	sb.WriteString("\t}\n\n")
+	sb.WriteString("\tstart := time.Now()\n")
+	sb.WriteString("\tsr := &statusRecorder{ResponseWriter: rw}\n")
+	sb.WriteString("\treturn sr, func() {\n")
+	sb.WriteString("\t\tduration := time.Since(start)\n")
+	sb.WriteString("\t\t// Handlers writing nothing are answered with 200 by net/http, and panicking\n")
+	sb.WriteString("\t\t// ones with 500 by recovery middleware, unless they wrote a header already\n")
+	sb.WriteString("\t\tpanicked := recover()\n")
+	sb.WriteString("\t\tstatus := sr.status\n")
+	sb.WriteString("\t\tif !sr.wroteHeader {\n")
+	sb.WriteString("\t\t\tstatus = http.StatusOK\n")
+	sb.WriteString("\t\t\tif panicked != nil {\n")
+	sb.WriteString("\t\t\t\tstatus = http.StatusInternalServerError\n")
+	sb.WriteString("\t\t\t}\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t\tif w.Hooks.OnResponse != nil {\n")
+	sb.WriteString("\t\t\tw.Hooks.OnResponse(ctx, operationID, status, duration)\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t\tif w.Logger != nil {\n")
+	sb.WriteString("\t\t\tlevel := slog.LevelInfo\n")
+	sb.WriteString("\t\t\tif status >= http.StatusInternalServerError || panicked != nil {\n")
+	sb.WriteString("\t\t\t\tlevel = slog.LevelError\n")
+	sb.WriteString("\t\t\t}\n")
+	sb.WriteString("\t\t\tw.Logger.LogAttrs(ctx, level, \"request\",\n")
+	sb.WriteString("\t\t\t\tslog.String(\"operation_id\", operationID),\n")
+	sb.WriteString("\t\t\t\tslog.String(\"method\", r.Method),\n")
+	sb.WriteString("\t\t\t\tslog.String(\"route\", route),\n")
+	sb.WriteString("\t\t\t\tslog.Any(\"params\", routeParams(r, route)),\n")
+	sb.WriteString("\t\t\t\tslog.Int(\"status\", status),\n")
+	sb.WriteString("\t\t\t\tslog.Duration(\"latency\", duration),\n")
+	sb.WriteString("\t\t\t)\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t\tif panicked != nil {\n")
+	sb.WriteString("\t\t\tpanic(panicked)\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("}\n\n")
+
+	sb.WriteString("// routeParams returns the path parameters matched for a route pattern\n")
+	sb.WriteString("func routeParams(r *http.Request, route string) map[string]string {\n")
+	sb.WriteString("\tparams := make(map[string]string)\n")
+	sb.WriteString("\tfor _, segment := range strings.Split(route, \"/\") {\n")
+	if g.router == RouterChi {
+		sb.WriteString("\t\tif segment == \"*\" {\n")
+		sb.WriteString("\t\t\tparams[segment] = chi.URLParam(r, segment)\n")
+		sb.WriteString("\t\t\tcontinue\n")
+		sb.WriteString("\t\t}\n")
+	}
+	sb.WriteString("\t\tif !strings.HasPrefix(segment, \"{\") || !strings.HasSuffix(segment, \"}\") {\n")
+	sb.WriteString("\t\t\tcontinue\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t\tname := strings.TrimSuffix(segment[1:len(segment)-1], \"...\")\n")
+	sb.WriteString("\t\tif name == \"$\" {\n")
+	sb.WriteString("\t\t\tcontinue\n")
+	sb.WriteString("\t\t}\n")
+	switch g.router {
+	case RouterChi:
+		sb.WriteString("\t\tparams[name] = chi.URLParam(r, name)\n")
+	case RouterStdlib:
+		sb.WriteString("\t\tparams[name] = r.PathValue(name)\n")
+	default:
+		sb.WriteString("\t\tparams[name] = router.URLParam(r, name)\n")
+	}
+	sb.WriteString("\t}\n")
+	sb.WriteString("\treturn params\n")
+	sb.WriteString("}\n\n")
+
+	g.generateCORS(sb)
+	g.generateIdempotency(sb)
+}
+
+// generateIdempotency generates the IdempotencyStore interface and the wrapper
+// logic replaying responses for repeated Idempotency-Key headers
+func (g *ServerGenerator) generateIdempotency(sb *strings.Builder) {
+	if !g.usesIdempotency() {
+		return
+	}
+
+	sb.WriteString("// StoredResponse is a response recorded for an Idempotency-Key. Header holds\n")
+	sb.WriteString("// only the Content-Type and the headers the operation declares, so per-request\n")
+	sb.WriteString("// headers such as Set-Cookie are not replayed.\n")
+	sb.WriteString("type StoredResponse struct {\n")
+	sb.WriteString("\tStatusCode int\n")
+	sb.WriteString("\tHeader     http.Header\n")
+	sb.WriteString("\tBody       []byte\n")
+	sb.WriteString("}\n\n")
+
+	sb.WriteString("// IdempotencyStore records the responses of operations accepting an\n")
+	sb.WriteString("// Idempotency-Key header so repeated requests replay the first response instead\n")
+	sb.WriteString("// of running the handler again. Keys are scoped to the operation ID and the\n")
+	sb.WriteString("// authenticated principal.\n")
+	sb.WriteString("type IdempotencyStore interface {\n")
+	sb.WriteString("\t// Get returns the response recorded for key and whether one exists\n")
+	sb.WriteString("\tGet(ctx context.Context, key string) (StoredResponse, bool, error)\n")
+	sb.WriteString("\t// Reserve marks key as in flight before the handler runs, atomically. It\n")
+	sb.WriteString("\t// returns false if key is already reserved or has a recorded response.\n")
+	sb.WriteString("\tReserve(ctx context.Context, key string) (bool, error)\n")
+	sb.WriteString("\t// Put records the response for a reserved key\n")
+	sb.WriteString("\tPut(ctx context.Context, key string, resp StoredResponse) error\n")
+	sb.WriteString("\t// Release removes the reservation of a key whose request failed, so it can be retried\n")
+	sb.WriteString("\tRelease(ctx context.Context, key string) error\n")
+	sb.WriteString("}\n\n")
+
+	sb.WriteString("// WithIdempotencyStore sets the store consulted for requests with an Idempotency-Key header\n")
+	sb.WriteString("func WithIdempotencyStore(store IdempotencyStore) ServerOption {\n")
+	sb.WriteString("\treturn func(w *ServerWrapper) {\n")
+	sb.WriteString("\t\tw.IdempotencyStore = store\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("}\n\n")
+
+	sb.WriteString("// idempotencyRecorder captures the response written for an Idempotency-Key.\n")
+	sb.WriteString("// status stays zero until the handler writes a header or body.\n")
+	sb.WriteString("type idempotencyRecorder struct {\n")
+	sb.WriteString("\thttp.ResponseWriter\n")
+	sb.WriteString("\tstatus      int\n")
+	sb.WriteString("\twroteHeader bool\n")
+	sb.WriteString("\tbody        bytes.Buffer\n")
+	sb.WriteString("}\n\n")
+	sb.WriteString("func (ir *idempotencyRecorder) WriteHeader(code int) {\n")
+	sb.WriteString("\tif !ir.wroteHeader && code >= 200 {\n")
+	sb.WriteString("\t\tir.status = code\n")
+	sb.WriteString("\t\tir.wroteHeader = true\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\tir.ResponseWriter.WriteHeader(code)\n")
+	sb.WriteString("}\n\n")
+	sb.WriteString("func (ir *idempotencyRecorder) Write(b []byte) (int, error) {\n")
+	sb.WriteString("\tif !ir.wroteHeader {\n")
+	sb.WriteString("\t\tir.status = http.StatusOK\n")
+	sb.WriteString("\t\tir.wroteHeader = true\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\tir.body.Write(b)\n")
+	sb.WriteString("\treturn ir.ResponseWriter.Write(b)\n")
+	sb.WriteString("}\n\n")
+
+	scoped := g.hasSecuritySchemes()
+	sb.WriteString("// startIdempotent replays the response recorded for a repeated Idempotency-Key,\n")
+	sb.WriteString("// reporting whether a response was written. Otherwise it reserves the key and\n")
+	sb.WriteString("// returns the response writer to use for the operation and a function recording\n")
+	sb.WriteString("// the response when done. Only successful responses are recorded, so failed\n")
+	sb.WriteString("// requests, panicking handlers and handlers writing nothing release the key\n")
+	sb.WriteString("// and can be retried. A repeated key whose first request is still running\n")
+	sb.WriteString("// is answered with 409 Conflict. headers are the response headers the operation\n")
+	sb.WriteString("// declares, which are recorded along with the Content-Type.\n")
+	if scoped {
+		sb.WriteString("// Keys of authenticated requests are scoped to the principal, so requests of\n")
+		sb.WriteString("// principals without an identifier (see PrincipalIdentifier) are not replayed.\n")
+	}
+	sb.WriteString("func (w *ServerWrapper) startIdempotent(ctx context.Context, operationID string, rw http.ResponseWriter, key string, headers []string) (http.ResponseWriter, bool, func()) {\n")
+	sb.WriteString("\tif w.IdempotencyStore == nil || key == \"\" {\n")
+	sb.WriteString("\t\treturn rw, false, func() {}\n")
+	sb.WriteString("\t}\n")
+	if scoped {
+		sb.WriteString("\tscope := \"\"\n")
+		sb.WriteString("\tif secCtx := GetSecurityContext(ctx); secCtx != nil {\n")
+		sb.WriteString("\t\tif scope = principalID(secCtx); scope == \"\" {\n")
+		sb.WriteString("\t\t\treturn rw, false, func() {}\n")
+		sb.WriteString("\t\t}\n")
+		sb.WriteString("\t}\n")
+		sb.WriteString("\tstoreKey := operationID + \":\" + scope + \":\" + key\n\n")
+	} else {
+		sb.WriteString("\tstoreKey := operationID + \":\" + key\n\n")
+	}
+	sb.WriteString("\tstored, ok, err := w.IdempotencyStore.Get(ctx, storeKey)\n")
+	sb.WriteString("\tif err != nil {\n")
+	sb.WriteString("\t\tw.handleError(ctx, operationID, rw, err)\n")
+	sb.WriteString("\t\treturn rw, true, func() {}\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\tif ok {\n")
+	sb.WriteString("\t\tfor name, values := range stored.Header {\n")
+	sb.WriteString("\t\t\trw.Header()[name] = values\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t\trw.Header().Set(\"Idempotent-Replayed\", \"true\")\n")
+	sb.WriteString("\t\trw.WriteHeader(stored.StatusCode)\n")
+	sb.WriteString("\t\trw.Write(stored.Body)\n")
+	sb.WriteString("\t\treturn rw, true, func() {}\n")
+	sb.WriteString("\t}\n\n")
+	sb.WriteString("\treserved, err := w.IdempotencyStore.Reserve(ctx, storeKey)\n")
+	sb.WriteString("\tif err != nil {\n")
+	sb.WriteString("\t\tw.handleError(ctx, operationID, rw, err)\n")
+	sb.WriteString("\t\treturn rw, true, func() {}\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\tif !reserved {\n")
+	sb.WriteString("\t\tw.handleError(ctx, operationID, rw, NewHTTPError(http.StatusConflict, \"a request with this Idempotency-Key is in progress\"))\n")
+	sb.WriteString("\t\treturn rw, true, func() {}\n")
+	sb.WriteString("\t}\n\n")
+	sb.WriteString("\tir := &idempotencyRecorder{ResponseWriter: rw}\n")
+	sb.WriteString("\treturn ir, false, func() {\n")
+	sb.WriteString("\t\tif p := recover(); p != nil {\n")
+	sb.WriteString("\t\t\tif err := w.IdempotencyStore.Release(ctx, storeKey); err != nil && w.Hooks.OnError != nil {\n")
+	sb.WriteString("\t\t\t\tw.Hooks.OnError(ctx, operationID, err)\n")
+	sb.WriteString("\t\t\t}\n")
+	sb.WriteString("\t\t\tpanic(p)\n")
+	sb.WriteString("\t\t}\n\n")
+	sb.WriteString("\t\tvar err error\n")
+	sb.WriteString("\t\tif ir.status < 200 || ir.status > 299 {\n")
+	sb.WriteString("\t\t\terr = w.IdempotencyStore.Release(ctx, storeKey)\n")
+	sb.WriteString("\t\t} else {\n")
+	sb.WriteString("\t\t\tresp := StoredResponse{StatusCode: ir.status, Header: make(http.Header), Body: ir.body.Bytes()}\n")
+	sb.WriteString("\t\t\tfor _, name := range append([]string{\"Content-Type\"}, headers...) {\n")
+	sb.WriteString("\t\t\t\tif values := ir.Header().Values(name); len(values) > 0 {\n")
+	sb.WriteString("\t\t\t\t\tresp.Header[http.CanonicalHeaderKey(name)] = slices.Clone(values)\n")
+	sb.WriteString("\t\t\t\t}\n")
+	sb.WriteString("\t\t\t}\n")
+	sb.WriteString("\t\t\terr = w.IdempotencyStore.Put(ctx, storeKey, resp)\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t\tif err != nil && w.Hooks.OnError != nil {\n")
+	sb.WriteString("\t\t\tw.Hooks.OnError(ctx, operationID, err)\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("}\n\n")
+
+}
+
+// usesPrincipalID reports whether the server code identifies principals, to
+// scope idempotency keys or rate limits to them
+func (g *ServerGenerator) usesPrincipalID() bool {
+	return g.hasSecuritySchemes() && ((g.idempotency && !g.thin) || g.usesRateLimit())
+}
+
+// generatePrincipalID generates PrincipalIdentifier and principalID, which
+// scope idempotency keys and rate limits to the authenticated principal
+func (g *ServerGenerator) generatePrincipalID(sb *strings.Builder) {
+	if !g.usesPrincipalID() {
+		return
+	}
+
+	sb.WriteString("// PrincipalIdentifier is implemented by principals with a stable identifier,\n")
+	sb.WriteString("// such as a user ID, which idempotency keys and rate limits are scoped to.\n")
+	sb.WriteString("// Principals that are strings are their own identifier.\n")
+	sb.WriteString("type PrincipalIdentifier interface {\n")
+	sb.WriteString("\tPrincipalID() string\n")
+	sb.WriteString("}\n\n")
+	sb.WriteString("// principalID returns an identifier of the authenticated principal, qualified\n")
+	sb.WriteString("// with its security scheme")
+	if usesTenants(g.spec) {
+		sb.WriteString(" and tenant")
+	}
+	sb.WriteString(", or \"\" if the principal has no identifier\n")
+	sb.WriteString("func principalID(secCtx *SecurityContext) string {\n")
+	sb.WriteString("\tvar id string\n")
+	sb.WriteString("\tswitch p := any(secCtx.Principal).(type) {\n")
+	sb.WriteString("\tcase PrincipalIdentifier:\n")
+	sb.WriteString("\t\tid = p.PrincipalID()\n")
+	sb.WriteString("\tcase string:\n")
+	sb.WriteString("\t\tid = p\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\tif id == \"\" {\n")
+	sb.WriteString("\t\treturn \"\"\n")
+	sb.WriteString("\t}\n")
+	if usesTenants(g.spec) {
+		sb.WriteString("\treturn strconv.Quote(secCtx.Tenant) + \":\" + secCtx.SchemeName + \":\" + strconv.Quote(id)\n")
+	} else {
+		sb.WriteString("\treturn secCtx.SchemeName + \":\" + strconv.Quote(id)\n")
+	}
+	sb.WriteString("}\n\n")
+}
+
+// generateCORS generates the CORS policy, its ServerOption and the handlers
+// answering OPTIONS and preflight requests
+func (g *ServerGenerator) generateCORS(sb *strings.Builder) {
+	if !g.cors {
+		return
+	}
+
+	sb.WriteString("// CORSPolicy configures the CORS headers added to responses and the answers\n")
+	sb.WriteString("// to preflight requests\n")
+	sb.WriteString("type CORSPolicy struct {\n")
+	sb.WriteString("\t// AllowedOrigins lists the origins allowed to make requests; \"*\" allows any origin\n")
+	sb.WriteString("\tAllowedOrigins []string\n")
+	sb.WriteString("\t// AllowedHeaders lists the request headers allowed by preflight responses.\n")
+	sb.WriteString("\t// When empty, the headers requested by the preflight request are allowed.\n")
+	sb.WriteString("\tAllowedHeaders []string\n")
+	sb.WriteString("\t// ExposedHeaders lists the response headers scripts are allowed to read\n")
+	sb.WriteString("\tExposedHeaders []string\n")
+	sb.WriteString("\t// AllowCredentials allows requests that include cookies or authorization headers\n")
+	sb.WriteString("\tAllowCredentials bool\n")
+	sb.WriteString("\t// MaxAge is how long, in seconds, browsers may cache preflight responses\n")
+	sb.WriteString("\tMaxAge int\n")
+	sb.WriteString("}\n\n")
+
+	sb.WriteString("// WithCORS applies the CORS policy to every operation and preflight request\n")
+	sb.WriteString("func WithCORS(policy CORSPolicy) ServerOption {\n")
+	sb.WriteString("\treturn func(w *ServerWrapper) {\n")
+	sb.WriteString("\t\tw.CORS = &policy\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("}\n\n")
+
+	sb.WriteString("// setHeaders adds the CORS headers for the request's origin and reports whether\n")
+	sb.WriteString("// the origin is allowed\n")
+	sb.WriteString("func (p *CORSPolicy) setHeaders(rw http.ResponseWriter, r *http.Request) bool {\n")
+	sb.WriteString("\torigin := r.Header.Get(\"Origin\")\n")
+	sb.WriteString("\tif origin == \"\" {\n")
+	sb.WriteString("\t\treturn false\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\trw.Header().Add(\"Vary\", \"Origin\")\n\n")
+	sb.WriteString("\tallowed, wildcard := false, false\n")
+	sb.WriteString("\tfor _, o := range p.AllowedOrigins {\n")
+	sb.WriteString("\t\tif o == \"*\" {\n")
+	sb.WriteString("\t\t\tallowed, wildcard = true, true\n")
+	sb.WriteString("\t\t} else if o == origin {\n")
+	sb.WriteString("\t\t\tallowed = true\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\tif !allowed {\n")
+	sb.WriteString("\t\treturn false\n")
+	sb.WriteString("\t}\n\n")
+	sb.WriteString("\t// Browsers reject the \"*\" wildcard on requests with credentials\n")
+	sb.WriteString("\tif wildcard && !p.AllowCredentials {\n")
+	sb.WriteString("\t\trw.Header().Set(\"Access-Control-Allow-Origin\", \"*\")\n")
+	sb.WriteString("\t} else {\n")
+	sb.WriteString("\t\trw.Header().Set(\"Access-Control-Allow-Origin\", origin)\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\tif p.AllowCredentials {\n")
+	sb.WriteString("\t\trw.Header().Set(\"Access-Control-Allow-Credentials\", \"true\")\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\tif len(p.ExposedHeaders) > 0 {\n")
+	sb.WriteString("\t\trw.Header().Set(\"Access-Control-Expose-Headers\", strings.Join(p.ExposedHeaders, \", \"))\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\treturn true\n")
+	sb.WriteString("}\n\n")
+
+	sb.WriteString("// withCORS adds the CORS headers of the configured policy to responses of h\n")
+	sb.WriteString("func (w *ServerWrapper) withCORS(h http.HandlerFunc) http.HandlerFunc {\n")
+	sb.WriteString("\tif w.CORS == nil {\n")
+	sb.WriteString("\t\treturn h\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\treturn func(rw http.ResponseWriter, r *http.Request) {\n")
+	sb.WriteString("\t\tw.CORS.setHeaders(rw, r)\n")
+	sb.WriteString("\t\th(rw, r)\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("}\n\n")
+
+	sb.WriteString("// optionsHandler answers OPTIONS requests for a path allowing the given methods.\n")
+	sb.WriteString("// Preflight requests from allowed origins also get the CORS policy's headers.\n")
+	sb.WriteString("func (w *ServerWrapper) optionsHandler(allow string) http.HandlerFunc {\n")
+	sb.WriteString("\treturn func(rw http.ResponseWriter, r *http.Request) {\n")
+	sb.WriteString("\t\trw.Header().Set(\"Allow\", allow)\n")
+	sb.WriteString("\t\tif w.CORS != nil && r.Header.Get(\"Access-Control-Request-Method\") != \"\" && w.CORS.setHeaders(rw, r) {\n")
+	sb.WriteString("\t\t\trw.Header().Set(\"Access-Control-Allow-Methods\", allow)\n")
+	sb.WriteString("\t\t\theaders := strings.Join(w.CORS.AllowedHeaders, \", \")\n")
+	sb.WriteString("\t\t\tif headers == \"\" {\n")
+	sb.WriteString("\t\t\t\theaders = r.Header.Get(\"Access-Control-Request-Headers\")\n")
+	sb.WriteString("\t\t\t}\n")
+	sb.WriteString("\t\t\tif headers != \"\" {\n")
+	sb.WriteString("\t\t\t\trw.Header().Set(\"Access-Control-Allow-Headers\", headers)\n")
+	sb.WriteString("\t\t\t}\n")
+	sb.WriteString("\t\t\tif w.CORS.MaxAge > 0 {\n")
+	sb.WriteString("\t\t\t\trw.Header().Set(\"Access-Control-Max-Age\", strconv.Itoa(w.CORS.MaxAge))\n")
+	sb.WriteString("\t\t\t}\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t\trw.WriteHeader(http.StatusNoContent)\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("}\n\n")
+}
+
+// generateAdapterMethods generates the adapter methods for the given operations
+func (g *ServerGenerator) generateAdapterMethods(sb *strings.Builder, operations []operationInfo) {
+	for _, info := range operations {
+		g.generateAdapterMethod(sb, info.HandlerName, info.Method, info.Path, info.Operation)
+	}
+}
+
+// generateErrorHandler generates the ServerWrapper error handler
+func (g *ServerGenerator) generateErrorHandler(sb *strings.Builder) {
+	sb.WriteString("// handleError handles errors and writes appropriate HTTP responses\n")
+	sb.WriteString("func (w *ServerWrapper) handleError(ctx context.Context, operationID string, rw http.ResponseWriter, err error) {\n")
+	sb.WriteString("\tif w.Hooks.OnError != nil {\n")
+	sb.WriteString("\t\tw.Hooks.OnError(ctx, operationID, err)\n")
+	sb.WriteString("\t}\n\n")
+	sb.WriteString("\tfor _, mapper := range w.ErrorMappers {\n")
+	sb.WriteString("\t\tif status, body, ok := mapper(err); ok {\n")
+	sb.WriteString("\t\t\tif body == nil {\n")
+	sb.WriteString("\t\t\t\trw.WriteHeader(status)\n")
+	sb.WriteString("\t\t\t\treturn\n")
+	sb.WriteString("\t\t\t}\n")
+	sb.WriteString("\t\t\tWriteJSON(rw, status, body)\n")
+	sb.WriteString("\t\t\treturn\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t}\n\n")
+	sb.WriteString("\tvar httpErr *HTTPError\n")
+	sb.WriteString("\tif errors.As(err, &httpErr) {\n")
+	sb.WriteString("\t\tWriteError(rw, httpErr.Code, httpErr)\n")
+	sb.WriteString("\t\treturn\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\t// Default to 500 Internal Server Error\n")
+	sb.WriteString("\tWriteError(rw, http.StatusInternalServerError, err)\n")
 	sb.WriteString("}\n\n")
 }
 
 // generateAdapterMethod generates an adapter method that bridges HTTP to the handler
-func (g *ServerGenerator) generateAdapterMethod(sb *strings.Builder, handlerName, path string, op *openapi.Operation) {
+func (g *ServerGenerator) generateAdapterMethod(sb *strings.Builder, handlerName, method, path string, op *openapi.Operation) {
 	requestTypeName := handlerName + "Request"
 	adapterMethodName := "handle" + handlerName
 
 	sb.WriteString(fmt.Sprintf("// %s adapts HTTP request to %s handler\n", adapterMethodName, handlerName))
 	sb.WriteString(fmt.Sprintf("func (w *ServerWrapper) %s(rw http.ResponseWriter, r *http.Request) {\n", adapterMethodName))
+	sb.WriteString(fmt.Sprintf("\tconst operationID = %q\n", getOperationID(handlerName, op)))
 	sb.WriteString("\tctx := r.Context()\n")
+	sb.WriteString(fmt.Sprintf("\trw, finish := w.startOperation(ctx, operationID, %q, rw, r)\n", g.routePattern(path)))
+	sb.WriteString("\tdefer finish()\n\n")
 	sb.WriteString(fmt.Sprintf("\treq := %s{}\n\n", requestTypeName))
 
+	// Negotiate the response media type when the operation offers more than plain JSON
+	mediaTypes := getResponseMediaTypes(op)
+	negotiate := len(mediaTypes) > 0 && !(len(mediaTypes) == 1 && mediaTypes[0] == "application/json")
+	if negotiate {
+		sb.WriteString("\t// Negotiate response content type\n")
+		sb.WriteString(fmt.Sprintf("\tcontentType := NegotiateContentType(r, %s)\n", formatStringSlice(mediaTypes)))
+		sb.WriteString("\tif contentType == \"\" {\n")
+		sb.WriteString("\t\tw.handleError(ctx, operationID, rw, NewHTTPError(http.StatusNotAcceptable, \"no acceptable representation available\"))\n")
+		sb.WriteString("\t\treturn\n")
+		sb.WriteString("\t}\n\n")
+	}
+
+	g.generateRequestParsing(sb, method, op)
+
+	if g.usesIdempotencyKey(method, op) {
+		sb.WriteString("\t// Replay the recorded response for a repeated Idempotency-Key\n")
+		headers := "nil"
+		if declared := declaredResponseHeaders(op); len(declared) > 0 {
+			headers = formatStringSlice(declared)
+		}
+		sb.WriteString(fmt.Sprintf("\trw, written, saveIdempotent := w.startIdempotent(ctx, operationID, rw, req.IdempotencyKey, %s)\n", headers))
+		sb.WriteString("\tif written {\n")
+		sb.WriteString("\t\treturn\n")
+		sb.WriteString("\t}\n")
+		sb.WriteString("\tdefer saveIdempotent()\n\n")
+	}
+
+	timeout := g.operationTimeout(op)
+	if timeout > 0 {
+		sb.WriteString("\t// Enforce the operation timeout\n")
+		sb.WriteString(fmt.Sprintf("\tctx, cancel := context.WithTimeout(ctx, %s)\n", durationLiteral(timeout)))
+		sb.WriteString("\tdefer cancel()\n\n")
+	}
+
+	// Call the handler
+	if usesInformational(op) {
+		sb.WriteString("\t// Let the handler send informational responses\n")
+		sb.WriteString("\tctx = context.WithValue(ctx, responseWriterKey{}, rw)\n\n")
+	}
+
+	sb.WriteString("\t// Call handler\n")
+	sb.WriteString(fmt.Sprintf("\tresp, err := w.Handler.%s(ctx, req)\n", handlerName))
+	if timeout > 0 {
+		sb.WriteString("\t// Report errors of handlers cut off by the deadline as timeouts; results\n")
+		sb.WriteString("\t// returned after it are still written, as their work is done\n")
+		sb.WriteString("\tif err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {\n")
+		sb.WriteString("\t\terr = WrapHTTPError(http.StatusGatewayTimeout, ctx.Err(), \"operation timed out\")\n")
+		sb.WriteString("\t}\n")
+	}
+	sb.WriteString("\tif err != nil {\n")
+	sb.WriteString("\t\tw.handleError(ctx, operationID, rw, err)\n")
+	sb.WriteString("\t\treturn\n")
+	sb.WriteString("\t}\n\n")
+
+	if usesETag(op) {
+		sb.WriteString("\t// Set the ETag and answer conditional requests\n")
+		sb.WriteString("\tif applyETag(rw, r, resp) {\n")
+		sb.WriteString("\t\treturn\n")
+		sb.WriteString("\t}\n\n")
+	}
+
+	// Write response
+	sb.WriteString("\t// Write response\n")
+	trailers := usesTrailers(op)
+	if trailers {
+		sb.WriteString("\ttrailers := declareTrailers(rw, resp)\n")
+	}
+	if negotiate {
+		sb.WriteString("\tif err := writeResponseWith(rw, resp, contentType, w.Encoders); err != nil {\n")
+		sb.WriteString("\t\tw.handleError(ctx, operationID, rw, err)\n")
+		if trailers {
+			sb.WriteString("\t\treturn\n")
+		}
+		sb.WriteString("\t}\n")
+	} else {
+		sb.WriteString("\tWriteResponse(rw, resp)\n")
+	}
+	if trailers {
+		sb.WriteString("\twriteTrailers(rw, trailers)\n")
+	}
+	sb.WriteString("}\n\n")
+}
+
+// generateRequestParsing generates code that fills req from the parameters,
+// headers and body of the incoming request
+func (g *ServerGenerator) generateRequestParsing(sb *strings.Builder, method string, op *openapi.Operation) {
 	// Parse path parameters
 	if op.Parameters != nil {
 		for _, param := range op.Parameters {
@@ -409,30 +1409,60 @@ func (g *ServerGenerator) generateAdapterMethod(sb *strings.Builder, handlerName
 		}
 	}
 
+	// Read conditional request headers
+	for _, param := range conditionalHeaderParams(op) {
+		sb.WriteString(fmt.Sprintf("\treq.%s = r.Header.Get(%q)\n", toPascalCase(param.Name), http.CanonicalHeaderKey(param.Name)))
+	}
+	if g.usesIdempotencyKey(method, op) {
+		sb.WriteString("\treq.IdempotencyKey = r.Header.Get(\"Idempotency-Key\")\n")
+	}
+	if len(conditionalHeaderParams(op)) > 0 || g.usesIdempotencyKey(method, op) {
+		sb.WriteString("\n")
+	}
+
 	// Parse request body
 	if op.RequestBody != nil {
 		content := op.RequestBody.Content
 		if _, ok := content["application/json"]; ok {
 			sb.WriteString("\t// Parse request body\n")
-			sb.WriteString("\tif err := ReadJSON(r, &req.Body); err != nil {\n")
-			sb.WriteString("\t\tw.handleError(rw, NewHTTPError(http.StatusBadRequest, \"invalid request body\"))\n")
-			sb.WriteString("\t\treturn\n")
+			if limit := g.bodySizeLimit(op); limit > 0 {
+				// Bind functions have no ResponseWriter to tell to close the connection
+				rw := "rw"
+				if g.thin {
+					rw = "nil"
+				}
+				sb.WriteString(fmt.Sprintf("\tr.Body = http.MaxBytesReader(%s, r.Body, %d)\n", rw, limit))
+				sb.WriteString("\tif err := ReadJSON(r, &req.Body); err != nil {\n")
+				g.writeRequestError(sb, "requestBodyError(err)")
+			} else {
+				sb.WriteString("\tif err := ReadJSON(r, &req.Body); err != nil {\n")
+				g.writeRequestError(sb, "NewHTTPError(http.StatusBadRequest, \"invalid request body\")")
+			}
 			sb.WriteString("\t}\n\n")
 		}
 	}
+}
 
-	// Call the handler
-	sb.WriteString("\t// Call handler\n")
-	sb.WriteString(fmt.Sprintf("\tresp, err := w.Handler.%s(ctx, req)\n", handlerName))
-	sb.WriteString("\tif err != nil {\n")
-	sb.WriteString("\t\tw.handleError(rw, err)\n")
+// writeRequestError generates the statements that reject an invalid request
+// with the given error: through the ServerWrapper error handler in adapter
+// methods, or by returning it from the bind function in thin mode
+func (g *ServerGenerator) writeRequestError(sb *strings.Builder, errExpr string) {
+	if g.thin {
+		sb.WriteString("\t\treturn req, " + errExpr + "\n")
+		return
+	}
+	sb.WriteString("\t\tw.handleError(ctx, operationID, rw, " + errExpr + ")\n")
 	sb.WriteString("\t\treturn\n")
-	sb.WriteString("\t}\n\n")
+}
 
-	// Write response
-	sb.WriteString("\t// Write response\n")
-	sb.WriteString("\tWriteResponse(rw, resp)\n")
-	sb.WriteString("}\n\n")
+// urlParamHelpers maps parameter types to the router package functions that
+// read path parameters of that type
+var urlParamHelpers = map[string]string{
+	"int":     "URLParamInt",
+	"int32":   "URLParamInt32",
+	"int64":   "URLParamInt64",
+	"float64": "URLParamFloat64",
+	"bool":    "URLParamBool",
 }
 
 // generateParamParsing generates code to parse a parameter
@@ -440,10 +1470,33 @@ func (g *ServerGenerator) generateParamParsing(sb *strings.Builder, param *opena
 	paramType := g.getParamType(param)
 	paramName := param.Name
 
+	// The built-in router package parses typed path parameters itself
+	if helper, ok := urlParamHelpers[paramType]; ok && isPath && g.router != RouterChi && g.router != RouterStdlib {
+		sb.WriteString(fmt.Sprintf("\t// Parse path parameter: %s\n", paramName))
+		sb.WriteString(fmt.Sprintf("\t%sVal, err := router.%s(r, %q)\n", paramName, helper, paramName))
+		sb.WriteString("\tif err != nil {\n")
+		g.writeRequestError(sb, fmt.Sprintf("NewHTTPError(http.StatusBadRequest, \"invalid %s parameter\")", paramName))
+		sb.WriteString("\t}\n")
+		sb.WriteString(fmt.Sprintf("\treq.%s = %sVal\n\n", fieldName, paramName))
+		return
+	}
+
 	// Get parameter value
 	if isPath {
 		sb.WriteString(fmt.Sprintf("\t// Parse path parameter: %s\n", paramName))
-		sb.WriteString(fmt.Sprintf("\t%sStr := router.URLParam(r, \"%s\")\n", paramName, paramName))
+		switch g.router {
+		case RouterChi:
+			chiName := paramName
+			if isWildcardParam(param) {
+				// chi names the catch-all segment *
+				chiName = "*"
+			}
+			sb.WriteString(fmt.Sprintf("\t%sStr := chi.URLParam(r, \"%s\")\n", paramName, chiName))
+		case RouterStdlib:
+			sb.WriteString(fmt.Sprintf("\t%sStr := r.PathValue(\"%s\")\n", paramName, serveMuxWildcardName(paramName)))
+		default:
+			sb.WriteString(fmt.Sprintf("\t%sStr := router.URLParam(r, \"%s\")\n", paramName, paramName))
+		}
 	} else {
 		sb.WriteString(fmt.Sprintf("\t// Parse query parameter: %s\n", paramName))
 		sb.WriteString(fmt.Sprintf("\t%sStr := r.URL.Query().Get(\"%s\")\n", paramName, paramName))
@@ -472,8 +1525,7 @@ func (g *ServerGenerator) generateParamParsing(sb *strings.Builder, param *opena
 		if param.Required || isPath {
 			sb.WriteString(fmt.Sprintf("\t%sVal, err := strconv.ParseInt(%sStr, 10, %s)\n", paramName, paramName, bitSize))
 			sb.WriteString("\tif err != nil {\n")
-			sb.WriteString(fmt.Sprintf("\t\tw.handleError(rw, NewHTTPError(http.StatusBadRequest, \"invalid %s parameter\"))\n", paramName))
-			sb.WriteString("\t\treturn\n")
+			g.writeRequestError(sb, fmt.Sprintf("NewHTTPError(http.StatusBadRequest, \"invalid %s parameter\")", paramName))
 			sb.WriteString("\t}\n")
 			if baseType == "int" {
 				sb.WriteString(fmt.Sprintf("\treq.%s = int(%sVal)\n", fieldName, paramName))
@@ -491,145 +1543,648 @@ func (g *ServerGenerator) generateParamParsing(sb *strings.Builder, param *opena
 				sb.WriteString(fmt.Sprintf("\t\t\t%sTyped := %s(%sVal)\n", paramName, baseType, paramName))
 				sb.WriteString(fmt.Sprintf("\t\t\treq.%s = &%sTyped\n", fieldName, paramName))
 			}
-			sb.WriteString("\t\t}\n")
-			sb.WriteString("\t}\n")
+			sb.WriteString("\t\t}\n")
+			sb.WriteString("\t}\n")
+		}
+	case "float32", "float64":
+		bitSize := "32"
+		if baseType == "float64" {
+			bitSize = "64"
+		}
+
+		if param.Required || isPath {
+			sb.WriteString(fmt.Sprintf("\t%sVal, err := strconv.ParseFloat(%sStr, %s)\n", paramName, paramName, bitSize))
+			sb.WriteString("\tif err != nil {\n")
+			g.writeRequestError(sb, fmt.Sprintf("NewHTTPError(http.StatusBadRequest, \"invalid %s parameter\")", paramName))
+			sb.WriteString("\t}\n")
+			sb.WriteString(fmt.Sprintf("\treq.%s = %s(%sVal)\n", fieldName, baseType, paramName))
+		} else {
+			sb.WriteString(fmt.Sprintf("\tif %sStr != \"\" {\n", paramName))
+			sb.WriteString(fmt.Sprintf("\t\t%sVal, err := strconv.ParseFloat(%sStr, %s)\n", paramName, paramName, bitSize))
+			sb.WriteString("\t\tif err == nil {\n")
+			sb.WriteString(fmt.Sprintf("\t\t\t%sTyped := %s(%sVal)\n", paramName, baseType, paramName))
+			sb.WriteString(fmt.Sprintf("\t\t\treq.%s = &%sTyped\n", fieldName, paramName))
+			sb.WriteString("\t\t}\n")
+			sb.WriteString("\t}\n")
+		}
+	case "bool":
+		if param.Required || isPath {
+			sb.WriteString(fmt.Sprintf("\t%sVal, err := strconv.ParseBool(%sStr)\n", paramName, paramName))
+			sb.WriteString("\tif err != nil {\n")
+			g.writeRequestError(sb, fmt.Sprintf("NewHTTPError(http.StatusBadRequest, \"invalid %s parameter\")", paramName))
+			sb.WriteString("\t}\n")
+			sb.WriteString(fmt.Sprintf("\treq.%s = %sVal\n", fieldName, paramName))
+		} else {
+			sb.WriteString(fmt.Sprintf("\tif %sStr != \"\" {\n", paramName))
+			sb.WriteString(fmt.Sprintf("\t\t%sVal, err := strconv.ParseBool(%sStr)\n", paramName, paramName))
+			sb.WriteString("\t\tif err == nil {\n")
+			sb.WriteString(fmt.Sprintf("\t\t\treq.%s = &%sVal\n", fieldName, paramName))
+			sb.WriteString("\t\t}\n")
+			sb.WriteString("\t}\n")
+		}
+	}
+
+	sb.WriteString("\n")
+}
+
+// generateRouter generates the router setup functions
+func (g *ServerGenerator) generateRouter(sb *strings.Builder) {
+	hasSecuritySchemes := g.hasSecuritySchemes()
+
+	// Generate security scheme info map if needed
+	if hasSecuritySchemes {
+		g.generateSecuritySchemeInfoMap(sb)
+	}
+
+	sb.WriteString("// BasePath is the path prefix all routes are registered under\n")
+	sb.WriteString(fmt.Sprintf("const BasePath = %q\n\n", g.resolveBasePath()))
+
+	g.generateEmbeddedSpec(sb)
+
+	g.generateDocs(sb)
+	g.generateOperationInfo(sb)
+	g.generateRateLimiting(sb, hasSecuritySchemes)
+	g.generatePrincipalID(sb)
+	g.generateHeadHandler(sb)
+
+	switch g.router {
+	case RouterChi:
+		g.generateChiRouter(sb, hasSecuritySchemes)
+		return
+	case RouterStdlib:
+		g.generateServeMuxRouter(sb, hasSecuritySchemes)
+		return
+	}
+
+	// Generate ConfigureRouter function that works with any router
+	sb.WriteString("// ConfigureRouter configures the given router with all routes.\n")
+	sb.WriteString("// This function allows you to use any router that implements the router.Router interface.\n")
+	sb.WriteString("//\n")
+	sb.WriteString("// The authenticator parameter is optional. If nil, no authentication will be performed.\n")
+	sb.WriteString("// If provided, authentication will be enforced for routes that require it.\n")
+	if !g.thin {
+		sb.WriteString("//\n")
+		sb.WriteString("// Options such as WithHooks configure the ServerWrapper that adapts HTTP requests\n")
+		sb.WriteString("// to the Server methods.\n")
+	}
+	sb.WriteString("//\n")
+	sb.WriteString("// Example with built-in router:\n")
+	sb.WriteString("//\n")
+	sb.WriteString("//\tr := router.NewRouter()\n")
+	sb.WriteString("//\tConfigureRouter(r, myServer, myAuthenticator)\n")
+	sb.WriteString("//\n")
+	sb.WriteString("// Example with custom router:\n")
+	sb.WriteString("//\n")
+	sb.WriteString("//\tr := myCustomRouter.New() // Must implement router.Router interface\n")
+	sb.WriteString("//\tConfigureRouter(r, myServer, myAuthenticator)\n")
+	sb.WriteString(fmt.Sprintf("func ConfigureRouter(r router.Router, %s) {\n", g.configureParams(hasSecuritySchemes)))
+	g.generateWrapperSetup(sb)
+	g.generateRouteRegistrations(sb, hasSecuritySchemes, methodRouteRegistration)
+	sb.WriteString("}\n\n")
+
+	// Generate NewRouter function for convenience (uses built-in router)
+	sb.WriteString("// NewRouter creates a new router with all routes configured using the built-in router.\n")
+	sb.WriteString("// For using a custom router, use ConfigureRouter instead.\n")
+	if !g.thin {
+		sb.WriteString("//\n")
+		sb.WriteString("// Requests are logged with slog.Default() unless WithLogger is passed.\n")
+	}
+	if hasSecuritySchemes {
+		sb.WriteString("//\n")
+		sb.WriteString("// The authenticator parameter is optional. If nil, no authentication will be performed.\n")
+	}
+	sb.WriteString(fmt.Sprintf("func NewRouter(%s) *router.Mux {\n", g.configureParams(hasSecuritySchemes)))
+	sb.WriteString("\tr := router.NewRouter()\n")
+	sb.WriteString("\n")
+	sb.WriteString("\t// Default middleware\n")
+	sb.WriteString("\tr.Use(router.Recoverer)\n")
+	sb.WriteString("\tr.Use(router.RequestID)\n")
+	sb.WriteString("\tr.Use(router.RealIP)\n")
+	sb.WriteString("\n")
+	g.generateDefaultLogger(sb)
+	sb.WriteString(fmt.Sprintf("\tConfigureRouter(r, %s)\n", g.configureArgs(hasSecuritySchemes)))
+	sb.WriteString("\treturn r\n")
+	sb.WriteString("}\n\n")
+}
+
+// generateChiRouter generates router setup functions that register routes on a chi.Router
+func (g *ServerGenerator) generateChiRouter(sb *strings.Builder, hasSecuritySchemes bool) {
+	sb.WriteString("// ConfigureChiRouter configures the given chi router with all routes.\n")
+	sb.WriteString("// Path parameters are read with chi.URLParam, so no adapter is required.\n")
+	sb.WriteString("//\n")
+	sb.WriteString("// The authenticator parameter is optional. If nil, no authentication will be performed.\n")
+	sb.WriteString("// If provided, authentication will be enforced for routes that require it.\n")
+	sb.WriteString("//\n")
+	sb.WriteString("// Example:\n")
+	sb.WriteString("//\n")
+	sb.WriteString("//\tr := chi.NewRouter()\n")
+	sb.WriteString("//\tr.Use(middleware.Recoverer)\n")
+	sb.WriteString("//\tConfigureChiRouter(r, myServer, myAuthenticator)\n")
+	sb.WriteString(fmt.Sprintf("func ConfigureChiRouter(r chi.Router, %s) {\n", g.configureParams(hasSecuritySchemes)))
+	g.generateWrapperSetup(sb)
+	g.generateRouteRegistrations(sb, hasSecuritySchemes, methodRouteRegistration)
+	sb.WriteString("}\n\n")
+
+	sb.WriteString("// NewRouter creates a new chi router with all routes configured.\n")
+	sb.WriteString("// For using an existing chi router, use ConfigureChiRouter instead.\n")
+	if !g.thin {
+		sb.WriteString("//\n")
+		sb.WriteString("// Requests are logged with slog.Default() unless WithLogger is passed.\n")
+	}
+	if hasSecuritySchemes {
+		sb.WriteString("//\n")
+		sb.WriteString("// The authenticator parameter is optional. If nil, no authentication will be performed.\n")
+	}
+	sb.WriteString(fmt.Sprintf("func NewRouter(%s) *chi.Mux {\n", g.configureParams(hasSecuritySchemes)))
+	sb.WriteString("\tr := chi.NewRouter()\n")
+	sb.WriteString("\n")
+	sb.WriteString("\t// Default middleware\n")
+	sb.WriteString("\tr.Use(middleware.Recoverer)\n")
+	sb.WriteString("\tr.Use(middleware.RequestID)\n")
+	sb.WriteString("\tr.Use(middleware.RealIP)\n")
+	sb.WriteString("\n")
+	g.generateDefaultLogger(sb)
+	sb.WriteString(fmt.Sprintf("\tConfigureChiRouter(r, %s)\n", g.configureArgs(hasSecuritySchemes)))
+	sb.WriteString("\treturn r\n")
+	sb.WriteString("}\n\n")
+}
+
+// generateServeMuxRouter generates router setup functions that register routes on
+// a net/http ServeMux using method and wildcard patterns
+func (g *ServerGenerator) generateServeMuxRouter(sb *strings.Builder, hasSecuritySchemes bool) {
+	sb.WriteString("// ConfigureServeMux configures the given ServeMux with all routes.\n")
+	sb.WriteString("// Routes use Go 1.22 patterns such as \"GET /pets/{petId}\" and path parameters\n")
+	sb.WriteString("// are read with r.PathValue, so no third-party router is required.\n")
+	sb.WriteString("//\n")
+	sb.WriteString("// The authenticator parameter is optional. If nil, no authentication will be performed.\n")
+	sb.WriteString("// If provided, authentication will be enforced for routes that require it.\n")
+	sb.WriteString("//\n")
+	sb.WriteString("// Example:\n")
+	sb.WriteString("//\n")
+	sb.WriteString("//\tmux := http.NewServeMux()\n")
+	sb.WriteString("//\tConfigureServeMux(mux, myServer, myAuthenticator)\n")
+	sb.WriteString(fmt.Sprintf("func ConfigureServeMux(mux *http.ServeMux, %s) {\n", g.configureParams(hasSecuritySchemes)))
+	g.generateWrapperSetup(sb)
+	g.generateRouteRegistrations(sb, hasSecuritySchemes, func(method, path, handler string) string {
+		return fmt.Sprintf("mux.HandleFunc(\"%s %s\", %s)", method, convertToServeMuxPath(path), handler)
+	})
+	sb.WriteString("}\n\n")
+
+	sb.WriteString("// NewRouter creates a new ServeMux with all routes configured.\n")
+	sb.WriteString("// For using an existing ServeMux, use ConfigureServeMux instead.\n")
+	if !g.thin {
+		sb.WriteString("//\n")
+		sb.WriteString("// Requests are logged with slog.Default() unless WithLogger is passed.\n")
+	}
+	if hasSecuritySchemes {
+		sb.WriteString("//\n")
+		sb.WriteString("// The authenticator parameter is optional. If nil, no authentication will be performed.\n")
+	}
+	sb.WriteString(fmt.Sprintf("func NewRouter(%s) *http.ServeMux {\n", g.configureParams(hasSecuritySchemes)))
+	sb.WriteString("\tmux := http.NewServeMux()\n")
+	g.generateDefaultLogger(sb)
+	sb.WriteString(fmt.Sprintf("\tConfigureServeMux(mux, %s)\n", g.configureArgs(hasSecuritySchemes)))
+	sb.WriteString("\treturn mux\n")
+	sb.WriteString("}\n\n")
+}
+
+// generateDefaultLogger makes NewRouter log requests with the default slog logger.
+// Options passed by the caller come later, so WithLogger overrides it.
+func (g *ServerGenerator) generateDefaultLogger(sb *strings.Builder) {
+	if g.thin {
+		return
+	}
+	sb.WriteString("\topts = append([]ServerOption{WithLogger(slog.Default())}, opts...)\n")
+}
+
+// configureParams returns the parameters of the generated router setup functions
+func (g *ServerGenerator) configureParams(hasSecuritySchemes bool) string {
+	params := "si Server"
+	if g.thin {
+		params = "si ServerInterface"
+	}
+	if hasSecuritySchemes {
+		params += ", authenticator Authenticator"
+	}
+	if !g.thin {
+		params += ", opts ...ServerOption"
+	}
+	return params
+}
+
+// configureArgs returns the arguments NewRouter passes on to the router setup function
+func (g *ServerGenerator) configureArgs(hasSecuritySchemes bool) string {
+	args := "si"
+	if hasSecuritySchemes {
+		args += ", authenticator"
+	}
+	if !g.thin {
+		args += ", opts..."
+	}
+	return args
+}
+
+// generateWrapperSetup creates the ServerWrapper routes are registered with.
+// In thin mode routes are registered with the ServerInterface methods directly,
+// and a spec without operations, such as one filtered down to none, has no routes.
+func (g *ServerGenerator) generateWrapperSetup(sb *strings.Builder) {
+	if g.thin || len(getOperations(g.spec)) == 0 {
+		return
+	}
+	sb.WriteString("\twrapper := NewServerWrapper(si, opts...)\n")
+	sb.WriteString("\n")
+}
+
+// routePattern returns the pattern a path is registered under on the target router
+func (g *ServerGenerator) routePattern(path string) string {
+	routerPath := g.routerPath(path)
+	if g.router == RouterStdlib {
+		return convertToServeMuxPath(routerPath)
+	}
+	return routerPath
+}
+
+// routerPath returns a path prefixed with the base path, with its wildcard
+// parameter turned into the catch-all segment of the target router
+func (g *ServerGenerator) routerPath(path string) string {
+	routerPath := convertToRouterPath(g.resolveBasePath()+path, pathWildcard(g.spec.Paths[path]))
+	if g.router == RouterChi {
+		return convertToChiPath(routerPath)
+	}
+	return routerPath
+}
+
+// generateRouteRegistrations writes one route registration per operation.
+// The register function renders the registration statement for the target router.
+func (g *ServerGenerator) generateRouteRegistrations(sb *strings.Builder, hasSecuritySchemes bool, register func(method, path, handler string) string) {
+	// Sort paths for deterministic output
+	paths := make([]string, 0, len(g.spec.Paths))
+	for path := range g.spec.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		pathItem := g.spec.Paths[path]
+		routerPath := g.routerPath(path)
+		if g.router != RouterStdlib {
+			// ServeMux patterns cannot constrain wildcards
+			routerPath = constrainPath(routerPath, pathConstraints(pathItem))
+		}
+		operations := getOperationsInOrder(pathItem)
+
+		var allowed []string
+		for _, methodOp := range operations {
+			method := methodOp.Method
+			op := methodOp.Operation
+
+			handlerName := generateHandlerName(method, path, op.OperationID)
+			handler := "wrapper.handle" + handlerName
+			if g.thin {
+				handler = "si." + handlerName
+			}
+
+			// Limit requests after authentication so they are counted per principal
+			if limit, period, _ := operationRateLimit(op); limit > 0 {
+				handler = fmt.Sprintf("rateLimit(%d, %s, %s)", limit, durationLiteral(period), handler)
+			}
+
+			// Check if this operation has security requirements
+			if hasSecuritySchemes && g.hasSecurityRequirements(op) {
+				// Wrap handler with auth middleware
+				handler = fmt.Sprintf("authMiddleware(authenticator, %s, securitySchemeInfoMap)(http.HandlerFunc(%s)).ServeHTTP",
+					g.generateSecurityRequirementsLiteral(op), handler)
+			}
+			// Expose the operation to the auth middleware and everything after it
+			handler = fmt.Sprintf("withOperation(operationInfos[%q], %s)", getOperationID(handlerName, op), handler)
+			if g.cors {
+				handler = "wrapper.withCORS(" + handler + ")"
+			}
+			sb.WriteString("\t" + register(method, routerPath, handler) + "\n")
+			allowed = append(allowed, method)
+
+			if method == http.MethodGet && g.needsHeadRoute(pathItem) {
+				sb.WriteString("\t" + register(http.MethodHead, routerPath, "headHandler("+handler+")") + "\n")
+				allowed = append(allowed, http.MethodHead)
+			}
 		}
-	case "float32", "float64":
-		bitSize := "32"
-		if baseType == "float64" {
-			bitSize = "64"
+
+		// Answer OPTIONS and CORS preflight requests unless the spec defines OPTIONS
+		if g.cors && pathItem.Options == nil && len(allowed) > 0 {
+			allow := strings.Join(append(allowed, http.MethodOptions), ", ")
+			sb.WriteString("\t" + register(http.MethodOptions, routerPath, fmt.Sprintf("wrapper.optionsHandler(%q)", allow)) + "\n")
 		}
+	}
 
-		if param.Required || isPath {
-			sb.WriteString(fmt.Sprintf("\t%sVal, err := strconv.ParseFloat(%sStr, %s)\n", paramName, paramName, bitSize))
-			sb.WriteString("\tif err != nil {\n")
-			sb.WriteString(fmt.Sprintf("\t\tw.handleError(rw, NewHTTPError(http.StatusBadRequest, \"invalid %s parameter\"))\n", paramName))
-			sb.WriteString("\t\treturn\n")
-			sb.WriteString("\t}\n")
-			sb.WriteString(fmt.Sprintf("\treq.%s = %s(%sVal)\n", fieldName, baseType, paramName))
-		} else {
-			sb.WriteString(fmt.Sprintf("\tif %sStr != \"\" {\n", paramName))
-			sb.WriteString(fmt.Sprintf("\t\t%sVal, err := strconv.ParseFloat(%sStr, %s)\n", paramName, paramName, bitSize))
-			sb.WriteString("\t\tif err == nil {\n")
-			sb.WriteString(fmt.Sprintf("\t\t\t%sTyped := %s(%sVal)\n", paramName, baseType, paramName))
-			sb.WriteString(fmt.Sprintf("\t\t\treq.%s = &%sTyped\n", fieldName, paramName))
-			sb.WriteString("\t\t}\n")
-			sb.WriteString("\t}\n")
+	// Serve the embedded spec unless the spec defines these paths itself
+	if g.embedSpec {
+		specRoutes := []struct{ path, handler string }{
+			{"/openapi.json", "serveSpec(\"application/json\", OpenAPIJSON)"},
+			{"/openapi.yaml", "serveSpec(\"application/yaml\", OpenAPIYAML)"},
 		}
-	case "bool":
-		if param.Required || isPath {
-			sb.WriteString(fmt.Sprintf("\t%sVal, err := strconv.ParseBool(%sStr)\n", paramName, paramName))
-			sb.WriteString("\tif err != nil {\n")
-			sb.WriteString(fmt.Sprintf("\t\tw.handleError(rw, NewHTTPError(http.StatusBadRequest, \"invalid %s parameter\"))\n", paramName))
-			sb.WriteString("\t\treturn\n")
-			sb.WriteString("\t}\n")
-			sb.WriteString(fmt.Sprintf("\treq.%s = %sVal\n", fieldName, paramName))
-		} else {
-			sb.WriteString(fmt.Sprintf("\tif %sStr != \"\" {\n", paramName))
-			sb.WriteString(fmt.Sprintf("\t\t%sVal, err := strconv.ParseBool(%sStr)\n", paramName, paramName))
-			sb.WriteString("\t\tif err == nil {\n")
-			sb.WriteString(fmt.Sprintf("\t\t\treq.%s = &%sVal\n", fieldName, paramName))
-			sb.WriteString("\t\t}\n")
-			sb.WriteString("\t}\n")
+		for _, route := range specRoutes {
+			if _, exists := g.spec.Paths[route.path]; exists {
+				continue
+			}
+			routerPath := g.routerPath(route.path)
+			sb.WriteString("\t" + register(http.MethodGet, routerPath, route.handler) + "\n")
 		}
 	}
-
-	sb.WriteString("\n")
 }
 
-// generateRouter generates the router setup functions
-func (g *ServerGenerator) generateRouter(sb *strings.Builder) {
-	hasSecuritySchemes := g.spec.Components != nil && g.spec.Components.SecuritySchemes != nil && len(g.spec.Components.SecuritySchemes) > 0
-
-	// Generate security scheme info map if needed
-	if hasSecuritySchemes {
-		g.generateSecuritySchemeInfoMap(sb)
+// generateEmbeddedSpec generates the embedded spec variables and the handler serving them
+func (g *ServerGenerator) generateEmbeddedSpec(sb *strings.Builder) {
+	if !g.embedSpec {
+		return
 	}
 
-	// Generate ConfigureRouter function that works with any router
-	sb.WriteString("// ConfigureRouter configures the given router with all routes.\n")
-	sb.WriteString("// This function allows you to use any router that implements the router.Router interface.\n")
+	sb.WriteString("// OpenAPIJSON is the OpenAPI specification this package was generated from, as JSON\n")
 	sb.WriteString("//\n")
-	sb.WriteString("// The authenticator parameter is optional. If nil, no authentication will be performed.\n")
-	sb.WriteString("// If provided, authentication will be enforced for routes that require it.\n")
-	sb.WriteString("//\n")
-	sb.WriteString("// Example with built-in router:\n")
+	sb.WriteString("//go:embed openapi.json\n")
+	sb.WriteString("var OpenAPIJSON []byte\n\n")
+	sb.WriteString("// OpenAPIYAML is the OpenAPI specification this package was generated from, as YAML\n")
 	sb.WriteString("//\n")
-	sb.WriteString("//\tr := router.NewRouter()\n")
-	sb.WriteString("//\tConfigureRouter(r, myServer, myAuthenticator)\n")
+	sb.WriteString("//go:embed openapi.yaml\n")
+	sb.WriteString("var OpenAPIYAML []byte\n\n")
+
+	sb.WriteString("// serveSpec returns a handler that writes an embedded specification document\n")
+	sb.WriteString("func serveSpec(contentType string, data []byte) http.HandlerFunc {\n")
+	sb.WriteString("\treturn func(w http.ResponseWriter, r *http.Request) {\n")
+	sb.WriteString("\t\tw.Header().Set(\"Content-Type\", contentType)\n")
+	sb.WriteString("\t\tw.Write(data)\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("}\n\n")
+}
+
+// generateDocs generates MountDocs, which serves Swagger UI and Redoc pages
+// backed by the embedded spec
+func (g *ServerGenerator) generateDocs(sb *strings.Builder) {
+	if !g.docs {
+		return
+	}
+
+	title := "API Documentation"
+	if g.spec.Info != nil && g.spec.Info.Title != "" {
+		title = g.spec.Info.Title
+	}
+	// The title is baked into format strings written as raw string literals
+	title = strings.NewReplacer("%", "%%", "`", "&#96;").Replace(html.EscapeString(title))
+
+	sb.WriteString("// MountDocs serves interactive API documentation under prefix: Swagger UI at\n")
+	sb.WriteString("// prefix, Redoc at prefix + \"/redoc\" and the spec they render at\n")
+	sb.WriteString("// prefix + \"/openapi.json\". The pages load their scripts from a public CDN.\n")
 	sb.WriteString("//\n")
-	sb.WriteString("// Example with custom router:\n")
+	sb.WriteString("// Only mount the documentation in environments where it should be exposed, e.g.:\n")
 	sb.WriteString("//\n")
-	sb.WriteString("//\tr := myCustomRouter.New() // Must implement router.Router interface\n")
-	sb.WriteString("//\tConfigureRouter(r, myServer, myAuthenticator)\n")
-	if hasSecuritySchemes {
-		sb.WriteString("func ConfigureRouter(r router.Router, si Server, authenticator Authenticator) {\n")
-	} else {
-		sb.WriteString("func ConfigureRouter(r router.Router, si Server) {\n")
+	sb.WriteString("//\tif os.Getenv(\"ENABLE_DOCS\") == \"true\" {\n")
+	sb.WriteString("//\t\tMountDocs(r, \"/docs\")\n")
+	sb.WriteString("//\t}\n")
+	index := "\"/\""
+	switch g.router {
+	case RouterChi:
+		sb.WriteString("func MountDocs(r chi.Router, prefix string) {\n")
+	case RouterStdlib:
+		sb.WriteString("func MountDocs(mux *http.ServeMux, prefix string) {\n")
+		index = "\"/{$}\""
+	default:
+		sb.WriteString("func MountDocs(r router.Router, prefix string) {\n")
 	}
-	sb.WriteString("\twrapper := &ServerWrapper{Handler: si}\n")
+	sb.WriteString("\tprefix = strings.TrimSuffix(prefix, \"/\")\n")
+	sb.WriteString("\tspecURL := prefix + \"/openapi.json\"\n")
+	sb.WriteString("\tindex := prefix\n")
+	sb.WriteString("\tif index == \"\" {\n")
+	sb.WriteString(fmt.Sprintf("\t\tindex = %s\n", index))
+	sb.WriteString("\t}\n")
 	sb.WriteString("\n")
+	if g.router == RouterStdlib {
+		sb.WriteString("\tmux.HandleFunc(\"GET \"+index, serveDocsPage(swaggerUIPage, specURL))\n")
+		sb.WriteString("\tmux.HandleFunc(\"GET \"+prefix+\"/redoc\", serveDocsPage(redocPage, specURL))\n")
+		sb.WriteString("\tmux.HandleFunc(\"GET \"+specURL, serveSpec(\"application/json\", OpenAPIJSON))\n")
+	} else {
+		sb.WriteString("\tr.Get(index, serveDocsPage(swaggerUIPage, specURL))\n")
+		sb.WriteString("\tr.Get(prefix+\"/redoc\", serveDocsPage(redocPage, specURL))\n")
+		sb.WriteString("\tr.Get(specURL, serveSpec(\"application/json\", OpenAPIJSON))\n")
+	}
+	sb.WriteString("}\n\n")
+
+	sb.WriteString("// serveDocsPage returns a handler that writes a documentation page rendering the spec at specURL\n")
+	sb.WriteString("func serveDocsPage(page, specURL string) http.HandlerFunc {\n")
+	sb.WriteString("\tbody := fmt.Sprintf(page, html.EscapeString(specURL))\n")
+	sb.WriteString("\treturn func(w http.ResponseWriter, r *http.Request) {\n")
+	sb.WriteString("\t\tw.Header().Set(\"Content-Type\", \"text/html; charset=utf-8\")\n")
+	sb.WriteString("\t\tio.WriteString(w, body)\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("}\n\n")
 
-	if g.spec.Paths != nil {
-		// Sort paths for deterministic output
-		paths := make([]string, 0, len(g.spec.Paths))
-		for path := range g.spec.Paths {
-			paths = append(paths, path)
+	sb.WriteString("// swaggerUIPage renders the spec with Swagger UI\n")
+	sb.WriteString("const swaggerUIPage = `<!DOCTYPE html>\n")
+	sb.WriteString("<html>\n")
+	sb.WriteString("<head>\n")
+	sb.WriteString("<meta charset=\"utf-8\">\n")
+	sb.WriteString(fmt.Sprintf("<title>%s</title>\n", title))
+	sb.WriteString("<link rel=\"stylesheet\" href=\"https://cdn.jsdelivr.net/npm/swagger-ui-dist@5/swagger-ui.css\">\n")
+	sb.WriteString("</head>\n")
+	sb.WriteString("<body>\n")
+	sb.WriteString("<div id=\"swagger-ui\" data-spec-url=\"%s\"></div>\n")
+	sb.WriteString("<script src=\"https://cdn.jsdelivr.net/npm/swagger-ui-dist@5/swagger-ui-bundle.js\"></script>\n")
+	sb.WriteString("<script>\n")
+	sb.WriteString("SwaggerUIBundle({url: document.getElementById(\"swagger-ui\").dataset.specUrl, dom_id: \"#swagger-ui\"});\n")
+	sb.WriteString("</script>\n")
+	sb.WriteString("</body>\n")
+	sb.WriteString("</html>\n")
+	sb.WriteString("`\n\n")
+
+	sb.WriteString("// redocPage renders the spec with Redoc\n")
+	sb.WriteString("const redocPage = `<!DOCTYPE html>\n")
+	sb.WriteString("<html>\n")
+	sb.WriteString("<head>\n")
+	sb.WriteString("<meta charset=\"utf-8\">\n")
+	sb.WriteString(fmt.Sprintf("<title>%s</title>\n", title))
+	sb.WriteString("</head>\n")
+	sb.WriteString("<body>\n")
+	sb.WriteString("<redoc spec-url=\"%s\"></redoc>\n")
+	sb.WriteString("<script src=\"https://cdn.jsdelivr.net/npm/redoc@2/bundles/redoc.standalone.js\"></script>\n")
+	sb.WriteString("</body>\n")
+	sb.WriteString("</html>\n")
+	sb.WriteString("`\n\n")
+}
+
+// generateOperationInfo generates OperationInfo, the table describing every
+// operation and the helpers that put it in the request context
+func (g *ServerGenerator) generateOperationInfo(sb *strings.Builder) {
+	sb.WriteString("// OperationInfo describes the operation a request was routed to\n")
+	sb.WriteString("type OperationInfo struct {\n")
+	sb.WriteString("\t// ID is the operationId, or the handler name when the spec has none\n")
+	sb.WriteString("\tID     string\n")
+	sb.WriteString("\tMethod string\n")
+	sb.WriteString("\t// Route is the route pattern, e.g. /pets/{petId}\n")
+	sb.WriteString("\tRoute string\n")
+	sb.WriteString("\tTags  []string\n")
+	sb.WriteString("\t// Scopes lists the scopes named by the operation's security requirements\n")
+	sb.WriteString("\tScopes []string\n")
+	sb.WriteString("\t// Roles lists the x-roles of the operation, one of which the principal must hold\n")
+	sb.WriteString("\tRoles []string\n")
+	sb.WriteString("\t// Permissions lists the x-permissions of the operation, all of which the\n")
+	sb.WriteString("\t// principal must hold\n")
+	sb.WriteString("\tPermissions []string\n")
+	sb.WriteString("}\n\n")
+
+	sb.WriteString("// operationInfos describes every operation, keyed by operation ID\n")
+	sb.WriteString("var operationInfos = map[string]*OperationInfo{\n")
+	for _, info := range getOperations(g.spec) {
+		op := info.Operation
+		fields := [][2]string{
+			{"ID:", strconv.Quote(getOperationID(info.HandlerName, op))},
+			{"Method:", strconv.Quote(info.Method)},
+			{"Route:", strconv.Quote(g.routePattern(info.Path))},
+		}
+		if len(op.Tags) > 0 {
+			fields = append(fields, [2]string{"Tags:", formatStringSlice(op.Tags)})
+		}
+		if scopes := g.operationScopes(op); len(scopes) > 0 {
+			fields = append(fields, [2]string{"Scopes:", formatStringSlice(scopes)})
+		}
+		if roles := extensionStrings(op, "x-roles"); len(roles) > 0 {
+			fields = append(fields, [2]string{"Roles:", formatStringSlice(roles)})
+		}
+		if permissions := extensionStrings(op, "x-permissions"); len(permissions) > 0 {
+			fields = append(fields, [2]string{"Permissions:", formatStringSlice(permissions)})
 		}
-		sort.Strings(paths)
 
-		for _, path := range paths {
-			pathItem := g.spec.Paths[path]
-			routerPath := convertToRouterPath(path)
-			operations := getOperationsInOrder(pathItem)
+		// Align the values as gofmt does
+		width := 0
+		for _, field := range fields {
+			width = max(width, len(field[0]))
+		}
+		sb.WriteString(fmt.Sprintf("\t%q: {\n", getOperationID(info.HandlerName, op)))
+		for _, field := range fields {
+			sb.WriteString(fmt.Sprintf("\t\t%-*s %s,\n", width, field[0], field[1]))
+		}
+		sb.WriteString("\t},\n")
+	}
+	sb.WriteString("}\n\n")
 
-			for _, methodOp := range operations {
-				method := methodOp.Method
-				op := methodOp.Operation
+	sb.WriteString("type operationInfoKey struct{}\n\n")
 
-				handlerName := generateHandlerName(method, path, op.OperationID)
-				adapterMethodName := "handle" + handlerName
+	sb.WriteString("// GetOperationInfo returns the operation a request was routed to, or nil when\n")
+	sb.WriteString("// the request did not go through a generated route. It is available to\n")
+	sb.WriteString("// authenticators and handlers.\n")
+	sb.WriteString("func GetOperationInfo(ctx context.Context) *OperationInfo {\n")
+	sb.WriteString("\tinfo, _ := ctx.Value(operationInfoKey{}).(*OperationInfo)\n")
+	sb.WriteString("\treturn info\n")
+	sb.WriteString("}\n\n")
 
-				// Check if this operation has security requirements
-				if hasSecuritySchemes && g.hasSecurityRequirements(op) {
-					// Wrap handler with auth middleware
-					sb.WriteString(fmt.Sprintf("\tr.%s(\"%s\", authMiddleware(authenticator, %s, securitySchemeInfoMap)(http.HandlerFunc(wrapper.%s)).ServeHTTP)\n",
-						getRouterMethodName(method), routerPath, g.generateSecurityRequirementsLiteral(op), adapterMethodName))
-				} else {
-					// No auth required
-					sb.WriteString(fmt.Sprintf("\tr.%s(\"%s\", wrapper.%s)\n",
-						getRouterMethodName(method), routerPath, adapterMethodName))
+	sb.WriteString("// withOperation adds the operation to the request context\n")
+	sb.WriteString("func withOperation(info *OperationInfo, h http.HandlerFunc) http.HandlerFunc {\n")
+	sb.WriteString("\treturn func(w http.ResponseWriter, r *http.Request) {\n")
+	sb.WriteString("\t\th(w, r.WithContext(context.WithValue(r.Context(), operationInfoKey{}, info)))\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("}\n\n")
+}
+
+// operationScopes returns the scopes named by an operation's effective
+// security requirements, without duplicates
+func (g *ServerGenerator) operationScopes(op *openapi.Operation) []string {
+	securityReqs := op.Security
+	if securityReqs == nil {
+		securityReqs = g.spec.Security
+	}
+
+	var scopes []string
+	seen := make(map[string]bool)
+	for _, req := range securityReqs {
+		for _, schemeName := range sortedKeys(req) {
+			for _, scope := range req[schemeName] {
+				if !seen[scope] {
+					seen[scope] = true
+					scopes = append(scopes, scope)
 				}
 			}
 		}
 	}
+	return scopes
+}
+
+// generateRateLimiting generates the rate limiter wrapping operations that
+// declare an x-rate-limit extension
+func (g *ServerGenerator) generateRateLimiting(sb *strings.Builder, hasSecuritySchemes bool) {
+	if !g.usesRateLimit() {
+		return
+	}
 
+	sb.WriteString("// rateLimit limits each client to limit requests per period with the token bucket\n")
+	sb.WriteString("// of router.RateLimit, refilled at limit tokens per period and holding up to limit\n")
+	sb.WriteString("// tokens. Requests over the limit are answered with 429 Too Many Requests and a\n")
+	sb.WriteString("// Retry-After header.\n")
+	sb.WriteString("func rateLimit(limit int, period time.Duration, next http.HandlerFunc) http.HandlerFunc {\n")
+	sb.WriteString("\treturn router.RateLimit(float64(limit)/period.Seconds(), limit,\n")
+	sb.WriteString("\t\trouter.WithRateLimitKey(rateLimitKey),\n")
+	sb.WriteString("\t\trouter.WithRateLimitExceeded(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {\n")
+	sb.WriteString("\t\t\tWriteError(w, http.StatusTooManyRequests, errors.New(\"rate limit exceeded\"))\n")
+	sb.WriteString("\t\t})),\n")
+	sb.WriteString("\t)(next).ServeHTTP\n")
 	sb.WriteString("}\n\n")
 
-	// Generate NewRouter function for convenience (uses built-in router)
-	sb.WriteString("// NewRouter creates a new router with all routes configured using the built-in router.\n")
-	sb.WriteString("// For using a custom router, use ConfigureRouter instead.\n")
 	if hasSecuritySchemes {
-		sb.WriteString("//\n")
-		sb.WriteString("// The authenticator parameter is optional. If nil, no authentication will be performed.\n")
-		sb.WriteString("func NewRouter(si Server, authenticator Authenticator) *router.Mux {\n")
+		sb.WriteString("// rateLimitKey identifies the client of a request for rate limiting: the\n")
+		sb.WriteString("// authenticated principal by its PrincipalIdentifier, or else the client IP\n")
+		sb.WriteString("// address, which also counts principals without an identifier\n")
+		sb.WriteString("func rateLimitKey(r *http.Request) string {\n")
+		sb.WriteString("\tif secCtx := GetSecurityContext(r.Context()); secCtx != nil {\n")
+		sb.WriteString("\t\tif id := principalID(secCtx); id != \"\" {\n")
+		sb.WriteString("\t\t\treturn \"principal:\" + id\n")
+		sb.WriteString("\t\t}\n")
+		sb.WriteString("\t}\n")
+		sb.WriteString("\treturn \"ip:\" + clientIP(r)\n")
+		sb.WriteString("}\n\n")
 	} else {
-		sb.WriteString("func NewRouter(si Server) *router.Mux {\n")
+		sb.WriteString("// rateLimitKey identifies the client of a request for rate limiting by its IP address\n")
+		sb.WriteString("func rateLimitKey(r *http.Request) string {\n")
+		sb.WriteString("\treturn \"ip:\" + clientIP(r)\n")
+		sb.WriteString("}\n\n")
 	}
-	sb.WriteString("\tr := router.NewRouter()\n")
-	sb.WriteString("\n")
-	sb.WriteString("\t// Default middleware\n")
-	sb.WriteString("\tr.Use(router.Logger)\n")
-	sb.WriteString("\tr.Use(router.Recoverer)\n")
-	sb.WriteString("\tr.Use(router.RequestID)\n")
-	sb.WriteString("\tr.Use(router.RealIP)\n")
-	sb.WriteString("\n")
-	if hasSecuritySchemes {
-		sb.WriteString("\tConfigureRouter(r, si, authenticator)\n")
-	} else {
-		sb.WriteString("\tConfigureRouter(r, si)\n")
+
+	sb.WriteString("// clientIP returns the IP address of the client of a request, without the port\n")
+	sb.WriteString("func clientIP(r *http.Request) string {\n")
+	sb.WriteString("\tif addrPort, err := netip.ParseAddrPort(r.RemoteAddr); err == nil {\n")
+	sb.WriteString("\t\treturn addrPort.Addr().String()\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\treturn r.RemoteAddr\n")
+	sb.WriteString("}\n\n")
+}
+
+// needsHeadRoute reports whether a HEAD route is derived from the GET operation of the path item
+func (g *ServerGenerator) needsHeadRoute(pathItem *openapi.PathItem) bool {
+	return g.autoHead && pathItem.Get != nil && pathItem.Head == nil
+}
+
+// generateHeadHandler generates headHandler, which serves HEAD requests with a GET handler
+func (g *ServerGenerator) generateHeadHandler(sb *strings.Builder) {
+	needed := false
+	for _, pathItem := range g.spec.Paths {
+		if g.needsHeadRoute(pathItem) {
+			needed = true
+			break
+		}
 	}
-	sb.WriteString("\treturn r\n")
+	if !needed {
+		return
+	}
+
+	sb.WriteString("// headHandler serves HEAD requests with the handler of the matching GET\n")
+	sb.WriteString("// operation. Status code and headers are kept and the body is discarded.\n")
+	sb.WriteString("func headHandler(h http.HandlerFunc) http.HandlerFunc {\n")
+	sb.WriteString("\treturn func(w http.ResponseWriter, r *http.Request) {\n")
+	sb.WriteString("\t\th(headResponseWriter{w}, r)\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("}\n\n")
+
+	sb.WriteString("// headResponseWriter discards the response body written for a HEAD request\n")
+	sb.WriteString("type headResponseWriter struct {\n")
+	sb.WriteString("\thttp.ResponseWriter\n")
+	sb.WriteString("}\n\n")
+	sb.WriteString("func (w headResponseWriter) Write(b []byte) (int, error) {\n")
+	sb.WriteString("\treturn len(b), nil\n")
 	sb.WriteString("}\n\n")
 }
 
@@ -653,15 +2208,32 @@ func (g *ServerGenerator) generateSecuritySchemeInfoMap(sb *strings.Builder) {
 			}
 
 			sb.WriteString(fmt.Sprintf("\t\"%s\": {\n", name))
-			sb.WriteString(fmt.Sprintf("\t\tType:   \"%s\",\n", scheme.Type))
-			if scheme.Scheme != "" {
-				sb.WriteString(fmt.Sprintf("\t\tScheme: \"%s\",\n", scheme.Scheme))
-			}
-			if scheme.In != "" {
-				sb.WriteString(fmt.Sprintf("\t\tIn:     \"%s\",\n", scheme.In))
-			}
-			if scheme.Name != "" {
-				sb.WriteString(fmt.Sprintf("\t\tName:   \"%s\",\n", scheme.Name))
+			tenantIn, tenantName, _ := schemeTenant(scheme)
+			if tenantIn == "" {
+				sb.WriteString(fmt.Sprintf("\t\tType:   \"%s\",\n", scheme.Type))
+				if scheme.Scheme != "" {
+					sb.WriteString(fmt.Sprintf("\t\tScheme: \"%s\",\n", scheme.Scheme))
+				}
+				if scheme.In != "" {
+					sb.WriteString(fmt.Sprintf("\t\tIn:     \"%s\",\n", scheme.In))
+				}
+				if scheme.Name != "" {
+					sb.WriteString(fmt.Sprintf("\t\tName:   \"%s\",\n", scheme.Name))
+				}
+			} else {
+				// Align the values with the longer tenant field names as gofmt does
+				fields := [][2]string{{"Type", scheme.Type}, {"Scheme", scheme.Scheme}, {"In", scheme.In}, {"Name", scheme.Name}, {"TenantIn", tenantIn}, {"TenantName", tenantName}}
+				width := 0
+				for _, field := range fields {
+					if field[1] != "" {
+						width = max(width, len(field[0]))
+					}
+				}
+				for _, field := range fields {
+					if field[1] != "" {
+						sb.WriteString(fmt.Sprintf("\t\t%-*s %q,\n", width+1, field[0]+":", field[1]))
+					}
+				}
 			}
 			sb.WriteString("\t},\n")
 		}
@@ -670,6 +2242,12 @@ func (g *ServerGenerator) generateSecuritySchemeInfoMap(sb *strings.Builder) {
 	sb.WriteString("}\n\n")
 }
 
+// hasSecuritySchemes reports whether the spec defines security schemes, so the
+// generated package has the authentication code
+func (g *ServerGenerator) hasSecuritySchemes() bool {
+	return g.spec.Components != nil && len(g.spec.Components.SecuritySchemes) > 0
+}
+
 // hasSecurityRequirements checks if an operation has security requirements
 func (g *ServerGenerator) hasSecurityRequirements(op *openapi.Operation) bool {
 	// Check operation-level security
@@ -763,30 +2341,378 @@ func (g *ServerGenerator) generateHelpers(sb *strings.Builder) {
 	sb.WriteString("\treturn WriteJSON(w, http.StatusOK, resp)\n")
 	sb.WriteString("}\n\n")
 
-	// Error response helper
-	sb.WriteString("// ErrorResponse represents an error response\n")
-	sb.WriteString("type ErrorResponse struct {\n")
-	sb.WriteString("\tError   string `json:\"error\"`\n")
-	sb.WriteString("\tMessage string `json:\"message,omitempty\"`\n")
+	// Negotiated response writer
+	sb.WriteString("// ResponseEncoder encodes a response body for a media type\n")
+	sb.WriteString("type ResponseEncoder func(w io.Writer, v any) error\n\n")
+
+	sb.WriteString("// WriteResponseAs writes a response encoded as the given content type\n")
+	sb.WriteString("func WriteResponseAs(w http.ResponseWriter, resp any, contentType string) error {\n")
+	sb.WriteString("\treturn writeResponseWith(w, resp, contentType, nil)\n")
+	sb.WriteString("}\n\n")
+
+	sb.WriteString("// writeResponseWith writes a response encoded as the given content type, using\n")
+	sb.WriteString("// the encoder registered for its media type if there is one\n")
+	sb.WriteString("func writeResponseWith(w http.ResponseWriter, resp any, contentType string, encoders map[string]ResponseEncoder) error {\n")
+	sb.WriteString("\tstatusCode := http.StatusOK\n")
+	sb.WriteString("\tbody := resp\n")
+	sb.WriteString("\tif rw, ok := resp.(interface {\n")
+	sb.WriteString("\t\tStatusCode() int\n")
+	sb.WriteString("\t\tResponseBody() any\n")
+	sb.WriteString("\t}); ok {\n")
+	sb.WriteString("\t\tstatusCode = rw.StatusCode()\n")
+	sb.WriteString("\t\tbody = rw.ResponseBody()\n")
+	sb.WriteString("\t}\n\n")
+	sb.WriteString("\t// For 204 No Content or nil body, don't write a body\n")
+	sb.WriteString("\tif statusCode == http.StatusNoContent || body == nil {\n")
+	sb.WriteString("\t\tw.WriteHeader(statusCode)\n")
+	sb.WriteString("\t\treturn nil\n")
+	sb.WriteString("\t}\n\n")
+	sb.WriteString("\t// Encode before writing headers so encoding errors can still be reported\n")
+	sb.WriteString("\tvar buf bytes.Buffer\n")
+	sb.WriteString("\tencode := func(w io.Writer, v any) error {\n")
+	sb.WriteString("\t\treturn encodeBody(w, contentType, v)\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\tif encoder, ok := encoders[baseMediaType(contentType)]; ok {\n")
+	sb.WriteString("\t\tencode = encoder\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\tif err := encode(&buf, body); err != nil {\n")
+	sb.WriteString("\t\treturn err\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\tw.Header().Set(\"Content-Type\", contentType)\n")
+	sb.WriteString("\tw.WriteHeader(statusCode)\n")
+	sb.WriteString("\t_, err := w.Write(buf.Bytes())\n")
+	sb.WriteString("\treturn err\n")
+	sb.WriteString("}\n\n")
+
+	sb.WriteString("// encodeBody encodes v into w according to the media type\n")
+	sb.WriteString("func encodeBody(w io.Writer, contentType string, v any) error {\n")
+	sb.WriteString("\tmediaType := baseMediaType(contentType)\n")
+	sb.WriteString("\tswitch {\n")
+	sb.WriteString("\tcase mediaType == \"application/json\" || strings.HasSuffix(mediaType, \"+json\"):\n")
+	sb.WriteString("\t\treturn json.NewEncoder(w).Encode(v)\n")
+	sb.WriteString("\tcase mediaType == \"application/xml\" || mediaType == \"text/xml\" || strings.HasSuffix(mediaType, \"+xml\"):\n")
+	sb.WriteString("\t\treturn xml.NewEncoder(w).Encode(v)\n")
+	sb.WriteString("\t}\n\n")
+	sb.WriteString("\t// Raw payloads are written as-is for any other media type\n")
+	sb.WriteString("\tswitch b := v.(type) {\n")
+	sb.WriteString("\tcase []byte:\n")
+	sb.WriteString("\t\t_, err := w.Write(b)\n")
+	sb.WriteString("\t\treturn err\n")
+	sb.WriteString("\tcase string:\n")
+	sb.WriteString("\t\t_, err := io.WriteString(w, b)\n")
+	sb.WriteString("\t\treturn err\n")
+	sb.WriteString("\tcase io.Reader:\n")
+	sb.WriteString("\t\t_, err := io.Copy(w, b)\n")
+	sb.WriteString("\t\treturn err\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\tif strings.HasPrefix(mediaType, \"text/\") {\n")
+	sb.WriteString("\t\t_, err := fmt.Fprint(w, v)\n")
+	sb.WriteString("\t\treturn err\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\treturn fmt.Errorf(\"no encoder for content type %q\", contentType)\n")
+	sb.WriteString("}\n\n")
+
+	sb.WriteString("// baseMediaType returns the lower-cased media type of a content type without parameters\n")
+	sb.WriteString("func baseMediaType(contentType string) string {\n")
+	sb.WriteString("\treturn strings.ToLower(strings.TrimSpace(strings.Split(contentType, \";\")[0]))\n")
+	sb.WriteString("}\n\n")
+
+	// Content negotiation helpers
+	sb.WriteString("// NegotiateContentType selects the best of the offered media types for the\n")
+	sb.WriteString("// request's Accept header. A missing Accept header selects the first offer.\n")
+	sb.WriteString("// Returns an empty string if none of the offered types is acceptable.\n")
+	sb.WriteString("func NegotiateContentType(r *http.Request, offered []string) string {\n")
+	sb.WriteString("\taccept := r.Header.Get(\"Accept\")\n")
+	sb.WriteString("\tif accept == \"\" {\n")
+	sb.WriteString("\t\tif len(offered) > 0 {\n")
+	sb.WriteString("\t\t\treturn offered[0]\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t\treturn \"\"\n")
+	sb.WriteString("\t}\n\n")
+	sb.WriteString("\tranges := parseAccept(accept)\n")
+	sb.WriteString("\tbest, bestQ := \"\", 0.0\n")
+	sb.WriteString("\tfor _, offer := range offered {\n")
+	sb.WriteString("\t\t// The most specific matching range determines the quality of an offer\n")
+	sb.WriteString("\t\tq, specificity := 0.0, -1\n")
+	sb.WriteString("\t\tfor _, ar := range ranges {\n")
+	sb.WriteString("\t\t\tif s := ar.match(offer); s > specificity {\n")
+	sb.WriteString("\t\t\t\tq, specificity = ar.q, s\n")
+	sb.WriteString("\t\t\t}\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t\tif q > bestQ {\n")
+	sb.WriteString("\t\t\tbest, bestQ = offer, q\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\treturn best\n")
+	sb.WriteString("}\n\n")
+
+	sb.WriteString("// acceptRange is a single media range from an Accept header\n")
+	sb.WriteString("type acceptRange struct {\n")
+	sb.WriteString("\tmediaType string\n")
+	sb.WriteString("\tsubType   string\n")
+	sb.WriteString("\tq         float64\n")
+	sb.WriteString("}\n\n")
+
+	sb.WriteString("// match reports how specifically the range matches a media type:\n")
+	sb.WriteString("// 2 for an exact match, 1 for type/*, 0 for */*, and -1 for no match\n")
+	sb.WriteString("func (ar acceptRange) match(offer string) int {\n")
+	sb.WriteString("\tmediaType, subType, _ := strings.Cut(strings.ToLower(offer), \"/\")\n")
+	sb.WriteString("\tswitch {\n")
+	sb.WriteString("\tcase ar.mediaType == \"*\" && ar.subType == \"*\":\n")
+	sb.WriteString("\t\treturn 0\n")
+	sb.WriteString("\tcase ar.mediaType == mediaType && ar.subType == \"*\":\n")
+	sb.WriteString("\t\treturn 1\n")
+	sb.WriteString("\tcase ar.mediaType == mediaType && ar.subType == subType:\n")
+	sb.WriteString("\t\treturn 2\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\treturn -1\n")
+	sb.WriteString("}\n\n")
+
+	sb.WriteString("// parseAccept parses an Accept header into media ranges\n")
+	sb.WriteString("func parseAccept(header string) []acceptRange {\n")
+	sb.WriteString("\tvar ranges []acceptRange\n")
+	sb.WriteString("\tfor _, part := range strings.Split(header, \",\") {\n")
+	sb.WriteString("\t\tparams := strings.Split(part, \";\")\n")
+	sb.WriteString("\t\tmediaType, subType, ok := strings.Cut(strings.ToLower(strings.TrimSpace(params[0])), \"/\")\n")
+	sb.WriteString("\t\tif !ok {\n")
+	sb.WriteString("\t\t\tcontinue\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t\tar := acceptRange{mediaType: mediaType, subType: subType, q: 1}\n")
+	sb.WriteString("\t\tfor _, param := range params[1:] {\n")
+	sb.WriteString("\t\t\tkey, value, _ := strings.Cut(strings.TrimSpace(param), \"=\")\n")
+	sb.WriteString("\t\t\tif strings.EqualFold(key, \"q\") {\n")
+	sb.WriteString("\t\t\t\tif q, err := strconv.ParseFloat(value, 64); err == nil {\n")
+	sb.WriteString("\t\t\t\t\tar.q = q\n")
+	sb.WriteString("\t\t\t\t}\n")
+	sb.WriteString("\t\t\t}\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t\tranges = append(ranges, ar)\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\treturn ranges\n")
+	sb.WriteString("}\n\n")
+
+	// Error response helper
+	sb.WriteString("// ErrorResponse represents an error response\n")
+	sb.WriteString("type ErrorResponse struct {\n")
+	sb.WriteString("\tError   string `json:\"error\"`\n")
+	sb.WriteString("\tMessage string `json:\"message,omitempty\"`\n")
+	sb.WriteString("}\n\n")
+
+	sb.WriteString("// WriteError writes an error response\n")
+	sb.WriteString("func WriteError(w http.ResponseWriter, status int, err error) {\n")
+	sb.WriteString("\tWriteJSON(w, status, ErrorResponse{\n")
+	sb.WriteString("\t\tError:   http.StatusText(status),\n")
+	sb.WriteString("\t\tMessage: err.Error(),\n")
+	sb.WriteString("\t})\n")
+	sb.WriteString("}\n\n")
+
+	// Read JSON helper
+	sb.WriteString("// ReadJSON reads and decodes JSON from request body\n")
+	sb.WriteString("func ReadJSON(r *http.Request, v any) error {\n")
+	sb.WriteString("\tdefer r.Body.Close()\n")
+	sb.WriteString("\tbody, err := io.ReadAll(r.Body)\n")
+	sb.WriteString("\tif err != nil {\n")
+	sb.WriteString("\t\treturn err\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\treturn json.Unmarshal(body, v)\n")
+	sb.WriteString("}\n\n")
+
+	if g.usesBodySizeLimit() {
+		sb.WriteString("// requestBodyError converts an error reading a request body into an HTTPError.\n")
+		sb.WriteString("// Bodies exceeding the size limit of their operation are rejected with\n")
+		sb.WriteString("// 413 Request Entity Too Large, wrapping the *http.MaxBytesError.\n")
+		sb.WriteString("func requestBodyError(err error) *HTTPError {\n")
+		sb.WriteString("\tvar maxBytesErr *http.MaxBytesError\n")
+		sb.WriteString("\tif errors.As(err, &maxBytesErr) {\n")
+		sb.WriteString("\t\treturn WrapHTTPError(http.StatusRequestEntityTooLarge, err, \"request body too large\")\n")
+		sb.WriteString("\t}\n")
+		sb.WriteString("\treturn NewHTTPError(http.StatusBadRequest, \"invalid request body\")\n")
+		sb.WriteString("}\n\n")
+	}
+
+	g.generateETagHelpers(sb)
+	g.generateInformationalHelpers(sb)
+	g.generateTrailerHelpers(sb)
+}
+
+// generateTrailersField adds the Trailers field to the response type of a response
+// declaring a Trailer header
+func (g *ServerGenerator) generateTrailersField(sb *strings.Builder, response *openapi.Response) {
+	if !hasResponseHeader(response, "Trailer") {
+		return
+	}
+	sb.WriteString("\t// Trailers are sent after the body. Their names are announced before the body is\n")
+	sb.WriteString("\t// written, so add every trailer first; values may be filled in while it is written.\n")
+	sb.WriteString("\tTrailers http.Header `json:\"-\"`\n")
+}
+
+// generateTrailersMethod generates the ResponseTrailers method of a response declaring a Trailer header
+func (g *ServerGenerator) generateTrailersMethod(sb *strings.Builder, concreteTypeName string, response *openapi.Response) {
+	if !hasResponseHeader(response, "Trailer") {
+		return
+	}
+	sb.WriteString(fmt.Sprintf("func (r %s) ResponseTrailers() http.Header { return r.Trailers }\n", concreteTypeName))
+}
+
+// generateInformationalHelpers generates SendInformational when any operation
+// declares a 1xx response
+func (g *ServerGenerator) generateInformationalHelpers(sb *strings.Builder) {
+	if !g.anyOperation(usesInformational) {
+		return
+	}
+
+	sb.WriteString("// InformationalResponse is a 1xx response, such as 103 Early Hints, sent ahead\n")
+	sb.WriteString("// of the final response\n")
+	sb.WriteString("type InformationalResponse interface {\n")
+	sb.WriteString("\tStatusCode() int\n")
+	sb.WriteString("\tResponseHeaders() http.Header\n")
+	sb.WriteString("}\n\n")
+
+	sb.WriteString("// responseWriterKey is the context key of the ResponseWriter informational\n")
+	sb.WriteString("// responses are written to\n")
+	sb.WriteString("type responseWriterKey struct{}\n\n")
+
+	sb.WriteString("// SendInformational writes an informational response before the handler returns\n")
+	sb.WriteString("// the final one. Call it with the context passed to the handler of an operation\n")
+	sb.WriteString("// declaring the informational status. Its headers are kept for the final response.\n")
+	sb.WriteString("func SendInformational(ctx context.Context, resp InformationalResponse) error {\n")
+	sb.WriteString("\trw, ok := ctx.Value(responseWriterKey{}).(http.ResponseWriter)\n")
+	sb.WriteString("\tif !ok {\n")
+	sb.WriteString("\t\treturn errors.New(\"informational responses are not supported by this operation\")\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\tfor name, values := range resp.ResponseHeaders() {\n")
+	sb.WriteString("\t\trw.Header()[http.CanonicalHeaderKey(name)] = values\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\trw.WriteHeader(resp.StatusCode())\n")
+	sb.WriteString("\treturn nil\n")
+	sb.WriteString("}\n\n")
+}
+
+// generateTrailerHelpers generates the helpers sending the trailers of responses
+// declaring a Trailer header
+func (g *ServerGenerator) generateTrailerHelpers(sb *strings.Builder) {
+	if !g.anyOperation(usesTrailers) {
+		return
+	}
+
+	sb.WriteString("// declareTrailers announces the trailers of a response in the Trailer header,\n")
+	sb.WriteString("// which has to happen before the response header is written\n")
+	sb.WriteString("func declareTrailers(rw http.ResponseWriter, resp any) http.Header {\n")
+	sb.WriteString("\ttr, ok := resp.(interface{ ResponseTrailers() http.Header })\n")
+	sb.WriteString("\tif !ok {\n")
+	sb.WriteString("\t\treturn nil\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\ttrailers := tr.ResponseTrailers()\n")
+	sb.WriteString("\tfor name := range trailers {\n")
+	sb.WriteString("\t\trw.Header().Add(\"Trailer\", name)\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\treturn trailers\n")
+	sb.WriteString("}\n\n")
+
+	sb.WriteString("// writeTrailers sets the trailer values once the body has been written\n")
+	sb.WriteString("func writeTrailers(rw http.ResponseWriter, trailers http.Header) {\n")
+	sb.WriteString("\tfor name, values := range trailers {\n")
+	sb.WriteString("\t\trw.Header()[http.CanonicalHeaderKey(name)] = values\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("}\n\n")
+}
+
+// generateETagField adds the ETag field to the response type of a response declaring an ETag header
+func (g *ServerGenerator) generateETagField(sb *strings.Builder, response *openapi.Response) {
+	if !hasResponseHeader(response, "ETag") {
+		return
+	}
+	sb.WriteString("\t// ETag is the entity tag of the representation, including quotes (see ComputeETag)\n")
+	sb.WriteString("\tETag string `json:\"-\"`\n")
+}
+
+// generateETagMethod generates the EntityTag method of a response declaring an ETag header
+func (g *ServerGenerator) generateETagMethod(sb *strings.Builder, concreteTypeName string, response *openapi.Response) {
+	if !hasResponseHeader(response, "ETag") {
+		return
+	}
+	sb.WriteString(fmt.Sprintf("func (r %s) EntityTag() string { return r.ETag }\n", concreteTypeName))
+}
+
+// generateETagHelpers generates helpers for computing entity tags and evaluating
+// conditional requests, if any operation uses them
+func (g *ServerGenerator) generateETagHelpers(sb *strings.Builder) {
+	needed := false
+	for _, info := range getOperations(g.spec) {
+		if usesETag(info.Operation) || len(conditionalHeaderParams(info.Operation)) > 0 {
+			needed = true
+			break
+		}
+	}
+	if !needed {
+		return
+	}
+
+	sb.WriteString("// ComputeETag returns a strong entity tag derived from the content of a representation\n")
+	sb.WriteString("func ComputeETag(data []byte) string {\n")
+	sb.WriteString("\tsum := sha256.Sum256(data)\n")
+	sb.WriteString("\treturn `\"` + hex.EncodeToString(sum[:16]) + `\"`\n")
+	sb.WriteString("}\n\n")
+
+	sb.WriteString("// ETagMatches reports whether an If-None-Match header value matches etag using\n")
+	sb.WriteString("// weak comparison. \"*\" matches any entity tag.\n")
+	sb.WriteString("func ETagMatches(header, etag string) bool {\n")
+	sb.WriteString("\tif header == \"\" || etag == \"\" {\n")
+	sb.WriteString("\t\treturn false\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\tfor _, candidate := range strings.Split(header, \",\") {\n")
+	sb.WriteString("\t\tcandidate = strings.TrimSpace(candidate)\n")
+	sb.WriteString("\t\tif candidate == \"*\" || strings.TrimPrefix(candidate, \"W/\") == strings.TrimPrefix(etag, \"W/\") {\n")
+	sb.WriteString("\t\t\treturn true\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\treturn false\n")
 	sb.WriteString("}\n\n")
 
-	sb.WriteString("// WriteError writes an error response\n")
-	sb.WriteString("func WriteError(w http.ResponseWriter, status int, err error) {\n")
-	sb.WriteString("\tWriteJSON(w, status, ErrorResponse{\n")
-	sb.WriteString("\t\tError:   http.StatusText(status),\n")
-	sb.WriteString("\t\tMessage: err.Error(),\n")
-	sb.WriteString("\t})\n")
+	sb.WriteString("// CheckIfMatch returns a 412 Precondition Failed error when the If-Match header is\n")
+	sb.WriteString("// set and does not match the current entity tag of the resource. Handlers of unsafe\n")
+	sb.WriteString("// operations call it before applying any change.\n")
+	sb.WriteString("func CheckIfMatch(ifMatch, currentETag string) error {\n")
+	sb.WriteString("\tif ifMatch == \"\" {\n")
+	sb.WriteString("\t\treturn nil\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\tfor _, candidate := range strings.Split(ifMatch, \",\") {\n")
+	sb.WriteString("\t\tcandidate = strings.TrimSpace(candidate)\n")
+	sb.WriteString("\t\tif candidate == \"*\" && currentETag != \"\" {\n")
+	sb.WriteString("\t\t\treturn nil\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t\t// If-Match uses strong comparison, so weak entity tags never match\n")
+	sb.WriteString("\t\tif candidate == currentETag && !strings.HasPrefix(currentETag, \"W/\") {\n")
+	sb.WriteString("\t\t\treturn nil\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\treturn NewHTTPError(http.StatusPreconditionFailed, \"entity tag does not match If-Match\")\n")
 	sb.WriteString("}\n\n")
 
-	// Read JSON helper
-	sb.WriteString("// ReadJSON reads and decodes JSON from request body\n")
-	sb.WriteString("func ReadJSON(r *http.Request, v any) error {\n")
-	sb.WriteString("\tdefer r.Body.Close()\n")
-	sb.WriteString("\tbody, err := io.ReadAll(r.Body)\n")
-	sb.WriteString("\tif err != nil {\n")
-	sb.WriteString("\t\treturn err\n")
+	sb.WriteString("// applyETag sets the ETag header of responses carrying an entity tag. When the\n")
+	sb.WriteString("// If-None-Match header of a GET or HEAD request for a successful response matches\n")
+	sb.WriteString("// it, the response is short-circuited with 304 Not Modified. It runs after the\n")
+	sb.WriteString("// handler, so unsafe methods have already applied their change: their If-Match and\n")
+	sb.WriteString("// If-None-Match checks belong in the handler, before the change, using CheckIfMatch\n")
+	sb.WriteString("// and ETagMatches. It reports whether a response was written.\n")
+	sb.WriteString("func applyETag(rw http.ResponseWriter, r *http.Request, resp any) bool {\n")
+	sb.WriteString("\ttagged, ok := resp.(interface{ EntityTag() string })\n")
+	sb.WriteString("\tif !ok || tagged.EntityTag() == \"\" {\n")
+	sb.WriteString("\t\treturn false\n")
 	sb.WriteString("\t}\n")
-	sb.WriteString("\treturn json.Unmarshal(body, v)\n")
+	sb.WriteString("\tetag := tagged.EntityTag()\n")
+	sb.WriteString("\trw.Header().Set(\"ETag\", etag)\n\n")
+	sb.WriteString("\tif r.Method != http.MethodGet && r.Method != http.MethodHead {\n")
+	sb.WriteString("\t\treturn false\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\tif sc, ok := resp.(interface{ StatusCode() int }); ok && (sc.StatusCode() < 200 || sc.StatusCode() > 299) {\n")
+	sb.WriteString("\t\treturn false\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\tif !ETagMatches(r.Header.Get(\"If-None-Match\"), etag) {\n")
+	sb.WriteString("\t\treturn false\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\trw.WriteHeader(http.StatusNotModified)\n")
+	sb.WriteString("\treturn true\n")
 	sb.WriteString("}\n\n")
 }
 
@@ -833,8 +2759,11 @@ func (g *ServerGenerator) resolveSchemaType(schemaRef *openapi.SchemaRef) string
 	if schemaRef.Ref != "" {
 		parts := strings.Split(schemaRef.Ref, "/")
 		if len(parts) > 0 {
-			typeName := parts[len(parts)-1]
-			return toPascalCase(typeName)
+			typeName := toPascalCase(parts[len(parts)-1])
+			if g.typesImport != "" {
+				return g.typesName + "." + typeName
+			}
+			return typeName
 		}
 	}
 
@@ -882,8 +2811,430 @@ func (g *ServerGenerator) resolveSchemaTypeFromValue(schema *openapi.Schema) str
 	}
 }
 
+// getResponseBodySchema returns the schema used for a response body.
+// JSON is preferred; otherwise the first media type (in sorted order) with a schema is used.
+func getResponseBodySchema(response *openapi.Response) *openapi.SchemaRef {
+	if response == nil || response.Content == nil {
+		return nil
+	}
+
+	if jsonContent, ok := response.Content["application/json"]; ok && jsonContent != nil && jsonContent.Schema != nil {
+		return jsonContent.Schema
+	}
+
+	mediaTypes := make([]string, 0, len(response.Content))
+	for mediaType := range response.Content {
+		mediaTypes = append(mediaTypes, mediaType)
+	}
+	sort.Strings(mediaTypes)
+
+	for _, mediaType := range mediaTypes {
+		if content := response.Content[mediaType]; content != nil && content.Schema != nil {
+			return content.Schema
+		}
+	}
+
+	return nil
+}
+
+// getResponseMediaTypes returns all media types offered by an operation's responses.
+// JSON comes first (making it the default when no Accept header is sent), followed by
+// the remaining media types in sorted order.
+func getResponseMediaTypes(op *openapi.Operation) []string {
+	seen := make(map[string]bool)
+	for _, response := range op.Responses {
+		if response == nil {
+			continue
+		}
+		for mediaType := range response.Content {
+			seen[mediaType] = true
+		}
+	}
+
+	mediaTypes := make([]string, 0, len(seen))
+	for mediaType := range seen {
+		if mediaType != "application/json" {
+			mediaTypes = append(mediaTypes, mediaType)
+		}
+	}
+	sort.Strings(mediaTypes)
+
+	if seen["application/json"] {
+		mediaTypes = append([]string{"application/json"}, mediaTypes...)
+	}
+
+	return mediaTypes
+}
+
+// formatStringSlice formats a string slice as a Go []string literal
+func formatStringSlice(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = strconv.Quote(v)
+	}
+	return "[]string{" + strings.Join(quoted, ", ") + "}"
+}
+
+// usesIdempotencyKey reports whether an operation accepts an Idempotency-Key
+// header, either declared in the spec or enabled for all POST and PATCH operations
+func (g *ServerGenerator) usesIdempotencyKey(method string, op *openapi.Operation) bool {
+	for _, param := range op.Parameters {
+		if param != nil && param.In == "header" && strings.EqualFold(param.Name, "Idempotency-Key") {
+			return true
+		}
+	}
+	return g.idempotency && (method == http.MethodPost || method == http.MethodPatch)
+}
+
+// usesIdempotency reports whether any operation accepts an Idempotency-Key header
+func (g *ServerGenerator) usesIdempotency() bool {
+	for _, info := range getOperations(g.spec) {
+		if g.usesIdempotencyKey(info.Method, info.Operation) {
+			return true
+		}
+	}
+	return false
+}
+
+// bodySizeLimit returns the request body size limit of an operation in bytes:
+// its x-max-body-size extension or else the configured default. Zero or a
+// negative extension value means the body is not limited.
+func (g *ServerGenerator) bodySizeLimit(op *openapi.Operation) int64 {
+	if size, ok := extensionInt(op, "x-max-body-size"); ok {
+		return max(size, 0)
+	}
+	return g.maxBodySize
+}
+
+// operationTimeout returns the deadline an operation's handler runs with: its
+// x-timeout extension or else the configured default. Zero means no deadline.
+// The extension is a duration string such as "5s" or a number of seconds.
+func (g *ServerGenerator) operationTimeout(op *openapi.Operation) time.Duration {
+	if value, ok := op.Extensions["x-timeout"].(string); ok {
+		if d, err := time.ParseDuration(value); err == nil {
+			return max(d, 0)
+		}
+	}
+	if seconds, ok := extensionInt(op, "x-timeout"); ok {
+		return max(time.Duration(seconds)*time.Second, 0)
+	}
+	return g.timeout
+}
+
+// operationRateLimit returns the x-rate-limit extension of an operation: the
+// number of requests each client may make per period. The period is a duration
+// string such as "1m" or a number of seconds. A zero limit means the operation
+// is not rate limited.
+func operationRateLimit(op *openapi.Operation) (int64, time.Duration, error) {
+	value, ok := op.Extensions["x-rate-limit"]
+	if !ok {
+		return 0, 0, nil
+	}
+	fields, ok := value.(map[string]any)
+	if !ok {
+		return 0, 0, fmt.Errorf("x-rate-limit must be an object with requests and period")
+	}
+
+	requests, ok := intValue(fields["requests"])
+	if !ok || requests <= 0 {
+		return 0, 0, fmt.Errorf("x-rate-limit requests must be a positive integer")
+	}
+
+	var period time.Duration
+	if s, ok := fields["period"].(string); ok {
+		if d, err := time.ParseDuration(s); err == nil {
+			period = d
+		}
+	} else if seconds, ok := intValue(fields["period"]); ok {
+		period = time.Duration(seconds) * time.Second
+	}
+	if period <= 0 {
+		return 0, 0, fmt.Errorf("x-rate-limit period must be a positive duration")
+	}
+	return requests, period, nil
+}
+
+// durationLiteral renders a duration as a Go expression using the largest
+// time unit that represents it exactly
+func durationLiteral(d time.Duration) string {
+	units := []struct {
+		unit time.Duration
+		name string
+	}{
+		{time.Hour, "time.Hour"},
+		{time.Minute, "time.Minute"},
+		{time.Second, "time.Second"},
+		{time.Millisecond, "time.Millisecond"},
+		{time.Microsecond, "time.Microsecond"},
+	}
+	for _, u := range units {
+		if d%u.unit == 0 {
+			return fmt.Sprintf("%d*%s", d/u.unit, u.name)
+		}
+	}
+	return fmt.Sprintf("time.Duration(%d)", int64(d))
+}
+
+// usesRateLimit reports whether any operation declares an x-rate-limit extension
+func (g *ServerGenerator) usesRateLimit() bool {
+	for _, info := range getOperations(g.spec) {
+		if limit, _, _ := operationRateLimit(info.Operation); limit > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// usesBodySizeLimit reports whether any operation limits its JSON request body
+func (g *ServerGenerator) usesBodySizeLimit() bool {
+	for _, info := range getOperations(g.spec) {
+		op := info.Operation
+		if op.RequestBody == nil {
+			continue
+		}
+		if _, ok := op.RequestBody.Content["application/json"]; ok && g.bodySizeLimit(op) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// extensionInt returns an integer specification extension of an operation.
+// YAML specs decode integers as int, JSON specs as float64.
+func extensionInt(op *openapi.Operation, name string) (int64, bool) {
+	return intValue(op.Extensions[name])
+}
+
+// intValue returns a decoded YAML or JSON number as an integer
+func intValue(value any) (int64, bool) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), true
+	case int64:
+		return v, true
+	case uint64:
+		return int64(v), true
+	case float64:
+		if v == float64(int64(v)) {
+			return int64(v), true
+		}
+	}
+	return 0, false
+}
+
+// extensionStrings returns a specification extension of an operation holding a
+// list of strings, or a single string
+func extensionStrings(op *openapi.Operation, name string) []string {
+	switch v := op.Extensions[name].(type) {
+	case string:
+		return []string{v}
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// isWildcardParam reports whether a path parameter is marked with x-wildcard
+// to capture the rest of the path, slashes included
+func isWildcardParam(param *openapi.Parameter) bool {
+	wildcard, _ := param.Extensions["x-wildcard"].(bool)
+	return param.In == "path" && wildcard
+}
+
+// pathWildcard returns the name of the wildcard parameter declared by the
+// operations of a path, or "" if there is none
+func pathWildcard(pathItem *openapi.PathItem) string {
+	if pathItem == nil {
+		return ""
+	}
+	for _, methodOp := range getOperationsInOrder(pathItem) {
+		for _, param := range methodOp.Operation.Parameters {
+			if param != nil && isWildcardParam(param) {
+				return param.Name
+			}
+		}
+	}
+	return ""
+}
+
+// pathConstraints returns the regular expression each path parameter of a path
+// must match, derived from the parameter schemas. A parameter that operations
+// declare with differing constraints is left unconstrained.
+func pathConstraints(pathItem *openapi.PathItem) map[string]string {
+	constraints := make(map[string]string)
+	conflicting := make(map[string]bool)
+	for _, methodOp := range getOperationsInOrder(pathItem) {
+		for _, param := range methodOp.Operation.Parameters {
+			if param == nil || param.In != "path" {
+				continue
+			}
+			constraint := paramConstraint(param)
+			if existing, ok := constraints[param.Name]; ok && existing != constraint {
+				conflicting[param.Name] = true
+			}
+			constraints[param.Name] = constraint
+		}
+	}
+	for name := range conflicting {
+		delete(constraints, name)
+	}
+	return constraints
+}
+
+// paramConstraint returns a regular expression matching the values a path
+// parameter's schema accepts, or "" if the segment is not constrained
+func paramConstraint(param *openapi.Parameter) string {
+	if isWildcardParam(param) || param.Schema == nil || param.Schema.Value == nil {
+		return ""
+	}
+
+	schema := param.Schema.Value
+	switch schema.GetSchemaType() {
+	case "integer":
+		if schema.Minimum != nil && *schema.Minimum >= 0 {
+			return "[0-9]+"
+		}
+		return "-?[0-9]+"
+	case "string":
+		if len(schema.Enum) > 0 {
+			values := make([]string, 0, len(schema.Enum))
+			for _, value := range schema.Enum {
+				s := fmt.Sprint(value)
+				if strings.ContainsAny(s, "/{}") {
+					return ""
+				}
+				values = append(values, regexp.QuoteMeta(s))
+			}
+			return "(?:" + strings.Join(values, "|") + ")"
+		}
+		if schema.Format == "uuid" {
+			return "[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}"
+		}
+		// Only an anchored pattern keeps its meaning once the router anchors it
+		if pattern, ok := strings.CutPrefix(schema.Pattern, "^"); ok && strings.HasSuffix(pattern, "$") {
+			pattern = strings.TrimSuffix(pattern, "$")
+			if _, err := regexp.Compile(pattern); err == nil && !strings.Contains(pattern, "/") {
+				return pattern
+			}
+		}
+	}
+	return ""
+}
+
+// writeStructFields writes struct fields with their types aligned as gofmt does
+func writeStructFields(sb *strings.Builder, fields [][2]string) {
+	width := 0
+	for _, field := range fields {
+		width = max(width, len(field[0]))
+	}
+	for _, field := range fields {
+		sb.WriteString(fmt.Sprintf("\t%-*s %s\n", width, field[0], field[1]))
+	}
+}
+
+// declaredResponseHeaders returns the canonical names of the headers the
+// responses of an operation declare, in order
+func declaredResponseHeaders(op *openapi.Operation) []string {
+	var headers []string
+	for _, response := range op.Responses {
+		if response == nil {
+			continue
+		}
+		for name := range response.Headers {
+			if name := http.CanonicalHeaderKey(name); !slices.Contains(headers, name) {
+				headers = append(headers, name)
+			}
+		}
+	}
+	slices.Sort(headers)
+	return headers
+}
+
+// hasResponseHeader reports whether a response declares the named header
+func hasResponseHeader(response *openapi.Response, name string) bool {
+	for header := range response.Headers {
+		if strings.EqualFold(header, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// usesInformational reports whether an operation declares a 1xx response
+func usesInformational(op *openapi.Operation) bool {
+	for statusCode, response := range op.Responses {
+		if code := parseStatusCode(statusCode); response != nil && code >= 100 && code < 200 {
+			return true
+		}
+	}
+	return false
+}
+
+// usesTrailers reports whether any response of an operation declares a Trailer header
+func usesTrailers(op *openapi.Operation) bool {
+	for _, response := range op.Responses {
+		if response != nil && hasResponseHeader(response, "Trailer") {
+			return true
+		}
+	}
+	return false
+}
+
+// anyOperation reports whether the predicate holds for any operation of the spec.
+// Response types, and the helpers they rely on, are not generated in thin mode.
+func (g *ServerGenerator) anyOperation(predicate func(op *openapi.Operation) bool) bool {
+	if g.thin {
+		return false
+	}
+	for _, info := range getOperations(g.spec) {
+		if predicate(info.Operation) {
+			return true
+		}
+	}
+	return false
+}
+
+// usesETag reports whether any response of an operation declares an ETag header
+func usesETag(op *openapi.Operation) bool {
+	for _, response := range op.Responses {
+		if response != nil && hasResponseHeader(response, "ETag") {
+			return true
+		}
+	}
+	return false
+}
+
+// conditionalHeaderParams returns the If-Match and If-None-Match header parameters of an operation
+func conditionalHeaderParams(op *openapi.Operation) []*openapi.Parameter {
+	var params []*openapi.Parameter
+	for _, param := range op.Parameters {
+		if param == nil || param.In != "header" {
+			continue
+		}
+		if strings.EqualFold(param.Name, "If-Match") || strings.EqualFold(param.Name, "If-None-Match") {
+			params = append(params, param)
+		}
+	}
+	return params
+}
+
+// parseStatusCodeRange returns the class (1-5) of a ranged status code such as
+// "2XX", or 0 if code is not a range
+func parseStatusCodeRange(code string) int {
+	if len(code) != 3 || !strings.EqualFold(code[1:], "XX") || code[0] < '1' || code[0] > '5' {
+		return 0
+	}
+	return int(code[0] - '0')
+}
+
 // parseStatusCode parses a status code string to int
-// Returns 0 for "default" or invalid codes, which should be filtered out by the caller
+// Returns 0 for "default", ranges or invalid codes, which should be filtered out by the caller
 func parseStatusCode(code string) int {
 	statusCode, err := strconv.Atoi(code)
 	if err != nil {
@@ -892,6 +3243,14 @@ func parseStatusCode(code string) int {
 	return statusCode
 }
 
+// getOperationID returns the spec's operationId, falling back to the handler name
+func getOperationID(handlerName string, op *openapi.Operation) string {
+	if op.OperationID != "" {
+		return op.OperationID
+	}
+	return handlerName
+}
+
 // generateHandlerName creates a handler function name from method, path and operationID
 func generateHandlerName(method, path, operationID string) string {
 	if operationID != "" {
@@ -912,10 +3271,89 @@ func generateHandlerName(method, path, operationID string) string {
 	return toPascalCase(name)
 }
 
-// convertToRouterPath converts OpenAPI path to router path format
-func convertToRouterPath(path string) string {
+// convertToRouterPath converts OpenAPI path to router path format.
+// The wildcard parameter, if any, becomes a {param...} catch-all segment.
+func convertToRouterPath(path, wildcard string) string {
 	// Both OpenAPI and our router use {param} format
-	return path
+	if wildcard == "" {
+		return path
+	}
+	return strings.TrimSuffix(path, "}") + "...}"
+}
+
+// constrainPath adds the regular expression constraints of path parameters
+// to a router path, turning {param} into {param:regexp}
+func constrainPath(path string, constraints map[string]string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if !strings.HasPrefix(segment, "{") || !strings.HasSuffix(segment, "}") {
+			continue
+		}
+		if constraint := constraints[segment[1:len(segment)-1]]; constraint != "" {
+			segments[i] = segment[:len(segment)-1] + ":" + constraint + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// convertToChiPath converts a router path to a chi pattern, where the
+// catch-all segment is written as *
+func convertToChiPath(path string) string {
+	if !strings.HasSuffix(path, "...}") {
+		return path
+	}
+	return path[:strings.LastIndex(path, "{")] + "*"
+}
+
+// resolveBasePath returns the path prefix for all routes: the configured
+// override, or else the path component of the spec's first server URL
+func (g *ServerGenerator) resolveBasePath() string {
+	if g.basePath != "" {
+		return openapi.NormalizeBasePath(g.basePath)
+	}
+	if len(g.spec.Servers) == 0 || g.spec.Servers[0] == nil {
+		return ""
+	}
+	return g.spec.Servers[0].BasePath()
+}
+
+// convertToServeMuxPath converts an OpenAPI path to a ServeMux pattern path.
+// Wildcards must be Go identifiers and a trailing slash would otherwise match
+// the whole subtree, so it is anchored with {$}.
+func convertToServeMuxPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasSuffix(segment, "...}") {
+			segments[i] = "{" + serveMuxWildcardName(segment[1:len(segment)-4]) + "...}"
+		} else if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			segments[i] = "{" + serveMuxWildcardName(segment[1:len(segment)-1]) + "}"
+		}
+	}
+
+	result := strings.Join(segments, "/")
+	if strings.HasSuffix(result, "/") {
+		result += "{$}"
+	}
+	return result
+}
+
+// serveMuxWildcardName converts a path parameter name to a valid ServeMux wildcard name
+func serveMuxWildcardName(name string) string {
+	var sb strings.Builder
+	for i, r := range name {
+		if r == '_' || unicode.IsLetter(r) || (i > 0 && unicode.IsDigit(r)) {
+			sb.WriteRune(r)
+		} else {
+			sb.WriteRune('_')
+		}
+	}
+	return sb.String()
+}
+
+// methodRouteRegistration renders a route registration using per-method router
+// functions such as r.Get and r.Post
+func methodRouteRegistration(method, path, handler string) string {
+	return fmt.Sprintf("r.%s(\"%s\", %s)", getRouterMethodName(method), path, handler)
 }
 
 // getRouterMethodName returns the router method name for an HTTP method
@@ -940,6 +3378,68 @@ func getRouterMethodName(method string) string {
 	}
 }
 
+// operationInfo describes an operation together with its path and handler name
+type operationInfo struct {
+	Path        string
+	Method      string
+	Operation   *openapi.Operation
+	HandlerName string
+}
+
+// getOperations returns all operations in the spec, sorted by path and then by method
+func getOperations(spec *openapi.Document) []operationInfo {
+	// Sort paths for deterministic output
+	paths := make([]string, 0, len(spec.Paths))
+	for path := range spec.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var operations []operationInfo
+	for _, path := range paths {
+		for _, methodOp := range getOperationsInOrder(spec.Paths[path]) {
+			operations = append(operations, operationInfo{
+				Path:        path,
+				Method:      methodOp.Method,
+				Operation:   methodOp.Operation,
+				HandlerName: generateHandlerName(methodOp.Method, path, methodOp.Operation.OperationID),
+			})
+		}
+	}
+	return operations
+}
+
+// tagInterfaceName returns the name of the per-tag interface an operation belongs to,
+// based on its first tag
+func tagInterfaceName(op *openapi.Operation) string {
+	if len(op.Tags) == 0 || toPascalCase(op.Tags[0]) == "" {
+		return "UntaggedServer"
+	}
+	return toPascalCase(op.Tags[0]) + "Server"
+}
+
+// sortedKeys returns the keys of a map in sorted order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// toFileNamePart converts a tag name to a lowercase file name component
+func toFileNamePart(name string) string {
+	words := splitWords(name)
+	for i, word := range words {
+		words[i] = strings.ToLower(word)
+	}
+	if len(words) == 0 {
+		return "untagged"
+	}
+	return strings.Join(words, "_")
+}
+
 // methodOperation represents an HTTP method and its operation
 type methodOperation struct {
 	Method    string
@@ -194,6 +194,33 @@ func TestGenerateSecuritySchemeInfoMap(t *testing.T) {
 	assert.Contains(t, result, `Name:   "X-API-Key"`)
 }
 
+func TestGenerateSecuritySchemeInfoMapWithTenants(t *testing.T) {
+	spec := &openapi.Document{
+		Components: &openapi.Components{
+			SecuritySchemes: map[string]*openapi.SecurityScheme{
+				"bearerAuth": {
+					Type:       "http",
+					Scheme:     "bearer",
+					Extensions: map[string]any{"x-tenant": map[string]any{"in": "path"}},
+				},
+			},
+		},
+	}
+
+	gen := NewServerGenerator(spec)
+	var sb strings.Builder
+	gen.generateSecuritySchemeInfoMap(&sb)
+
+	assert.Contains(t, sb.String(), "\t\tType:     \"http\",\n\t\tScheme:   \"bearer\",\n\t\tTenantIn: \"path\",\n")
+
+	t.Run("invalid extension", func(t *testing.T) {
+		spec.Components.SecuritySchemes["bearerAuth"].Extensions = map[string]any{"x-tenant": map[string]any{"in": "query"}}
+
+		_, err := NewServerGenerator(spec).Generate()
+		assert.ErrorContains(t, err, "security scheme bearerAuth")
+	})
+}
+
 func TestGenerateRouterWithAuth(t *testing.T) {
 	spec := &openapi.Document{
 		OpenAPI: "3.1.0",
@@ -241,10 +268,10 @@ func TestGenerateRouterWithAuth(t *testing.T) {
 	assert.Contains(t, code, "var securitySchemeInfoMap")
 
 	// Verify ConfigureRouter accepts authenticator
-	assert.Contains(t, code, "func ConfigureRouter(r router.Router, si Server, authenticator Authenticator)")
+	assert.Contains(t, code, "func ConfigureRouter(r router.Router, si Server, authenticator Authenticator, opts ...ServerOption)")
 
 	// Verify NewRouter accepts authenticator
-	assert.Contains(t, code, "func NewRouter(si Server, authenticator Authenticator)")
+	assert.Contains(t, code, "func NewRouter(si Server, authenticator Authenticator, opts ...ServerOption)")
 
 	// Verify protected endpoint uses auth middleware
 	assert.Contains(t, code, "authMiddleware(authenticator,")
@@ -288,12 +315,12 @@ func TestGenerateRouterWithoutAuth(t *testing.T) {
 	assert.NotContains(t, code, "var securitySchemeInfoMap")
 
 	// Verify ConfigureRouter doesn't accept authenticator
-	assert.Contains(t, code, "func ConfigureRouter(r router.Router, si Server)")
-	assert.NotContains(t, code, "func ConfigureRouter(r router.Router, si Server, authenticator Authenticator)")
+	assert.Contains(t, code, "func ConfigureRouter(r router.Router, si Server, opts ...ServerOption)")
+	assert.NotContains(t, code, "func ConfigureRouter(r router.Router, si Server, authenticator Authenticator")
 
 	// Verify NewRouter doesn't accept authenticator
-	assert.Contains(t, code, "func NewRouter(si Server)")
-	assert.NotContains(t, code, "func NewRouter(si Server, authenticator Authenticator)")
+	assert.Contains(t, code, "func NewRouter(si Server, opts ...ServerOption)")
+	assert.NotContains(t, code, "func NewRouter(si Server, authenticator Authenticator")
 
 	// Verify no auth middleware is used
 	assert.NotContains(t, code, "authMiddleware")
@@ -0,0 +1,1176 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/christopherklint97/specweaver/pkg/openapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newPetSpec returns a minimal spec with a single GET /pets/{petId} operation
+// whose responses can be customized by each test
+func newPetSpec(responses openapi.Responses) *openapi.Document {
+	return &openapi.Document{
+		OpenAPI: "3.1.0",
+		Info:    &openapi.Info{Title: "Test", Version: "1.0.0"},
+		Paths: openapi.Paths{
+			"/pets/{petId}": {
+				Get: &openapi.Operation{
+					OperationID: "getPet",
+					Parameters: []*openapi.Parameter{
+						{
+							Name:     "petId",
+							In:       "path",
+							Required: true,
+							Schema:   &openapi.SchemaRef{Value: &openapi.Schema{Type: []string{"string"}}},
+						},
+					},
+					Responses: responses,
+				},
+			},
+		},
+	}
+}
+
+func TestGenerateDefaultResponse(t *testing.T) {
+	t.Run("default response with body", func(t *testing.T) {
+		spec := newPetSpec(openapi.Responses{
+			"200": {Description: "OK"},
+			"default": {
+				Description: "Unexpected error",
+				Content: map[string]*openapi.MediaType{
+					"application/json": {
+						Schema: &openapi.SchemaRef{Ref: "#/components/schemas/Error"},
+					},
+				},
+			},
+		})
+
+		code, err := NewServerGenerator(spec).Generate()
+		require.NoError(t, err)
+
+		assert.Contains(t, code, "type GetPetDefaultResponse struct {")
+		assert.Contains(t, code, "\tCode int `json:\"-\"`")
+		assert.Contains(t, code, "\tBody Error `json:\"body\"`")
+		assert.Contains(t, code, "func (r GetPetDefaultResponse) isGetPetResponse() {}")
+		assert.Contains(t, code, "func (r GetPetDefaultResponse) StatusCode() int {")
+		assert.Contains(t, code, "return http.StatusInternalServerError")
+		assert.Contains(t, code, "func (r GetPetDefaultResponse) ResponseBody() any { return r.Body }")
+	})
+
+	t.Run("default response without body", func(t *testing.T) {
+		spec := newPetSpec(openapi.Responses{
+			"default": {Description: "Unexpected error"},
+		})
+
+		code, err := NewServerGenerator(spec).Generate()
+		require.NoError(t, err)
+
+		assert.Contains(t, code, "type GetPetDefaultResponse struct {")
+		assert.Contains(t, code, "func (r GetPetDefaultResponse) ResponseBody() any { return nil }")
+	})
+}
+
+func TestGenerateRangeResponse(t *testing.T) {
+	spec := newPetSpec(openapi.Responses{
+		"200": {Description: "OK"},
+		"4XX": {
+			Description: "Client error",
+			Content: map[string]*openapi.MediaType{
+				"application/json": {
+					Schema: &openapi.SchemaRef{Ref: "#/components/schemas/Error"},
+				},
+			},
+		},
+		"5xx": {Description: "Server error"},
+	})
+
+	code, err := NewServerGenerator(spec).Generate()
+	require.NoError(t, err)
+
+	assert.Contains(t, code, "type GetPet4XXResponse struct {\n\t// Code is the HTTP status code to send, between 400 and 499 (defaults to 400)\n\tCode int `json:\"-\"`\n\tBody Error `json:\"body\"`\n}")
+	assert.Contains(t, code, "func (r GetPet4XXResponse) isGetPetResponse() {}")
+	assert.Contains(t, code, "func (r GetPet4XXResponse) StatusCode() int {\n\tif r.Code < 400 || r.Code > 499 {\n\t\treturn 400\n\t}\n\treturn r.Code\n}")
+	assert.Contains(t, code, "func (r GetPet4XXResponse) ResponseBody() any { return r.Body }")
+	assert.Contains(t, code, "type GetPet5XXResponse struct {")
+	assert.Contains(t, code, "func (r GetPet5XXResponse) ResponseBody() any { return nil }")
+}
+
+func TestParseStatusCodeRange(t *testing.T) {
+	assert.Equal(t, 2, parseStatusCodeRange("2XX"))
+	assert.Equal(t, 5, parseStatusCodeRange("5xx"))
+	assert.Equal(t, 0, parseStatusCodeRange("200"))
+	assert.Equal(t, 0, parseStatusCodeRange("6XX"))
+	assert.Equal(t, 0, parseStatusCodeRange("default"))
+}
+
+func TestGenerateETag(t *testing.T) {
+	spec := newPetSpec(openapi.Responses{
+		"200": {
+			Description: "OK",
+			Headers:     map[string]*openapi.Header{"ETag": {Schema: &openapi.SchemaRef{Value: &openapi.Schema{Type: []string{"string"}}}}},
+		},
+		"404": {Description: "Not found"},
+	})
+	spec.Paths["/pets/{petId}"].Get.Parameters = append(spec.Paths["/pets/{petId}"].Get.Parameters,
+		&openapi.Parameter{Name: "if-none-match", In: "header"})
+
+	code, err := NewServerGenerator(spec).Generate()
+	require.NoError(t, err)
+
+	assert.Contains(t, code, "\tIfNoneMatch string `json:\"if-none-match,omitempty\"`\n")
+	assert.Contains(t, code, "\treq.IfNoneMatch = r.Header.Get(\"If-None-Match\")\n")
+	assert.Contains(t, code, "\tETag string `json:\"-\"`\n")
+	assert.Contains(t, code, "func (r GetPet200Response) EntityTag() string { return r.ETag }")
+	assert.NotContains(t, code, "func (r GetPet404Response) EntityTag() string")
+	assert.Contains(t, code, "\tif applyETag(rw, r, resp) {\n\t\treturn\n\t}\n")
+	assert.Contains(t, code, "func ComputeETag(data []byte) string {")
+	assert.Contains(t, code, "func CheckIfMatch(ifMatch, currentETag string) error {")
+	assert.Contains(t, code, "\tif r.Method != http.MethodGet && r.Method != http.MethodHead {\n\t\treturn false\n\t}\n")
+	assert.NotContains(t, code, "rw.WriteHeader(http.StatusPreconditionFailed)")
+	assert.Contains(t, code, "\t\"crypto/sha256\"\n")
+
+	t.Run("not generated without ETag headers", func(t *testing.T) {
+		code, err := NewServerGenerator(newPetSpec(openapi.Responses{"200": {Description: "OK"}})).Generate()
+		require.NoError(t, err)
+
+		assert.NotContains(t, code, "ETag")
+	})
+}
+
+func TestGenerateContentNegotiation(t *testing.T) {
+	itemSchema := &openapi.SchemaRef{Ref: "#/components/schemas/Item"}
+
+	t.Run("multiple media types negotiate on Accept", func(t *testing.T) {
+		spec := newPetSpec(openapi.Responses{
+			"200": {
+				Description: "OK",
+				Content: map[string]*openapi.MediaType{
+					"application/xml":  {Schema: itemSchema},
+					"application/json": {Schema: itemSchema},
+				},
+			},
+		})
+
+		code, err := NewServerGenerator(spec).Generate()
+		require.NoError(t, err)
+
+		assert.Contains(t, code, `contentType := NegotiateContentType(r, []string{"application/json", "application/xml"})`)
+		assert.Contains(t, code, "http.StatusNotAcceptable")
+		assert.Contains(t, code, "if err := writeResponseWith(rw, resp, contentType, w.Encoders); err != nil {")
+		assert.Contains(t, code, "func NegotiateContentType(r *http.Request, offered []string) string {")
+		assert.Contains(t, code, "return xml.NewEncoder(w).Encode(v)")
+	})
+
+	t.Run("JSON-only operations skip negotiation", func(t *testing.T) {
+		spec := newPetSpec(openapi.Responses{
+			"200": {
+				Description: "OK",
+				Content: map[string]*openapi.MediaType{
+					"application/json": {Schema: itemSchema},
+				},
+			},
+		})
+
+		code, err := NewServerGenerator(spec).Generate()
+		require.NoError(t, err)
+
+		assert.NotContains(t, code, "contentType := NegotiateContentType(")
+		assert.Contains(t, code, "\tWriteResponse(rw, resp)\n")
+	})
+
+	t.Run("non-JSON body is typed from its schema", func(t *testing.T) {
+		spec := newPetSpec(openapi.Responses{
+			"200": {
+				Description: "OK",
+				Content: map[string]*openapi.MediaType{
+					"text/plain": {Schema: &openapi.SchemaRef{Value: &openapi.Schema{Type: []string{"string"}}}},
+				},
+			},
+		})
+
+		code, err := NewServerGenerator(spec).Generate()
+		require.NoError(t, err)
+
+		assert.Contains(t, code, "type GetPet200Response struct {\n\tBody string `json:\"body\"`")
+		assert.Contains(t, code, `contentType := NegotiateContentType(r, []string{"text/plain"})`)
+	})
+}
+
+func TestGenerateResponseEncoders(t *testing.T) {
+	spec := newPetSpec(openapi.Responses{
+		"200": {
+			Description: "OK",
+			Content: map[string]*openapi.MediaType{
+				"application/json": {Schema: &openapi.SchemaRef{Value: &openapi.Schema{Type: []string{"string"}}}},
+				"text/csv":         {Schema: &openapi.SchemaRef{Value: &openapi.Schema{Type: []string{"string"}}}},
+			},
+		},
+	})
+
+	code, err := NewServerGenerator(spec).Generate()
+	require.NoError(t, err)
+
+	assert.Contains(t, code, "type ResponseEncoder func(w io.Writer, v any) error")
+	assert.Contains(t, code, "\tEncoders     map[string]ResponseEncoder\n")
+	assert.Contains(t, code, "func WithEncoder(mediaType string, encoder ResponseEncoder) ServerOption {")
+	assert.Contains(t, code, "\t\tw.Encoders[baseMediaType(mediaType)] = encoder\n")
+	assert.Contains(t, code, "\tif encoder, ok := encoders[baseMediaType(contentType)]; ok {\n")
+	// WriteResponseAs keeps working without a ServerWrapper
+	assert.Contains(t, code, "\treturn writeResponseWith(w, resp, contentType, nil)\n")
+}
+
+func TestGenerateInformationalResponse(t *testing.T) {
+	spec := newPetSpec(openapi.Responses{
+		"103": {Description: "Early Hints"},
+		"200": {Description: "OK"},
+	})
+
+	code, err := NewServerGenerator(spec).Generate()
+	require.NoError(t, err)
+
+	assert.Contains(t, code, "type GetPet103Response struct {")
+	assert.Contains(t, code, "func (r GetPet103Response) StatusCode() int { return 103 }")
+	// Informational responses are not final responses
+	assert.NotContains(t, code, "func (r GetPet103Response) isGetPetResponse() {}")
+	assert.Contains(t, code, "func SendInformational(ctx context.Context, resp InformationalResponse) error {")
+	assert.Contains(t, code, "\tctx = context.WithValue(ctx, responseWriterKey{}, rw)\n")
+	// The status recorder keeps the final status
+	assert.Contains(t, code, "\tif !sr.wroteHeader && code >= 200 {\n")
+
+	code, err = NewServerGenerator(newPetSpec(openapi.Responses{"200": {Description: "OK"}})).Generate()
+	require.NoError(t, err)
+	assert.NotContains(t, code, "SendInformational")
+	assert.NotContains(t, code, "responseWriterKey")
+}
+
+func TestGenerateTrailers(t *testing.T) {
+	spec := newPetSpec(openapi.Responses{
+		"200": {
+			Description: "OK",
+			Headers:     map[string]*openapi.Header{"Trailer": {Schema: &openapi.SchemaRef{Value: &openapi.Schema{Type: []string{"string"}}}}},
+		},
+	})
+
+	code, err := NewServerGenerator(spec).Generate()
+	require.NoError(t, err)
+
+	assert.Contains(t, code, "\tTrailers http.Header `json:\"-\"`\n")
+	assert.Contains(t, code, "func (r GetPet200Response) ResponseTrailers() http.Header { return r.Trailers }")
+	assert.Contains(t, code, "\ttrailers := declareTrailers(rw, resp)\n")
+	assert.Contains(t, code, "\twriteTrailers(rw, trailers)\n")
+	assert.Contains(t, code, "func declareTrailers(rw http.ResponseWriter, resp any) http.Header {")
+
+	code, err = NewServerGenerator(newPetSpec(openapi.Responses{"200": {Description: "OK"}})).Generate()
+	require.NoError(t, err)
+	assert.NotContains(t, code, "declareTrailers")
+}
+
+func TestGetResponseMediaTypes(t *testing.T) {
+	op := &openapi.Operation{
+		Responses: openapi.Responses{
+			"200": {Content: map[string]*openapi.MediaType{"text/csv": {}, "application/json": {}}},
+			"404": {Content: map[string]*openapi.MediaType{"application/problem+json": {}}},
+			"204": {},
+		},
+	}
+
+	assert.Equal(t, []string{"application/json", "application/problem+json", "text/csv"}, getResponseMediaTypes(op))
+	assert.Empty(t, getResponseMediaTypes(&openapi.Operation{}))
+}
+
+func TestGenerateLifecycleHooks(t *testing.T) {
+	spec := newPetSpec(openapi.Responses{
+		"200": {Description: "OK"},
+	})
+
+	code, err := NewServerGenerator(spec).Generate()
+	require.NoError(t, err)
+
+	assert.Contains(t, code, "type ServerHooks struct {")
+	assert.Contains(t, code, "OnRequest func(ctx context.Context, operationID string, r *http.Request)")
+	assert.Contains(t, code, "OnResponse func(ctx context.Context, operationID string, status int, duration time.Duration)")
+	assert.Contains(t, code, "OnError func(ctx context.Context, operationID string, err error)")
+	assert.Contains(t, code, "func WithHooks(hooks ServerHooks) ServerOption {")
+	assert.Contains(t, code, "func NewServerWrapper(si Server, opts ...ServerOption) *ServerWrapper {")
+	assert.Contains(t, code, "wrapper := NewServerWrapper(si, opts...)")
+
+	// Each adapter reports its operation ID to the hooks
+	assert.Contains(t, code, "\tconst operationID = \"getPet\"\n")
+	assert.Contains(t, code, "rw, finish := w.startOperation(ctx, operationID, \"/pets/{petId}\", rw, r)")
+	assert.Contains(t, code, "w.handleError(ctx, operationID, rw, ")
+
+	// Panicking handlers are reported as 500 and passed on to recovery middleware
+	assert.Contains(t, code, "\tsr := &statusRecorder{ResponseWriter: rw}\n")
+	assert.Contains(t, code, "\t\tpanicked := recover()\n\t\tstatus := sr.status\n\t\tif !sr.wroteHeader {\n\t\t\tstatus = http.StatusOK\n\t\t\tif panicked != nil {\n\t\t\t\tstatus = http.StatusInternalServerError\n")
+	assert.Contains(t, code, "\t\t\tif status >= http.StatusInternalServerError || panicked != nil {\n\t\t\t\tlevel = slog.LevelError\n")
+	assert.Contains(t, code, "\t\tif panicked != nil {\n\t\t\tpanic(panicked)\n\t\t}\n")
+}
+
+func TestGenerateOperationInfo(t *testing.T) {
+	spec := newPetSpec(openapi.Responses{"200": {Description: "OK"}})
+	op := spec.Paths["/pets/{petId}"].Get
+	op.Tags = []string{"pets"}
+	op.Security = []openapi.SecurityRequirement{{"oauth": {"read", "admin"}}, {"apiKey": {}}, {"oauth": {"read"}}}
+	spec.Components = &openapi.Components{SecuritySchemes: map[string]*openapi.SecurityScheme{
+		"oauth":  {Type: "oauth2"},
+		"apiKey": {Type: "apiKey", In: "header", Name: "X-API-Key"},
+	}}
+
+	code, err := NewServerGenerator(spec).Generate()
+	require.NoError(t, err)
+
+	assert.Contains(t, code, "type OperationInfo struct {")
+	assert.Contains(t, code, "\t\"getPet\": {\n\t\tID:     \"getPet\",\n\t\tMethod: \"GET\",\n\t\tRoute:  \"/pets/{petId}\",\n")
+	assert.Contains(t, code, "\t\tTags:   []string{\"pets\"},\n")
+	assert.Contains(t, code, "\t\tScopes: []string{\"read\", \"admin\"},\n")
+	assert.Contains(t, code, "func GetOperationInfo(ctx context.Context) *OperationInfo {")
+
+	// The operation is in the context before the auth middleware runs
+	assert.Contains(t, code, "\tr.Get(\"/pets/{petId}\", withOperation(operationInfos[\"getPet\"], authMiddleware(")
+}
+
+func TestGenerateOperationRoles(t *testing.T) {
+	spec := newPetSpec(openapi.Responses{"200": {Description: "OK"}})
+	op := spec.Paths["/pets/{petId}"].Get
+	op.Extensions = map[string]any{"x-roles": []any{"admin", "vet"}, "x-permissions": "pets:read"}
+
+	_, err := NewServerGenerator(spec).Generate()
+	assert.EqualError(t, err, "operation getPet declares x-roles or x-permissions but has no security requirements")
+
+	op.Security = []openapi.SecurityRequirement{{"basicAuth": {}}}
+	spec.Components = &openapi.Components{SecuritySchemes: map[string]*openapi.SecurityScheme{
+		"basicAuth": {Type: "http", Scheme: "basic"},
+	}}
+	code, err := NewServerGenerator(spec).Generate()
+	require.NoError(t, err)
+
+	assert.Contains(t, code, "\t\tRoles:       []string{\"admin\", \"vet\"},\n")
+	assert.Contains(t, code, "\t\tPermissions: []string{\"pets:read\"},\n")
+}
+
+func TestGenerateRequestLogging(t *testing.T) {
+	spec := newPetSpec(openapi.Responses{"200": {Description: "OK"}})
+	spec.Servers = []*openapi.Server{{URL: "/api/v1"}}
+
+	tests := []struct {
+		router     string
+		route      string
+		paramValue string
+		middleware string
+	}{
+		{RouterBuiltin, "/api/v1/pets/{petId}", "router.URLParam(r, name)", "router.Logger"},
+		{RouterChi, "/api/v1/pets/{petId}", "chi.URLParam(r, name)", "middleware.Logger"},
+		{RouterStdlib, "/api/v1/pets/{petId}", "r.PathValue(name)", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.router, func(t *testing.T) {
+			code, err := NewServerGeneratorWithConfig(spec, Config{Router: tt.router}).Generate()
+			require.NoError(t, err)
+
+			assert.Contains(t, code, "func WithLogger(logger *slog.Logger) ServerOption {")
+			assert.Contains(t, code, "\tLogger       *slog.Logger\n")
+			assert.Contains(t, code, "rw, finish := w.startOperation(ctx, operationID, \""+tt.route+"\", rw, r)")
+			assert.Contains(t, code, "\t\t\t\tslog.Any(\"params\", routeParams(r, route)),\n")
+			assert.Contains(t, code, "\t\tparams[name] = "+tt.paramValue+"\n")
+			// NewRouter logs API requests with slog instead of the line-based logger
+			assert.Contains(t, code, "\topts = append([]ServerOption{WithLogger(slog.Default())}, opts...)\n")
+			if tt.middleware != "" {
+				assert.NotContains(t, code, "r.Use("+tt.middleware+")")
+			}
+		})
+	}
+}
+
+func TestGenerateErrorMappers(t *testing.T) {
+	spec := newPetSpec(openapi.Responses{
+		"200": {Description: "OK"},
+	})
+
+	code, err := NewServerGenerator(spec).Generate()
+	require.NoError(t, err)
+
+	assert.Contains(t, code, "type ErrorMapper func(err error) (status int, body any, ok bool)")
+	assert.Contains(t, code, "ErrorMappers []ErrorMapper")
+	assert.Contains(t, code, "func WithErrorMapper(mapper ErrorMapper) ServerOption {")
+
+	// Mappers are consulted before the HTTPError fallback
+	mapperIdx := strings.Index(code, "for _, mapper := range w.ErrorMappers {")
+	httpErrIdx := strings.Index(code, "if errors.As(err, &httpErr) {")
+	require.True(t, mapperIdx > 0, "Should range over error mappers")
+	assert.Less(t, mapperIdx, httpErrIdx)
+}
+
+func TestGenerateRouterTargets(t *testing.T) {
+	spec := newPetSpec(openapi.Responses{
+		"200": {Description: "OK"},
+	})
+
+	t.Run("builtin router", func(t *testing.T) {
+		code, err := NewServerGenerator(spec).Generate()
+		require.NoError(t, err)
+
+		assert.Contains(t, code, "\"github.com/christopherklint97/specweaver/pkg/router\"")
+		assert.Contains(t, code, "petIdStr := router.URLParam(r, \"petId\")")
+		assert.Contains(t, code, "func ConfigureRouter(r router.Router, si Server, opts ...ServerOption) {")
+		assert.NotContains(t, code, "go-chi")
+	})
+
+	t.Run("chi router", func(t *testing.T) {
+		code, err := NewServerGeneratorWithConfig(spec, Config{Router: RouterChi}).Generate()
+		require.NoError(t, err)
+
+		assert.Contains(t, code, "\"github.com/go-chi/chi/v5\"")
+		assert.Contains(t, code, "petIdStr := chi.URLParam(r, \"petId\")")
+		assert.Contains(t, code, "func ConfigureChiRouter(r chi.Router, si Server, opts ...ServerOption) {")
+		assert.Contains(t, code, "\tr.Get(\"/pets/{petId}\", withOperation(operationInfos[\"getPet\"], wrapper.handleGetPet))\n")
+		assert.Contains(t, code, "func NewRouter(si Server, opts ...ServerOption) *chi.Mux {")
+		assert.NotContains(t, code, "specweaver/pkg/router")
+		assert.NotContains(t, code, "func ConfigureRouter(")
+	})
+
+	t.Run("stdlib ServeMux", func(t *testing.T) {
+		code, err := NewServerGeneratorWithConfig(spec, Config{Router: RouterStdlib}).Generate()
+		require.NoError(t, err)
+
+		assert.Contains(t, code, "petIdStr := r.PathValue(\"petId\")")
+		assert.Contains(t, code, "func ConfigureServeMux(mux *http.ServeMux, si Server, opts ...ServerOption) {")
+		assert.Contains(t, code, "\tmux.HandleFunc(\"GET /pets/{petId}\", withOperation(operationInfos[\"getPet\"], wrapper.handleGetPet))\n")
+		assert.Contains(t, code, "func NewRouter(si Server, opts ...ServerOption) *http.ServeMux {")
+		assert.NotContains(t, code, "specweaver/pkg/router")
+	})
+
+	t.Run("unsupported router", func(t *testing.T) {
+		_, err := NewServerGeneratorWithConfig(spec, Config{Router: "gin"}).Generate()
+		assert.Error(t, err)
+	})
+}
+
+func TestGenerateTypedPathParams(t *testing.T) {
+	spec := newPetSpec(openapi.Responses{
+		"200": {Description: "OK"},
+	})
+	spec.Paths["/pets/{petId}"].Get.Parameters[0].Schema.Value = &openapi.Schema{Type: []string{"integer"}, Format: "int64"}
+
+	code, err := NewServerGenerator(spec).Generate()
+	require.NoError(t, err)
+	assert.Contains(t, code, "\tpetIdVal, err := router.URLParamInt64(r, \"petId\")\n")
+	assert.Contains(t, code, "\treq.PetId = petIdVal\n")
+	assert.NotContains(t, code, "strconv.ParseInt(petIdStr")
+
+	code, err = NewServerGeneratorWithConfig(spec, Config{Router: RouterChi}).Generate()
+	require.NoError(t, err)
+	assert.Contains(t, code, "strconv.ParseInt(petIdStr, 10, 64)", "Should parse chi parameters with strconv")
+}
+
+func TestConvertToServeMuxPath(t *testing.T) {
+	assert.Equal(t, "/pets/{petId}", convertToServeMuxPath("/pets/{petId}"))
+	assert.Equal(t, "/pets/{pet_id}/toys", convertToServeMuxPath("/pets/{pet-id}/toys"))
+	assert.Equal(t, "/{$}", convertToServeMuxPath("/"))
+	assert.Equal(t, "/pets/{$}", convertToServeMuxPath("/pets/"))
+	assert.Equal(t, "/files/{file_path...}", convertToServeMuxPath("/files/{file-path...}"))
+}
+
+func TestGenerateWildcardPath(t *testing.T) {
+	newFileSpec := func(path string) *openapi.Document {
+		return &openapi.Document{
+			OpenAPI: "3.1.0",
+			Info:    &openapi.Info{Title: "Test", Version: "1.0.0"},
+			Paths: openapi.Paths{
+				path: {
+					Get: &openapi.Operation{
+						OperationID: "getFile",
+						Parameters: []*openapi.Parameter{
+							{
+								Name:       "filePath",
+								In:         "path",
+								Required:   true,
+								Schema:     &openapi.SchemaRef{Value: &openapi.Schema{Type: []string{"string"}}},
+								Extensions: map[string]any{"x-wildcard": true},
+							},
+						},
+						Responses: openapi.Responses{"200": {Description: "OK"}},
+					},
+				},
+			},
+		}
+	}
+	spec := newFileSpec("/files/{filePath}")
+
+	t.Run("builtin", func(t *testing.T) {
+		code, err := NewServerGenerator(spec).Generate()
+		require.NoError(t, err)
+
+		assert.Contains(t, code, "r.Get(\"/files/{filePath...}\", ")
+		assert.Contains(t, code, "filePathStr := router.URLParam(r, \"filePath\")")
+	})
+
+	t.Run("chi", func(t *testing.T) {
+		code, err := NewServerGeneratorWithConfig(spec, Config{Router: RouterChi}).Generate()
+		require.NoError(t, err)
+
+		assert.Contains(t, code, "r.Get(\"/files/*\", ")
+		assert.Contains(t, code, "filePathStr := chi.URLParam(r, \"*\")")
+	})
+
+	t.Run("stdlib", func(t *testing.T) {
+		code, err := NewServerGeneratorWithConfig(spec, Config{Router: RouterStdlib}).Generate()
+		require.NoError(t, err)
+
+		assert.Contains(t, code, "mux.HandleFunc(\"GET /files/{filePath...}\", ")
+		assert.Contains(t, code, "filePathStr := r.PathValue(\"filePath\")")
+	})
+
+	t.Run("not the last segment", func(t *testing.T) {
+		_, err := NewServerGenerator(newFileSpec("/files/{filePath}/raw")).Generate()
+		assert.ErrorContains(t, err, "wildcard parameter filePath must be the last path segment")
+	})
+}
+
+func TestGeneratePathConstraints(t *testing.T) {
+	spec := newPetSpec(openapi.Responses{"200": {Description: "OK"}})
+	spec.Paths["/pets/{petId}"].Get.Parameters[0].Schema.Value.Type = []string{"integer"}
+
+	t.Run("builtin", func(t *testing.T) {
+		code, err := NewServerGenerator(spec).Generate()
+		require.NoError(t, err)
+
+		assert.Contains(t, code, "\tr.Get(\"/pets/{petId:-?[0-9]+}\", ")
+		assert.Contains(t, code, "Route:  \"/pets/{petId}\",", "Should report the unconstrained route pattern")
+	})
+
+	t.Run("chi", func(t *testing.T) {
+		code, err := NewServerGeneratorWithConfig(spec, Config{Router: RouterChi}).Generate()
+		require.NoError(t, err)
+
+		assert.Contains(t, code, "\tr.Get(\"/pets/{petId:-?[0-9]+}\", ")
+	})
+
+	t.Run("stdlib", func(t *testing.T) {
+		code, err := NewServerGeneratorWithConfig(spec, Config{Router: RouterStdlib}).Generate()
+		require.NoError(t, err)
+
+		assert.Contains(t, code, "\tmux.HandleFunc(\"GET /pets/{petId}\", ")
+	})
+}
+
+func TestParamConstraint(t *testing.T) {
+	minimum := 1.0
+	tests := []struct {
+		name     string
+		schema   *openapi.Schema
+		expected string
+	}{
+		{"integer", &openapi.Schema{Type: []string{"integer"}}, "-?[0-9]+"},
+		{"non-negative integer", &openapi.Schema{Type: []string{"integer"}, Minimum: &minimum}, "[0-9]+"},
+		{"enum", &openapi.Schema{Type: []string{"string"}, Enum: []any{"dog", "cat.v2"}}, "(?:dog|cat\\.v2)"},
+		{"uuid", &openapi.Schema{Type: []string{"string"}, Format: "uuid"}, "[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}"},
+		{"anchored pattern", &openapi.Schema{Type: []string{"string"}, Pattern: "^[A-Z]{3}$"}, "[A-Z]{3}"},
+		{"unanchored pattern", &openapi.Schema{Type: []string{"string"}, Pattern: "[A-Z]{3}"}, ""},
+		{"pattern with slash", &openapi.Schema{Type: []string{"string"}, Pattern: "^a/b$"}, ""},
+		{"plain string", &openapi.Schema{Type: []string{"string"}}, ""},
+		{"number", &openapi.Schema{Type: []string{"number"}}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			param := &openapi.Parameter{Name: "id", In: "path", Schema: &openapi.SchemaRef{Value: tt.schema}}
+			assert.Equal(t, tt.expected, paramConstraint(param))
+		})
+	}
+
+	t.Run("conflicting declarations", func(t *testing.T) {
+		pathItem := &openapi.PathItem{
+			Get: &openapi.Operation{Parameters: []*openapi.Parameter{
+				{Name: "id", In: "path", Schema: &openapi.SchemaRef{Value: &openapi.Schema{Type: []string{"integer"}}}},
+			}},
+			Delete: &openapi.Operation{Parameters: []*openapi.Parameter{
+				{Name: "id", In: "path", Schema: &openapi.SchemaRef{Value: &openapi.Schema{Type: []string{"string"}}}},
+			}},
+		}
+		assert.Empty(t, pathConstraints(pathItem)["id"])
+	})
+}
+
+func TestWithFileHeader(t *testing.T) {
+	g := NewServerGenerator(&openapi.Document{})
+
+	code := g.withFileHeader("// uses fmt.Sprintf in a comment only\nvar _ = http.StatusOK\nvar _ = router.URLParam\n")
+
+	assert.Contains(t, code, "package api\n\nimport (\n\t\"net/http\"\n\n\t\"github.com/christopherklint97/specweaver/pkg/router\"\n)\n")
+	assert.NotContains(t, code, "\"fmt\"")
+	assert.Equal(t, "package api\n\nvar x = 1\n", g.withFileHeader("var x = 1\n"))
+}
+
+func TestGenerateDocs(t *testing.T) {
+	spec := newPetSpec(openapi.Responses{"200": {Description: "OK"}})
+	spec.Info = &openapi.Info{Title: "Pets <100%>", Version: "1.0.0"}
+
+	code, err := NewServerGeneratorWithConfig(spec, Config{Docs: true}).Generate()
+	require.NoError(t, err)
+
+	// Docs are served from the embedded spec, so they imply EmbedSpec
+	assert.Contains(t, code, "//go:embed openapi.json\n")
+	assert.Contains(t, code, "func MountDocs(r router.Router, prefix string) {\n")
+	assert.Contains(t, code, "\tr.Get(index, serveDocsPage(swaggerUIPage, specURL))\n")
+	assert.Contains(t, code, "\tr.Get(prefix+\"/redoc\", serveDocsPage(redocPage, specURL))\n")
+	assert.Contains(t, code, "<title>Pets &lt;100%%&gt;</title>\n")
+	assert.Contains(t, code, "\t\"html\"\n")
+
+	t.Run("stdlib", func(t *testing.T) {
+		code, err := NewServerGeneratorWithConfig(spec, Config{Docs: true, Router: RouterStdlib}).Generate()
+		require.NoError(t, err)
+
+		assert.Contains(t, code, "func MountDocs(mux *http.ServeMux, prefix string) {\n")
+		assert.Contains(t, code, "\t\tindex = \"/{$}\"\n")
+		assert.Contains(t, code, "\tmux.HandleFunc(\"GET \"+specURL, serveSpec(\"application/json\", OpenAPIJSON))\n")
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		code, err := NewServerGeneratorWithConfig(spec, Config{EmbedSpec: true}).Generate()
+		require.NoError(t, err)
+
+		assert.NotContains(t, code, "MountDocs")
+	})
+}
+
+func TestGenerateAutoHead(t *testing.T) {
+	spec := newPetSpec(openapi.Responses{"200": {Description: "OK"}})
+	spec.Paths["/health"] = &openapi.PathItem{
+		Get: &openapi.Operation{
+			OperationID: "getHealth",
+			Responses:   openapi.Responses{"200": {Description: "OK"}},
+		},
+		Head: &openapi.Operation{
+			OperationID: "headHealth",
+			Responses:   openapi.Responses{"200": {Description: "OK"}},
+		},
+	}
+
+	code, err := NewServerGeneratorWithConfig(spec, Config{AutoHead: true}).Generate()
+	require.NoError(t, err)
+
+	assert.Contains(t, code, "func headHandler(h http.HandlerFunc) http.HandlerFunc {")
+	assert.Contains(t, code, "\tr.Head(\"/pets/{petId}\", headHandler(withOperation(operationInfos[\"getPet\"], wrapper.handleGetPet)))\n")
+	// Paths defining HEAD keep their own operation
+	assert.Contains(t, code, "\tr.Head(\"/health\", withOperation(operationInfos[\"headHealth\"], wrapper.handleHeadHealth))\n")
+	assert.NotContains(t, code, "headHandler(wrapper.handleGetHealth)")
+
+	t.Run("stdlib", func(t *testing.T) {
+		code, err := NewServerGeneratorWithConfig(spec, Config{AutoHead: true, Router: RouterStdlib}).Generate()
+		require.NoError(t, err)
+
+		assert.Contains(t, code, "\tmux.HandleFunc(\"HEAD /pets/{petId}\", headHandler(withOperation(operationInfos[\"getPet\"], wrapper.handleGetPet)))\n")
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		code, err := NewServerGenerator(spec).Generate()
+		require.NoError(t, err)
+
+		assert.NotContains(t, code, "headHandler")
+	})
+}
+
+func TestGenerateThin(t *testing.T) {
+	spec := newPetSpec(openapi.Responses{"200": {Description: "OK"}})
+
+	code, err := NewServerGeneratorWithConfig(spec, Config{Thin: true}).Generate()
+	require.NoError(t, err)
+
+	assert.Contains(t, code, "type ServerInterface interface {\n\tGetPet(w http.ResponseWriter, r *http.Request)\n}")
+	assert.Contains(t, code, "func BindGetPetRequest(r *http.Request) (GetPetRequest, error) {")
+	assert.Contains(t, code, "\treq.PetId = petIdStr\n\n\treturn req, nil\n")
+	assert.Contains(t, code, "func ConfigureRouter(r router.Router, si ServerInterface) {\n\tr.Get(\"/pets/{petId}\", withOperation(operationInfos[\"getPet\"], si.GetPet))\n}")
+	assert.Contains(t, code, "func NewRouter(si ServerInterface) *router.Mux {")
+	assert.NotContains(t, code, "ServerWrapper")
+	assert.NotContains(t, code, "GetPetResponse")
+
+	t.Run("invalid parameters are returned", func(t *testing.T) {
+		spec := newPetSpec(openapi.Responses{"200": {Description: "OK"}})
+		spec.Paths["/pets/{petId}"].Get.Parameters[0].Schema.Value.Type = []string{"integer"}
+
+		code, err := NewServerGeneratorWithConfig(spec, Config{Thin: true, Router: RouterChi}).Generate()
+		require.NoError(t, err)
+
+		assert.Contains(t, code, "\t\treturn req, NewHTTPError(http.StatusBadRequest, \"invalid petId parameter\")\n")
+		assert.Contains(t, code, "func ConfigureChiRouter(r chi.Router, si ServerInterface) {")
+	})
+
+	t.Run("unsupported options", func(t *testing.T) {
+		_, err := NewServerGeneratorWithConfig(spec, Config{Thin: true, CORS: true}).Generate()
+		assert.Error(t, err)
+
+		_, err = NewServerGeneratorWithConfig(spec, Config{Thin: true, Idempotency: true}).Generate()
+		assert.Error(t, err)
+	})
+}
+
+func TestGenerateBodySizeLimit(t *testing.T) {
+	newSpec := func() *openapi.Document {
+		return &openapi.Document{
+			OpenAPI: "3.1.0",
+			Info:    &openapi.Info{Title: "Test", Version: "1.0.0"},
+			Paths: openapi.Paths{
+				"/orders": {
+					Post: &openapi.Operation{
+						OperationID: "createOrder",
+						RequestBody: &openapi.RequestBody{
+							Content: map[string]*openapi.MediaType{
+								"application/json": {Schema: &openapi.SchemaRef{Value: &openapi.Schema{Type: []string{"object"}}}},
+							},
+						},
+						Responses: openapi.Responses{"204": {Description: "Created"}},
+					},
+				},
+			},
+		}
+	}
+
+	t.Run("global option", func(t *testing.T) {
+		code, err := NewServerGeneratorWithConfig(newSpec(), Config{MaxBodySize: 1 << 20}).Generate()
+		require.NoError(t, err)
+
+		assert.Contains(t, code, "\tr.Body = http.MaxBytesReader(rw, r.Body, 1048576)\n\tif err := ReadJSON(r, &req.Body); err != nil {\n\t\tw.handleError(ctx, operationID, rw, requestBodyError(err))\n")
+		assert.Contains(t, code, "func requestBodyError(err error) *HTTPError {")
+		assert.Contains(t, code, "http.StatusRequestEntityTooLarge")
+	})
+
+	t.Run("operation extension overrides the option", func(t *testing.T) {
+		spec := newSpec()
+		spec.Paths["/orders"].Post.Extensions = map[string]any{"x-max-body-size": 512}
+
+		code, err := NewServerGeneratorWithConfig(spec, Config{MaxBodySize: 1 << 20}).Generate()
+		require.NoError(t, err)
+		assert.Contains(t, code, "http.MaxBytesReader(rw, r.Body, 512)")
+
+		spec.Paths["/orders"].Post.Extensions = map[string]any{"x-max-body-size": 0}
+		code, err = NewServerGeneratorWithConfig(spec, Config{MaxBodySize: 1 << 20}).Generate()
+		require.NoError(t, err)
+		assert.NotContains(t, code, "MaxBytesReader")
+		assert.NotContains(t, code, "requestBodyError")
+	})
+
+	t.Run("thin mode", func(t *testing.T) {
+		code, err := NewServerGeneratorWithConfig(newSpec(), Config{Thin: true, MaxBodySize: 1024}).Generate()
+		require.NoError(t, err)
+
+		assert.Contains(t, code, "\tr.Body = http.MaxBytesReader(nil, r.Body, 1024)\n")
+		assert.Contains(t, code, "\t\treturn req, requestBodyError(err)\n")
+	})
+
+	t.Run("unlimited by default", func(t *testing.T) {
+		code, err := NewServerGenerator(newSpec()).Generate()
+		require.NoError(t, err)
+
+		assert.NotContains(t, code, "MaxBytesReader")
+	})
+}
+
+func TestExtensionInt(t *testing.T) {
+	for _, value := range []any{1024, int64(1024), uint64(1024), float64(1024)} {
+		size, ok := extensionInt(&openapi.Operation{Extensions: map[string]any{"x-max-body-size": value}}, "x-max-body-size")
+		assert.True(t, ok)
+		assert.Equal(t, int64(1024), size)
+	}
+
+	_, ok := extensionInt(&openapi.Operation{Extensions: map[string]any{"x-max-body-size": "1MB"}}, "x-max-body-size")
+	assert.False(t, ok)
+	_, ok = extensionInt(&openapi.Operation{}, "x-max-body-size")
+	assert.False(t, ok)
+}
+
+func TestGenerateTimeout(t *testing.T) {
+	spec := newPetSpec(openapi.Responses{"200": {Description: "OK"}})
+	spec.Paths["/health"] = &openapi.PathItem{
+		Get: &openapi.Operation{
+			OperationID: "getHealth",
+			Extensions:  map[string]any{"x-timeout": "250ms"},
+			Responses:   openapi.Responses{"200": {Description: "OK"}},
+		},
+	}
+
+	code, err := NewServerGeneratorWithConfig(spec, Config{Timeout: 30 * time.Second}).Generate()
+	require.NoError(t, err)
+
+	assert.Contains(t, code, "\tctx, cancel := context.WithTimeout(ctx, 30*time.Second)\n\tdefer cancel()\n\n\t// Call handler\n\tresp, err := w.Handler.GetPet(ctx, req)\n")
+	assert.Contains(t, code, "\tctx, cancel := context.WithTimeout(ctx, 250*time.Millisecond)\n")
+	assert.Contains(t, code, "\tif err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {\n\t\terr = WrapHTTPError(http.StatusGatewayTimeout, ctx.Err(), \"operation timed out\")\n", "Should only replace errors, not late successes")
+
+	t.Run("no deadline by default", func(t *testing.T) {
+		code, err := NewServerGenerator(newPetSpec(openapi.Responses{"200": {Description: "OK"}})).Generate()
+		require.NoError(t, err)
+
+		assert.NotContains(t, code, "WithTimeout")
+	})
+
+	t.Run("not supported in thin mode", func(t *testing.T) {
+		_, err := NewServerGeneratorWithConfig(spec, Config{Thin: true, Timeout: time.Second}).Generate()
+		assert.Error(t, err)
+
+		_, err = NewServerGeneratorWithConfig(spec, Config{Thin: true}).Generate()
+		require.Error(t, err, "Should reject x-timeout extensions as well as the option")
+		assert.Contains(t, err.Error(), "operation getHealth: x-timeout is not supported in thin mode")
+	})
+}
+
+func TestOperationTimeout(t *testing.T) {
+	g := NewServerGeneratorWithConfig(&openapi.Document{}, Config{Timeout: time.Minute})
+
+	assert.Equal(t, 5*time.Second, g.operationTimeout(&openapi.Operation{Extensions: map[string]any{"x-timeout": "5s"}}))
+	assert.Equal(t, 10*time.Second, g.operationTimeout(&openapi.Operation{Extensions: map[string]any{"x-timeout": 10}}))
+	assert.Equal(t, time.Duration(0), g.operationTimeout(&openapi.Operation{Extensions: map[string]any{"x-timeout": 0}}))
+	assert.Equal(t, time.Minute, g.operationTimeout(&openapi.Operation{}))
+}
+
+func TestGenerateRateLimit(t *testing.T) {
+	spec := newPetSpec(openapi.Responses{"200": {Description: "OK"}})
+	spec.Paths["/pets/{petId}"].Get.Extensions = map[string]any{
+		"x-rate-limit": map[string]any{"requests": 100, "period": "1m"},
+	}
+
+	code, err := NewServerGenerator(spec).Generate()
+	require.NoError(t, err)
+
+	assert.Contains(t, code, "rateLimit(100, 1*time.Minute, wrapper.handleGetPet)")
+	assert.Contains(t, code, "\treturn router.RateLimit(float64(limit)/period.Seconds(), limit,\n\t\trouter.WithRateLimitKey(rateLimitKey),\n",
+		"Should use the token bucket of the router package")
+	assert.Contains(t, code, "WriteError(w, http.StatusTooManyRequests, errors.New(\"rate limit exceeded\"))")
+	assert.Contains(t, code, "\treturn \"ip:\" + clientIP(r)\n")
+	assert.NotContains(t, code, "type rateLimiter struct", "Should not generate a limiter of its own")
+	assert.NotContains(t, code, "func principalID(")
+
+	t.Run("keyed by principal", func(t *testing.T) {
+		spec.Components = &openapi.Components{
+			SecuritySchemes: map[string]*openapi.SecurityScheme{
+				"bearerAuth": {Type: "http", Scheme: "bearer"},
+			},
+		}
+		spec.Paths["/pets/{petId}"].Get.Security = []openapi.SecurityRequirement{{"bearerAuth": {}}}
+
+		code, err := NewServerGenerator(spec).Generate()
+		require.NoError(t, err)
+
+		assert.Contains(t, code, "(http.HandlerFunc(rateLimit(100, 1*time.Minute, wrapper.handleGetPet)))",
+			"Should limit requests after authentication")
+		assert.Contains(t, code, "\t\tif id := principalID(secCtx); id != \"\" {\n\t\t\treturn \"principal:\" + id\n\t\t}\n")
+		assert.NotContains(t, code, "fmt.Sprint(secCtx.Principal)", "Should not key pointer principals by address")
+		assert.Equal(t, 1, strings.Count(code, "func principalID("))
+
+		code, err = NewServerGeneratorWithConfig(spec, Config{Idempotency: true}).Generate()
+		require.NoError(t, err)
+		assert.Equal(t, 1, strings.Count(code, "func principalID("), "Should generate principalID once for idempotency and rate limits")
+	})
+
+	t.Run("not limited by default", func(t *testing.T) {
+		code, err := NewServerGenerator(newPetSpec(openapi.Responses{"200": {Description: "OK"}})).Generate()
+		require.NoError(t, err)
+
+		assert.NotContains(t, code, "rateLimit")
+	})
+
+	t.Run("invalid extension", func(t *testing.T) {
+		spec := newPetSpec(openapi.Responses{"200": {Description: "OK"}})
+		spec.Paths["/pets/{petId}"].Get.Extensions = map[string]any{"x-rate-limit": 100}
+
+		_, err := NewServerGenerator(spec).Generate()
+		assert.Error(t, err)
+	})
+}
+
+func TestOperationRateLimit(t *testing.T) {
+	rateLimit := func(value any) (int64, time.Duration, error) {
+		return operationRateLimit(&openapi.Operation{Extensions: map[string]any{"x-rate-limit": value}})
+	}
+
+	limit, period, err := rateLimit(map[string]any{"requests": 10, "period": "1s"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), limit)
+	assert.Equal(t, time.Second, period)
+
+	limit, period, err = rateLimit(map[string]any{"requests": float64(5), "period": float64(60)})
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), limit)
+	assert.Equal(t, time.Minute, period)
+
+	_, _, err = rateLimit(map[string]any{"requests": 0, "period": "1s"})
+	assert.Error(t, err)
+	_, _, err = rateLimit(map[string]any{"requests": 10})
+	assert.Error(t, err)
+	_, _, err = rateLimit("10/s")
+	assert.Error(t, err)
+
+	limit, _, err = operationRateLimit(&openapi.Operation{})
+	require.NoError(t, err)
+	assert.Zero(t, limit)
+}
+
+func TestDurationLiteral(t *testing.T) {
+	assert.Equal(t, "2*time.Hour", durationLiteral(2*time.Hour))
+	assert.Equal(t, "90*time.Second", durationLiteral(90*time.Second))
+	assert.Equal(t, "1500*time.Millisecond", durationLiteral(1500*time.Millisecond))
+	assert.Equal(t, "time.Duration(1)", durationLiteral(1))
+}
+
+func TestGenerateCORS(t *testing.T) {
+	spec := newPetSpec(openapi.Responses{"200": {Description: "OK"}})
+	spec.Paths["/pets/{petId}"].Delete = &openapi.Operation{
+		OperationID: "deletePet",
+		Responses:   openapi.Responses{"204": {Description: "Deleted"}},
+	}
+	spec.Paths["/custom"] = &openapi.PathItem{
+		Options: &openapi.Operation{
+			OperationID: "customOptions",
+			Responses:   openapi.Responses{"204": {Description: "OK"}},
+		},
+	}
+
+	code, err := NewServerGeneratorWithConfig(spec, Config{CORS: true, AutoHead: true}).Generate()
+	require.NoError(t, err)
+
+	assert.Contains(t, code, "type CORSPolicy struct {")
+	assert.Contains(t, code, "func WithCORS(policy CORSPolicy) ServerOption {")
+	assert.Contains(t, code, "\tCORS         *CORSPolicy\n")
+	assert.Contains(t, code, "\tr.Get(\"/pets/{petId}\", wrapper.withCORS(withOperation(operationInfos[\"getPet\"], wrapper.handleGetPet)))\n")
+	assert.Contains(t, code, "\tr.Head(\"/pets/{petId}\", headHandler(wrapper.withCORS(withOperation(operationInfos[\"getPet\"], wrapper.handleGetPet))))\n")
+	assert.Contains(t, code, "\tr.Options(\"/pets/{petId}\", wrapper.optionsHandler(\"GET, HEAD, DELETE, OPTIONS\"))\n")
+	// Paths defining OPTIONS keep their own operation
+	assert.Contains(t, code, "\tr.Options(\"/custom\", wrapper.withCORS(withOperation(operationInfos[\"customOptions\"], wrapper.handleCustomOptions)))\n")
+	assert.NotContains(t, code, "wrapper.optionsHandler(\"OPTIONS\")")
+
+	t.Run("stdlib", func(t *testing.T) {
+		code, err := NewServerGeneratorWithConfig(spec, Config{CORS: true, Router: RouterStdlib}).Generate()
+		require.NoError(t, err)
+
+		assert.Contains(t, code, "\tmux.HandleFunc(\"OPTIONS /pets/{petId}\", wrapper.optionsHandler(\"GET, DELETE, OPTIONS\"))\n")
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		code, err := NewServerGenerator(spec).Generate()
+		require.NoError(t, err)
+
+		assert.NotContains(t, code, "CORS")
+		assert.NotContains(t, code, "optionsHandler")
+	})
+}
+
+func TestGenerateIdempotency(t *testing.T) {
+	spec := newPetSpec(openapi.Responses{"200": {Description: "OK"}})
+	spec.Paths["/pets"] = &openapi.PathItem{
+		Post: &openapi.Operation{
+			OperationID: "createPet",
+			Responses:   openapi.Responses{"201": {Description: "Created"}},
+		},
+	}
+
+	t.Run("enabled for POST operations by option", func(t *testing.T) {
+		code, err := NewServerGeneratorWithConfig(spec, Config{Idempotency: true}).Generate()
+		require.NoError(t, err)
+
+		assert.Contains(t, code, "type IdempotencyStore interface {")
+		assert.Contains(t, code, "func WithIdempotencyStore(store IdempotencyStore) ServerOption {")
+		assert.Contains(t, code, "\tHandler          Server\n")
+		assert.Contains(t, code, "\tIdempotencyStore IdempotencyStore\n")
+		assert.Contains(t, code, "type CreatePetRequest struct {\n\t// IdempotencyKey identifies retries of the same request (Idempotency-Key header)\n\tIdempotencyKey string")
+		assert.Contains(t, code, "\treq.IdempotencyKey = r.Header.Get(\"Idempotency-Key\")\n")
+		assert.Contains(t, code, "\trw, written, saveIdempotent := w.startIdempotent(ctx, operationID, rw, req.IdempotencyKey, nil)\n")
+		assert.Contains(t, code, "type GetPetRequest struct {\n\tPetId string")
+		assert.Contains(t, code, "\tReserve(ctx context.Context, key string) (bool, error)\n")
+		assert.Contains(t, code, "NewHTTPError(http.StatusConflict, ")
+		assert.Contains(t, code, "\tstoreKey := operationID + \":\" + key\n")
+		assert.NotContains(t, code, "ir.Header().Clone()", "Should only record declared headers")
+	})
+
+	t.Run("panicking handlers release the key", func(t *testing.T) {
+		code, err := NewServerGeneratorWithConfig(spec, Config{Idempotency: true}).Generate()
+		require.NoError(t, err)
+
+		assert.Contains(t, code, "\tir := &idempotencyRecorder{ResponseWriter: rw}\n", "Should not default to a recorded 200")
+		assert.Contains(t, code, "\t\tif p := recover(); p != nil {\n\t\t\tif err := w.IdempotencyStore.Release(ctx, storeKey); err != nil && w.Hooks.OnError != nil {\n\t\t\t\tw.Hooks.OnError(ctx, operationID, err)\n\t\t\t}\n\t\t\tpanic(p)\n\t\t}\n")
+		assert.Contains(t, code, "\tif !ir.wroteHeader {\n\t\tir.status = http.StatusOK\n", "Should record 200 for bodies written without a header")
+	})
+
+	t.Run("declared response headers", func(t *testing.T) {
+		spec := newPetSpec(openapi.Responses{"200": {Description: "OK"}})
+		spec.Paths["/pets"] = &openapi.PathItem{
+			Post: &openapi.Operation{
+				OperationID: "createPet",
+				Responses: openapi.Responses{"201": {
+					Description: "Created",
+					Headers:     map[string]*openapi.Header{"location": {}},
+				}},
+			},
+		}
+
+		code, err := NewServerGeneratorWithConfig(spec, Config{Idempotency: true}).Generate()
+		require.NoError(t, err)
+
+		assert.Contains(t, code, "w.startIdempotent(ctx, operationID, rw, req.IdempotencyKey, []string{\"Location\"})\n")
+	})
+
+	t.Run("scoped to the principal", func(t *testing.T) {
+		spec := newPetSpec(openapi.Responses{"200": {Description: "OK"}})
+		spec.Paths["/pets"] = &openapi.PathItem{
+			Post: &openapi.Operation{
+				OperationID: "createPet",
+				Responses:   openapi.Responses{"201": {Description: "Created"}},
+			},
+		}
+		spec.Components = &openapi.Components{SecuritySchemes: map[string]*openapi.SecurityScheme{
+			"apiKey": {Type: "apiKey", In: "header", Name: "X-API-Key", Extensions: map[string]any{
+				"x-tenant": map[string]any{"in": "header", "name": "X-Tenant-ID"},
+			}},
+		}}
+
+		code, err := NewServerGeneratorWithConfig(spec, Config{Idempotency: true}).Generate()
+		require.NoError(t, err)
+
+		assert.Contains(t, code, "type PrincipalIdentifier interface {")
+		assert.Contains(t, code, "\tstoreKey := operationID + \":\" + scope + \":\" + key\n")
+		assert.Contains(t, code, "return strconv.Quote(secCtx.Tenant) + \":\" + secCtx.SchemeName + \":\" + strconv.Quote(id)\n")
+	})
+
+	t.Run("declared header parameter", func(t *testing.T) {
+		spec := newPetSpec(openapi.Responses{"200": {Description: "OK"}})
+		spec.Paths["/pets/{petId}"].Get.Parameters = append(spec.Paths["/pets/{petId}"].Get.Parameters,
+			&openapi.Parameter{Name: "Idempotency-Key", In: "header"})
+
+		code, err := NewServerGenerator(spec).Generate()
+		require.NoError(t, err)
+
+		assert.Contains(t, code, "\treq.IdempotencyKey = r.Header.Get(\"Idempotency-Key\")\n")
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		code, err := NewServerGenerator(spec).Generate()
+		require.NoError(t, err)
+
+		assert.NotContains(t, code, "Idempotency")
+		assert.Contains(t, code, "\tHandler      Server\n")
+	})
+}
+
+func TestGenerateEmbedSpec(t *testing.T) {
+	spec := newPetSpec(openapi.Responses{"200": {Description: "OK"}})
+	spec.Servers = []*openapi.Server{{URL: "/api/v1"}}
+
+	code, err := NewServerGeneratorWithConfig(spec, Config{EmbedSpec: true}).Generate()
+	require.NoError(t, err)
+
+	assert.Contains(t, code, "\t\"context\"\n\t_ \"embed\"\n\t\"encoding/json\"\n")
+	assert.Contains(t, code, "//go:embed openapi.json\nvar OpenAPIJSON []byte\n")
+	assert.Contains(t, code, "//go:embed openapi.yaml\nvar OpenAPIYAML []byte\n")
+	assert.Contains(t, code, "\tr.Get(\"/api/v1/openapi.json\", serveSpec(\"application/json\", OpenAPIJSON))\n")
+	assert.Contains(t, code, "\tr.Get(\"/api/v1/openapi.yaml\", serveSpec(\"application/yaml\", OpenAPIYAML))\n")
+
+	t.Run("disabled by default", func(t *testing.T) {
+		code, err := NewServerGenerator(spec).Generate()
+		require.NoError(t, err)
+
+		assert.NotContains(t, code, "embed")
+		assert.NotContains(t, code, "openapi.json")
+	})
+
+	t.Run("paths defined by the spec take precedence", func(t *testing.T) {
+		spec := newPetSpec(openapi.Responses{"200": {Description: "OK"}})
+		spec.Paths["/openapi.json"] = &openapi.PathItem{
+			Get: &openapi.Operation{
+				OperationID: "getSpec",
+				Responses:   openapi.Responses{"200": {Description: "OK"}},
+			},
+		}
+
+		code, err := NewServerGeneratorWithConfig(spec, Config{EmbedSpec: true}).Generate()
+		require.NoError(t, err)
+
+		assert.Contains(t, code, "\tr.Get(\"/openapi.json\", withOperation(operationInfos[\"getSpec\"], wrapper.handleGetSpec))\n")
+		assert.NotContains(t, code, "serveSpec(\"application/json\"")
+		assert.Contains(t, code, "\tr.Get(\"/openapi.yaml\", serveSpec(\"application/yaml\", OpenAPIYAML))\n")
+	})
+}
+
+func TestGenerateTagInterfaces(t *testing.T) {
+	spec := &openapi.Document{
+		OpenAPI: "3.1.0",
+		Info:    &openapi.Info{Title: "Test", Version: "1.0.0"},
+		Paths: openapi.Paths{
+			"/pets": {
+				Get: &openapi.Operation{
+					OperationID: "listPets",
+					Tags:        []string{"pets"},
+					Responses:   openapi.Responses{"200": {Description: "OK"}},
+				},
+			},
+			"/users": {
+				Get: &openapi.Operation{
+					OperationID: "listUsers",
+					Tags:        []string{"user accounts"},
+					Responses:   openapi.Responses{"200": {Description: "OK"}},
+				},
+			},
+			"/health": {
+				Get: &openapi.Operation{
+					OperationID: "getHealth",
+					Responses:   openapi.Responses{"204": {Description: "Healthy"}},
+				},
+			},
+		},
+	}
+
+	t.Run("disabled by default", func(t *testing.T) {
+		code, err := NewServerGenerator(spec).Generate()
+		require.NoError(t, err)
+
+		assert.NotContains(t, code, "PetsServer")
+		assert.NotContains(t, code, "func ComposeServer(")
+	})
+
+	t.Run("one interface per tag", func(t *testing.T) {
+		code, err := NewServerGeneratorWithConfig(spec, Config{TagInterfaces: true}).Generate()
+		require.NoError(t, err)
+
+		assert.Contains(t, code, "type PetsServer interface {\n\tListPets(ctx context.Context, req ListPetsRequest) (ListPetsResponse, error)\n}")
+		assert.Contains(t, code, "type UserAccountsServer interface {\n\tListUsers(")
+		assert.Contains(t, code, "type UntaggedServer interface {\n\tGetHealth(")
+		assert.Contains(t, code, "type Server interface {\n\tPetsServer\n\tUntaggedServer\n\tUserAccountsServer\n}")
+		assert.Contains(t, code, "func ComposeServer(petsServer PetsServer, untaggedServer UntaggedServer, userAccountsServer UserAccountsServer) Server {")
+	})
+}
+
+func TestGenerateBasePath(t *testing.T) {
+	t.Run("derived from the first server URL", func(t *testing.T) {
+		spec := newPetSpec(openapi.Responses{"200": {Description: "OK"}})
+		spec.Servers = []*openapi.Server{{URL: "https://api.example.com/api/v1/"}}
+
+		code, err := NewServerGenerator(spec).Generate()
+		require.NoError(t, err)
+
+		assert.Contains(t, code, "const BasePath = \"/api/v1\"")
+		assert.Contains(t, code, "\tr.Get(\"/api/v1/pets/{petId}\", withOperation(operationInfos[\"getPet\"], wrapper.handleGetPet))\n")
+	})
+
+	t.Run("override replaces the server URL path", func(t *testing.T) {
+		spec := newPetSpec(openapi.Responses{"200": {Description: "OK"}})
+		spec.Servers = []*openapi.Server{{URL: "/api/v1"}}
+
+		code, err := NewServerGeneratorWithConfig(spec, Config{BasePath: "v2", Router: RouterStdlib}).Generate()
+		require.NoError(t, err)
+
+		assert.Contains(t, code, "const BasePath = \"/v2\"")
+		assert.Contains(t, code, "\tmux.HandleFunc(\"GET /v2/pets/{petId}\", withOperation(operationInfos[\"getPet\"], wrapper.handleGetPet))\n")
+	})
+
+	t.Run("root override disables the prefix", func(t *testing.T) {
+		spec := newPetSpec(openapi.Responses{"200": {Description: "OK"}})
+		spec.Servers = []*openapi.Server{{URL: "/api/v1"}}
+
+		code, err := NewServerGeneratorWithConfig(spec, Config{BasePath: "/"}).Generate()
+		require.NoError(t, err)
+
+		assert.Contains(t, code, "const BasePath = \"\"")
+		assert.Contains(t, code, "\tr.Get(\"/pets/{petId}\", withOperation(operationInfos[\"getPet\"], wrapper.handleGetPet))\n")
+	})
+}
@@ -0,0 +1,69 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/christopherklint97/specweaver/pkg/openapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthGeneratorGenerateSession(t *testing.T) {
+	spec := &openapi.Document{
+		OpenAPI: "3.1.0",
+		Info: &openapi.Info{
+			Title:   "Test API",
+			Version: "1.0.0",
+		},
+		Components: &openapi.Components{
+			SecuritySchemes: map[string]*openapi.SecurityScheme{
+				"sessionAuth": {
+					Type: "apiKey",
+					In:   "cookie",
+					Name: "session_id",
+				},
+				"headerKey": {
+					Type: "apiKey",
+					In:   "header",
+					Name: "X-API-Key",
+				},
+			},
+		},
+	}
+
+	code, err := NewAuthGenerator(spec).GenerateSession()
+	require.NoError(t, err, "GenerateSession should not fail")
+
+	assert.Contains(t, code, "package api", "Should have package declaration")
+	assert.Contains(t, code, "\tSessionAuthCookieName = \"session_id\"\n", "Should name the cookie of the scheme")
+	assert.Contains(t, code, "var sessionCookieNames = []string{SessionAuthCookieName}\n", "Should only list cookie schemes")
+	assert.Contains(t, code, "func SetSessionCookie(w http.ResponseWriter, name, value string, maxAge time.Duration) {",
+		"Should have SetSessionCookie")
+	assert.Contains(t, code, "func ClearSessionCookie(w http.ResponseWriter, name string) {", "Should have ClearSessionCookie")
+	assert.Contains(t, code, "\t\tHttpOnly: true,\n", "Should keep session cookies from scripts")
+	assert.Contains(t, code, "func SetCSRFCookie(w http.ResponseWriter) string {", "Should have SetCSRFCookie")
+	assert.Contains(t, code, "\tif _, err := rand.Read(b[:]); err != nil {\n", "Should read random bytes for the CSRF token")
+	assert.NotContains(t, code, "rand.Text()", "Should not need Go 1.24 for crypto/rand.Text")
+	assert.Contains(t, code, "func CSRFProtect(next http.Handler) http.Handler {", "Should have the CSRF middleware")
+	assert.Contains(t, code, "subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(token)) != 1",
+		"Should compare the tokens in constant time")
+}
+
+func TestAuthGeneratorGenerateSessionWithoutCookieSchemes(t *testing.T) {
+	spec := &openapi.Document{
+		OpenAPI: "3.1.0",
+		Info: &openapi.Info{
+			Title:   "Test API",
+			Version: "1.0.0",
+		},
+		Components: &openapi.Components{
+			SecuritySchemes: map[string]*openapi.SecurityScheme{
+				"bearerAuth": {Type: "http", Scheme: "bearer"},
+			},
+		},
+	}
+
+	code, err := NewAuthGenerator(spec).GenerateSession()
+	require.NoError(t, err, "GenerateSession should not fail")
+	assert.Empty(t, code, "Should not generate session helpers without cookie schemes")
+}
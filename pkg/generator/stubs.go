@@ -0,0 +1,156 @@
+package generator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/christopherklint97/specweaver/pkg/openapi"
+)
+
+// StubGenerator generates handler scaffolding so a server compiles and boots
+// before any handlers are implemented
+type StubGenerator struct {
+	spec        *openapi.Document
+	packageName string
+	thin        bool
+}
+
+// NewStubGenerator creates a new StubGenerator instance
+func NewStubGenerator(spec *openapi.Document) *StubGenerator {
+	return NewStubGeneratorWithConfig(spec, Config{})
+}
+
+// NewStubGeneratorWithConfig creates a new StubGenerator instance whose stubs
+// match the handler style selected by the configuration
+func NewStubGeneratorWithConfig(spec *openapi.Document, config Config) *StubGenerator {
+	if config.PackageName == "" {
+		config.PackageName = "api"
+	}
+	return &StubGenerator{
+		spec:        spec,
+		packageName: config.PackageName,
+		thin:        config.Thin,
+	}
+}
+
+// Generate generates UnimplementedServer, which answers every operation with 501 Not Implemented
+func (g *StubGenerator) Generate() (string, error) {
+	var sb strings.Builder
+	operations := getOperations(g.spec)
+
+	sb.WriteString(fmt.Sprintf("package %s\n\n", g.packageName))
+	if len(operations) > 0 {
+		sb.WriteString("import (\n")
+		if !g.thin {
+			sb.WriteString("\t\"context\"\n")
+		}
+		sb.WriteString("\t\"net/http\"\n")
+		sb.WriteString(")\n\n")
+	}
+
+	serverInterface := "Server"
+	if g.thin {
+		serverInterface = "ServerInterface"
+	}
+	sb.WriteString(fmt.Sprintf("// UnimplementedServer implements %s by returning 501 Not Implemented for\n", serverInterface))
+	sb.WriteString("// every operation. Embed it in your own server type and override operations\n")
+	sb.WriteString("// as you implement them.\n")
+	sb.WriteString("type UnimplementedServer struct{}\n\n")
+
+	for _, info := range operations {
+		handlerName := info.HandlerName
+		sb.WriteString(fmt.Sprintf("// %s returns 501 Not Implemented\n", handlerName))
+		if g.thin {
+			sb.WriteString(fmt.Sprintf("func (UnimplementedServer) %s(w http.ResponseWriter, r *http.Request) {\n", handlerName))
+			sb.WriteString(fmt.Sprintf("\tWriteError(w, http.StatusNotImplemented, NewHTTPError(http.StatusNotImplemented, %q))\n", handlerName+" is not implemented"))
+		} else {
+			sb.WriteString(fmt.Sprintf("func (UnimplementedServer) %s(ctx context.Context, req %sRequest) (%sResponse, error) {\n", handlerName, handlerName, handlerName))
+			sb.WriteString(fmt.Sprintf("\treturn nil, NewHTTPError(http.StatusNotImplemented, %q)\n", handlerName+" is not implemented"))
+		}
+		sb.WriteString("}\n\n")
+	}
+
+	return sb.String(), nil
+}
+
+// GenerateMain generates a skeleton main package that serves UnimplementedServer.
+// importPath is the import path of the generated package.
+func (g *StubGenerator) GenerateMain(importPath string, hasSecuritySchemes bool) string {
+	var sb strings.Builder
+
+	sb.WriteString("package main\n\n")
+	sb.WriteString("import (\n")
+	sb.WriteString("\t\"log\"\n")
+	sb.WriteString("\t\"net/http\"\n")
+	sb.WriteString("\n")
+	sb.WriteString(fmt.Sprintf("\tapi %q\n", importPath))
+	sb.WriteString(")\n\n")
+
+	serverInterface := "api.Server"
+	if g.thin {
+		serverInterface = "api.ServerInterface"
+	}
+	sb.WriteString(fmt.Sprintf("// server implements %s. Operations not implemented here respond\n", serverInterface))
+	sb.WriteString("// with 501 Not Implemented via the embedded api.UnimplementedServer.\n")
+	sb.WriteString("type server struct {\n")
+	sb.WriteString("\tapi.UnimplementedServer\n")
+	sb.WriteString("}\n\n")
+
+	sb.WriteString("func main() {\n")
+	if hasSecuritySchemes {
+		sb.WriteString("\t// TODO: pass an api.Authenticator to enforce authentication\n")
+		sb.WriteString("\trouter := api.NewRouter(&server{}, nil)\n")
+	} else {
+		sb.WriteString("\trouter := api.NewRouter(&server{})\n")
+	}
+	sb.WriteString("\n")
+	sb.WriteString("\tlog.Println(\"Listening on :8080\")\n")
+	sb.WriteString("\tlog.Fatal(http.ListenAndServe(\":8080\", router))\n")
+	sb.WriteString("}\n")
+
+	return sb.String()
+}
+
+// ImportPath derives the import path of dir from the nearest enclosing go.mod.
+// It returns false when dir is not inside a Go module.
+func ImportPath(dir string) (string, bool) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", false
+	}
+
+	for current := absDir; ; current = filepath.Dir(current) {
+		data, err := os.ReadFile(filepath.Join(current, "go.mod"))
+		if err == nil {
+			modulePath := parseModulePath(string(data))
+			if modulePath == "" {
+				return "", false
+			}
+			rel, err := filepath.Rel(current, absDir)
+			if err != nil {
+				return "", false
+			}
+			if rel == "." {
+				return modulePath, true
+			}
+			return modulePath + "/" + filepath.ToSlash(rel), true
+		}
+
+		if filepath.Dir(current) == current {
+			return "", false
+		}
+	}
+}
+
+// parseModulePath returns the module path declared in go.mod contents
+func parseModulePath(goMod string) string {
+	for _, line := range strings.Split(goMod, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "module ") {
+			return strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "module ")), "\"")
+		}
+	}
+	return ""
+}
@@ -0,0 +1,79 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/christopherklint97/specweaver/pkg/openapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStubGenerator(t *testing.T) {
+	t.Run("returns 501 for every operation", func(t *testing.T) {
+		spec := newPetSpec(openapi.Responses{
+			"200": {Description: "OK"},
+		})
+
+		code, err := NewStubGenerator(spec).Generate()
+		require.NoError(t, err)
+
+		assert.Contains(t, code, "type UnimplementedServer struct{}")
+		assert.Contains(t, code, "func (UnimplementedServer) GetPet(ctx context.Context, req GetPetRequest) (GetPetResponse, error) {")
+		assert.Contains(t, code, "return nil, NewHTTPError(http.StatusNotImplemented, \"GetPet is not implemented\")")
+	})
+
+	t.Run("thin mode", func(t *testing.T) {
+		spec := newPetSpec(openapi.Responses{
+			"200": {Description: "OK"},
+		})
+
+		code, err := NewStubGeneratorWithConfig(spec, Config{Thin: true}).Generate()
+		require.NoError(t, err)
+
+		assert.NotContains(t, code, "\"context\"")
+		assert.Contains(t, code, "func (UnimplementedServer) GetPet(w http.ResponseWriter, r *http.Request) {")
+		assert.Contains(t, code, "WriteError(w, http.StatusNotImplemented, NewHTTPError(http.StatusNotImplemented, \"GetPet is not implemented\"))")
+	})
+
+	t.Run("no imports without operations", func(t *testing.T) {
+		code, err := NewStubGenerator(&openapi.Document{}).Generate()
+		require.NoError(t, err)
+
+		assert.NotContains(t, code, "import")
+		assert.Contains(t, code, "type UnimplementedServer struct{}")
+	})
+}
+
+func TestStubGeneratorMain(t *testing.T) {
+	gen := NewStubGenerator(&openapi.Document{})
+
+	code := gen.GenerateMain("example.com/app/api", false)
+	assert.Contains(t, code, "package main")
+	assert.Contains(t, code, "api \"example.com/app/api\"")
+	assert.Contains(t, code, "api.UnimplementedServer")
+	assert.Contains(t, code, "router := api.NewRouter(&server{})")
+
+	code = gen.GenerateMain("example.com/app/api", true)
+	assert.Contains(t, code, "router := api.NewRouter(&server{}, nil)")
+}
+
+func TestImportPath(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "go.mod"), []byte("module example.com/app\n\ngo 1.22\n"), 0644))
+
+	importPath, ok := ImportPath(filepath.Join(root, "internal", "api"))
+	assert.True(t, ok)
+	assert.Equal(t, "example.com/app/internal/api", importPath)
+
+	importPath, ok = ImportPath(root)
+	assert.True(t, ok)
+	assert.Equal(t, "example.com/app", importPath)
+}
+
+func TestParseModulePath(t *testing.T) {
+	assert.Equal(t, "example.com/app", parseModulePath("// comment\nmodule example.com/app\n\ngo 1.22\n"))
+	assert.Equal(t, "example.com/quoted", parseModulePath("module \"example.com/quoted\"\n"))
+	assert.Empty(t, parseModulePath("go 1.22\n"))
+}
@@ -10,17 +10,28 @@ import (
 
 // TypeGenerator generates Go types from OpenAPI schemas
 type TypeGenerator struct {
-	spec      *openapi.Document
-	generated map[string]bool
-	usesTime  bool // tracks if time.Time is used
-	usesDate  bool // tracks if date.Date is used
+	spec        *openapi.Document
+	packageName string
+	generated   map[string]bool
+	usesTime    bool // tracks if time.Time is used
+	usesDate    bool // tracks if date.Date is used
 }
 
 // NewTypeGenerator creates a new TypeGenerator instance
 func NewTypeGenerator(spec *openapi.Document) *TypeGenerator {
+	return NewTypeGeneratorWithConfig(spec, Config{})
+}
+
+// NewTypeGeneratorWithConfig creates a new TypeGenerator instance writing the
+// package named in the configuration
+func NewTypeGeneratorWithConfig(spec *openapi.Document, config Config) *TypeGenerator {
+	if config.PackageName == "" {
+		config.PackageName = "api"
+	}
 	return &TypeGenerator{
-		spec:      spec,
-		generated: make(map[string]bool),
+		spec:        spec,
+		packageName: config.PackageName,
+		generated:   make(map[string]bool),
 	}
 }
 
@@ -28,7 +39,7 @@ func NewTypeGenerator(spec *openapi.Document) *TypeGenerator {
 func (g *TypeGenerator) Generate() (string, error) {
 	var sb strings.Builder
 
-	sb.WriteString("package api\n\n")
+	sb.WriteString(fmt.Sprintf("package %s\n\n", g.packageName))
 
 	if g.spec.Components == nil || g.spec.Components.Schemas == nil {
 		return sb.String(), nil
@@ -0,0 +1,356 @@
+package mock
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/christopherklint97/specweaver/pkg/openapi"
+	"github.com/christopherklint97/specweaver/pkg/router"
+)
+
+// Options configures the handler returned by NewHandler
+type Options struct {
+	// BasePath prefixes all routes. It defaults to the path of the spec's
+	// first server URL; use "/" for no prefix.
+	BasePath string
+
+	// CORS allows requests from any origin and answers preflight requests,
+	// so a frontend served from another port can call the mock
+	CORS bool
+}
+
+// NewHandler returns a handler serving fake responses for the operations of
+// doc. Each operation answers with its lowest declared 2xx response, or with
+// the status a client asks for in a "Prefer: code=404" header. Response
+// bodies are the media type's example, the example named in a
+// "Prefer: example=name" header, or a value generated from the schema.
+//
+// Operations requiring security answer 401 unless the request carries the
+// credentials of one of their security requirements: an Authorization header
+// of the scheme for http, oauth2 and openIdConnect schemes, and the named
+// header, query parameter or cookie for apiKey schemes. Credential values are
+// not checked.
+func NewHandler(doc *openapi.Document, opts Options) http.Handler {
+	mux := router.NewRouter()
+	if opts.CORS {
+		mux.Use(allowCORS)
+	}
+
+	basePath := openapi.NormalizeBasePath(opts.BasePath)
+	if opts.BasePath == "" && len(doc.Servers) > 0 && doc.Servers[0] != nil {
+		basePath = doc.Servers[0].BasePath()
+	}
+
+	register := map[string]func(pattern string, handler http.HandlerFunc){
+		http.MethodGet:     mux.Get,
+		http.MethodPut:     mux.Put,
+		http.MethodPost:    mux.Post,
+		http.MethodDelete:  mux.Delete,
+		http.MethodOptions: mux.Options,
+		http.MethodHead:    mux.Head,
+		http.MethodPatch:   mux.Patch,
+	}
+	for _, path := range slices.Sorted(maps.Keys(doc.Paths)) {
+		item := doc.Paths[path]
+		if item == nil {
+			continue
+		}
+		for _, route := range []struct {
+			method string
+			op     *openapi.Operation
+		}{
+			{http.MethodGet, item.Get},
+			{http.MethodPut, item.Put},
+			{http.MethodPost, item.Post},
+			{http.MethodDelete, item.Delete},
+			{http.MethodOptions, item.Options},
+			{http.MethodHead, item.Head},
+			{http.MethodPatch, item.Patch},
+		} {
+			if route.op == nil {
+				continue
+			}
+			security := doc.Security
+			if route.op.Security != nil {
+				security = route.op.Security
+			}
+			o := &operation{doc: doc, op: route.op, security: security}
+			register[route.method](basePath+path, o.ServeHTTP)
+		}
+	}
+
+	return mux
+}
+
+// operation serves the fake responses of one operation
+type operation struct {
+	doc      *openapi.Document
+	op       *openapi.Operation
+	security []openapi.SecurityRequirement
+}
+
+func (o *operation) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	prefer := parsePrefer(r.Header.Get("Prefer"))
+
+	if scheme, ok := o.authorize(r); !ok {
+		if challenge := authChallenge(scheme); challenge != "" {
+			w.Header().Set("WWW-Authenticate", challenge)
+		}
+		if o.op.Responses["401"] != nil {
+			o.respond(w, http.StatusUnauthorized, o.op.Responses["401"], prefer["example"])
+			return
+		}
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"message": "missing credentials"})
+		return
+	}
+
+	status, key := o.defaultStatus()
+	if code := prefer["code"]; code != "" {
+		requested, err := strconv.Atoi(code)
+		if err != nil || requested < 100 || requested > 599 || o.responseKey(code) == "" {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"message": fmt.Sprintf("the operation declares no %s response", code)})
+			return
+		}
+		status, key = requested, o.responseKey(code)
+	}
+	o.respond(w, status, o.op.Responses[key], prefer["example"])
+}
+
+// defaultStatus returns the status and response key of the response served
+// when the client asks for none: the lowest 2xx response, then a 2XX range
+// or default response answered with 200, then the lowest declared response
+func (o *operation) defaultStatus() (int, string) {
+	keys := slices.Sorted(maps.Keys(o.op.Responses))
+	for _, key := range keys {
+		if code, err := strconv.Atoi(key); err == nil && code >= 200 && code < 300 {
+			return code, key
+		}
+	}
+	for _, key := range []string{"2XX", "2xx", "default"} {
+		if o.op.Responses[key] != nil {
+			return http.StatusOK, key
+		}
+	}
+	for _, key := range keys {
+		if code, err := strconv.Atoi(key); err == nil {
+			return code, key
+		}
+	}
+	return http.StatusOK, ""
+}
+
+// responseKey returns the key of the response declared for a status code,
+// falling back to its range such as 4XX and then to default
+func (o *operation) responseKey(code string) string {
+	if len(code) != 3 {
+		return ""
+	}
+	for _, key := range []string{code, code[:1] + "XX", code[:1] + "xx", "default"} {
+		if o.op.Responses[key] != nil {
+			return key
+		}
+	}
+	return ""
+}
+
+// respond writes a response with status, its first declared header values
+// and a body for its preferred media type
+func (o *operation) respond(w http.ResponseWriter, status int, response *openapi.Response, exampleName string) {
+	response, err := o.doc.ResolveResponse(response)
+	if err != nil || response == nil {
+		w.WriteHeader(status)
+		return
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(response.Headers)) {
+		header := response.Headers[name]
+		if header == nil || header.Schema == nil || strings.EqualFold(name, "Content-Type") {
+			continue
+		}
+		if value := (&values{doc: o.doc}).value(header.Schema, 0); value != nil {
+			w.Header().Set(name, fmt.Sprint(value))
+		}
+	}
+
+	mediaType := preferredMediaType(response.Content)
+	if mediaType == "" {
+		w.WriteHeader(status)
+		return
+	}
+	body := o.example(response.Content[mediaType], exampleName)
+
+	w.Header().Set("Content-Type", mediaType)
+	if s, ok := body.(string); ok && !isJSON(mediaType) {
+		w.WriteHeader(status)
+		w.Write([]byte(s))
+		return
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		http.Error(w, "encoding example: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(status)
+	w.Write(data)
+}
+
+// example returns the body for a media type: the named example if there is
+// one, else the media type's example, its first named example, or a value
+// generated from its schema
+func (o *operation) example(mediaType *openapi.MediaType, name string) any {
+	if example, err := o.doc.ResolveExample(mediaType.Examples[name]); err == nil && example != nil {
+		return example.Value
+	}
+	if mediaType.Example != nil {
+		return mediaType.Example
+	}
+	for _, name := range slices.Sorted(maps.Keys(mediaType.Examples)) {
+		if example, err := o.doc.ResolveExample(mediaType.Examples[name]); err == nil && example != nil {
+			return example.Value
+		}
+	}
+	return (&values{doc: o.doc}).value(mediaType.Schema, 0)
+}
+
+// authorize reports whether the request carries the credentials of one of the
+// operation's security requirements. Otherwise it also returns the first
+// scheme asked for, to challenge the client with.
+func (o *operation) authorize(r *http.Request) (*openapi.SecurityScheme, bool) {
+	if len(o.security) == 0 {
+		return nil, true
+	}
+
+	var challenge *openapi.SecurityScheme
+	for _, requirement := range o.security {
+		satisfied := true
+		for _, name := range slices.Sorted(maps.Keys(requirement)) {
+			var scheme *openapi.SecurityScheme
+			if o.doc.Components != nil {
+				scheme = o.doc.Components.SecuritySchemes[name]
+			}
+			if scheme == nil || !hasCredentials(scheme, r) {
+				satisfied = false
+				if challenge == nil {
+					challenge = scheme
+				}
+			}
+		}
+		if satisfied {
+			return nil, true
+		}
+	}
+	return challenge, false
+}
+
+// hasCredentials reports whether the request carries credentials for scheme
+func hasCredentials(scheme *openapi.SecurityScheme, r *http.Request) bool {
+	switch scheme.Type {
+	case "http":
+		return hasAuthorization(r, scheme.Scheme)
+	case "oauth2", "openIdConnect":
+		return hasAuthorization(r, "Bearer")
+	case "apiKey":
+		switch scheme.In {
+		case "header":
+			return r.Header.Get(scheme.Name) != ""
+		case "query":
+			return r.URL.Query().Get(scheme.Name) != ""
+		case "cookie":
+			cookie, err := r.Cookie(scheme.Name)
+			return err == nil && cookie.Value != ""
+		}
+	case "mutualTLS":
+		return r.TLS != nil && len(r.TLS.PeerCertificates) > 0
+	}
+	return false
+}
+
+// hasAuthorization reports whether the Authorization header holds credentials
+// of an authentication scheme such as Bearer, matched case-insensitively
+func hasAuthorization(r *http.Request, authScheme string) bool {
+	prefix, credentials, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	return ok && strings.EqualFold(prefix, authScheme) && strings.TrimSpace(credentials) != ""
+}
+
+// authChallenge returns the WWW-Authenticate header for a scheme, if it has one
+func authChallenge(scheme *openapi.SecurityScheme) string {
+	if scheme == nil {
+		return ""
+	}
+	switch scheme.Type {
+	case "http":
+		if scheme.Scheme == "" {
+			return ""
+		}
+		return strings.ToUpper(scheme.Scheme[:1]) + strings.ToLower(scheme.Scheme[1:])
+	case "oauth2", "openIdConnect":
+		return "Bearer"
+	}
+	return ""
+}
+
+// parsePrefer parses the parameters of a Prefer header such as
+// "code=404, example=notFound"
+func parsePrefer(header string) map[string]string {
+	prefer := make(map[string]string)
+	for _, part := range strings.FieldsFunc(header, func(r rune) bool { return r == ',' || r == ';' }) {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		prefer[strings.ToLower(key)] = strings.Trim(value, `"`)
+	}
+	return prefer
+}
+
+// preferredMediaType returns the media type to respond with: JSON if the
+// response has it, else the first in name order
+func preferredMediaType(content map[string]*openapi.MediaType) string {
+	types := slices.Sorted(maps.Keys(content))
+	if i := slices.IndexFunc(types, isJSON); i >= 0 {
+		return types[i]
+	}
+	if len(types) > 0 {
+		return types[0]
+	}
+	return ""
+}
+
+// isJSON reports whether a media type is JSON, such as application/json or
+// application/problem+json
+func isJSON(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// writeJSON writes a JSON response for errors of the mock itself
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// allowCORS allows cross-origin requests from any origin, answering preflight
+// requests itself
+func allowCORS(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+		w.Header().Add("Vary", "Origin")
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			w.Header().Set("Access-Control-Allow-Methods", r.Header.Get("Access-Control-Request-Method"))
+			if headers := r.Header.Get("Access-Control-Request-Headers"); headers != "" {
+				w.Header().Set("Access-Control-Allow-Headers", headers)
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,208 @@
+package mock
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/christopherklint97/specweaver/pkg/openapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSpec = `openapi: 3.1.0
+info:
+  title: Test API
+  version: 1.0.0
+servers:
+  - url: http://localhost:8080/api
+security:
+  - bearerAuth: []
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      security: []
+      responses:
+        '200':
+          description: The pets
+          content:
+            application/json:
+              schema:
+                type: array
+                minItems: 2
+                items:
+                  $ref: '#/components/schemas/Pet'
+        default:
+          description: Unexpected error
+  /pets/{petId}:
+    get:
+      operationId: getPet
+      parameters:
+        - name: petId
+          in: path
+          required: true
+          schema:
+            type: integer
+      responses:
+        '200':
+          description: The pet
+          content:
+            application/json:
+              examples:
+                rex:
+                  value: {id: 1, name: Rex}
+                tom:
+                  $ref: '#/components/examples/Tom'
+        '404':
+          $ref: '#/components/responses/NotFound'
+    delete:
+      operationId: deletePet
+      security:
+        - apiKey: []
+      parameters:
+        - name: petId
+          in: path
+          required: true
+          schema:
+            type: integer
+      responses:
+        '204':
+          description: Deleted
+components:
+  schemas:
+    Pet:
+      type: object
+      required: [id, name]
+      properties:
+        id:
+          type: integer
+          format: int64
+          minimum: 10
+        name:
+          type: string
+          example: Rex
+        status:
+          type: string
+          enum: [available, sold]
+        bornAt:
+          type: string
+          format: date-time
+        password:
+          type: string
+          writeOnly: true
+        parent:
+          $ref: '#/components/schemas/Pet'
+  examples:
+    Tom:
+      value: {id: 2, name: Tom}
+  responses:
+    NotFound:
+      description: Not found
+      content:
+        application/json:
+          example: {message: pet not found}
+  securitySchemes:
+    bearerAuth:
+      type: http
+      scheme: bearer
+    apiKey:
+      type: apiKey
+      in: query
+      name: key
+`
+
+// serve sends a request with the given headers to a mock of testSpec
+func serve(t *testing.T, method, target string, headers map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+	doc, err := openapi.LoadFromData([]byte(testSpec), "test.yaml")
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(method, target, nil)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	rec := httptest.NewRecorder()
+	NewHandler(doc, Options{CORS: true}).ServeHTTP(rec, req)
+	return rec
+}
+
+func TestMockSchemaValues(t *testing.T) {
+	rec := serve(t, http.MethodGet, "/api/pets", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var pets []map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &pets))
+	require.Len(t, pets, 2, "Should honor minItems")
+	assert.Equal(t, float64(10), pets[0]["id"], "Should honor minimum")
+	assert.Equal(t, "Rex", pets[0]["name"], "Should use the schema example")
+	assert.Equal(t, "available", pets[0]["status"], "Should use the first enum value")
+	assert.Equal(t, "2024-01-01T12:00:00Z", pets[0]["bornAt"])
+	assert.NotContains(t, pets[0], "password", "Should leave out write-only properties")
+	assert.Contains(t, pets[0], "parent", "Should follow recursive references up to a depth")
+}
+
+func TestMockExamples(t *testing.T) {
+	auth := map[string]string{"Authorization": "Bearer token"}
+
+	rec := serve(t, http.MethodGet, "/api/pets/1", auth)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"id": 1, "name": "Rex"}`, rec.Body.String(), "Should use the first named example")
+
+	rec = serve(t, http.MethodGet, "/api/pets/1", map[string]string{"Authorization": "Bearer token", "Prefer": "example=tom"})
+	assert.JSONEq(t, `{"id": 2, "name": "Tom"}`, rec.Body.String(), "Should resolve referenced examples")
+
+	rec = serve(t, http.MethodGet, "/api/pets/1", map[string]string{"Authorization": "Bearer token", "Prefer": "code=404"})
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.JSONEq(t, `{"message": "pet not found"}`, rec.Body.String(), "Should resolve referenced responses")
+
+	rec = serve(t, http.MethodGet, "/api/pets/1", map[string]string{"Authorization": "Bearer token", "Prefer": "code=500"})
+	assert.Equal(t, http.StatusBadRequest, rec.Code, "Should reject undeclared status codes")
+
+	rec = serve(t, http.MethodGet, "/api/pets", map[string]string{"Prefer": "code=503"})
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code, "Should answer with the default response")
+
+	for _, code := range []string{"099", "600", "-99"} {
+		rec = serve(t, http.MethodGet, "/api/pets", map[string]string{"Prefer": "code=" + code})
+		assert.Equal(t, http.StatusBadRequest, rec.Code, "Should reject status code %s despite the default response", code)
+	}
+}
+
+func TestMockSecurity(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		target   string
+		headers  map[string]string
+		expected int
+	}{
+		{"Public operation", http.MethodGet, "/api/pets", nil, http.StatusOK},
+		{"Missing bearer token", http.MethodGet, "/api/pets/1", nil, http.StatusUnauthorized},
+		{"Wrong scheme", http.MethodGet, "/api/pets/1", map[string]string{"Authorization": "Basic dXNlcjpwYXNz"}, http.StatusUnauthorized},
+		{"Bearer token", http.MethodGet, "/api/pets/1", map[string]string{"Authorization": "bearer token"}, http.StatusOK},
+		{"Missing API key", http.MethodDelete, "/api/pets/1", map[string]string{"Authorization": "Bearer token"}, http.StatusUnauthorized},
+		{"API key", http.MethodDelete, "/api/pets/1?key=secret", nil, http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(t, tt.method, tt.target, tt.headers)
+			assert.Equal(t, tt.expected, rec.Code)
+		})
+	}
+
+	rec := serve(t, http.MethodGet, "/api/pets/1", nil)
+	assert.Equal(t, "Bearer", rec.Header().Get("WWW-Authenticate"))
+}
+
+func TestMockCORS(t *testing.T) {
+	rec := serve(t, http.MethodOptions, "/api/pets", map[string]string{
+		"Origin":                        "http://localhost:3000",
+		"Access-Control-Request-Method": "GET",
+	})
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "http://localhost:3000", rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "GET", rec.Header().Get("Access-Control-Allow-Methods"))
+}
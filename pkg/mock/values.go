@@ -0,0 +1,176 @@
+package mock
+
+import (
+	"maps"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/christopherklint97/specweaver/pkg/openapi"
+)
+
+// maxDepth limits how deeply values nests schemas, so recursive schemas end
+const maxDepth = 8
+
+// formatExamples are the strings generated for string formats
+var formatExamples = map[string]string{
+	"date-time": "2024-01-01T12:00:00Z",
+	"date":      "2024-01-01",
+	"time":      "12:00:00",
+	"duration":  "PT1H",
+	"email":     "user@example.com",
+	"uuid":      "3fa85f64-5717-4562-b3fc-2c963f66afa6",
+	"uri":       "https://example.com",
+	"url":       "https://example.com",
+	"hostname":  "example.com",
+	"ipv4":      "192.0.2.1",
+	"ipv6":      "2001:db8::1",
+	"byte":      "ZXhhbXBsZQ==",
+	"password":  "secret",
+}
+
+// values generates fake values from schemas, preferring the examples,
+// defaults and enum values they declare
+type values struct {
+	doc *openapi.Document
+}
+
+// value returns a value valid for the schema, or nil if there is none to give
+func (v *values) value(ref *openapi.SchemaRef, depth int) any {
+	if ref == nil || depth > maxDepth {
+		return nil
+	}
+	schema, err := v.doc.ResolveSchemaRef(ref)
+	if err != nil || schema == nil {
+		return nil
+	}
+
+	switch {
+	case schema.Example != nil:
+		return schema.Example
+	case schema.Default != nil:
+		return schema.Default
+	case len(schema.Enum) > 0:
+		return schema.Enum[0]
+	case len(schema.AllOf) > 0:
+		return v.allOf(schema, depth)
+	case len(schema.OneOf) > 0:
+		return v.value(schema.OneOf[0], depth+1)
+	case len(schema.AnyOf) > 0:
+		return v.value(schema.AnyOf[0], depth+1)
+	}
+
+	switch schemaType(schema) {
+	case "string":
+		return stringValue(schema)
+	case "integer":
+		return int64(numberValue(schema, 1, true))
+	case "number":
+		return numberValue(schema, 1.5, false)
+	case "boolean":
+		return true
+	case "array":
+		count := 1
+		if schema.MinItems != nil && *schema.MinItems > count {
+			count = *schema.MinItems
+		}
+		if schema.MaxItems != nil && *schema.MaxItems < count {
+			count = *schema.MaxItems
+		}
+		items := make([]any, 0, count)
+		for range count {
+			if item := v.value(schema.Items, depth+1); item != nil {
+				items = append(items, item)
+			}
+		}
+		return items
+	case "object":
+		return v.object(schema, depth)
+	}
+	return nil
+}
+
+// object returns an object holding a value for every property that is not
+// write-only
+func (v *values) object(schema *openapi.Schema, depth int) map[string]any {
+	object := make(map[string]any)
+	for _, name := range slices.Sorted(maps.Keys(schema.Properties)) {
+		property := schema.Properties[name]
+		if resolved, err := v.doc.ResolveSchemaRef(property); err == nil && resolved != nil && resolved.WriteOnly {
+			continue
+		}
+		if value := v.value(property, depth+1); value != nil {
+			object[name] = value
+		}
+	}
+	return object
+}
+
+// allOf returns the properties of the schema and all its allOf schemas merged
+// into one object, or the value of the first allOf schema if it is no object
+func (v *values) allOf(schema *openapi.Schema, depth int) any {
+	merged := v.object(schema, depth)
+	for _, ref := range schema.AllOf {
+		value := v.value(ref, depth+1)
+		object, ok := value.(map[string]any)
+		if !ok {
+			if len(merged) == 0 {
+				return value
+			}
+			continue
+		}
+		for name, property := range object {
+			merged[name] = property
+		}
+	}
+	return merged
+}
+
+// schemaType returns the first type of the schema other than null, or object
+// for schemas without a type that have properties
+func schemaType(schema *openapi.Schema) string {
+	if i := slices.IndexFunc(schema.Type, func(t string) bool { return t != "null" }); i >= 0 {
+		return schema.Type[i]
+	}
+	if len(schema.Properties) > 0 {
+		return "object"
+	}
+	return ""
+}
+
+// stringValue returns a string for the schema's format, fitted to its length
+// limits
+func stringValue(schema *openapi.Schema) string {
+	value, ok := formatExamples[schema.Format]
+	if !ok {
+		value = "string"
+	}
+	if schema.MinLength != nil && len(value) < *schema.MinLength {
+		value += strings.Repeat("x", *schema.MinLength-len(value))
+	}
+	if schema.MaxLength != nil && len(value) > *schema.MaxLength {
+		value = value[:*schema.MaxLength]
+	}
+	return value
+}
+
+// numberValue returns fallback moved within the schema's minimum and maximum
+func numberValue(schema *openapi.Schema, fallback float64, integer bool) float64 {
+	value := fallback
+	if schema.Minimum != nil && value < *schema.Minimum {
+		value = *schema.Minimum
+	}
+	if schema.ExclusiveMinimum != nil && value <= *schema.ExclusiveMinimum {
+		value = *schema.ExclusiveMinimum + 1
+	}
+	if schema.Maximum != nil && value > *schema.Maximum {
+		value = *schema.Maximum
+	}
+	if schema.ExclusiveMaximum != nil && value >= *schema.ExclusiveMaximum {
+		value = *schema.ExclusiveMaximum - 1
+	}
+	if integer {
+		value = math.Ceil(value)
+	}
+	return value
+}
@@ -0,0 +1,209 @@
+package openapi
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// SeverityOff disables a lint rule in a LintConfig
+const SeverityOff Severity = "off"
+
+// LintRule is a style check run by Lint. Unlike the checks of Validate, lint
+// rules flag documents that are valid but harder to use or generate from.
+type LintRule struct {
+	Name        string
+	Severity    Severity // default severity of the rule's findings
+	Description string
+
+	check func(doc *Document, report func(location, format string, args ...any))
+}
+
+// LintRules are the rules Lint runs, in name order
+var LintRules = []LintRule{
+	{
+		Name:        "info-description",
+		Severity:    SeverityInfo,
+		Description: "info has a description",
+		check: func(doc *Document, report func(location, format string, args ...any)) {
+			if doc.Info != nil && doc.Info.Description == "" {
+				report("info", "info has no description")
+			}
+		},
+	},
+	{
+		Name:        "operation-id",
+		Severity:    SeverityWarning,
+		Description: "operations have an operationId",
+		check: eachOperation(func(location, path string, op *Operation, report func(location, format string, args ...any)) {
+			if op.OperationID == "" {
+				report(location, "operation has no operationId")
+			}
+		}),
+	},
+	{
+		Name:        "operation-success-response",
+		Severity:    SeverityWarning,
+		Description: "operations declare a 2xx or default response",
+		check: eachOperation(func(location, path string, op *Operation, report func(location, format string, args ...any)) {
+			if len(op.Responses) == 0 {
+				return // reported by Validate
+			}
+			for status := range op.Responses {
+				if strings.HasPrefix(status, "2") || status == "default" {
+					return
+				}
+			}
+			report(location, "operation has no 2xx or default response")
+		}),
+	},
+	{
+		Name:        "operation-summary",
+		Severity:    SeverityWarning,
+		Description: "operations have a summary or description",
+		check: eachOperation(func(location, path string, op *Operation, report func(location, format string, args ...any)) {
+			if op.Summary == "" && op.Description == "" {
+				report(location, "operation has no summary or description")
+			}
+		}),
+	},
+	{
+		Name:        "operation-tag-defined",
+		Severity:    SeverityWarning,
+		Description: "operation tags are declared in the top-level tags",
+		check: func(doc *Document, report func(location, format string, args ...any)) {
+			declared := make(map[string]bool)
+			for _, tag := range doc.Tags {
+				if tag != nil {
+					declared[tag.Name] = true
+				}
+			}
+			eachOperation(func(location, path string, op *Operation, report func(location, format string, args ...any)) {
+				for _, tag := range op.Tags {
+					if !declared[tag] {
+						report(location, "tag %s is not declared in the top-level tags", tag)
+					}
+				}
+			})(doc, report)
+		},
+	},
+	{
+		Name:        "operation-tags",
+		Severity:    SeverityWarning,
+		Description: "operations have at least one tag",
+		check: eachOperation(func(location, path string, op *Operation, report func(location, format string, args ...any)) {
+			if len(op.Tags) == 0 {
+				report(location, "operation has no tags")
+			}
+		}),
+	},
+	{
+		Name:        "path-casing",
+		Severity:    SeverityWarning,
+		Description: "path segments are lower-case kebab-case",
+		check: func(doc *Document, report func(location, format string, args ...any)) {
+			for _, path := range sortedKeys(doc.Paths) {
+				for _, segment := range strings.Split(pathTemplateParam.ReplaceAllString(path, ""), "/") {
+					if !kebabSegment.MatchString(segment) {
+						report(path, "path segment %q is not lower-case kebab-case", segment)
+						break
+					}
+				}
+			}
+		},
+	},
+	{
+		Name:        "path-trailing-slash",
+		Severity:    SeverityWarning,
+		Description: "paths do not end with a slash",
+		check: func(doc *Document, report func(location, format string, args ...any)) {
+			for _, path := range sortedKeys(doc.Paths) {
+				if len(path) > 1 && strings.HasSuffix(path, "/") {
+					report(path, "path ends with a slash")
+				}
+			}
+		},
+	},
+	{
+		Name:        "schema-description",
+		Severity:    SeverityInfo,
+		Description: "component schemas have a description",
+		check: func(doc *Document, report func(location, format string, args ...any)) {
+			if doc.Components == nil {
+				return
+			}
+			for _, name := range sortedKeys(doc.Components.Schemas) {
+				ref := doc.Components.Schemas[name]
+				if ref != nil && ref.Ref == "" && ref.Value != nil && ref.Value.Description == "" {
+					report("components.schemas."+name, "schema has no description")
+				}
+			}
+		},
+	},
+}
+
+// kebabSegment matches path segments of lower-case words joined by hyphens,
+// dots or underscores, once their parameters have been removed
+var kebabSegment = regexp.MustCompile(`^[a-z0-9._-]*$`)
+
+// eachOperation adapts a check of a single operation to a LintRule check
+// running it on every operation of the document
+func eachOperation(check func(location, path string, op *Operation, report func(location, format string, args ...any))) func(doc *Document, report func(location, format string, args ...any)) {
+	return func(doc *Document, report func(location, format string, args ...any)) {
+		for _, path := range sortedKeys(doc.Paths) {
+			item := doc.Paths[path]
+			if item == nil {
+				continue
+			}
+			for _, method := range []string{"GET", "PUT", "POST", "DELETE", "OPTIONS", "HEAD", "PATCH", "TRACE"} {
+				if op := item.operation(method); op != nil {
+					check(method+" "+path, path, op, report)
+				}
+			}
+		}
+	}
+}
+
+// LintConfig adjusts the rules Lint runs
+type LintConfig struct {
+	// Rules overrides the severity of rules by name. SeverityOff disables a
+	// rule; rules not listed keep their default severity.
+	Rules map[string]Severity `yaml:"rules" json:"rules"`
+}
+
+// Lint runs the enabled LintRules on the document, returning findings sorted
+// by location with Rule set. It fails if config names an unknown rule or
+// severity.
+func (doc *Document) Lint(config LintConfig) ([]Finding, error) {
+	for _, name := range sortedKeys(config.Rules) {
+		if !slices.ContainsFunc(LintRules, func(rule LintRule) bool { return rule.Name == name }) {
+			return nil, fmt.Errorf("unknown lint rule %q", name)
+		}
+		switch config.Rules[name] {
+		case SeverityError, SeverityWarning, SeverityInfo, SeverityOff:
+		default:
+			return nil, fmt.Errorf("lint rule %s: unknown severity %q, expected error, warning, info or off", name, config.Rules[name])
+		}
+	}
+
+	var findings []Finding
+	for _, rule := range LintRules {
+		severity := rule.Severity
+		if override, ok := config.Rules[rule.Name]; ok {
+			severity = override
+		}
+		if severity == SeverityOff {
+			continue
+		}
+		rule.check(doc, func(location, format string, args ...any) {
+			findings = append(findings, Finding{Severity: severity, Location: location, Message: fmt.Sprintf(format, args...), Rule: rule.Name})
+		})
+	}
+
+	slices.SortStableFunc(findings, func(a, b Finding) int {
+		return cmp.Compare(a.Location, b.Location)
+	})
+	return findings, nil
+}
@@ -0,0 +1,77 @@
+package openapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLint(t *testing.T) {
+	doc, err := LoadFromData([]byte(`openapi: 3.1.0
+info:
+  title: Test API
+  version: 1.0.0
+  description: A test API
+tags:
+  - name: pets
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      summary: List pets
+      tags: [pets]
+      responses:
+        '200':
+          description: OK
+  /petOwners/:
+    get:
+      tags: [owners]
+      responses:
+        '404':
+          description: Not found
+components:
+  schemas:
+    Pet:
+      type: object
+`), "test.yaml")
+	require.NoError(t, err)
+
+	findings, err := doc.Lint(LintConfig{})
+	require.NoError(t, err)
+
+	var reported []string
+	for _, f := range findings {
+		reported = append(reported, f.Rule+" "+f.Location)
+	}
+	assert.Equal(t, []string{
+		"path-casing /petOwners/",
+		"path-trailing-slash /petOwners/",
+		"operation-id GET /petOwners/",
+		"operation-success-response GET /petOwners/",
+		"operation-summary GET /petOwners/",
+		"operation-tag-defined GET /petOwners/",
+		"schema-description components.schemas.Pet",
+	}, reported)
+	assert.Equal(t, SeverityInfo, findings[len(findings)-1].Severity)
+
+	t.Run("Severity overrides", func(t *testing.T) {
+		findings, err := doc.Lint(LintConfig{Rules: map[string]Severity{
+			"path-casing":        SeverityError,
+			"schema-description": SeverityOff,
+		}})
+		require.NoError(t, err)
+		assert.Len(t, findings, 6)
+		assert.True(t, HasErrors(findings))
+	})
+
+	t.Run("Unknown rule", func(t *testing.T) {
+		_, err := doc.Lint(LintConfig{Rules: map[string]Severity{"no-such-rule": SeverityOff}})
+		assert.Error(t, err)
+	})
+
+	t.Run("Unknown severity", func(t *testing.T) {
+		_, err := doc.Lint(LintConfig{Rules: map[string]Severity{"path-casing": "fatal"}})
+		assert.Error(t, err)
+	})
+}
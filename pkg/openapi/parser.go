@@ -275,6 +275,40 @@ func (doc *Document) ResolveSchemaRef(ref *SchemaRef) (*Schema, error) {
 	return s, nil
 }
 
+// ResolveResponse returns the response a response reference points to, or
+// response itself if it is not a reference
+func (doc *Document) ResolveResponse(response *Response) (*Response, error) {
+	if response == nil || response.Ref == "" {
+		return response, nil
+	}
+	resolved, err := doc.resolveReference(response.Ref)
+	if err != nil {
+		return nil, err
+	}
+	r, ok := resolved.(*Response)
+	if !ok {
+		return nil, fmt.Errorf("reference does not resolve to a response: %s", response.Ref)
+	}
+	return r, nil
+}
+
+// ResolveExample returns the example an example reference points to, or
+// example itself if it is not a reference
+func (doc *Document) ResolveExample(example *Example) (*Example, error) {
+	if example == nil || example.Ref == "" {
+		return example, nil
+	}
+	resolved, err := doc.resolveReference(example.Ref)
+	if err != nil {
+		return nil, err
+	}
+	e, ok := resolved.(*Example)
+	if !ok {
+		return nil, fmt.Errorf("reference does not resolve to an example: %s", example.Ref)
+	}
+	return e, nil
+}
+
 // resolveReference resolves a $ref to the actual object
 func (doc *Document) resolveReference(refPath string) (any, error) {
 	// Only support local references for now (#/...)
@@ -333,6 +367,11 @@ func (doc *Document) resolveReference(refPath string) (any, error) {
 					return nil, fmt.Errorf("requestBodies not defined in components")
 				}
 				current = components.RequestBodies
+			case "examples":
+				if components.Examples == nil {
+					return nil, fmt.Errorf("examples not defined in components")
+				}
+				current = components.Examples
 			default:
 				return nil, fmt.Errorf("unsupported component type: %s", part)
 			}
@@ -369,6 +408,13 @@ func (doc *Document) resolveReference(refPath string) (any, error) {
 				}
 				doc.refCache[refPath] = reqBody
 				return reqBody, nil
+			case map[string]*Example:
+				example, ok := v[part]
+				if !ok {
+					return nil, fmt.Errorf("example not found: %s", part)
+				}
+				doc.refCache[refPath] = example
+				return example, nil
 			default:
 				return nil, fmt.Errorf("unexpected type at component name level: %T", v)
 			}
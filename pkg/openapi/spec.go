@@ -1,5 +1,10 @@
 package openapi
 
+import (
+	"net/url"
+	"strings"
+)
+
 // Document represents the root OpenAPI specification document
 // Supports OpenAPI 3.0.x, 3.1.x, and 3.2.x
 type Document struct {
@@ -83,6 +88,8 @@ type Operation struct {
 	Deprecated  bool                  `yaml:"deprecated,omitempty" json:"deprecated,omitempty"`
 	Security    []SecurityRequirement `yaml:"security,omitempty" json:"security,omitempty"`
 	Servers     []*Server             `yaml:"servers,omitempty" json:"servers,omitempty"`
+	// Extensions holds the specification extensions (x-* fields) of the operation
+	Extensions map[string]any `yaml:"-" json:"-"`
 }
 
 // Parameter describes a single operation parameter
@@ -96,6 +103,9 @@ type Parameter struct {
 	Schema          *SchemaRef  `yaml:"schema,omitempty" json:"schema,omitempty"`
 	Example         any         `yaml:"example,omitempty" json:"example,omitempty"`
 	Ref             string      `yaml:"$ref,omitempty" json:"$ref,omitempty"`
+
+	// Extensions holds the specification extensions (x-* fields) of the parameter
+	Extensions map[string]any `yaml:"-" json:"-"`
 }
 
 // RequestBody describes a request body
@@ -218,6 +228,8 @@ type SecurityScheme struct {
 	BearerFormat     string            `yaml:"bearerFormat,omitempty" json:"bearerFormat,omitempty"`
 	Flows            *OAuthFlows       `yaml:"flows,omitempty" json:"flows,omitempty"`
 	OpenIDConnectURL string            `yaml:"openIdConnectUrl,omitempty" json:"openIdConnectUrl,omitempty"`
+	// Extensions holds the specification extensions (x-* fields) of the security scheme
+	Extensions map[string]any `yaml:"-" json:"-"`
 }
 
 // OAuthFlows allows configuration of the supported OAuth Flows
@@ -258,3 +270,33 @@ func (s *Schema) GetSchemaType() string {
 func (sr *SchemaRef) IsRefOnly() bool {
 	return sr != nil && sr.Ref != ""
 }
+
+// BasePath returns the path component of the server URL, with server
+// variables replaced by their default values, normalized by NormalizeBasePath
+func (s *Server) BasePath() string {
+	rawURL := s.URL
+	for name, variable := range s.Variables {
+		if variable != nil {
+			rawURL = strings.ReplaceAll(rawURL, "{"+name+"}", variable.Default)
+		}
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return NormalizeBasePath(parsed.Path)
+}
+
+// NormalizeBasePath ensures a base path has a leading slash and no trailing
+// slash. The root path "/" normalizes to no prefix at all.
+func NormalizeBasePath(basePath string) string {
+	basePath = strings.TrimRight(basePath, "/")
+	if basePath == "" {
+		return ""
+	}
+	if !strings.HasPrefix(basePath, "/") {
+		basePath = "/" + basePath
+	}
+	return basePath
+}
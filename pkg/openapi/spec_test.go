@@ -308,6 +308,26 @@ func TestComponentsStructure(t *testing.T) {
 	})
 }
 
+func TestServerBasePath(t *testing.T) {
+	assert.Equal(t, "/v1", (&Server{URL: "https://api.example.com/v1/"}).BasePath())
+	assert.Equal(t, "/api", (&Server{URL: "/api"}).BasePath())
+	assert.Equal(t, "", (&Server{URL: "https://api.example.com/"}).BasePath())
+	assert.Equal(t, "/v3", (&Server{
+		URL: "{scheme}://api.example.com/{version}",
+		Variables: map[string]*ServerVariable{
+			"scheme":  {Default: "https"},
+			"version": {Default: "v3"},
+		},
+	}).BasePath())
+}
+
+func TestNormalizeBasePath(t *testing.T) {
+	assert.Equal(t, "/api/v1", NormalizeBasePath("api/v1/"))
+	assert.Equal(t, "/api", NormalizeBasePath("/api"))
+	assert.Equal(t, "", NormalizeBasePath("/"))
+	assert.Equal(t, "", NormalizeBasePath(""))
+}
+
 // Helper functions for tests
 func intPtr(i int) *int {
 	return &i
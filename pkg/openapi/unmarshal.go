@@ -1,8 +1,11 @@
 package openapi
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
@@ -95,3 +98,269 @@ func handleTypeField(data json.RawMessage, schema *Schema) error {
 
 	return fmt.Errorf("type field must be a string or array of strings")
 }
+
+// MarshalJSON implements custom JSON marshaling for Schema
+// A single type is written as a string so the output stays valid OpenAPI 3.0
+func (s Schema) MarshalJSON() ([]byte, error) {
+	// Use a type alias to avoid infinite recursion
+	type schemaAlias Schema
+	aux := struct {
+		Type any `json:"type,omitempty"`
+		*schemaAlias
+	}{
+		schemaAlias: (*schemaAlias)(&s),
+	}
+
+	switch len(s.Type) {
+	case 0:
+	case 1:
+		aux.Type = s.Type[0]
+	default:
+		aux.Type = s.Type
+	}
+
+	return json.Marshal(aux)
+}
+
+// MarshalJSON implements custom JSON marshaling for SchemaRef
+// References are written as {"$ref": ...}, inline schemas are written in place
+func (sr SchemaRef) MarshalJSON() ([]byte, error) {
+	if sr.Ref != "" {
+		return json.Marshal(map[string]string{"$ref": sr.Ref})
+	}
+	if sr.Value == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(sr.Value)
+}
+
+// UnmarshalJSON implements custom JSON unmarshaling for SchemaRef
+// This mirrors the inline YAML handling: a $ref is kept as a reference and
+// any other fields are decoded into the inline schema
+func (sr *SchemaRef) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	if refRaw, ok := raw["$ref"]; ok {
+		if err := json.Unmarshal(refRaw, &sr.Ref); err != nil {
+			return err
+		}
+		delete(raw, "$ref")
+	}
+
+	if len(raw) == 0 {
+		return nil
+	}
+
+	remaining, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+
+	sr.Value = &Schema{}
+	return json.Unmarshal(remaining, sr.Value)
+}
+
+// MarshalJSON implements custom JSON marshaling for Operation
+// An explicitly empty security list (security: []) removes global security
+// for the operation, so it must survive marshaling instead of being omitted
+func (op Operation) MarshalJSON() ([]byte, error) {
+	// Use a type alias to avoid infinite recursion
+	type operationAlias Operation
+	var data []byte
+	var err error
+	if op.Security == nil || len(op.Security) > 0 {
+		data, err = json.Marshal(operationAlias(op))
+	} else {
+		data, err = json.Marshal(struct {
+			operationAlias
+			Security []SecurityRequirement `json:"security"`
+		}{
+			operationAlias: operationAlias(op),
+			Security:       op.Security,
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return appendExtensions(data, op.Extensions)
+}
+
+// UnmarshalYAML implements custom YAML unmarshaling for Operation
+// Specification extensions (x-* fields) are collected into Extensions
+func (op *Operation) UnmarshalYAML(node *yaml.Node) error {
+	// Use a type alias to avoid infinite recursion
+	type operationAlias Operation
+	if err := node.Decode((*operationAlias)(op)); err != nil {
+		return err
+	}
+
+	return decodeYAMLExtensions(node, &op.Extensions)
+}
+
+// UnmarshalJSON implements custom JSON unmarshaling for Operation
+// Specification extensions (x-* fields) are collected into Extensions
+func (op *Operation) UnmarshalJSON(data []byte) error {
+	// Use a type alias to avoid infinite recursion
+	type operationAlias Operation
+	if err := json.Unmarshal(data, (*operationAlias)(op)); err != nil {
+		return err
+	}
+
+	return decodeJSONExtensions(data, &op.Extensions)
+}
+
+// MarshalJSON implements custom JSON marshaling for SecurityScheme
+// Specification extensions are written as x-* fields
+func (s SecurityScheme) MarshalJSON() ([]byte, error) {
+	// Use a type alias to avoid infinite recursion
+	type securitySchemeAlias SecurityScheme
+	data, err := json.Marshal(securitySchemeAlias(s))
+	if err != nil {
+		return nil, err
+	}
+
+	return appendExtensions(data, s.Extensions)
+}
+
+// UnmarshalYAML implements custom YAML unmarshaling for SecurityScheme
+// Specification extensions (x-* fields) are collected into Extensions
+func (s *SecurityScheme) UnmarshalYAML(node *yaml.Node) error {
+	// Use a type alias to avoid infinite recursion
+	type securitySchemeAlias SecurityScheme
+	if err := node.Decode((*securitySchemeAlias)(s)); err != nil {
+		return err
+	}
+
+	return decodeYAMLExtensions(node, &s.Extensions)
+}
+
+// UnmarshalJSON implements custom JSON unmarshaling for SecurityScheme
+// Specification extensions (x-* fields) are collected into Extensions
+func (s *SecurityScheme) UnmarshalJSON(data []byte) error {
+	// Use a type alias to avoid infinite recursion
+	type securitySchemeAlias SecurityScheme
+	if err := json.Unmarshal(data, (*securitySchemeAlias)(s)); err != nil {
+		return err
+	}
+
+	return decodeJSONExtensions(data, &s.Extensions)
+}
+
+// MarshalJSON implements custom JSON marshaling for Parameter
+// Specification extensions are written as x-* fields
+func (p Parameter) MarshalJSON() ([]byte, error) {
+	// Use a type alias to avoid infinite recursion
+	type parameterAlias Parameter
+	data, err := json.Marshal(parameterAlias(p))
+	if err != nil {
+		return nil, err
+	}
+
+	return appendExtensions(data, p.Extensions)
+}
+
+// UnmarshalYAML implements custom YAML unmarshaling for Parameter
+// Specification extensions (x-* fields) are collected into Extensions
+func (p *Parameter) UnmarshalYAML(node *yaml.Node) error {
+	// Use a type alias to avoid infinite recursion
+	type parameterAlias Parameter
+	if err := node.Decode((*parameterAlias)(p)); err != nil {
+		return err
+	}
+
+	return decodeYAMLExtensions(node, &p.Extensions)
+}
+
+// UnmarshalJSON implements custom JSON unmarshaling for Parameter
+// Specification extensions (x-* fields) are collected into Extensions
+func (p *Parameter) UnmarshalJSON(data []byte) error {
+	// Use a type alias to avoid infinite recursion
+	type parameterAlias Parameter
+	if err := json.Unmarshal(data, (*parameterAlias)(p)); err != nil {
+		return err
+	}
+
+	return decodeJSONExtensions(data, &p.Extensions)
+}
+
+// decodeYAMLExtensions collects the specification extensions (x-* fields) of a
+// YAML mapping node into extensions
+func decodeYAMLExtensions(node *yaml.Node, extensions *map[string]any) error {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i].Value
+		if !strings.HasPrefix(key, "x-") {
+			continue
+		}
+		var value any
+		if err := node.Content[i+1].Decode(&value); err != nil {
+			return err
+		}
+		if *extensions == nil {
+			*extensions = make(map[string]any)
+		}
+		(*extensions)[key] = value
+	}
+
+	return nil
+}
+
+// decodeJSONExtensions collects the specification extensions (x-* fields) of a
+// JSON object into extensions
+func decodeJSONExtensions(data []byte, extensions *map[string]any) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	for key, rawValue := range raw {
+		if !strings.HasPrefix(key, "x-") {
+			continue
+		}
+		var value any
+		if err := json.Unmarshal(rawValue, &value); err != nil {
+			return err
+		}
+		if *extensions == nil {
+			*extensions = make(map[string]any)
+		}
+		(*extensions)[key] = value
+	}
+
+	return nil
+}
+
+// appendExtensions adds specification extensions to a marshaled JSON object,
+// after its other fields and sorted by name
+func appendExtensions(data []byte, extensions map[string]any) ([]byte, error) {
+	if len(extensions) == 0 {
+		return data, nil
+	}
+
+	keys := make([]string, 0, len(extensions))
+	for key := range extensions {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	buf.Write(bytes.TrimSuffix(data, []byte("}")))
+	for i, key := range keys {
+		value, err := json.Marshal(extensions[key])
+		if err != nil {
+			return nil, fmt.Errorf("extension %s: %w", key, err)
+		}
+		if i > 0 || len(data) > 2 {
+			buf.WriteByte(',')
+		}
+		name, _ := json.Marshal(key)
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
@@ -274,3 +274,162 @@ func TestSchemaRefIsRefOnly(t *testing.T) {
 		assert.False(t, ref.IsRefOnly())
 	})
 }
+
+func TestSchemaMarshalJSON(t *testing.T) {
+	t.Run("Single type is written as a string", func(t *testing.T) {
+		data, err := json.Marshal(&Schema{Type: []string{"string"}})
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"type":"string"}`, string(data))
+	})
+
+	t.Run("Multiple types are written as an array", func(t *testing.T) {
+		data, err := json.Marshal(&Schema{Type: []string{"string", "null"}})
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"type":["string","null"]}`, string(data))
+	})
+}
+
+func TestSchemaRefJSONRoundTrip(t *testing.T) {
+	t.Run("Reference", func(t *testing.T) {
+		data, err := json.Marshal(&SchemaRef{Ref: "#/components/schemas/Pet"})
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"$ref":"#/components/schemas/Pet"}`, string(data))
+
+		var ref SchemaRef
+		require.NoError(t, json.Unmarshal(data, &ref))
+		assert.Equal(t, "#/components/schemas/Pet", ref.Ref)
+	})
+
+	t.Run("Inline schema", func(t *testing.T) {
+		original := &SchemaRef{
+			Value: &Schema{
+				Type:     []string{"object"},
+				Required: []string{"name"},
+				Properties: map[string]*SchemaRef{
+					"name": {Value: &Schema{Type: []string{"string"}}},
+				},
+			},
+		}
+
+		data, err := json.Marshal(original)
+		require.NoError(t, err)
+
+		var ref SchemaRef
+		require.NoError(t, json.Unmarshal(data, &ref))
+		require.NotNil(t, ref.Value)
+		assert.Equal(t, []string{"object"}, ref.Value.Type)
+		assert.Equal(t, []string{"name"}, ref.Value.Required)
+		assert.Equal(t, []string{"string"}, ref.Value.Properties["name"].Value.Type)
+	})
+}
+
+func TestOperationMarshalJSON(t *testing.T) {
+	t.Run("Empty security is preserved", func(t *testing.T) {
+		data, err := json.Marshal(&Operation{OperationID: "health", Security: []SecurityRequirement{}})
+		require.NoError(t, err)
+		assert.Contains(t, string(data), `"security":[]`)
+	})
+
+	t.Run("Unset security is omitted", func(t *testing.T) {
+		data, err := json.Marshal(&Operation{OperationID: "health"})
+		require.NoError(t, err)
+		assert.NotContains(t, string(data), "security")
+	})
+}
+
+func TestOperationExtensions(t *testing.T) {
+	t.Run("YAML", func(t *testing.T) {
+		yamlData := `operationId: createOrder
+x-max-body-size: 1024
+x-internal: true`
+
+		var op Operation
+		require.NoError(t, yaml.Unmarshal([]byte(yamlData), &op))
+
+		assert.Equal(t, "createOrder", op.OperationID)
+		assert.Equal(t, map[string]any{"x-max-body-size": 1024, "x-internal": true}, op.Extensions)
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		var op Operation
+		require.NoError(t, json.Unmarshal([]byte(`{"operationId":"createOrder","x-max-body-size":1024}`), &op))
+
+		assert.Equal(t, "createOrder", op.OperationID)
+		assert.Equal(t, map[string]any{"x-max-body-size": float64(1024)}, op.Extensions)
+	})
+
+	t.Run("Marshaled after the other fields", func(t *testing.T) {
+		data, err := json.Marshal(&Operation{
+			OperationID: "createOrder",
+			Extensions:  map[string]any{"x-timeout": "5s", "x-max-body-size": 1024},
+		})
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"operationId":"createOrder","x-max-body-size":1024,"x-timeout":"5s"}`, string(data))
+		assert.Contains(t, string(data), `"x-max-body-size":1024,"x-timeout":"5s"}`)
+	})
+
+	t.Run("Marshaled without other fields", func(t *testing.T) {
+		data, err := json.Marshal(&Operation{Extensions: map[string]any{"x-internal": true}})
+		require.NoError(t, err)
+		assert.Equal(t, `{"x-internal":true}`, string(data))
+	})
+}
+
+func TestSecuritySchemeExtensions(t *testing.T) {
+	t.Run("YAML", func(t *testing.T) {
+		yamlData := `type: apiKey
+in: header
+name: X-API-Key
+x-tenant:
+  in: host`
+
+		var scheme SecurityScheme
+		require.NoError(t, yaml.Unmarshal([]byte(yamlData), &scheme))
+
+		assert.Equal(t, "X-API-Key", scheme.Name)
+		assert.Equal(t, map[string]any{"x-tenant": map[string]any{"in": "host"}}, scheme.Extensions)
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		var scheme SecurityScheme
+		require.NoError(t, json.Unmarshal([]byte(`{"type":"http","scheme":"bearer","x-tenant":{"in":"path"}}`), &scheme))
+
+		assert.Equal(t, "bearer", scheme.Scheme)
+		assert.Equal(t, map[string]any{"x-tenant": map[string]any{"in": "path"}}, scheme.Extensions)
+	})
+
+	t.Run("Marshaled", func(t *testing.T) {
+		data, err := json.Marshal(&SecurityScheme{Type: "http", Scheme: "bearer", Extensions: map[string]any{"x-tenant": map[string]any{"in": "host"}}})
+		require.NoError(t, err)
+		assert.Equal(t, `{"type":"http","scheme":"bearer","x-tenant":{"in":"host"}}`, string(data))
+	})
+}
+
+func TestParameterExtensions(t *testing.T) {
+	t.Run("YAML", func(t *testing.T) {
+		yamlData := `name: filePath
+in: path
+required: true
+x-wildcard: true`
+
+		var param Parameter
+		require.NoError(t, yaml.Unmarshal([]byte(yamlData), &param))
+
+		assert.Equal(t, "filePath", param.Name)
+		assert.Equal(t, map[string]any{"x-wildcard": true}, param.Extensions)
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		var param Parameter
+		require.NoError(t, json.Unmarshal([]byte(`{"name":"filePath","in":"path","x-wildcard":true}`), &param))
+
+		assert.Equal(t, "path", param.In)
+		assert.Equal(t, map[string]any{"x-wildcard": true}, param.Extensions)
+	})
+
+	t.Run("Marshaled", func(t *testing.T) {
+		data, err := json.Marshal(&Parameter{Name: "filePath", In: "path", Extensions: map[string]any{"x-wildcard": true}})
+		require.NoError(t, err)
+		assert.Equal(t, `{"name":"filePath","in":"path","x-wildcard":true}`, string(data))
+	})
+}
@@ -0,0 +1,267 @@
+package openapi
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Severity is how serious a validation finding is
+type Severity string
+
+const (
+	// SeverityError marks a finding that makes the document invalid or that
+	// code cannot be generated from
+	SeverityError Severity = "error"
+	// SeverityWarning marks a finding worth fixing that does not stop generation
+	SeverityWarning Severity = "warning"
+	// SeverityInfo marks a note on how code is generated, such as an
+	// identifier derived from a name that is not valid Go
+	SeverityInfo Severity = "info"
+)
+
+// Finding is a problem found by Validate
+type Finding struct {
+	Severity Severity `json:"severity"`
+	// Location is where the problem is, such as "GET /pets/{petId}" or
+	// "components.schemas.Pet"
+	Location string `json:"location"`
+	Message  string `json:"message"`
+	// Rule is the name of the LintRule that reported the finding, if any
+	Rule string `json:"rule,omitempty"`
+}
+
+// String formats the finding as "severity: location: message", followed by
+// the rule in brackets for lint findings
+func (f Finding) String() string {
+	if f.Rule != "" {
+		return fmt.Sprintf("%s: %s: %s [%s]", f.Severity, f.Location, f.Message, f.Rule)
+	}
+	return fmt.Sprintf("%s: %s: %s", f.Severity, f.Location, f.Message)
+}
+
+// HasErrors reports whether any of findings is an error
+func HasErrors(findings []Finding) bool {
+	return slices.ContainsFunc(findings, func(f Finding) bool { return f.Severity == SeverityError })
+}
+
+// pathTemplateParam matches the {name} parameters of a path template
+var pathTemplateParam = regexp.MustCompile(`\{([^}]+)\}`)
+
+// Validate checks the document beyond the minimum requirements Load enforces,
+// returning findings sorted by location. It checks that:
+//
+//   - references resolve
+//   - operation IDs are unique
+//   - every operation has responses
+//   - path templates and path parameters match, and path parameters are required
+//   - security requirements name defined security schemes
+func (doc *Document) Validate() []Finding {
+	v := &validator{doc: doc, operationIDs: make(map[string]string)}
+
+	if doc.Components != nil {
+		for _, name := range sortedKeys(doc.Components.Schemas) {
+			v.schemaRef("components.schemas."+name, doc.Components.Schemas[name])
+		}
+	}
+	v.security("security", doc.Security)
+
+	for _, path := range sortedKeys(doc.Paths) {
+		item := doc.Paths[path]
+		if item == nil {
+			continue
+		}
+		for _, method := range []string{"GET", "PUT", "POST", "DELETE", "OPTIONS", "HEAD", "PATCH", "TRACE"} {
+			if op := item.operation(method); op != nil {
+				v.operation(method+" "+path, path, item, op)
+			}
+		}
+	}
+
+	slices.SortStableFunc(v.findings, func(a, b Finding) int {
+		return cmp.Compare(a.Location, b.Location)
+	})
+	return v.findings
+}
+
+// validator collects the findings of Validate
+type validator struct {
+	doc          *Document
+	findings     []Finding
+	operationIDs map[string]string // location of the operation using each ID
+}
+
+func (v *validator) add(severity Severity, location, format string, args ...any) {
+	v.findings = append(v.findings, Finding{Severity: severity, Location: location, Message: fmt.Sprintf(format, args...)})
+}
+
+// operation checks an operation of the path item at path
+func (v *validator) operation(location, path string, item *PathItem, op *Operation) {
+	if op.OperationID == "" {
+		v.add(SeverityWarning, location, "operation has no operationId, so its handler is named after the method and path")
+	} else if other, ok := v.operationIDs[op.OperationID]; ok {
+		v.add(SeverityError, location, "operationId %q is already used by %s", op.OperationID, other)
+	} else {
+		v.operationIDs[op.OperationID] = location
+	}
+
+	if len(op.Responses) == 0 {
+		v.add(SeverityError, location, "operation has no responses")
+	}
+
+	// Path parameters may be declared on the path item or the operation
+	declared := make(map[string]bool)
+	for _, params := range [][]*Parameter{item.Parameters, op.Parameters} {
+		for _, param := range params {
+			param = v.parameter(location, param)
+			if param == nil || param.In != "path" {
+				continue
+			}
+			declared[param.Name] = true
+			if !param.Required {
+				v.add(SeverityError, location, "path parameter %s must be required", param.Name)
+			}
+			if !strings.Contains(path, "{"+param.Name+"}") {
+				v.add(SeverityError, location, "path parameter %s does not appear in the path", param.Name)
+			}
+		}
+	}
+	for _, match := range pathTemplateParam.FindAllStringSubmatch(path, -1) {
+		if !declared[match[1]] {
+			v.add(SeverityError, location, "path parameter %s is not declared", match[1])
+		}
+	}
+
+	if op.RequestBody != nil {
+		if op.RequestBody.Ref != "" {
+			v.ref(location+" requestBody", op.RequestBody.Ref)
+		}
+		for _, mediaType := range sortedKeys(op.RequestBody.Content) {
+			if content := op.RequestBody.Content[mediaType]; content != nil {
+				v.schemaRef(location+" requestBody "+mediaType, content.Schema)
+			}
+		}
+	}
+	for _, status := range sortedKeys(op.Responses) {
+		response := op.Responses[status]
+		if response == nil {
+			continue
+		}
+		if response.Ref != "" {
+			v.ref(location+" response "+status, response.Ref)
+		}
+		for _, mediaType := range sortedKeys(response.Content) {
+			if content := response.Content[mediaType]; content != nil {
+				v.schemaRef(location+" response "+status+" "+mediaType, content.Schema)
+			}
+		}
+	}
+
+	v.security(location, op.Security)
+}
+
+// parameter checks a parameter, returning it with its reference resolved, or
+// nil if it cannot be resolved
+func (v *validator) parameter(location string, param *Parameter) *Parameter {
+	if param == nil || param.Ref == "" {
+		return param
+	}
+	resolved, err := v.doc.resolveReference(param.Ref)
+	if err != nil {
+		v.add(SeverityError, location, "unresolved reference %s: %v", param.Ref, err)
+		return nil
+	}
+	p, ok := resolved.(*Parameter)
+	if !ok {
+		v.add(SeverityError, location, "reference %s is not a parameter", param.Ref)
+		return nil
+	}
+	return p
+}
+
+// ref checks that a reference resolves
+func (v *validator) ref(location, ref string) {
+	if _, err := v.doc.resolveReference(ref); err != nil {
+		v.add(SeverityError, location, "unresolved reference %s: %v", ref, err)
+	}
+}
+
+// schemaRef checks the references of a schema and the schemas it contains
+func (v *validator) schemaRef(location string, ref *SchemaRef) {
+	if ref == nil {
+		return
+	}
+	if ref.Ref != "" {
+		if _, err := v.doc.ResolveSchemaRef(ref); err != nil {
+			v.add(SeverityError, location, "unresolved reference %s: %v", ref.Ref, err)
+		}
+		return
+	}
+
+	schema := ref.Value
+	if schema == nil {
+		return
+	}
+	for _, name := range sortedKeys(schema.Properties) {
+		v.schemaRef(location+".properties."+name, schema.Properties[name])
+	}
+	v.schemaRef(location+".additionalProperties", schema.AdditionalProperties)
+	v.schemaRef(location+".items", schema.Items)
+	v.schemaRef(location+".not", schema.Not)
+	for i, r := range schema.AllOf {
+		v.schemaRef(fmt.Sprintf("%s.allOf[%d]", location, i), r)
+	}
+	for i, r := range schema.OneOf {
+		v.schemaRef(fmt.Sprintf("%s.oneOf[%d]", location, i), r)
+	}
+	for i, r := range schema.AnyOf {
+		v.schemaRef(fmt.Sprintf("%s.anyOf[%d]", location, i), r)
+	}
+}
+
+// security checks that security requirements name defined schemes
+func (v *validator) security(location string, requirements []SecurityRequirement) {
+	for _, requirement := range requirements {
+		for _, name := range sortedKeys(requirement) {
+			if v.doc.Components == nil || v.doc.Components.SecuritySchemes[name] == nil {
+				v.add(SeverityError, location, "security scheme %s is not defined", name)
+			}
+		}
+	}
+}
+
+// operation returns the operation of the path item for an upper-case method
+func (item *PathItem) operation(method string) *Operation {
+	switch method {
+	case "GET":
+		return item.Get
+	case "PUT":
+		return item.Put
+	case "POST":
+		return item.Post
+	case "DELETE":
+		return item.Delete
+	case "OPTIONS":
+		return item.Options
+	case "HEAD":
+		return item.Head
+	case "PATCH":
+		return item.Patch
+	case "TRACE":
+		return item.Trace
+	}
+	return nil
+}
+
+// sortedKeys returns the keys of m in order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,100 @@
+package openapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	doc, err := LoadFromData([]byte(`openapi: 3.1.0
+info:
+  title: Test API
+  version: 1.0.0
+security:
+  - apiKey: []
+paths:
+  /pets/{petId}:
+    parameters:
+      - name: petId
+        in: path
+        required: true
+        schema:
+          type: string
+    get:
+      operationId: getPet
+      responses:
+        '200':
+          description: OK
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Pet'
+  /owners/{ownerId}/pets:
+    get:
+      operationId: getPet
+      parameters:
+        - name: petId
+          in: path
+          schema:
+            type: string
+      responses:
+        '200':
+          description: OK
+    post:
+      parameters:
+        - $ref: '#/components/parameters/OwnerId'
+      security:
+        - bearerAuth: []
+      responses: {}
+components:
+  schemas:
+    Pet:
+      type: object
+      properties:
+        owner:
+          $ref: '#/components/schemas/Owner'
+        tags:
+          type: array
+          items:
+            $ref: '#/components/schemas/Tag'
+    Tag:
+      type: string
+  parameters:
+    OwnerId:
+      name: ownerId
+      in: path
+      required: true
+      schema:
+        type: string
+`), "spec.yaml")
+	require.NoError(t, err)
+
+	findings := doc.Validate()
+	var messages []string
+	for _, f := range findings {
+		messages = append(messages, f.String())
+	}
+	assert.Equal(t, []string{
+		`error: GET /owners/{ownerId}/pets: path parameter petId must be required`,
+		`error: GET /owners/{ownerId}/pets: path parameter petId does not appear in the path`,
+		`error: GET /owners/{ownerId}/pets: path parameter ownerId is not declared`,
+		`error: GET /pets/{petId}: operationId "getPet" is already used by GET /owners/{ownerId}/pets`,
+		`warning: POST /owners/{ownerId}/pets: operation has no operationId, so its handler is named after the method and path`,
+		`error: POST /owners/{ownerId}/pets: operation has no responses`,
+		`error: POST /owners/{ownerId}/pets: security scheme bearerAuth is not defined`,
+		`error: components.schemas.Pet.properties.owner: unresolved reference #/components/schemas/Owner: schema not found: Owner`,
+		`error: security: security scheme apiKey is not defined`,
+	}, messages)
+	assert.True(t, HasErrors(findings))
+}
+
+func TestValidateValidDocument(t *testing.T) {
+	doc, err := Load("../../examples/petstore.yaml")
+	require.NoError(t, err)
+
+	findings := doc.Validate()
+	assert.Empty(t, findings)
+	assert.False(t, HasErrors(findings))
+}
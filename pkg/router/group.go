@@ -0,0 +1,208 @@
+package router
+
+import (
+	"io/fs"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// mountedHandler is a handler serving every path under a prefix
+type mountedHandler struct {
+	prefix  string
+	handler http.Handler
+}
+
+// Route registers the routes added by fn under a path prefix. Middleware added
+// with Use inside fn only applies to these routes, and runs after the
+// middleware of the Mux.
+//
+// Example:
+//
+//	r.Route("/api/v1", func(r router.Router) {
+//		r.Use(authMiddleware)
+//		r.Get("/users", listUsers)
+//	})
+func (m *Mux) Route(prefix string, fn func(r Router)) {
+	fn(&group{mux: m, prefix: prefix})
+}
+
+// Mount serves every request under a path prefix with handler, for example
+// another Mux or a file server. The prefix is removed from the request path
+// before handler sees it, so generated routes can be mounted under any prefix.
+// Routes registered on the Mux take precedence over mounted handlers.
+func (m *Mux) Mount(prefix string, handler http.Handler) {
+	prefix = "/" + strings.Trim(prefix, "/")
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Build a new slice so requests iterating the old one are not disturbed
+	mounts := append(slices.Clone(m.mounts), mountedHandler{prefix: prefix, handler: handler})
+	for i := len(mounts) - 1; i > 0 && len(mounts[i].prefix) > len(mounts[i-1].prefix); i-- {
+		mounts[i], mounts[i-1] = mounts[i-1], mounts[i]
+	}
+	m.mounts = mounts
+}
+
+// Unmount removes the handler mounted under a path prefix, and reports
+// whether there was one
+func (m *Mux) Unmount(prefix string) bool {
+	prefix = "/" + strings.Trim(prefix, "/")
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := slices.IndexFunc(m.mounts, func(mounted mountedHandler) bool { return mounted.prefix == prefix })
+	if i < 0 {
+		return false
+	}
+	m.mounts = slices.Delete(slices.Clone(m.mounts), i, i+1)
+	return true
+}
+
+// serveMounted serves a request with the handler mounted under the longest
+// matching prefix, and reports whether there was one
+func (m *Mux) serveMounted(w http.ResponseWriter, r *http.Request) bool {
+	m.mu.RLock()
+	mounts := m.mounts
+	m.mu.RUnlock()
+
+	for _, mounted := range mounts {
+		rest, ok := strings.CutPrefix(r.URL.Path, mounted.prefix)
+		if mounted.prefix == "/" {
+			rest, ok = r.URL.Path, true
+		}
+		if !ok || (rest != "" && !strings.HasPrefix(rest, "/")) {
+			continue
+		}
+
+		// Pass on a copy of the request with the prefix removed from its path
+		mounted.handler.ServeHTTP(w, withPath(r, rest))
+
+		// Report the route a mounted Mux matched under the mount prefix
+		if rc, ok := r.Context().Value(routeContextKey).(*routeContext); ok {
+			if rc.pattern == "" {
+				rc.pattern = joinPath(mounted.prefix, "/*")
+			} else {
+				rc.pattern = joinPath(mounted.prefix, rc.pattern)
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// withPath returns a shallow copy of r requesting path instead
+func withPath(r *http.Request, path string) *http.Request {
+	r2 := new(http.Request)
+	*r2 = *r
+	r2.URL = new(url.URL)
+	*r2.URL = *r.URL
+	r2.URL.Path = "/" + strings.TrimPrefix(path, "/")
+	r2.URL.RawPath = ""
+	return r2
+}
+
+// Static serves the files of fsys under a path prefix, for example a docs UI
+// or the build output of a single-page app. GET and HEAD requests for
+// prefix/name are answered with the file name in fsys; requests for a
+// directory are answered with its index.html, or else a listing.
+//
+// Example:
+//
+//	//go:embed dist
+//	var dist embed.FS
+//
+//	assets, _ := fs.Sub(dist, "dist")
+//	r.Static("/assets", assets)
+func (m *Mux) Static(prefix string, fsys fs.FS) {
+	fileServer := http.FileServerFS(fsys)
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		fileServer.ServeHTTP(w, withPath(r, URLParam(r, "filepath")))
+	}
+	pattern := joinPath(prefix, "{filepath...}")
+	m.Get(pattern, handler)
+	m.Head(pattern, handler)
+}
+
+// group registers routes on a Mux under a path prefix, wrapped in its own middleware
+type group struct {
+	mux        *Mux
+	prefix     string
+	middleware []func(http.Handler) http.Handler
+}
+
+// ServeHTTP serves requests with the Mux the group belongs to
+func (g *group) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	g.mux.ServeHTTP(w, r)
+}
+
+// Use adds middleware applying to the routes of the group
+func (g *group) Use(middleware ...func(http.Handler) http.Handler) {
+	g.mux.mu.Lock()
+	defer g.mux.mu.Unlock()
+	g.middleware = append(g.middleware, middleware...)
+}
+
+// Get registers a GET route
+func (g *group) Get(pattern string, handler http.HandlerFunc) {
+	g.handle(http.MethodGet, pattern, handler)
+}
+
+// Post registers a POST route
+func (g *group) Post(pattern string, handler http.HandlerFunc) {
+	g.handle(http.MethodPost, pattern, handler)
+}
+
+// Put registers a PUT route
+func (g *group) Put(pattern string, handler http.HandlerFunc) {
+	g.handle(http.MethodPut, pattern, handler)
+}
+
+// Delete registers a DELETE route
+func (g *group) Delete(pattern string, handler http.HandlerFunc) {
+	g.handle(http.MethodDelete, pattern, handler)
+}
+
+// Patch registers a PATCH route
+func (g *group) Patch(pattern string, handler http.HandlerFunc) {
+	g.handle(http.MethodPatch, pattern, handler)
+}
+
+// Options registers an OPTIONS route
+func (g *group) Options(pattern string, handler http.HandlerFunc) {
+	g.handle(http.MethodOptions, pattern, handler)
+}
+
+// Head registers a HEAD route
+func (g *group) Head(pattern string, handler http.HandlerFunc) {
+	g.handle(http.MethodHead, pattern, handler)
+}
+
+// handle registers a route on the Mux under the group prefix
+func (g *group) handle(method, pattern string, handler http.HandlerFunc) {
+	g.mux.handle(method, joinPath(g.prefix, pattern), g.wrap(handler))
+}
+
+// joinPath joins a prefix and a path with a single slash
+func joinPath(prefix, path string) string {
+	return strings.TrimSuffix(prefix, "/") + "/" + strings.TrimPrefix(path, "/")
+}
+
+// wrap applies the group middleware to a handler. The chain is built per
+// request so middleware added after a route was registered applies too.
+func (g *group) wrap(handler http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		g.mux.mu.RLock()
+		middleware := g.middleware
+		g.mux.mu.RUnlock()
+
+		h := handler
+		for i := len(middleware) - 1; i >= 0; i-- {
+			h = middleware[i](h)
+		}
+		h.ServeHTTP(w, r)
+	}
+}
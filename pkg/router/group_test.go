@@ -0,0 +1,170 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouterRoute(t *testing.T) {
+	router := NewRouter()
+	var order []string
+
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			order = append(order, "mux")
+			next.ServeHTTP(w, r)
+		})
+	})
+	router.Get("/health", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	})
+	router.Route("/api/v1", func(r Router) {
+		r.Use(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, "group")
+				next.ServeHTTP(w, r)
+			})
+		})
+		r.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("user-" + URLParam(r, "id")))
+		})
+	})
+
+	tests := []struct {
+		name          string
+		path          string
+		expectedCode  int
+		expectedBody  string
+		expectedOrder []string
+	}{
+		{"Group route", "/api/v1/users/42", http.StatusOK, "user-42", []string{"mux", "group"}},
+		{"Route outside the group", "/health", http.StatusOK, "ok", []string{"mux"}},
+		{"Route without the prefix", "/users/42", http.StatusNotFound, "", []string{"mux"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order = nil
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectedBody != "" {
+				assert.Equal(t, tt.expectedBody, w.Body.String())
+			}
+			assert.Equal(t, tt.expectedOrder, order)
+		})
+	}
+}
+
+func TestRouterMount(t *testing.T) {
+	api := NewRouter()
+	api.Get("/pets/{id}", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("pet-" + URLParam(r, "id") + " " + r.URL.Path))
+	})
+	api.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("api root"))
+	})
+
+	router := NewRouter()
+	router.Mount("/api/v1/", api)
+	router.Mount("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("fallback " + r.URL.Path))
+	}))
+	router.Get("/api/v1/status", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("status"))
+	})
+
+	tests := []struct {
+		name         string
+		path         string
+		expectedCode int
+		expectedBody string
+	}{
+		{"Mounted route", "/api/v1/pets/7", http.StatusOK, "pet-7 /pets/7"},
+		{"Mounted root", "/api/v1", http.StatusOK, "api root"},
+		{"Mounted not found", "/api/v1/nonexistent", http.StatusNotFound, ""},
+		{"Registered route wins", "/api/v1/status", http.StatusOK, "status"},
+		{"Prefix must end at a segment", "/api/v1x/pets/7", http.StatusOK, "fallback /api/v1x/pets/7"},
+		{"Root mount", "/other", http.StatusOK, "fallback /other"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectedBody != "" {
+				assert.Equal(t, tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestRouterStatic(t *testing.T) {
+	fsys := fstest.MapFS{
+		"index.html":    {Data: []byte("<h1>docs</h1>")},
+		"css/site.css":  {Data: []byte("body{}")},
+		"js/app/app.js": {Data: []byte("app()")},
+	}
+
+	router := NewRouter()
+	router.Static("/docs", fsys)
+	router.Get("/docs/api", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("api"))
+	})
+
+	tests := []struct {
+		method string
+		path   string
+		code   int
+		body   string
+	}{
+		{http.MethodGet, "/docs/css/site.css", http.StatusOK, "body{}"},
+		{http.MethodGet, "/docs/js/app/app.js", http.StatusOK, "app()"},
+		{http.MethodGet, "/docs/", http.StatusOK, "<h1>docs</h1>"},
+		{http.MethodGet, "/docs/api", http.StatusOK, "api"},
+		{http.MethodHead, "/docs/css/site.css", http.StatusOK, ""},
+		{http.MethodGet, "/docs/missing.js", http.StatusNotFound, ""},
+		{http.MethodPost, "/docs/css/site.css", http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+
+			assert.Equal(t, tt.code, w.Code)
+			if tt.body != "" {
+				assert.Equal(t, tt.body, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestRouterUnmount(t *testing.T) {
+	router := NewRouter()
+	router.Mount("/api", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/pets", nil))
+	assert.Equal(t, http.StatusTeapot, w.Code)
+
+	assert.True(t, router.Unmount("/api/"))
+	assert.False(t, router.Unmount("/api"))
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/pets", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -9,7 +9,8 @@ import "net/http"
 // The router must:
 //  1. Support all standard HTTP methods (GET, POST, PUT, DELETE, PATCH, OPTIONS, HEAD)
 //  2. Support middleware via the Use method
-//  3. Support path parameters in the format {paramName}
+//  3. Support path parameters in the format {paramName}, constrained to a regular
+//     expression as {paramName:regexp}, and catch-all segments as {paramName...}
 //  4. Store path parameters in the request context using URLParamKey (defined in this package)
 //  5. Implement http.Handler interface
 //
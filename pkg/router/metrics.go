@@ -0,0 +1,214 @@
+package router
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultMetricsBuckets are the upper bounds, in seconds, of the request
+// duration histogram buckets used unless NewMetrics is given others. They
+// match the defaults of the Prometheus client libraries.
+var DefaultMetricsBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// unmatchedRoute is the route label of requests that matched no route
+const unmatchedRoute = "unmatched"
+
+// otherMethod is the method label of requests whose method is not one of
+// standardMethods
+const otherMethod = "OTHER"
+
+// standardMethods are the methods requests are labeled with as sent. Clients
+// choose the method freely, so any other would add a series per value.
+var standardMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+	http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace,
+}
+
+// Metrics collects request metrics and serves them in the Prometheus text
+// exposition format:
+//
+//   - http_requests_total: counter of requests by method, route and status
+//   - http_request_duration_seconds: histogram of request durations by method and route
+//   - http_requests_in_flight: gauge of requests being served
+//
+// Requests are labeled with the pattern of the route they matched, such as
+// /pets/{petId}, rather than the raw path, so the number of series stays
+// bounded. Route patterns are known when Metrics.Middleware wraps a Mux;
+// other handlers are labeled "unmatched". For the same reason, methods other
+// than the standard HTTP methods are labeled "OTHER".
+//
+// Example:
+//
+//	metrics := router.NewMetrics()
+//	r := api.NewRouter(server)
+//	r.Use(metrics.Middleware)
+//	metrics.Mount(r)
+type Metrics struct {
+	buckets   []float64
+	inFlight  atomic.Int64
+	mu        sync.Mutex
+	requests  map[requestSeries]uint64
+	durations map[durationSeries]*histogram
+}
+
+// requestSeries identifies a series of http_requests_total
+type requestSeries struct {
+	method, route string
+	status        int
+}
+
+// durationSeries identifies a series of http_request_duration_seconds
+type durationSeries struct {
+	method, route string
+}
+
+// histogram holds the observations of one duration series. counts[i] is the
+// number of observations in bucket i alone, with the last entry for +Inf.
+type histogram struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// NewMetrics creates a Metrics with the given histogram bucket upper bounds in
+// seconds, or DefaultMetricsBuckets if there are none
+func NewMetrics(buckets ...float64) *Metrics {
+	if len(buckets) == 0 {
+		buckets = DefaultMetricsBuckets
+	}
+	buckets = slices.Clone(buckets)
+	slices.Sort(buckets)
+
+	return &Metrics{
+		buckets:   slices.Compact(buckets),
+		requests:  make(map[requestSeries]uint64),
+		durations: make(map[durationSeries]*histogram),
+	}
+}
+
+// Middleware records metrics for every request served by next
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		m.inFlight.Add(1)
+		defer m.inFlight.Add(-1)
+
+		// The Mux records the pattern of the route it matches here
+		r, rc := withRouteContext(r)
+		lrw := &loggingResponseWriter{
+			ResponseWriter: w,
+			statusCode:     http.StatusOK,
+		}
+
+		next.ServeHTTP(lrw, r)
+
+		route := rc.pattern
+		if route == "" {
+			route = unmatchedRoute
+		}
+		m.observe(methodLabel(r.Method), route, lrw.statusCode, time.Since(start))
+	})
+}
+
+// observe records a served request
+func (m *Metrics) observe(method, route string, status int, duration time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.requests[requestSeries{method: method, route: route, status: status}]++
+
+	series := durationSeries{method: method, route: route}
+	h, ok := m.durations[series]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(m.buckets)+1)}
+		m.durations[series] = h
+	}
+	seconds := duration.Seconds()
+	h.counts[sort.SearchFloat64s(m.buckets, seconds)]++
+	h.sum += seconds
+	h.count++
+}
+
+// Mount registers GET /metrics on r to serve the collected metrics
+func (m *Metrics) Mount(r Router) {
+	r.Get("/metrics", m.ServeHTTP)
+}
+
+// ServeHTTP writes the collected metrics in the Prometheus text exposition format
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.write(w)
+}
+
+// write writes the collected metrics, with series in a stable order
+func (m *Metrics) write(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fmt.Fprintln(w, "# HELP http_requests_total Total number of HTTP requests served.")
+	fmt.Fprintln(w, "# TYPE http_requests_total counter")
+	requests := make([]requestSeries, 0, len(m.requests))
+	for series := range m.requests {
+		requests = append(requests, series)
+	}
+	slices.SortFunc(requests, func(a, b requestSeries) int {
+		return cmp.Or(cmp.Compare(a.route, b.route), cmp.Compare(a.method, b.method), cmp.Compare(a.status, b.status))
+	})
+	for _, series := range requests {
+		fmt.Fprintf(w, "http_requests_total{method=%s,route=%s,status=\"%d\"} %d\n",
+			labelValue(series.method), labelValue(series.route), series.status, m.requests[series])
+	}
+
+	fmt.Fprintln(w, "# HELP http_request_duration_seconds Duration of HTTP requests in seconds.")
+	fmt.Fprintln(w, "# TYPE http_request_duration_seconds histogram")
+	durations := make([]durationSeries, 0, len(m.durations))
+	for series := range m.durations {
+		durations = append(durations, series)
+	}
+	slices.SortFunc(durations, func(a, b durationSeries) int {
+		return cmp.Or(cmp.Compare(a.route, b.route), cmp.Compare(a.method, b.method))
+	})
+	for _, series := range durations {
+		h := m.durations[series]
+		labels := "method=" + labelValue(series.method) + ",route=" + labelValue(series.route)
+		var cumulative uint64
+		for i, count := range h.counts {
+			cumulative += count
+			le := "+Inf"
+			if i < len(m.buckets) {
+				le = strconv.FormatFloat(m.buckets[i], 'g', -1, 64)
+			}
+			fmt.Fprintf(w, "http_request_duration_seconds_bucket{%s,le=%q} %d\n", labels, le, cumulative)
+		}
+		fmt.Fprintf(w, "http_request_duration_seconds_sum{%s} %s\n", labels, strconv.FormatFloat(h.sum, 'g', -1, 64))
+		fmt.Fprintf(w, "http_request_duration_seconds_count{%s} %d\n", labels, h.count)
+	}
+
+	fmt.Fprintln(w, "# HELP http_requests_in_flight Number of HTTP requests currently being served.")
+	fmt.Fprintln(w, "# TYPE http_requests_in_flight gauge")
+	fmt.Fprintf(w, "http_requests_in_flight %d\n", m.inFlight.Load())
+}
+
+// methodLabel returns the method label of a request method
+func methodLabel(method string) string {
+	if slices.Contains(standardMethods, method) {
+		return method
+	}
+	return otherMethod
+}
+
+// labelValue quotes a label value as the exposition format requires
+func labelValue(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, "\n", `\n`)
+	return `"` + strings.ReplaceAll(value, `"`, `\"`) + `"`
+}
@@ -0,0 +1,107 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics(t *testing.T) {
+	metrics := NewMetrics(0.1, 1)
+	router := NewRouter()
+	router.Use(metrics.Middleware)
+	router.Get("/pets/{petId}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	router.Post("/pets", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+	metrics.Mount(router)
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/pets/1", nil),
+		httptest.NewRequest(http.MethodGet, "/pets/2", nil),
+		httptest.NewRequest(http.MethodPost, "/pets", nil),
+		httptest.NewRequest(http.MethodGet, "/unknown/path", nil),
+	} {
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", w.Header().Get("Content-Type"))
+
+	body := w.Body.String()
+	assert.Contains(t, body, "# TYPE http_requests_total counter\n")
+	assert.Contains(t, body, `http_requests_total{method="GET",route="/pets/{petId}",status="200"} 2`+"\n",
+		"Should label requests by route pattern, not path")
+	assert.Contains(t, body, `http_requests_total{method="POST",route="/pets",status="201"} 1`+"\n")
+	assert.Contains(t, body, `http_requests_total{method="GET",route="unmatched",status="404"} 1`+"\n")
+	assert.NotContains(t, body, "/pets/1")
+
+	assert.Contains(t, body, "# TYPE http_request_duration_seconds histogram\n")
+	assert.Contains(t, body, `http_request_duration_seconds_bucket{method="GET",route="/pets/{petId}",le="0.1"} 2`+"\n")
+	assert.Contains(t, body, `http_request_duration_seconds_bucket{method="GET",route="/pets/{petId}",le="1"} 2`+"\n")
+	assert.Contains(t, body, `http_request_duration_seconds_bucket{method="GET",route="/pets/{petId}",le="+Inf"} 2`+"\n")
+	assert.Contains(t, body, `http_request_duration_seconds_count{method="GET",route="/pets/{petId}"} 2`+"\n")
+
+	assert.Contains(t, body, "# TYPE http_requests_in_flight gauge\nhttp_requests_in_flight 1\n",
+		"Should count the metrics request itself as in flight")
+}
+
+func TestMetricsMountedRoutes(t *testing.T) {
+	metrics := NewMetrics()
+
+	api := NewRouter()
+	api.Get("/pets/{petId}", func(w http.ResponseWriter, r *http.Request) {})
+
+	router := NewRouter()
+	router.Use(metrics.Middleware)
+	router.Mount("/api/v1", api)
+	router.Mount("/static", http.NotFoundHandler())
+	router.Route("/admin", func(r Router) {
+		r.Get("/stats", func(w http.ResponseWriter, r *http.Request) {})
+	})
+
+	for _, path := range []string{"/api/v1/pets/1", "/static/site.css", "/admin/stats"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	var sb strings.Builder
+	metrics.write(&sb)
+	body := sb.String()
+
+	assert.Contains(t, body, `http_requests_total{method="GET",route="/api/v1/pets/{petId}",status="200"} 1`)
+	assert.Contains(t, body, `http_requests_total{method="GET",route="/static/*",status="404"} 1`)
+	assert.Contains(t, body, `http_requests_total{method="GET",route="/admin/stats",status="200"} 1`)
+}
+
+func TestMetricsNonStandardMethods(t *testing.T) {
+	metrics := NewMetrics()
+	router := NewRouter()
+	router.Use(metrics.Middleware)
+
+	for _, method := range []string{"PROPFIND", "X-1", "X-2", "get"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, "/pets", nil))
+	}
+
+	var sb strings.Builder
+	metrics.write(&sb)
+	body := sb.String()
+
+	assert.Contains(t, body, `http_requests_total{method="OTHER",route="unmatched",status="404"} 4`+"\n",
+		"Should label methods outside the standard set as OTHER")
+	assert.NotContains(t, body, "PROPFIND")
+	assert.NotContains(t, body, `method="get"`)
+}
+
+func TestLabelValue(t *testing.T) {
+	assert.Equal(t, `"/pets/{petId}"`, labelValue("/pets/{petId}"))
+	assert.Equal(t, `"a\"b\\c\nd"`, labelValue("a\"b\\c\nd"))
+}
@@ -4,27 +4,112 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
 	"runtime/debug"
+	"slices"
+	"sync/atomic"
 	"time"
 )
 
-// Logger is a middleware that logs HTTP requests
+// Logger is a middleware that logs HTTP requests with slog.Default().
+// Use NewLogger to configure the logger and what gets logged.
 func Logger(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
+	return NewLogger(LoggerOptions{})(next)
+}
 
-		// Create a custom response writer to capture status code
-		lrw := &loggingResponseWriter{
-			ResponseWriter: w,
-			statusCode:     http.StatusOK,
-		}
+// LoggerOptions configures the middleware returned by NewLogger
+type LoggerOptions struct {
+	// Logger receives the log records. It defaults to slog.Default().
+	Logger *slog.Logger
 
-		next.ServeHTTP(lrw, r)
+	// Level is the level successful requests are logged at. Responses with a
+	// 4xx status are logged at least at warning level, and 5xx at error level.
+	Level slog.Level
 
-		duration := time.Since(start)
-		log.Printf("%s %s %d %s", r.Method, r.URL.Path, lrw.statusCode, duration)
-	})
+	// SkipPaths lists request paths that are not logged, such as health probes
+	SkipPaths []string
+
+	// SampleSuccesses logs only one in every SampleSuccesses requests with a
+	// status below 400. Failed requests are always logged. Values below 2 log
+	// every request.
+	SampleSuccesses int
+
+	// Attrs returns additional attributes to log for a request
+	Attrs func(r *http.Request) []slog.Attr
+}
+
+// NewLogger returns middleware that logs each request as a structured slog
+// record with the method, path, matched route, status, duration and, when
+// RequestID runs first, the request ID.
+//
+// Example:
+//
+//	r.Use(router.NewLogger(router.LoggerOptions{
+//		Logger:          slog.New(slog.NewJSONHandler(os.Stdout, nil)),
+//		SkipPaths:       []string{"/healthz"},
+//		SampleSuccesses: 10,
+//	}))
+func NewLogger(opts LoggerOptions) func(http.Handler) http.Handler {
+	var successes atomic.Uint64
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if slices.Contains(opts.SkipPaths, r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			start := time.Now()
+			r, rc := withRouteContext(r)
+
+			// Create a custom response writer to capture status code
+			lrw := &loggingResponseWriter{
+				ResponseWriter: w,
+				statusCode:     http.StatusOK,
+			}
+
+			next.ServeHTTP(lrw, r)
+
+			duration := time.Since(start)
+			level := opts.Level
+			switch {
+			case lrw.statusCode >= http.StatusInternalServerError:
+				level = max(level, slog.LevelError)
+			case lrw.statusCode >= http.StatusBadRequest:
+				level = max(level, slog.LevelWarn)
+			case opts.SampleSuccesses > 1 && successes.Add(1)%uint64(opts.SampleSuccesses) != 1:
+				return
+			}
+
+			logger := opts.Logger
+			if logger == nil {
+				logger = slog.Default()
+			}
+			ctx := r.Context()
+			if !logger.Enabled(ctx, level) {
+				return
+			}
+
+			attrs := []slog.Attr{
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+			}
+			if rc.pattern != "" {
+				attrs = append(attrs, slog.String("route", rc.pattern))
+			}
+			attrs = append(attrs,
+				slog.Int("status", lrw.statusCode),
+				slog.Duration("duration", duration),
+			)
+			if requestID := GetRequestID(ctx); requestID != "" {
+				attrs = append(attrs, slog.String("request_id", requestID))
+			}
+			if opts.Attrs != nil {
+				attrs = append(attrs, opts.Attrs(r)...)
+			}
+			logger.LogAttrs(ctx, level, "request", attrs...)
+		})
+	}
 }
 
 // loggingResponseWriter wraps http.ResponseWriter to capture status code
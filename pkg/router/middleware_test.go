@@ -2,10 +2,13 @@ package router
 
 import (
 	"bytes"
+	"encoding/json"
 	"log"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -73,6 +76,106 @@ func TestLoggerWithDifferentStatusCodes(t *testing.T) {
 	}
 }
 
+func TestNewLogger(t *testing.T) {
+	newLogger := func(buf *bytes.Buffer) *slog.Logger {
+		return slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	}
+	records := func(buf *bytes.Buffer) []map[string]any {
+		var records []map[string]any
+		for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+			if line == "" {
+				continue
+			}
+			var record map[string]any
+			require.NoError(t, json.Unmarshal([]byte(line), &record))
+			records = append(records, record)
+		}
+		return records
+	}
+
+	t.Run("structured fields", func(t *testing.T) {
+		var buf bytes.Buffer
+		router := NewRouter()
+		router.Use(RequestID)
+		router.Use(NewLogger(LoggerOptions{
+			Logger: newLogger(&buf),
+			Attrs: func(r *http.Request) []slog.Attr {
+				return []slog.Attr{slog.String("user_agent", r.UserAgent())}
+			},
+		}))
+		router.Get("/pets/{petId}", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/pets/42", nil)
+		req.Header.Set("X-Request-ID", "req-1")
+		req.Header.Set("User-Agent", "test-agent")
+		router.ServeHTTP(httptest.NewRecorder(), req)
+
+		logged := records(&buf)
+		require.Len(t, logged, 1)
+		assert.Equal(t, "request", logged[0]["msg"])
+		assert.Equal(t, "INFO", logged[0]["level"])
+		assert.Equal(t, "GET", logged[0]["method"])
+		assert.Equal(t, "/pets/42", logged[0]["path"])
+		assert.Equal(t, "/pets/{petId}", logged[0]["route"])
+		assert.Equal(t, float64(http.StatusOK), logged[0]["status"])
+		assert.Contains(t, logged[0], "duration")
+		assert.Equal(t, "req-1", logged[0]["request_id"])
+		assert.Equal(t, "test-agent", logged[0]["user_agent"])
+	})
+
+	t.Run("levels", func(t *testing.T) {
+		var buf bytes.Buffer
+		middleware := NewLogger(LoggerOptions{Logger: newLogger(&buf), Level: slog.LevelDebug})
+
+		for _, code := range []int{http.StatusOK, http.StatusNotFound, http.StatusInternalServerError} {
+			handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(code)
+			}))
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/test", nil))
+		}
+
+		logged := records(&buf)
+		require.Len(t, logged, 3)
+		assert.Equal(t, "DEBUG", logged[0]["level"])
+		assert.Equal(t, "WARN", logged[1]["level"])
+		assert.Equal(t, "ERROR", logged[2]["level"])
+	})
+
+	t.Run("skip paths", func(t *testing.T) {
+		var buf bytes.Buffer
+		handler := NewLogger(LoggerOptions{Logger: newLogger(&buf), SkipPaths: []string{"/healthz"}})(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/pets", nil))
+
+		logged := records(&buf)
+		require.Len(t, logged, 1)
+		assert.Equal(t, "/pets", logged[0]["path"])
+	})
+
+	t.Run("sample successes", func(t *testing.T) {
+		var buf bytes.Buffer
+		status := http.StatusOK
+		handler := NewLogger(LoggerOptions{Logger: newLogger(&buf), SampleSuccesses: 3})(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(status)
+			}))
+
+		for range 6 {
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/test", nil))
+		}
+		status = http.StatusBadRequest
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/test", nil))
+
+		logged := records(&buf)
+		require.Len(t, logged, 3, "Should log every third success and every failure")
+		assert.Equal(t, float64(http.StatusBadRequest), logged[2]["status"])
+	})
+}
+
 func TestLoggingResponseWriter(t *testing.T) {
 	t.Run("WriteHeader captures status code", func(t *testing.T) {
 		w := httptest.NewRecorder()
@@ -0,0 +1,109 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ParamError is returned by the typed URL parameter helpers when a parameter
+// is missing or does not parse as the requested type
+type ParamError struct {
+	Name  string // the parameter name
+	Value string // the raw parameter value, "" if it is missing
+	Err   error  // the parse error
+}
+
+func (e *ParamError) Error() string {
+	if e.Value == "" {
+		return fmt.Sprintf("missing URL parameter %q", e.Name)
+	}
+	return fmt.Sprintf("invalid URL parameter %q: %v", e.Name, e.Err)
+}
+
+func (e *ParamError) Unwrap() error {
+	return e.Err
+}
+
+// URLParamInt returns a URL parameter parsed as a base 10 int
+func URLParamInt(r *http.Request, key string) (int, error) {
+	return parseURLParam(r, key, func(s string) (int, error) {
+		return strconv.Atoi(s)
+	})
+}
+
+// URLParamInt32 returns a URL parameter parsed as a base 10 int32
+func URLParamInt32(r *http.Request, key string) (int32, error) {
+	return parseURLParam(r, key, func(s string) (int32, error) {
+		v, err := strconv.ParseInt(s, 10, 32)
+		return int32(v), err
+	})
+}
+
+// URLParamInt64 returns a URL parameter parsed as a base 10 int64
+func URLParamInt64(r *http.Request, key string) (int64, error) {
+	return parseURLParam(r, key, func(s string) (int64, error) {
+		return strconv.ParseInt(s, 10, 64)
+	})
+}
+
+// URLParamFloat64 returns a URL parameter parsed as a float64
+func URLParamFloat64(r *http.Request, key string) (float64, error) {
+	return parseURLParam(r, key, func(s string) (float64, error) {
+		return strconv.ParseFloat(s, 64)
+	})
+}
+
+// URLParamBool returns a URL parameter parsed as a bool, accepting the values
+// strconv.ParseBool does
+func URLParamBool(r *http.Request, key string) (bool, error) {
+	return parseURLParam(r, key, strconv.ParseBool)
+}
+
+// URLParamUUID returns a URL parameter holding a UUID in its canonical
+// 8-4-4-4-12 hex form, lowercased
+func URLParamUUID(r *http.Request, key string) (string, error) {
+	return parseURLParam(r, key, func(s string) (string, error) {
+		if !isUUID(s) {
+			return "", fmt.Errorf("%q is not a UUID", s)
+		}
+		return strings.ToLower(s), nil
+	})
+}
+
+// parseURLParam reads a URL parameter and parses it, wrapping failures in a ParamError
+func parseURLParam[T any](r *http.Request, key string, parse func(string) (T, error)) (T, error) {
+	value := URLParam(r, key)
+	if value == "" {
+		var zero T
+		return zero, &ParamError{Name: key}
+	}
+	v, err := parse(value)
+	if err != nil {
+		var zero T
+		return zero, &ParamError{Name: key, Value: value, Err: err}
+	}
+	return v, nil
+}
+
+// isUUID reports whether s is a UUID in the 8-4-4-4-12 hex form
+func isUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		switch i {
+		case 8, 13, 18, 23:
+			if s[i] != '-' {
+				return false
+			}
+		default:
+			c := s[i]
+			if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+				return false
+			}
+		}
+	}
+	return true
+}
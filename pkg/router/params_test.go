@@ -0,0 +1,61 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTypedURLParams(t *testing.T) {
+	serve := func(value string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/params", nil)
+		return r.WithContext(context.WithValue(r.Context(), URLParamKey, map[string]string{"value": value}))
+	}
+
+	n, err := URLParamInt(serve("-42"), "value")
+	require.NoError(t, err)
+	assert.Equal(t, -42, n)
+
+	n32, err := URLParamInt32(serve("2147483647"), "value")
+	require.NoError(t, err)
+	assert.Equal(t, int32(2147483647), n32)
+
+	_, err = URLParamInt32(serve("2147483648"), "value")
+	assert.ErrorIs(t, err, strconv.ErrRange)
+
+	n64, err := URLParamInt64(serve("9007199254740993"), "value")
+	require.NoError(t, err)
+	assert.Equal(t, int64(9007199254740993), n64)
+
+	f, err := URLParamFloat64(serve("1.5"), "value")
+	require.NoError(t, err)
+	assert.Equal(t, 1.5, f)
+
+	b, err := URLParamBool(serve("true"), "value")
+	require.NoError(t, err)
+	assert.True(t, b)
+
+	id, err := URLParamUUID(serve("3F2504E0-4F89-11D3-9A0C-0305E82C3301"), "value")
+	require.NoError(t, err)
+	assert.Equal(t, "3f2504e0-4f89-11d3-9a0c-0305e82c3301", id)
+
+	_, err = URLParamUUID(serve("3f2504e0-4f89-11d3-9a0c"), "value")
+	assert.Error(t, err)
+
+	_, err = URLParamInt64(serve("abc"), "value")
+	var paramErr *ParamError
+	require.True(t, errors.As(err, &paramErr))
+	assert.Equal(t, "value", paramErr.Name)
+	assert.Equal(t, "abc", paramErr.Value)
+	assert.ErrorIs(t, err, strconv.ErrSyntax)
+	assert.Equal(t, `invalid URL parameter "value": strconv.ParseInt: parsing "abc": invalid syntax`, err.Error())
+
+	_, err = URLParamInt(serve("1"), "missing")
+	assert.EqualError(t, err, `missing URL parameter "missing"`)
+}
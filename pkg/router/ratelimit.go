@@ -0,0 +1,151 @@
+package router
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimitOption configures the RateLimit middleware
+type RateLimitOption func(*rateLimiter)
+
+// WithRateLimitKey sets the function that decides which requests share a
+// token bucket, for example by API key or user instead of client IP.
+// Requests for which key returns "" are not limited.
+func WithRateLimitKey(key func(r *http.Request) string) RateLimitOption {
+	return func(l *rateLimiter) {
+		l.key = key
+	}
+}
+
+// WithRateLimitExceeded sets the handler answering requests over the limit,
+// for example to write errors in the format of an API. The Retry-After header
+// is set before it runs. By default the response is a plain text 429.
+func WithRateLimitExceeded(handler http.Handler) RateLimitOption {
+	return func(l *rateLimiter) {
+		l.exceeded = handler
+	}
+}
+
+// RateLimit returns middleware that limits clients to rate requests per
+// second on average, with bursts of up to burst requests, using a token bucket
+// per client IP. Requests over the limit get 429 Too Many Requests with a
+// Retry-After header saying when a token is next available.
+//
+// Use it on a Mux or route group to limit every route, or wrap a single handler:
+//
+//	r.Use(router.RateLimit(10, 20))
+//	r.Post("/login", router.RateLimit(0.2, 3)(loginHandler).ServeHTTP)
+//
+// The client IP is taken from r.RemoteAddr, so add RealIP first when the
+// server runs behind a proxy. RateLimit panics if rate or burst is not positive.
+func RateLimit(rate float64, burst int, opts ...RateLimitOption) func(http.Handler) http.Handler {
+	return newRateLimiter(rate, burst, opts...).middleware
+}
+
+// rateLimiter holds the token buckets of the RateLimit middleware
+type rateLimiter struct {
+	rate      float64
+	burst     int
+	key       func(r *http.Request) string
+	exceeded  http.Handler
+	now       func() time.Time
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+// tokenBucket holds the tokens left for one key as of the last request
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// newRateLimiter creates a rateLimiter, panicking on an invalid configuration
+func newRateLimiter(rate float64, burst int, opts ...RateLimitOption) *rateLimiter {
+	if rate <= 0 || math.IsInf(rate, 0) || math.IsNaN(rate) {
+		panic("router: RateLimit rate must be a positive number")
+	}
+	if burst < 1 {
+		panic("router: RateLimit burst must be at least 1")
+	}
+
+	l := &rateLimiter{
+		rate:    rate,
+		burst:   burst,
+		key:     clientIP,
+		now:     time.Now,
+		buckets: make(map[string]*tokenBucket),
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// middleware rejects requests whose bucket is empty
+func (l *rateLimiter) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := l.key(r)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if ok, wait := l.allow(key); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			if l.exceeded != nil {
+				l.exceeded.ServeHTTP(w, r)
+				return
+			}
+			http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// allow takes a token from the bucket of key. When the bucket is empty, it
+// reports how long until the next token is added instead.
+func (l *rateLimiter) allow(key string) (bool, time.Duration) {
+	now := l.now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Buckets that have refilled completely hold no state worth keeping
+	refill := time.Duration(float64(l.burst) / l.rate * float64(time.Second))
+	if now.Sub(l.lastSweep) >= refill {
+		for k, b := range l.buckets {
+			if now.Sub(b.last) >= refill {
+				delete(l.buckets, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: float64(l.burst), last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = min(float64(l.burst), b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+}
+
+// clientIP returns the IP address of the client, without the port
+func clientIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
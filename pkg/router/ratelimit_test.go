@@ -0,0 +1,123 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimit(t *testing.T) {
+	handler := RateLimit(1, 2)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	send := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		req.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, send("1.2.3.4:1000").Code)
+	assert.Equal(t, http.StatusOK, send("1.2.3.4:1001").Code, "Should allow a burst from another port of the same IP")
+
+	w := send("1.2.3.4:1002")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+
+	assert.Equal(t, http.StatusOK, send("5.6.7.8:1000").Code, "Should keep a bucket per client IP")
+}
+
+func TestRateLimitRefill(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	limiter := newRateLimiter(0.5, 1)
+	limiter.now = func() time.Time { return now }
+
+	ok, _ := limiter.allow("client")
+	assert.True(t, ok)
+
+	ok, wait := limiter.allow("client")
+	assert.False(t, ok)
+	assert.Equal(t, 2*time.Second, wait)
+
+	now = now.Add(time.Second)
+	ok, wait = limiter.allow("client")
+	assert.False(t, ok)
+	assert.Equal(t, time.Second, wait, "Should count the token partially refilled so far")
+
+	now = now.Add(time.Second)
+	ok, _ = limiter.allow("client")
+	assert.True(t, ok)
+
+	// Idle buckets are dropped once they would have refilled
+	now = now.Add(time.Minute)
+	limiter.allow("other")
+	assert.NotContains(t, limiter.buckets, "client")
+}
+
+func TestRateLimitKey(t *testing.T) {
+	handler := RateLimit(1, 1, WithRateLimitKey(func(r *http.Request) string {
+		return r.Header.Get("X-API-Key")
+	}))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	send := func(apiKey string) int {
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		if apiKey != "" {
+			req.Header.Set("X-API-Key", apiKey)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, send("alice"))
+	assert.Equal(t, http.StatusTooManyRequests, send("alice"))
+	assert.Equal(t, http.StatusOK, send("bob"))
+	assert.Equal(t, http.StatusOK, send(""), "Should not limit requests without a key")
+	assert.Equal(t, http.StatusOK, send(""))
+}
+
+func TestRateLimitExceeded(t *testing.T) {
+	handler := RateLimit(1, 1, WithRateLimitExceeded(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(`{"error":"rate limit exceeded"}`))
+	})))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/test", nil))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test", nil))
+
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, `{"error":"rate limit exceeded"}`, w.Body.String())
+	assert.Equal(t, "1", w.Header().Get("Retry-After"), "Should set Retry-After before the handler runs")
+}
+
+func TestRateLimitPerRoute(t *testing.T) {
+	router := NewRouter()
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	router.Post("/login", RateLimit(1, 1)(http.HandlerFunc(ok)).ServeHTTP)
+	router.Get("/health", ok)
+
+	send := func(method, path string) int {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, send(http.MethodPost, "/login"))
+	assert.Equal(t, http.StatusTooManyRequests, send(http.MethodPost, "/login"))
+	assert.Equal(t, http.StatusOK, send(http.MethodGet, "/health"))
+	assert.Equal(t, http.StatusOK, send(http.MethodGet, "/health"))
+}
+
+func TestRateLimitInvalidConfig(t *testing.T) {
+	assert.Panics(t, func() { RateLimit(0, 1) })
+	assert.Panics(t, func() { RateLimit(1, 0) })
+}
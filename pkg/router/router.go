@@ -2,29 +2,65 @@ package router
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"net/url"
+	"regexp"
 	"strings"
+	"sync"
 )
 
-// Mux is a simple HTTP request multiplexer
+// Mux is a simple HTTP request multiplexer. Routes, middleware and mounted
+// handlers can be registered, replaced and removed while it serves requests.
 type Mux struct {
-	routes     []*route
-	middleware []func(http.Handler) http.Handler
-	notFound   http.Handler
+	mu            sync.RWMutex // guards everything below but trailingSlash
+	routes        *node
+	mounts        []mountedHandler
+	middleware    []func(http.Handler) http.Handler
+	notFound      http.Handler
+	notAllowed    http.Handler
+	trailingSlash TrailingSlash
 }
 
-// route represents a single route
-type route struct {
-	method  string
-	pattern string
-	handler http.HandlerFunc
-	parts   []pathPart
+// Option configures a Mux created by NewRouter
+type Option func(*Mux)
+
+// TrailingSlash is the policy for request paths that end with a slash
+type TrailingSlash int
+
+const (
+	// TrailingSlashStrip ignores trailing slashes in both patterns and request
+	// paths, so /pets/ is served by the route for /pets. This is the default.
+	TrailingSlashStrip TrailingSlash = iota
+
+	// TrailingSlashStrict only serves /pets/ with a route registered for
+	// /pets/, and /pets with a route registered for /pets
+	TrailingSlashStrict
+
+	// TrailingSlashMovedPermanently matches like TrailingSlashStrict, but
+	// redirects with 301 Moved Permanently when only the path with or without
+	// the trailing slash has a route
+	TrailingSlashMovedPermanently
+
+	// TrailingSlashPermanentRedirect redirects like TrailingSlashMovedPermanently
+	// with 308 Permanent Redirect, which clients follow without changing the
+	// method or dropping the body
+	TrailingSlashPermanentRedirect
+)
+
+// WithTrailingSlash sets how the Mux treats trailing slashes in request paths
+func WithTrailingSlash(policy TrailingSlash) Option {
+	return func(m *Mux) {
+		m.trailingSlash = policy
+	}
 }
 
 // pathPart represents a part of a URL path
 type pathPart struct {
-	isParam bool
-	value   string
+	isParam  bool
+	catchAll bool
+	value    string
+	pattern  *regexp.Regexp // constraint on a parameter's value, if any
 }
 
 // contextKey is a custom type for context keys
@@ -35,17 +71,71 @@ const (
 	URLParamKey contextKey = "urlParams"
 )
 
+// routeContextKey is the context key of the routeContext of a request
+const routeContextKey contextKey = "routeContext"
+
+// routeContext is filled in by the Mux with the route a request matched and
+// its path parameters. Middleware that runs before routing, such as Metrics,
+// attaches one to read the pattern afterwards; otherwise the Mux takes one from
+// routeContextPool as it starts serving the request.
+type routeContext struct {
+	pattern string
+	params  matchedParams
+	buf     [maxInlineParams]string // backs params.values for most routes
+	serving bool                    // whether the handler of the route is running
+
+	// outer is the routeContext of the Mux whose route is served by this
+	// routeContext's Mux, if any. URLParam falls back to its parameters.
+	outer *routeContext
+}
+
+// routeContextPool holds routeContexts for reuse across requests
+var routeContextPool = sync.Pool{
+	New: func() any { return new(routeContext) },
+}
+
+// withRouteContext returns r with a routeContext the Mux fills in, reusing the
+// one of an outer middleware if there is one
+func withRouteContext(r *http.Request) (*http.Request, *routeContext) {
+	if rc, ok := r.Context().Value(routeContextKey).(*routeContext); ok {
+		return r, rc
+	}
+	rc := &routeContext{}
+	return r.WithContext(context.WithValue(r.Context(), routeContextKey, rc)), rc
+}
+
+// routeMethods are the methods routes can be registered for, other than
+// OPTIONS, in the order they are listed in Allow headers
+var routeMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+}
+
+// maxInlineParams is the number of path parameters a request can capture
+// before matching allocates
+const maxInlineParams = 8
+
 // NewRouter creates a new Mux router
-func NewRouter() *Mux {
-	return &Mux{
-		routes:     make([]*route, 0),
+func NewRouter(opts ...Option) *Mux {
+	m := &Mux{
+		routes:     &node{},
 		middleware: make([]func(http.Handler) http.Handler, 0),
 		notFound:   http.NotFoundHandler(),
 	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
 }
 
 // Use adds middleware to the router
 func (m *Mux) Use(middleware ...func(http.Handler) http.Handler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.middleware = append(m.middleware, middleware...)
 }
 
@@ -84,25 +174,85 @@ func (m *Mux) Head(pattern string, handler http.HandlerFunc) {
 	m.handle(http.MethodHead, pattern, handler)
 }
 
+// Handle registers a handler for every request method on pattern, for
+// handlers that dispatch on the method themselves such as health probes.
+// Routes registered for a specific method on the same pattern take precedence.
+func (m *Mux) Handle(pattern string, handler http.Handler) {
+	m.handle(anyMethod, pattern, handler.ServeHTTP)
+}
+
+// NotFound sets the handler for requests that match no route, which defaults
+// to http.NotFoundHandler
+func (m *Mux) NotFound(handler http.HandlerFunc) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.notFound = handler
+}
+
+// MethodNotAllowed sets the handler for requests to a path that only has
+// routes for other methods. The Allow header lists those methods when handler
+// runs. Until it is set, such requests are handled as not found.
+func (m *Mux) MethodNotAllowed(handler http.HandlerFunc) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.notAllowed = handler
+}
+
+// Replace registers a route with the given method and pattern, replacing the
+// handler of an existing route instead of keeping it as the other methods do
+func (m *Mux) Replace(method, pattern string, handler http.HandlerFunc) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.routes.remove(method, pattern, m.slashPattern(pattern))
+	m.routes.insert(method, pattern, m.slashPattern(pattern), handler)
+}
+
+// Remove unregisters the route with the given method and pattern, and reports
+// whether there was one. Pass "" as the method to remove a route registered
+// with Handle. Requests already being served by the route are not affected.
+func (m *Mux) Remove(method, pattern string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.routes.remove(method, pattern, m.slashPattern(pattern))
+}
+
 // handle registers a route with the given method and pattern
 func (m *Mux) handle(method, pattern string, handler http.HandlerFunc) {
-	parts := parsePattern(pattern)
-	m.routes = append(m.routes, &route{
-		method:  method,
-		pattern: pattern,
-		handler: handler,
-		parts:   parts,
-	})
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.routes.insert(method, pattern, m.slashPattern(pattern), handler)
+}
+
+// slashPattern reports whether routes for pattern only serve paths with a
+// trailing slash
+func (m *Mux) slashPattern(pattern string) bool {
+	return m.trailingSlash != TrailingSlashStrip && len(pattern) > 1 && strings.HasSuffix(pattern, "/")
 }
 
 // ServeHTTP implements the http.Handler interface
 func (m *Mux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Attach the routeContext before middleware runs, so that middleware and
+	// routing share it and matching path parameters does not allocate
+	if rc, ok := routeContextFor(r); !ok {
+		r = r.WithContext(context.WithValue(r.Context(), routeContextKey, rc))
+		defer releaseRouteContext(rc)
+	}
+
+	m.mu.RLock()
+	middleware := m.middleware
+	m.mu.RUnlock()
+
+	if len(middleware) == 0 {
+		m.serve(w, r)
+		return
+	}
+
 	// Build the handler chain with middleware
 	var handler http.Handler = http.HandlerFunc(m.serve)
 
 	// Apply middleware in reverse order
-	for i := len(m.middleware) - 1; i >= 0; i-- {
-		handler = m.middleware[i](handler)
+	for i := len(middleware) - 1; i >= 0; i-- {
+		handler = middleware[i](handler)
 	}
 
 	handler.ServeHTTP(w, r)
@@ -110,27 +260,172 @@ func (m *Mux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 // serve handles the actual routing
 func (m *Mux) serve(w http.ResponseWriter, r *http.Request) {
-	path := r.URL.Path
+	path := strings.TrimPrefix(r.URL.Path, "/")
+	slash := m.trailingSlash != TrailingSlashStrip && strings.HasSuffix(path, "/")
+	path = strings.TrimSuffix(path, "/")
 
-	// Find matching route
-	for _, route := range m.routes {
-		if route.method != r.Method {
-			continue
+	// Find matching route, capturing parameters without allocating
+	var buf [maxInlineParams]string
+	m.mu.RLock()
+	e, values, head := m.lookup(r.Method, path, slash, buf[:0])
+	m.mu.RUnlock()
+	if e != nil {
+		if head {
+			w = headResponseWriter{w}
 		}
+		serveEndpoint(w, r, e, values)
+		return
+	}
 
-		if params, ok := matchPattern(route.parts, path); ok {
-			// Add URL parameters to context
-			ctx := r.Context()
-			if len(params) > 0 {
-				ctx = context.WithValue(ctx, URLParamKey, params)
-			}
-			route.handler.ServeHTTP(w, r.WithContext(ctx))
+	// Redirect to the path with or without a trailing slash if it has a route
+	if code := m.slashRedirectCode(); code != 0 && path != "" {
+		m.mu.RLock()
+		e, _, _ := m.lookup(r.Method, path, !slash, buf[:0])
+		m.mu.RUnlock()
+		if e != nil {
+			redirectSlash(w, r, !slash, code)
+			return
+		}
+	}
+
+	// Answer OPTIONS with the methods the path has routes for
+	if r.Method == http.MethodOptions {
+		if allowed := m.allowed(path, slash); len(allowed) > 0 {
+			w.Header().Set("Allow", strings.Join(append(allowed, http.MethodOptions), ", "))
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+
+	// Fall back to handlers mounted under a prefix of the path
+	if m.serveMounted(w, r) {
+		return
+	}
+
+	m.mu.RLock()
+	notFound, notAllowed := m.notFound, m.notAllowed
+	m.mu.RUnlock()
+
+	// The path has routes, but not for this method
+	if notAllowed != nil {
+		if allowed := m.allowed(path, slash); len(allowed) > 0 {
+			w.Header().Set("Allow", strings.Join(append(allowed, http.MethodOptions), ", "))
+			notAllowed.ServeHTTP(w, r)
 			return
 		}
 	}
 
 	// No route found
-	m.notFound.ServeHTTP(w, r)
+	notFound.ServeHTTP(w, r)
+}
+
+// allowed returns the methods with a route matching path
+func (m *Mux) allowed(path string, slash bool) []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.routes.allowed(path, slash)
+}
+
+// lookup finds the route serving method on path. HEAD requests fall back to
+// the GET route of the path, in which case lookup reports that the response
+// body must be discarded. The caller must hold m.mu.
+func (m *Mux) lookup(method, path string, slash bool, values []string) (*endpoint, []string, bool) {
+	if e, matched := m.routes.match(method, path, slash, values); e != nil {
+		return e, matched, false
+	}
+	if method == http.MethodHead {
+		if e, matched := m.routes.match(http.MethodGet, path, slash, values); e != nil {
+			return e, matched, true
+		}
+	}
+	return nil, nil, false
+}
+
+// slashRedirectCode returns the status code of trailing slash redirects, or 0
+// if the trailing slash policy does not redirect
+func (m *Mux) slashRedirectCode() int {
+	switch m.trailingSlash {
+	case TrailingSlashMovedPermanently:
+		return http.StatusMovedPermanently
+	case TrailingSlashPermanentRedirect:
+		return http.StatusPermanentRedirect
+	}
+	return 0
+}
+
+// redirectSlash redirects a request to its path with a trailing slash added, or
+// removed. The path is taken from the request URI, so a Mux mounted under a
+// prefix redirects to the full path.
+func redirectSlash(w http.ResponseWriter, r *http.Request, slash bool, code int) {
+	u := r.URL
+	if parsed, err := url.ParseRequestURI(r.RequestURI); err == nil {
+		u = parsed
+	}
+
+	target := strings.TrimSuffix(u.EscapedPath(), "/")
+	if slash {
+		target += "/"
+	}
+	if u.RawQuery != "" {
+		target += "?" + u.RawQuery
+	}
+	http.Redirect(w, r, target, code)
+}
+
+// routeContextFor returns the routeContext for a Mux to fill in while serving r,
+// and whether r already carries it. That is the case when middleware such as
+// Metrics, or a Mux the Mux is mounted on, attached it. Otherwise, including
+// when the Mux is the handler of a route another Mux matched, the returned
+// routeContext is taken from routeContextPool. It is stacked on the
+// routeContext of the other Mux, so the parameters of both routes are kept.
+func routeContextFor(r *http.Request) (*routeContext, bool) {
+	outer, ok := r.Context().Value(routeContextKey).(*routeContext)
+	if ok && !outer.serving {
+		return outer, true
+	}
+	rc := routeContextPool.Get().(*routeContext)
+	rc.outer = outer
+	return rc, false
+}
+
+// releaseRouteContext returns a routeContext taken by routeContextFor to the
+// pool. The route a nested Mux matched is reported to the outer Mux, as it is
+// the more specific one.
+func releaseRouteContext(rc *routeContext) {
+	if rc.outer != nil && rc.pattern != "" {
+		rc.outer.pattern = rc.pattern
+	}
+	*rc = routeContext{}
+	routeContextPool.Put(rc)
+}
+
+// serveEndpoint serves a request with a matched route, storing the path
+// parameters in the routeContext ServeHTTP attached to the request
+func serveEndpoint(w http.ResponseWriter, r *http.Request, e *endpoint, values []string) {
+	if rc, ok := r.Context().Value(routeContextKey).(*routeContext); ok {
+		rc.pattern = e.pattern
+		rc.params = matchedParams{keys: e.paramNames, values: append(rc.buf[:0], values...)}
+		rc.serving = true
+		e.handler.ServeHTTP(w, r)
+		rc.serving = false
+		return
+	}
+	e.handler.ServeHTTP(w, r)
+}
+
+// headResponseWriter discards the response body a GET handler writes for a
+// HEAD request, keeping the status code and headers
+type headResponseWriter struct {
+	http.ResponseWriter
+}
+
+func (w headResponseWriter) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+// Unwrap returns the underlying ResponseWriter for http.ResponseController
+func (w headResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
 }
 
 // parsePattern parses a URL pattern into parts
@@ -146,11 +441,27 @@ func parsePattern(pattern string) []pathPart {
 	parts := make([]pathPart, len(segments))
 
 	for i, segment := range segments {
-		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
-			// This is a parameter
+		if i == len(segments)-1 && (segment == "*" || strings.HasSuffix(segment, "...}")) {
+			// A final {name...} or * segment captures the rest of the path
+			name := strings.TrimSuffix(strings.TrimPrefix(segment, "{"), "...}")
+			parts[i] = pathPart{
+				isParam:  true,
+				catchAll: true,
+				value:    name,
+			}
+		} else if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			// This is a parameter, optionally constrained as {name:regexp}
+			name, expr, constrained := strings.Cut(segment[1:len(segment)-1], ":")
 			parts[i] = pathPart{
 				isParam: true,
-				value:   segment[1 : len(segment)-1],
+				value:   name,
+			}
+			if constrained {
+				re, err := regexp.Compile("^(?:" + expr + ")$")
+				if err != nil {
+					panic(fmt.Sprintf("router: invalid pattern for parameter %q: %v", name, err))
+				}
+				parts[i].pattern = re
 			}
 		} else {
 			// This is a literal segment
@@ -164,44 +475,23 @@ func parsePattern(pattern string) []pathPart {
 	return parts
 }
 
-// matchPattern checks if a path matches a pattern and returns parameters
-func matchPattern(parts []pathPart, path string) (map[string]string, bool) {
-	path = strings.TrimPrefix(path, "/")
-	path = strings.TrimSuffix(path, "/")
-
-	pathSegments := []string{}
-	if path != "" {
-		pathSegments = strings.Split(path, "/")
-	}
-
-	// Check if the number of segments matches
-	if len(parts) != len(pathSegments) {
-		return nil, false
-	}
-
-	params := make(map[string]string)
-
-	for i, part := range parts {
-		if part.isParam {
-			// This is a parameter, capture it
-			params[part.value] = pathSegments[i]
-		} else {
-			// This is a literal, it must match exactly
-			if part.value != pathSegments[i] {
-				return nil, false
-			}
-		}
-	}
-
-	return params, true
-}
-
-// URLParam returns a URL parameter from the request context
+// URLParam returns a URL parameter from the request context. It reads the
+// parameters matched by Mux as well as a map[string]string stored under
+// URLParamKey by a custom router. When a route's handler is itself a Mux, the
+// parameters of its route come first, then those of the outer route.
+// Parameters matched by Mux are only valid until the handler returns, so
+// handlers must read them before passing the request to a goroutine that
+// outlives them.
 func URLParam(r *http.Request, key string) string {
 	ctx := r.Context()
-	params, ok := ctx.Value(URLParamKey).(map[string]string)
-	if !ok {
-		return ""
+	rc, _ := ctx.Value(routeContextKey).(*routeContext)
+	for ; rc != nil; rc = rc.outer {
+		if value, ok := rc.params.get(key); ok {
+			return value
+		}
+	}
+	if params, ok := ctx.Value(URLParamKey).(map[string]string); ok {
+		return params[key]
 	}
-	return params[key]
+	return ""
 }
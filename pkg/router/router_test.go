@@ -1,9 +1,12 @@
 package router
 
 import (
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -154,6 +157,88 @@ func TestRouterURLParams(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)
 }
 
+func TestRouterCatchAll(t *testing.T) {
+	router := NewRouter()
+
+	router.Get("/repos/{owner}/files/{filePath...}", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(URLParam(r, "owner") + ":" + URLParam(r, "filePath")))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/repos/acme/files/src/main.go", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "acme:src/main.go", w.Body.String())
+}
+
+func TestRouterNestedMuxParams(t *testing.T) {
+	inner := NewRouter()
+	inner.Get("/orgs/{org}/repos/{repo}", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(URLParam(r, "org") + "/" + URLParam(r, "repo") + " "))
+	})
+
+	metrics := NewMetrics()
+	outer := NewRouter()
+	outer.Use(metrics.Middleware)
+	outer.Get("/orgs/{org}/{rest...}", func(w http.ResponseWriter, r *http.Request) {
+		inner.ServeHTTP(w, r)
+		_, _ = w.Write([]byte(URLParam(r, "org") + ":" + URLParam(r, "rest")))
+	})
+
+	w := httptest.NewRecorder()
+	outer.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/orgs/acme/repos/api", nil))
+
+	assert.Equal(t, "acme/api acme:repos/api", w.Body.String(),
+		"Should give the nested Mux its own parameters and keep those of the outer route")
+
+	var sb strings.Builder
+	metrics.write(&sb)
+	assert.Contains(t, sb.String(), `http_requests_total{method="GET",route="/orgs/{org}/repos/{repo}",status="200"} 1`,
+		"Should report the route the nested Mux matched")
+}
+
+func TestRouterConstrainedParams(t *testing.T) {
+	router := NewRouter()
+
+	router.Get("/pets/{petId:[0-9]+}", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("id:" + URLParam(r, "petId")))
+	})
+	router.Get("/pets/{name}", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("name:" + URLParam(r, "name")))
+	})
+	router.Get("/orders/{orderId:[0-9]+}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/pets/42", http.StatusOK, "id:42"},
+		{"/pets/rex", http.StatusOK, "name:rex"},
+		{"/orders/abc", http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			assert.Equal(t, tt.code, w.Code)
+			if tt.body != "" {
+				assert.Equal(t, tt.body, w.Body.String())
+			}
+		})
+	}
+
+	assert.Panics(t, func() {
+		router.Get("/bad/{id:[0-9}", func(w http.ResponseWriter, r *http.Request) {})
+	}, "Should reject an invalid constraint when the route is registered")
+}
+
 func TestRouterURLParamNotFound(t *testing.T) {
 	router := NewRouter()
 
@@ -200,6 +285,41 @@ func TestRouterMethodNotAllowed(t *testing.T) {
 	assert.Equal(t, http.StatusNotFound, w.Code)
 }
 
+func TestRouterCustomErrorHandlers(t *testing.T) {
+	router := NewRouter()
+	router.Get("/pets/{petId}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	router.Delete("/pets/{petId}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	router.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":"not found"}`))
+	})
+	router.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		w.Write([]byte(`{"error":"method not allowed"}`))
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/owners", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.JSONEq(t, `{"error":"not found"}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/pets/1", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.JSONEq(t, `{"error":"method not allowed"}`, w.Body.String())
+	assert.Equal(t, "GET, HEAD, DELETE, OPTIONS", w.Header().Get("Allow"))
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/pets/1", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
 func TestRouterMiddleware(t *testing.T) {
 	router := NewRouter()
 
@@ -302,6 +422,30 @@ func TestParsePattern(t *testing.T) {
 				{isParam: true, value: "postId"},
 			},
 		},
+		{
+			name:    "Catch-all parameter",
+			pattern: "/files/{filePath...}",
+			expected: []pathPart{
+				{isParam: false, value: "files"},
+				{isParam: true, catchAll: true, value: "filePath"},
+			},
+		},
+		{
+			name:    "Star catch-all",
+			pattern: "/static/*",
+			expected: []pathPart{
+				{isParam: false, value: "static"},
+				{isParam: true, catchAll: true, value: "*"},
+			},
+		},
+		{
+			name:    "Constrained parameter",
+			pattern: "/pets/{petId:[0-9]+}",
+			expected: []pathPart{
+				{isParam: false, value: "pets"},
+				{isParam: true, value: "petId"},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -312,6 +456,7 @@ func TestParsePattern(t *testing.T) {
 
 			for i, expected := range tt.expected {
 				assert.Equal(t, expected.isParam, parts[i].isParam, "Part %d: isParam mismatch", i)
+				assert.Equal(t, expected.catchAll, parts[i].catchAll, "Part %d: catchAll mismatch", i)
 				assert.Equal(t, expected.value, parts[i].value, "Part %d: value mismatch", i)
 			}
 		})
@@ -371,20 +516,81 @@ func TestMatchPattern(t *testing.T) {
 			shouldMatch:    true,
 			expectedParams: map[string]string{},
 		},
+		{
+			name:        "Catch-all spans segments",
+			pattern:     "/files/{filePath...}",
+			path:        "/files/docs/2024/report.pdf",
+			shouldMatch: true,
+			expectedParams: map[string]string{
+				"filePath": "docs/2024/report.pdf",
+			},
+		},
+		{
+			name:        "Catch-all matches empty rest",
+			pattern:     "/files/{filePath...}",
+			path:        "/files/",
+			shouldMatch: true,
+			expectedParams: map[string]string{
+				"filePath": "",
+			},
+		},
+		{
+			name:        "Catch-all requires prefix",
+			pattern:     "/files/{filePath...}",
+			path:        "/docs/report.pdf",
+			shouldMatch: false,
+		},
+		{
+			name:        "Star catch-all",
+			pattern:     "/static/*",
+			path:        "/static/css/site.css",
+			shouldMatch: true,
+			expectedParams: map[string]string{
+				"*": "css/site.css",
+			},
+		},
+		{
+			name:        "Constraint satisfied",
+			pattern:     "/pets/{petId:[0-9]+}",
+			path:        "/pets/42",
+			shouldMatch: true,
+			expectedParams: map[string]string{
+				"petId": "42",
+			},
+		},
+		{
+			name:        "Constraint is anchored",
+			pattern:     "/pets/{petId:[0-9]+}",
+			path:        "/pets/42abc",
+			shouldMatch: false,
+		},
+		{
+			name:        "Constraint with quantifier",
+			pattern:     "/codes/{code:[A-Z]{3}}",
+			path:        "/codes/SEK",
+			shouldMatch: true,
+			expectedParams: map[string]string{
+				"code": "SEK",
+			},
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			parts := parsePattern(tt.pattern)
-			params, matched := matchPattern(parts, tt.path)
+			root := &node{}
+			root.insert(http.MethodGet, tt.pattern, false, func(w http.ResponseWriter, r *http.Request) {})
+			path := strings.TrimSuffix(strings.TrimPrefix(tt.path, "/"), "/")
+			e, values := root.match(http.MethodGet, path, false, nil)
 
-			assert.Equal(t, tt.shouldMatch, matched)
+			assert.Equal(t, tt.shouldMatch, e != nil)
 
 			if tt.shouldMatch {
-				require.Len(t, params, len(tt.expectedParams))
+				require.Len(t, values, len(tt.expectedParams))
 
+				params := &matchedParams{keys: e.paramNames, values: values}
 				for key, expectedValue := range tt.expectedParams {
-					assert.Equal(t, expectedValue, params[key], "Param %s mismatch", key)
+					value, _ := params.get(key)
+					assert.Equal(t, expectedValue, value, "Param %s mismatch", key)
 				}
 			}
 		})
@@ -419,6 +625,79 @@ func TestRouterTrailingSlash(t *testing.T) {
 	}
 }
 
+func TestRouterTrailingSlashPolicy(t *testing.T) {
+	newRouter := func(policy TrailingSlash) *Mux {
+		router := NewRouter(WithTrailingSlash(policy))
+		router.Get("/pets", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("pets"))
+		})
+		router.Post("/pets", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("create"))
+		})
+		router.Get("/owners/", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("owners"))
+		})
+		router.Get("/files/{path...}", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("files:" + URLParam(r, "path")))
+		})
+		return router
+	}
+
+	tests := []struct {
+		name     string
+		policy   TrailingSlash
+		method   string
+		path     string
+		code     int
+		body     string
+		location string
+	}{
+		{"Strip serves without slash", TrailingSlashStrip, http.MethodGet, "/pets", http.StatusOK, "pets", ""},
+		{"Strip serves with slash", TrailingSlashStrip, http.MethodGet, "/pets/", http.StatusOK, "pets", ""},
+		{"Strip ignores slash in pattern", TrailingSlashStrip, http.MethodGet, "/owners", http.StatusOK, "owners", ""},
+		{"Strict serves exact path", TrailingSlashStrict, http.MethodGet, "/pets", http.StatusOK, "pets", ""},
+		{"Strict rejects added slash", TrailingSlashStrict, http.MethodGet, "/pets/", http.StatusNotFound, "", ""},
+		{"Strict serves slash pattern", TrailingSlashStrict, http.MethodGet, "/owners/", http.StatusOK, "owners", ""},
+		{"Strict rejects missing slash", TrailingSlashStrict, http.MethodGet, "/owners", http.StatusNotFound, "", ""},
+		{"Strict catch-all ignores slash", TrailingSlashStrict, http.MethodGet, "/files/a/b/", http.StatusOK, "files:a/b", ""},
+		{"301 removes slash", TrailingSlashMovedPermanently, http.MethodGet, "/pets/?limit=5", http.StatusMovedPermanently, "", "/pets?limit=5"},
+		{"301 adds slash", TrailingSlashMovedPermanently, http.MethodGet, "/owners", http.StatusMovedPermanently, "", "/owners/"},
+		{"301 serves exact path", TrailingSlashMovedPermanently, http.MethodGet, "/pets", http.StatusOK, "pets", ""},
+		{"301 only redirects routed paths", TrailingSlashMovedPermanently, http.MethodGet, "/unknown/", http.StatusNotFound, "", ""},
+		{"308 removes slash", TrailingSlashPermanentRedirect, http.MethodPost, "/pets/", http.StatusPermanentRedirect, "", "/pets"},
+		{"308 checks method", TrailingSlashPermanentRedirect, http.MethodPost, "/owners", http.StatusNotFound, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			w := httptest.NewRecorder()
+
+			newRouter(tt.policy).ServeHTTP(w, req)
+
+			assert.Equal(t, tt.code, w.Code)
+			assert.Equal(t, tt.location, w.Header().Get("Location"))
+			if tt.body != "" {
+				assert.Equal(t, tt.body, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestRouterTrailingSlashRedirectMounted(t *testing.T) {
+	api := NewRouter(WithTrailingSlash(TrailingSlashPermanentRedirect))
+	api.Get("/pets", func(w http.ResponseWriter, r *http.Request) {})
+
+	router := NewRouter()
+	router.Mount("/api", api)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/pets/", nil))
+
+	assert.Equal(t, http.StatusPermanentRedirect, w.Code)
+	assert.Equal(t, "/api/pets", w.Header().Get("Location"), "Should keep the mount prefix")
+}
+
 func TestRouterComplexRouting(t *testing.T) {
 	router := NewRouter()
 
@@ -479,3 +758,151 @@ func TestRouterComplexRouting(t *testing.T) {
 		})
 	}
 }
+
+func TestRouterHandle(t *testing.T) {
+	router := NewRouter()
+	router.Handle("/healthz", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("any " + r.Method))
+	}))
+	router.Post("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("post"))
+	})
+
+	for method, body := range map[string]string{
+		http.MethodGet:    "any GET",
+		http.MethodDelete: "any DELETE",
+		http.MethodPost:   "post",
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, "/healthz", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, body, w.Body.String(), "Method %s", method)
+	}
+}
+
+func TestRouterAutomaticOptions(t *testing.T) {
+	router := NewRouter()
+	noop := func(w http.ResponseWriter, r *http.Request) {}
+	router.Get("/pets/{petId}", noop)
+	router.Delete("/pets/{petId}", noop)
+	router.Put("/pets/{id:[0-9]+}", noop)
+	router.Post("/pets", noop)
+	router.Options("/custom", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := []struct {
+		path  string
+		code  int
+		allow string
+	}{
+		{"/pets/42", http.StatusNoContent, "GET, HEAD, PUT, DELETE, OPTIONS"},
+		{"/pets/rex", http.StatusNoContent, "GET, HEAD, DELETE, OPTIONS"},
+		{"/pets", http.StatusNoContent, "POST, OPTIONS"},
+		{"/custom", http.StatusOK, ""},
+		{"/unknown", http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodOptions, tt.path, nil))
+
+			assert.Equal(t, tt.code, w.Code)
+			assert.Equal(t, tt.allow, w.Header().Get("Allow"))
+		})
+	}
+}
+
+func TestRouterHeadFallback(t *testing.T) {
+	router := NewRouter()
+	router.Get("/pets/{petId}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Pet-Id", URLParam(r, "petId"))
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"id":1}`))
+	})
+	router.Head("/explicit", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Handler", "head")
+	})
+	router.Get("/explicit", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Handler", "get")
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodHead, "/pets/1", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.Equal(t, "1", w.Header().Get("X-Pet-Id"))
+	assert.Empty(t, w.Body.String(), "Should discard the body")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodHead, "/explicit", nil))
+	assert.Equal(t, "head", w.Header().Get("X-Handler"), "Should prefer an explicit HEAD route")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodHead, "/missing", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestRouterReplaceAndRemove(t *testing.T) {
+	router := NewRouter()
+	router.Get("/pets/{petId}", namedHandler("v1", "petId"))
+	router.Handle("/health", namedHandler("health"))
+
+	send := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+
+	router.Replace(http.MethodGet, "/pets/{id}", namedHandler("v2", "id"))
+	assert.Equal(t, "v2 id=1", send(http.MethodGet, "/pets/1").Body.String())
+
+	router.Replace(http.MethodPost, "/pets", namedHandler("create"))
+	assert.Equal(t, "create", send(http.MethodPost, "/pets").Body.String(), "Should register a route that did not exist")
+
+	assert.True(t, router.Remove(http.MethodGet, "/pets/{petId}"))
+	assert.False(t, router.Remove(http.MethodGet, "/pets/{petId}"))
+	assert.False(t, router.Remove(http.MethodGet, "/pets/{petId:[0-9]+}"))
+	assert.False(t, router.Remove(http.MethodGet, "/owners"))
+	assert.Equal(t, http.StatusNotFound, send(http.MethodGet, "/pets/1").Code)
+
+	assert.True(t, router.Remove("", "/health"))
+	assert.Equal(t, http.StatusNotFound, send(http.MethodGet, "/health").Code)
+
+	router.Get("/pets/{petId}", namedHandler("v3", "petId"))
+	assert.Equal(t, "v3 petId=1", send(http.MethodGet, "/pets/1").Body.String())
+}
+
+func TestRouterConcurrentRegistration(t *testing.T) {
+	router := NewRouter()
+	router.Get("/pets", namedHandler("pets"))
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := range 50 {
+				pattern := fmt.Sprintf("/flags/%d/%d", i, j)
+				router.Get(pattern, namedHandler("flag"))
+				router.Replace(http.MethodGet, pattern, namedHandler("flag"))
+				router.Remove(http.MethodGet, pattern)
+				router.Mount(pattern, http.NotFoundHandler())
+				router.Unmount(pattern)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for range 50 {
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/pets", nil))
+				assert.Equal(t, "pets", w.Body.String())
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,220 @@
+package router
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// node is a node of the routing tree. Each level of the tree matches one
+// segment of the request path, so matching a request costs one step per
+// segment regardless of how many routes are registered.
+type node struct {
+	static    map[string]*node          // children matching a literal segment
+	params    []*node                   // children matching a parameter, constrained ones first
+	catchAll  *node                     // child matching the rest of the path
+	part      pathPart                  // the pattern part a parameter child matches
+	endpoints map[endpointKey]*endpoint // routes ending at this node
+}
+
+// endpointKey identifies the routes of a node by method, or anyMethod, and by
+// whether their pattern ends with a slash. Slashes only distinguish routes
+// when the Mux does not strip them.
+type endpointKey struct {
+	method string
+	slash  bool
+}
+
+// anyMethod is the method key of endpoints serving every method
+const anyMethod = ""
+
+// endpoint is a route registered for one method, or for every method
+type endpoint struct {
+	pattern    string
+	handler    http.HandlerFunc
+	paramNames []string // names of the path parameters, in pattern order
+}
+
+// insert adds a route to the tree rooted at n, as a route whose pattern ends
+// with a slash if slash is set. The first route registered for a method and
+// pattern wins.
+func (n *node) insert(method, pattern string, slash bool, handler http.HandlerFunc) {
+	var paramNames []string
+	for _, part := range parsePattern(pattern) {
+		switch {
+		case part.catchAll:
+			if n.catchAll == nil {
+				n.catchAll = &node{part: part}
+			}
+			n = n.catchAll
+		case part.isParam:
+			n = n.paramChild(part)
+		default:
+			child, ok := n.static[part.value]
+			if !ok {
+				if n.static == nil {
+					n.static = make(map[string]*node)
+				}
+				child = &node{part: part}
+				n.static[part.value] = child
+			}
+			n = child
+		}
+		if part.isParam {
+			paramNames = append(paramNames, part.value)
+		}
+	}
+
+	if n.endpoints == nil {
+		n.endpoints = make(map[endpointKey]*endpoint)
+	}
+	key := endpointKey{method: method, slash: slash}
+	if _, exists := n.endpoints[key]; !exists {
+		n.endpoints[key] = &endpoint{pattern: pattern, handler: handler, paramNames: paramNames}
+	}
+}
+
+// remove deletes the route registered for method and pattern below n, and
+// reports whether there was one. Nodes left without routes stay in the tree,
+// ready for the pattern to be registered again.
+func (n *node) remove(method, pattern string, slash bool) bool {
+	for _, part := range parsePattern(pattern) {
+		switch {
+		case part.catchAll:
+			n = n.catchAll
+		case part.isParam:
+			i := slices.IndexFunc(n.params, func(child *node) bool {
+				return constraintOf(child.part) == constraintOf(part)
+			})
+			if i < 0 {
+				return false
+			}
+			n = n.params[i]
+		default:
+			n = n.static[part.value]
+		}
+		if n == nil {
+			return false
+		}
+	}
+
+	key := endpointKey{method: method, slash: slash}
+	if _, exists := n.endpoints[key]; !exists {
+		return false
+	}
+	delete(n.endpoints, key)
+	return true
+}
+
+// paramChild returns the child of n matching a parameter with the constraint
+// of part, creating it if needed. Parameter names do not distinguish children:
+// they are recorded per endpoint.
+func (n *node) paramChild(part pathPart) *node {
+	for _, child := range n.params {
+		if constraintOf(child.part) == constraintOf(part) {
+			return child
+		}
+	}
+
+	child := &node{part: part}
+	if part.pattern == nil {
+		n.params = append(n.params, child)
+		return child
+	}
+
+	// Try constrained parameters before an unconstrained one
+	i := len(n.params)
+	for i > 0 && n.params[i-1].part.pattern == nil {
+		i--
+	}
+	n.params = append(n.params[:i], append([]*node{child}, n.params[i:]...)...)
+	return child
+}
+
+// constraintOf returns the source of a parameter's constraint, or "" if it has none
+func constraintOf(part pathPart) string {
+	if part.pattern == nil {
+		return ""
+	}
+	return part.pattern.String()
+}
+
+// endpoint returns the endpoint of n serving method, preferring one registered
+// for the method itself over one serving every method
+func (n *node) endpoint(method string, slash bool) *endpoint {
+	if e := n.endpoints[endpointKey{method: method, slash: slash}]; e != nil {
+		return e
+	}
+	return n.endpoints[endpointKey{method: anyMethod, slash: slash}]
+}
+
+// match finds the endpoint for method serving path below n, where path has no
+// leading or trailing slash and slash tells whether the request path had one.
+// Catch-all segments ignore trailing slashes. The values of path parameters
+// are appended to values, which callers can back with a fixed-size array so
+// that matching does not allocate. Literal segments take precedence over parameters, and
+// parameters over catch-all segments; a branch that leads to no endpoint for
+// the method is abandoned for the next candidate.
+func (n *node) match(method, path string, slash bool, values []string) (*endpoint, []string) {
+	if path == "" {
+		if e := n.endpoint(method, slash); e != nil {
+			return e, values
+		}
+		if n.catchAll != nil {
+			if e := n.catchAll.endpoint(method, false); e != nil {
+				return e, append(values, "")
+			}
+		}
+		return nil, nil
+	}
+
+	segment, rest, _ := strings.Cut(path, "/")
+	if child := n.static[segment]; child != nil {
+		if e, matched := child.match(method, rest, slash, values); e != nil {
+			return e, matched
+		}
+	}
+	for _, child := range n.params {
+		if child.part.pattern != nil && !child.part.pattern.MatchString(segment) {
+			continue
+		}
+		if e, matched := child.match(method, rest, slash, append(values, segment)); e != nil {
+			return e, matched
+		}
+	}
+	if n.catchAll != nil {
+		if e := n.catchAll.endpoint(method, false); e != nil {
+			return e, append(values, path)
+		}
+	}
+	return nil, nil
+}
+
+// allowed returns the methods with a route matching path, in routeMethods
+// order. HEAD is allowed wherever GET is, since the Mux falls back to it.
+func (n *node) allowed(path string, slash bool) []string {
+	var allowed []string
+	var buf [maxInlineParams]string
+	for _, method := range routeMethods {
+		if e, _ := n.match(method, path, slash, buf[:0]); e != nil || (method == http.MethodHead && slices.Contains(allowed, http.MethodGet)) {
+			allowed = append(allowed, method)
+		}
+	}
+	return allowed
+}
+
+// matchedParams holds the path parameters of a matched route
+type matchedParams struct {
+	keys   []string
+	values []string
+}
+
+// get returns the value of the named parameter, and whether there is one
+func (p *matchedParams) get(key string) (string, bool) {
+	for i, k := range p.keys {
+		if k == key {
+			return p.values[i], true
+		}
+	}
+	return "", false
+}
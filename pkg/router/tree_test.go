@@ -0,0 +1,154 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// namedHandler returns a handler that writes name and the given URL parameters
+func namedHandler(name string, params ...string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body := name
+		for _, param := range params {
+			body += " " + param + "=" + URLParam(r, param)
+		}
+		_, _ = w.Write([]byte(body))
+	}
+}
+
+func TestRouterPrecedence(t *testing.T) {
+	router := NewRouter()
+
+	// Registered in an order a linear scan would get wrong
+	router.Get("/files/*", namedHandler("catchAll", "*"))
+	router.Get("/files/{name}", namedHandler("param", "name"))
+	router.Get("/files/{id:[0-9]+}", namedHandler("constrained", "id"))
+	router.Get("/files/latest", namedHandler("static"))
+	router.Post("/files/{id:[0-9]+}/copy", namedHandler("copy", "id"))
+	router.Post("/files/{name}/rename", namedHandler("rename", "name"))
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{http.MethodGet, "/files/latest", "static"},
+		{http.MethodGet, "/files/42", "constrained id=42"},
+		{http.MethodGet, "/files/report", "param name=report"},
+		{http.MethodGet, "/files/a/b/c", "catchAll *=a/b/c"},
+		{http.MethodPost, "/files/42/copy", "copy id=42"},
+		// The constrained branch has no rename route, so matching backtracks
+		{http.MethodPost, "/files/42/rename", "rename name=42"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.body, w.Body.String())
+		})
+	}
+}
+
+func TestRouterParamNamesPerRoute(t *testing.T) {
+	router := NewRouter()
+	router.Get("/pets/{petId}", namedHandler("get", "petId"))
+	router.Delete("/pets/{id}", namedHandler("delete", "id"))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/pets/7", nil))
+	assert.Equal(t, "get petId=7", w.Body.String())
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/pets/7", nil))
+	assert.Equal(t, "delete id=7", w.Body.String())
+}
+
+func TestRouterFirstRegistrationWins(t *testing.T) {
+	router := NewRouter()
+	router.Get("/test", namedHandler("first"))
+	router.Get("/test", namedHandler("second"))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test", nil))
+	assert.Equal(t, "first", w.Body.String())
+}
+
+func TestMatchDoesNotAllocate(t *testing.T) {
+	root := &node{}
+	for i := range 100 {
+		root.insert(http.MethodGet, fmt.Sprintf("/resource%d/{id}/items/{itemId:[0-9]+}", i), false, namedHandler("items"))
+	}
+	root.insert(http.MethodGet, "/static/{path...}", false, namedHandler("static"))
+
+	var itemsBuf, staticBuf [maxInlineParams]string
+	var items, static *endpoint
+	var values []string
+	allocs := testing.AllocsPerRun(100, func() {
+		items, values = root.match(http.MethodGet, "resource99/abc/items/42", false, itemsBuf[:0])
+		static, _ = root.match(http.MethodGet, "static/css/site.css", false, staticBuf[:0])
+	})
+	require.NotNil(t, items)
+	require.NotNil(t, static)
+	assert.Equal(t, []string{"abc", "42"}, values)
+	assert.Zero(t, allocs)
+}
+
+func BenchmarkRouter(b *testing.B) {
+	router := NewRouter()
+	for i := range 500 {
+		router.Get(fmt.Sprintf("/resource%d/{id}", i), func(w http.ResponseWriter, r *http.Request) {})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/resource499/123", nil)
+	w := httptest.NewRecorder()
+	b.ReportAllocs()
+	for b.Loop() {
+		router.ServeHTTP(w, req)
+	}
+}
+
+func TestServeParamsAddNoAllocations(t *testing.T) {
+	router := NewRouter()
+	var petID string
+	router.Get("/owners/{ownerId}/pets/{petId}", func(w http.ResponseWriter, r *http.Request) {
+		petID = URLParam(r, "petId")
+	})
+	router.Get("/health", func(w http.ResponseWriter, r *http.Request) {})
+
+	params := httptest.NewRequest(http.MethodGet, "/owners/1/pets/2", nil)
+	static := httptest.NewRequest(http.MethodGet, "/health", nil)
+	w := httptest.NewRecorder()
+	withParams := testing.AllocsPerRun(100, func() {
+		router.ServeHTTP(w, params)
+	})
+	withoutParams := testing.AllocsPerRun(100, func() {
+		router.ServeHTTP(w, static)
+	})
+	assert.Equal(t, "2", petID)
+	assert.Equal(t, withoutParams, withParams, "Matching path parameters should not allocate")
+}
+
+func TestServeParamsDoNotAllocate(t *testing.T) {
+	router := NewRouter()
+	var petID string
+	router.Get("/owners/{ownerId}/pets/{petId}", func(w http.ResponseWriter, r *http.Request) {
+		petID = URLParam(r, "petId")
+	})
+
+	// Middleware such as Metrics attaches a routeContext before routing
+	req, _ := withRouteContext(httptest.NewRequest(http.MethodGet, "/owners/1/pets/2", nil))
+	w := httptest.NewRecorder()
+	allocs := testing.AllocsPerRun(100, func() {
+		router.ServeHTTP(w, req)
+	})
+	assert.Equal(t, "2", petID)
+	assert.Zero(t, allocs)
+}
@@ -31,6 +31,7 @@ package specweaver
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/christopherklint97/specweaver/pkg/generator"
 	"github.com/christopherklint97/specweaver/pkg/openapi"
@@ -49,6 +50,100 @@ type Options struct {
 	// PackageName is the name of the generated Go package
 	// Default: "api"
 	PackageName string
+
+	// Router selects the router the generated server registers its routes on:
+	// "builtin" (the bundled router package), "chi", or "stdlib" (net/http ServeMux)
+	// Default: "builtin"
+	Router string
+
+	// SplitByTag writes server code into one server_<tag>.go file per tag
+	// plus a shared server_common.go instead of a single server.go
+	SplitByTag bool
+
+	// TagInterfaces generates one interface per tag (e.g. PetsServer) that
+	// Server embeds, plus a ComposeServer helper to combine implementations
+	TagInterfaces bool
+
+	// Stubs additionally writes unimplemented.go, with an UnimplementedServer
+	// returning 501 for every operation, and a skeleton cmd/server/main.go
+	Stubs bool
+
+	// BasePath overrides the route prefix, which otherwise is the path of the
+	// spec's first server URL (e.g. "/api/v1"). Use "/" for no prefix.
+	BasePath string
+
+	// EmbedSpec embeds the spec in the generated package (as openapi.json and
+	// openapi.yaml) and serves it at <BasePath>/openapi.json and /openapi.yaml
+	EmbedSpec bool
+
+	// Docs generates MountDocs, which serves Swagger UI and Redoc pages backed
+	// by the embedded spec. Implies EmbedSpec.
+	Docs bool
+
+	// AutoHead registers a HEAD route running the GET handler, without a body,
+	// for every path that defines GET but not HEAD
+	AutoHead bool
+
+	// CORS registers OPTIONS routes listing each path's allowed methods and
+	// generates WithCORS to apply a CORS policy to responses and preflight requests
+	CORS bool
+
+	// Idempotency accepts an Idempotency-Key header on all POST and PATCH
+	// operations, in addition to operations declaring it, and replays recorded
+	// responses for repeated keys through an IdempotencyStore
+	Idempotency bool
+
+	// Thin generates a ServerInterface of plain (w, r) handler methods plus
+	// Bind<Operation>Request functions for parsing parameters and bodies,
+	// leaving responses entirely to the handlers
+	Thin bool
+
+	// MaxBodySize limits JSON request bodies to this many bytes, answering
+	// larger bodies with 413 Request Entity Too Large. Operations override it
+	// with an x-max-body-size extension. Zero means unlimited.
+	MaxBodySize int64
+
+	// Timeout is the deadline of the context handlers run with. Handlers are not
+	// cut off; errors they return once it has passed are answered with 504
+	// Gateway Timeout. Operations override it with an
+	// x-timeout extension ("5s" or a number of seconds). Zero means no deadline.
+	Timeout time.Duration
+
+	// PrincipalType is the Go type of authenticated principals, such as "*User",
+	// used for SecurityContext.Principal instead of any
+	PrincipalType string
+
+	// Only limits generation to the listed artifacts: "types", "server",
+	// "auth" and "stubs". Empty means all of them. Use it to generate only
+	// the types for a shared models package, for example. Selections that
+	// leave out an artifact a selected one depends on, such as the server
+	// without the types, are rejected.
+	Only []string
+
+	// Skip leaves out the listed artifacts
+	Skip []string
+
+	// IncludeTags limits generation to operations with at least one of the
+	// listed tags, e.g. to leave internal operations out of a public server
+	IncludeTags []string
+
+	// ExcludeTags leaves out operations with any of the listed tags
+	ExcludeTags []string
+
+	// IncludePaths limits generation to operations whose spec path matches
+	// one of the listed globs, where * matches within a segment and ** across
+	// segments, e.g. "/v1/**" or "/pets/*"
+	IncludePaths []string
+
+	// ExcludePaths leaves out operations whose spec path matches any of the listed globs
+	ExcludePaths []string
+
+	// Quiet stops Generate from printing the files it wrote
+	Quiet bool
+
+	// TypesOutputDir writes the types to a package of their own in this
+	// directory, which must be inside a module
+	TypesOutputDir string
 }
 
 // Generate is a convenience function that parses an OpenAPI spec file
@@ -69,8 +164,30 @@ func Generate(specPath string, opts Options) error {
 
 	// Generate code
 	config := generator.Config{
-		OutputDir:   opts.OutputDir,
-		PackageName: opts.PackageName,
+		OutputDir:      opts.OutputDir,
+		PackageName:    opts.PackageName,
+		Router:         opts.Router,
+		SplitByTag:     opts.SplitByTag,
+		TagInterfaces:  opts.TagInterfaces,
+		Stubs:          opts.Stubs,
+		BasePath:       opts.BasePath,
+		EmbedSpec:      opts.EmbedSpec,
+		Docs:           opts.Docs,
+		AutoHead:       opts.AutoHead,
+		CORS:           opts.CORS,
+		Idempotency:    opts.Idempotency,
+		Thin:           opts.Thin,
+		MaxBodySize:    opts.MaxBodySize,
+		Timeout:        opts.Timeout,
+		PrincipalType:  opts.PrincipalType,
+		Only:           opts.Only,
+		Skip:           opts.Skip,
+		IncludeTags:    opts.IncludeTags,
+		ExcludeTags:    opts.ExcludeTags,
+		IncludePaths:   opts.IncludePaths,
+		ExcludePaths:   opts.ExcludePaths,
+		Quiet:          opts.Quiet,
+		TypesOutputDir: opts.TypesOutputDir,
 	}
 
 	gen := generator.NewGenerator(p.GetSpec(), config)
@@ -117,8 +234,30 @@ type Generator struct {
 // NewGenerator creates a new code generator instance for the given OpenAPI specification
 func NewGenerator(spec *openapi.Document, opts Options) *Generator {
 	config := generator.Config{
-		OutputDir:   opts.OutputDir,
-		PackageName: opts.PackageName,
+		OutputDir:      opts.OutputDir,
+		PackageName:    opts.PackageName,
+		Router:         opts.Router,
+		SplitByTag:     opts.SplitByTag,
+		TagInterfaces:  opts.TagInterfaces,
+		Stubs:          opts.Stubs,
+		BasePath:       opts.BasePath,
+		EmbedSpec:      opts.EmbedSpec,
+		Docs:           opts.Docs,
+		AutoHead:       opts.AutoHead,
+		CORS:           opts.CORS,
+		Idempotency:    opts.Idempotency,
+		Thin:           opts.Thin,
+		MaxBodySize:    opts.MaxBodySize,
+		Timeout:        opts.Timeout,
+		PrincipalType:  opts.PrincipalType,
+		Only:           opts.Only,
+		Skip:           opts.Skip,
+		IncludeTags:    opts.IncludeTags,
+		ExcludeTags:    opts.ExcludeTags,
+		IncludePaths:   opts.IncludePaths,
+		ExcludePaths:   opts.ExcludePaths,
+		Quiet:          opts.Quiet,
+		TypesOutputDir: opts.TypesOutputDir,
 	}
 
 	return &Generator{
@@ -130,3 +269,17 @@ func NewGenerator(spec *openapi.Document, opts Options) *Generator {
 func (g *Generator) Generate() error {
 	return g.g.Generate()
 }
+
+// Diagnostics returns warnings about parts of the spec that are not generated,
+// such as TRACE operations, and infos about Go names that differ from the
+// names in the spec
+func (g *Generator) Diagnostics() []openapi.Finding {
+	return g.g.Diagnostics()
+}
+
+// Check generates the code in memory and returns the files in the output
+// directory that are missing or differ from it, without writing anything.
+// No changes means the generated code is current.
+func (g *Generator) Check() ([]generator.FileChange, error) {
+	return g.g.Check()
+}